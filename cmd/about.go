@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/resignipa/pkg/update"
+)
+
+// showAboutDialog shows the build information and checks GitHub for a
+// newer release; when there is one, its notes and a download button for
+// this machine's binary fill the dialog
+func showAboutDialog(a fyne.App, window fyne.Window) {
+	info := widget.NewLabel(fmt.Sprintf("ResignIPA %s\ncommit %s, built %s", version, commit, buildDate))
+
+	notes := widget.NewRichTextFromMarkdown("Checking for updates...")
+	notes.Wrapping = fyne.TextWrapWord
+	download := widget.NewButtonWithIcon("Download", theme.DownloadIcon(), nil)
+	download.Importance = widget.HighImportance
+	download.Hide()
+
+	content := container.NewBorder(info, container.NewCenter(download), nil, nil, container.NewVScroll(notes))
+	about := dialog.NewCustom("About ResignIPA", "Close", content, window)
+	about.Resize(fyne.NewSize(560, 460))
+
+	// Closing the dialog abandons a slow check
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	about.SetOnClosed(cancel)
+	about.Show()
+
+	go func() {
+		defer cancel()
+		release, err := update.Latest(ctx, http.DefaultClient, update.Repository)
+		if err != nil {
+			notes.ParseMarkdown(fmt.Sprintf("Update check failed: %v", err))
+			return
+		}
+		notes.ParseMarkdown(releaseNotesMarkdown(version, release))
+		if !update.Newer(version, release.Tag) {
+			return
+		}
+		link, err := url.Parse(release.DownloadURL(runtime.GOARCH))
+		if err != nil {
+			return
+		}
+		download.OnTapped = func() {
+			if err := a.OpenURL(link); err != nil {
+				dialog.ShowError(fmt.Errorf("failed to open %s: %w", link, err), window)
+			}
+		}
+		download.Show()
+	}()
+}
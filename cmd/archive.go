@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/resignipa/pkg/resigner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	packOutput     string
+	unpackOutput   string
+	unpackPassword string
+	unpackRef      string
+)
+
+var packCmd = &cobra.Command{
+	Use:   "pack <dir>",
+	Short: "Package an extracted app directory as an IPA",
+	Long: `Package an extracted IPA directory (containing Payload/) or a single .app
+bundle as an IPA. Symlinks and file modes are preserved, and packing the same
+tree always produces identical bytes.
+
+Example:
+  resignipa pack extracted/ -o MyApp.ipa
+  resignipa pack MyApp.app -o MyApp.ipa`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := args[0]
+		output := packOutput
+		if output == "" {
+			output = strings.TrimSuffix(filepath.Base(filepath.Clean(dir)), ".app") + ".ipa"
+		}
+
+		if err := resigner.PackIPA(dir, output); err != nil {
+			fmt.Printf("\n❌ Pack failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Packed %s into %s\n", dir, output)
+	},
+}
+
+var unpackCmd = &cobra.Command{
+	Use:   "unpack <app.ipa>",
+	Short: "Extract an IPA into a directory",
+	Long: `Extract an IPA into a directory for manual modification. Entries that would
+escape the directory are rejected, and symlinks and file modes are preserved
+so the tree can be repackaged with "resignipa pack".
+
+Example:
+  resignipa unpack MyApp.ipa -d extracted/`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ipa := args[0]
+		dir := unpackOutput
+		if dir == "" {
+			dir = strings.TrimSuffix(filepath.Base(ipa), filepath.Ext(ipa))
+		}
+
+		if _, err := os.Stat(dir); err == nil {
+			fmt.Printf("\n❌ Unpack failed: destination already exists: %s\n", dir)
+			os.Exit(1)
+		}
+		password, err := resolveSecret(cmd.Context(), "password", unpackPassword, unpackRef)
+		if err != nil {
+			fmt.Printf("\n❌ Unpack failed: %v\n", err)
+			os.Exit(1)
+		}
+		if err := resigner.UnpackIPA(ipa, dir, password); err != nil {
+			fmt.Printf("\n❌ Unpack failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Unpacked %s into %s\n", ipa, dir)
+	},
+}
+
+func init() {
+	packCmd.Flags().StringVarP(&packOutput, "output", "o", "", "Output IPA path (default: <name>.ipa)")
+	unpackCmd.Flags().StringVarP(&unpackOutput, "dir", "d", "", "Destination directory (default: <name>)")
+	unpackCmd.Flags().StringVar(&unpackPassword, "password", "", "Password for encrypted (ZipCrypto) IPA archives")
+	unpackCmd.Flags().StringVar(&unpackRef, "password-ref", "", "Read the password from a secret reference, e.g. keychain:service/account")
+
+	rootCmd.AddCommand(packCmd)
+	rootCmd.AddCommand(unpackCmd)
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/resignipa/pkg/resigner"
+	"github.com/spf13/cobra"
+)
+
+var auditJSON bool
+
+var auditCmd = &cobra.Command{
+	Use:   "audit <app.ipa|App.app>",
+	Short: "Check embedded frameworks against the app's deployment target",
+	Long: `Scan the app's embedded frameworks and report any that require a newer OS than
+the app supports or that depend on a Swift runtime the app does not provide.
+Both pass on the developer's device and crash at launch elsewhere, which is
+often mistaken for a signing problem after resigning.
+
+Exits with status 1 when issues are found.
+
+Example:
+  resignipa audit MyApp.ipa`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		audit, err := resigner.AuditFrameworks(args[0])
+		if err != nil {
+			fmt.Printf("\n❌ Audit failed: %v\n", err)
+			os.Exit(1)
+		}
+
+		if auditJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			encoder.Encode(audit)
+		} else {
+			printAudit(audit)
+		}
+		if len(audit.Issues) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// printAudit renders the framework table followed by any issues
+func printAudit(audit *resigner.FrameworkAudit) {
+	fmt.Printf("\n📦 %s (minimum OS %s)\n", audit.App, orDash(audit.MinimumOS))
+	for _, framework := range audit.Frameworks {
+		swift := ""
+		if framework.SwiftRuntime != "" {
+			swift = "Swift (" + framework.SwiftRuntime + " runtime)"
+		}
+		fmt.Printf("   %-40s %-8s %s\n", framework.Path, orDash(framework.MinimumOS), swift)
+	}
+
+	if len(audit.Issues) == 0 {
+		fmt.Println("\n✅ No framework issues found")
+		return
+	}
+	fmt.Println()
+	for _, issue := range audit.Issues {
+		fmt.Printf("❌ %s %s\n", issue.Framework, issue.Message)
+	}
+}
+
+// orDash substitutes a dash for an unknown value
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func init() {
+	auditCmd.Flags().BoolVar(&auditJSON, "json", false, "Print the audit as JSON")
+
+	rootCmd.AddCommand(auditCmd)
+}
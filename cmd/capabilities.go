@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/resignipa/pkg/resigner"
+	"github.com/spf13/cobra"
+)
+
+var capabilitiesJSON bool
+
+var capabilitiesCmd = &cobra.Command{
+	Use:   "capabilities <app.ipa|App.app>",
+	Short: "Show what an app is entitled to do",
+	Long: `Print a capability matrix (push, HealthKit, NFC, app groups, background modes,
+associated domains, ...) for the app and each of its extensions, read from the
+signed entitlements and Info.plist. Nothing is extracted or executed, so this
+is safe to run on third-party IPAs before resigning or deploying them.
+
+Example:
+  resignipa capabilities MyApp.ipa
+  resignipa capabilities MyApp.ipa --json`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		bundles, err := resigner.InspectCapabilities(args[0])
+		if err != nil {
+			fmt.Printf("\n❌ Inspection failed: %v\n", err)
+			os.Exit(1)
+		}
+
+		if capabilitiesJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			encoder.Encode(bundles)
+			return
+		}
+		for _, bundle := range bundles {
+			printCapabilities(bundle)
+		}
+	},
+}
+
+// printCapabilities renders one bundle's capability matrix
+func printCapabilities(bundle resigner.BundleCapabilities) {
+	fmt.Printf("\n📦 %s (%s)\n", bundle.Path, bundle.BundleID)
+	for _, capability := range bundle.Capabilities {
+		mark := "  "
+		if capability.Enabled {
+			mark = "✅"
+		}
+		fmt.Printf("  %s %-28s %s\n", mark, capability.Name, strings.Join(capability.Details, ", "))
+	}
+
+	if len(bundle.UsageDescriptions) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(bundle.UsageDescriptions))
+	for key := range bundle.UsageDescriptions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Println("\n  🔒 Privacy usage descriptions:")
+	for _, key := range keys {
+		fmt.Printf("     %s: %q\n", key, bundle.UsageDescriptions[key])
+	}
+}
+
+func init() {
+	capabilitiesCmd.Flags().BoolVar(&capabilitiesJSON, "json", false, "Print the report as JSON, including the raw entitlements")
+
+	rootCmd.AddCommand(capabilitiesCmd)
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/resignipa/pkg/resigner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cleanupDryRun   bool
+	cleanupCacheDir string
+	cleanupYes      bool
+)
+
+var cleanupCmd = &cobra.Command{
+	Use:   "cleanup [source or directory ...]",
+	Short: "Remove temporary workspaces, outputs and caches left by resigning",
+	Long: `Remove what resigning leaves behind next to sources: the tmp-*/ workspaces of
+interrupted runs and the default Resigned/ output directory. Each argument is a
+source IPA or .app, whose directory is cleaned, or a directory that held
+sources; without arguments the current directory is cleaned.
+
+With --cache-dir, the cached workspaces in that directory are removed as well.
+Other files in the cache directory are kept.
+
+Use --dry-run to list what would be removed and how much space it takes.
+Without --yes, cleanup asks before removing anything.
+
+Example:
+  resignipa cleanup --dry-run ~/Downloads
+  resignipa cleanup app.ipa
+  resignipa cleanup --cache-dir ~/.cache/resignipa --yes`,
+	Run: func(cmd *cobra.Command, args []string) {
+		targets, err := cleanupTargets(args, cleanupCacheDir)
+		if err != nil {
+			fmt.Printf("\n❌ Cleanup failed: %v\n", err)
+			os.Exit(1)
+		}
+		if len(targets) == 0 {
+			fmt.Println("✅ Nothing to clean up")
+			return
+		}
+
+		var total int64
+		for _, target := range targets {
+			size := diskUsage(target)
+			total += size
+			fmt.Printf("   %-60s %s\n", target, formatBytes(size))
+		}
+		fmt.Printf("\n%d item(s), %s\n", len(targets), formatBytes(total))
+
+		if cleanupDryRun {
+			fmt.Println("Dry run, nothing was removed")
+			return
+		}
+		if !cleanupYes {
+			if !isInteractive() {
+				fmt.Println("\n❌ Cleanup failed: confirm with --yes when not running in a terminal")
+				os.Exit(1)
+			}
+			if !promptConfirm(fmt.Sprintf("Remove %d item(s)", len(targets))) {
+				return
+			}
+		}
+
+		failed := false
+		for _, target := range targets {
+			if err := os.RemoveAll(target); err != nil {
+				fmt.Printf("❌ %v\n", err)
+				failed = true
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Freed %s\n", formatBytes(total))
+	},
+}
+
+// cleanupTargets collects the leftovers next to each source or directory
+// in paths, followed by the entries of cacheDir
+func cleanupTargets(paths []string, cacheDir string) ([]string, error) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	seen := make(map[string]bool)
+	var targets []string
+	add := func(target string) {
+		if abs, err := filepath.Abs(target); err == nil {
+			target = abs
+		}
+		if !seen[target] {
+			seen[target] = true
+			targets = append(targets, target)
+		}
+	}
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		// A .app bundle is a directory, but is cleaned like an IPA
+		dir := path
+		if !info.IsDir() || filepath.Ext(path) == ".app" {
+			dir = filepath.Dir(path)
+		}
+		for _, leftover := range resigner.Leftovers(dir) {
+			add(leftover)
+		}
+	}
+
+	if cacheDir != "" {
+		entries, err := resigner.CacheEntries(cacheDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			add(entry)
+		}
+	}
+	return targets, nil
+}
+
+// diskUsage adds up the size of the files under path
+func diskUsage(path string) int64 {
+	var size int64
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+// formatBytes renders a size for humans
+func formatBytes(size int64) string {
+	return resigner.FormatSize(size)
+}
+
+func init() {
+	cleanupCmd.Flags().BoolVarP(&cleanupDryRun, "dry-run", "n", false, "List what would be removed without removing it")
+	cleanupCmd.Flags().StringVar(&cleanupCacheDir, "cache-dir", "", "Also remove the cached workspaces in this directory")
+	cleanupCmd.Flags().BoolVarP(&cleanupYes, "yes", "y", false, "Remove without asking")
+
+	rootCmd.AddCommand(cleanupCmd)
+}
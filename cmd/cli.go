@@ -1,9 +1,14 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"time"
 
 	"github.com/resignipa/pkg/resigner"
 	"github.com/spf13/cobra"
@@ -12,9 +17,69 @@ import (
 var (
 	sourceIPA       string
 	certificate     string
+	keychain        string
 	entitlements    string
 	mobileProvision string
+	provisionBase64 string
+	p12Base64       string
+	p12Password     string
 	bundleID        string
+	teamID          string
+	dsymPath        string
+	privacyManifest string
+	expectSHA256    string
+	archivePassword string
+	outputDir       string
+	concurrency     int
+	verify          bool
+	skipValid       bool
+	timeout         time.Duration
+	commandTimeout  time.Duration
+	fixBundle       bool
+	noQuarantine    bool
+	installProfile  bool
+	cacheDir        string
+	dumpDir         string
+	maxOutputSize   sizeFlag
+	warnOutputSize  sizeFlag
+	presetName      string
+	savePreset      string
+	recipeFile      string
+	exportRecipe    string
+	outputFormat    string
+	assumeYes       bool
+	quiet           bool
+	adHoc           bool
+	inPlace         bool
+	noGUI           bool
+	device          string
+
+	componentIdentities map[string]string
+	hooks               map[string]string
+	hookTimeout         time.Duration
+	policyFile          string
+	archivePasswordRef  string
+	p12PasswordRef      string
+
+	mdmManifestURL   string
+	mdmDisplayImage  string
+	mdmFullSizeImage string
+	mdmTitle         string
+	mdmChunkSize     int64
+
+	bundleFromProfile      bool
+	forceBundleFromProfile bool
+	suffixOnConflict       bool
+	enterprise             bool
+	autoStrip              bool
+
+	skipVerify  bool
+	skipPackage bool
+	stopAfter   string
+
+	entitlementsMerge string
+	betaReports       string
+	compression       string
 )
 
 var rootCmd = &cobra.Command{
@@ -23,10 +88,21 @@ var rootCmd = &cobra.Command{
 	Long: `ResignIPA is a tool that allows you to resign iOS IPA files with a new certificate,
 provisioning profile, bundle identifier, and entitlements.
 
-If no arguments are provided, the GUI will be launched.`,
+If no arguments are provided in a terminal, the GUI will be launched. In CI,
+scripts without a terminal, or with --no-gui, usage is printed instead; use
+"resignipa gui" to launch the GUI explicitly.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// If no flags are set, launch GUI
 		if sourceIPA == "" && certificate == "" {
+			// A script passing empty variables must fail rather than open
+			// a window nobody will close
+			if noGUI || !guiAllowed() {
+				fmt.Println("❌ Error: no source (-s) or certificate (-c) given")
+				fmt.Println("Run `resignipa gui` to launch the GUI.")
+				fmt.Println()
+				printUsageExamples()
+				os.Exit(1)
+			}
 			LaunchGUI()
 			return
 		}
@@ -51,46 +127,389 @@ Example:
 func init() {
 	// Add flags to both root and resign commands
 	for _, cmd := range []*cobra.Command{rootCmd, resignCmd} {
-		cmd.Flags().StringVarP(&sourceIPA, "source", "s", "", "Path to IPA file which you want to sign/resign (required)")
+		cmd.Flags().StringVarP(&sourceIPA, "source", "s", "", "Path or s3:// / gs:// URL of the IPA file which you want to sign/resign (required)")
 		cmd.Flags().StringVarP(&certificate, "certificate", "c", "", "Signing certificate Common Name from Keychain (required)")
+		cmd.Flags().StringVar(&keychain, "keychain", "", "Find the certificate in this keychain only (path or name), leaving the search list alone")
+		cmd.Flags().StringVar(&p12Base64, "p12-base64", "", "Signing certificate and key as a base64 .p12, imported into a temporary keychain; \"-\" reads stdin, or use RESIGNIPA_P12_B64")
+		cmd.Flags().StringVar(&p12Password, "p12-password", "", "Password of the --p12-base64 certificate; prefer RESIGNIPA_P12_PASSWORD over the command line")
+		cmd.Flags().StringVar(&p12PasswordRef, "p12-password-ref", "", "Read the p12 password from a secret reference: env:NAME, keychain:service/account, op://vault/item/field or vault:path#field")
 		cmd.Flags().StringVarP(&entitlements, "entitlements", "e", "", "New entitlements to change (optional)")
 		cmd.Flags().StringVarP(&mobileProvision, "provision", "p", "", "Path to mobile provisioning file (optional)")
+		cmd.Flags().StringVar(&provisionBase64, "provision-base64", "", "Provisioning profile as base64, e.g. from a CI secret; prefer RESIGNIPA_PROFILE_B64 over the command line")
 		cmd.Flags().StringVarP(&bundleID, "bundle", "b", "", "Bundle identifier (optional)")
+		cmd.Flags().StringVar(&teamID, "team-id", "", "Team ID for team-scoped entitlements (default: detected from certificate or profile)")
+		cmd.Flags().StringVarP(&outputDir, "output-dir", "o", "", "Directory, or s3:// / gs:// folder URL, for the resigned output (default: Resigned/ next to the source)")
+		cmd.Flags().IntVar(&concurrency, "concurrency", 1, "Number of components to sign in parallel")
+		cmd.Flags().BoolVar(&fixBundle, "fix", false, "Repair common bundle defects (junk files, broken symlinks, missing executable bits) before signing")
+		cmd.Flags().BoolVar(&noQuarantine, "no-quarantine", false, "Remove the com.apple.quarantine attribute from the output")
+		cmd.Flags().BoolVar(&installProfile, "install-profile", false, "Install the --provision profile into ~/Library/MobileDevice/Provisioning Profiles after signing")
+		cmd.Flags().BoolVar(&skipValid, "skip-valid", false, "Leave components already signed by the same identity and entitlements untouched")
+		cmd.Flags().DurationVar(&timeout, "timeout", 0, "Fail if the whole resign takes longer than this, e.g. 30m (default: no limit)")
+		cmd.Flags().DurationVar(&commandTimeout, "command-timeout", 0, "Fail if a single codesign or security call takes longer than this, e.g. 5m (default: no limit)")
+		cmd.Flags().BoolVar(&verify, "verify", false, "Verify the signature with codesign --verify --strict after signing")
+		cmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Confirm destructive steps (overwriting outputs, dropping entitlements) without asking")
+		cmd.Flags().StringVar(&outputFormat, "output-format", formatText, "Result format: text, json, junit or github-actions")
+		cmd.Flags().StringVar(&presetName, "preset", "", "Fill options not given on the command line from a saved preset")
+		cmd.Flags().StringVar(&savePreset, "save-preset", "", "Save the given options as a named preset (the first becomes the default)")
+		cmd.Flags().StringVar(&recipeFile, "recipe", "", "Fill options not given on the command line from a "+resigner.RecipeExt+" archive")
+		cmd.Flags().StringVar(&exportRecipe, "export-recipe", "", "Save the options, entitlements, profile and privacy manifest of this run as a "+resigner.RecipeExt+" archive")
+		cmd.Flags().StringVar(&expectSHA256, "expect-sha256", "", "Fail unless the source file has this SHA-256 digest")
+		cmd.Flags().StringVar(&archivePassword, "archive-password", "", "Password for encrypted (ZipCrypto) IPA archives")
+		cmd.Flags().StringVar(&archivePasswordRef, "archive-password-ref", "", "Read the archive password from a secret reference: env:NAME, keychain:service/account, op://vault/item/field or vault:path#field")
+		cmd.Flags().StringVar(&privacyManifest, "privacy-manifest", "", "PrivacyInfo.xcprivacy to merge into the app's privacy manifest")
+		cmd.Flags().StringVar(&cacheDir, "cache-dir", "", "Reuse extracted workspaces across runs of the same IPA (keyed by SHA-256)")
+		cmd.Flags().StringVar(&compression, "compression", "", "Output IPA compression: balanced (default), fast, max or store")
+		cmd.Flags().Var(&maxOutputSize, "max-output-size", "Fail if the output IPA is larger than this, e.g. 150MB")
+		cmd.Flags().Var(&warnOutputSize, "warn-output-size", "Warn if the output IPA is larger than this, e.g. 100MB")
+		cmd.Flags().StringVar(&dumpDir, "dump-entitlements", "", "Write the entitlements each component was actually signed with to this folder")
+		cmd.Flags().StringVar(&dsymPath, "dsym", "", "dSYM bundle, folder or zip to verify against the signed binaries and package with the output")
+		cmd.Flags().BoolVar(&inPlace, "in-place", false, "Sign an extracted .app directory directly, without copying it or creating an IPA")
+		cmd.Flags().BoolVar(&adHoc, "adhoc", false, "Sign ad-hoc (no identity or provisioning profile); -c is not required")
+		cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Suppress progress output; only the result and errors are printed")
+		cmd.Flags().BoolVar(&bundleFromProfile, "bundle-from-profile", false, "Set the bundle ID (and derived extension IDs) from the provisioning profile's app ID")
+		cmd.Flags().BoolVar(&forceBundleFromProfile, "force-bundle-from-profile", false, "Adopt the provisioning profile's explicit app ID when the bundle ID does not match")
+		cmd.Flags().StringVar(&device, "device", "", "UDID of the device to install on; checks it for the same app from another team (needs ideviceinstaller)")
+		cmd.Flags().StringVar(&mdmManifestURL, "mdm-manifest-url", "", "Write App.manifest.plist for MDM InstallApplication next to the IPA, which will be served from this URL")
+		cmd.Flags().StringVar(&mdmDisplayImage, "mdm-display-image", "", "URL of the 57x57 icon shown while the MDM install runs")
+		cmd.Flags().StringVar(&mdmFullSizeImage, "mdm-full-size-image", "", "URL of the 512x512 icon shown while the MDM install runs")
+		cmd.Flags().StringVar(&mdmTitle, "mdm-title", "", "Title of the MDM manifest (default: the app's name)")
+		cmd.Flags().Int64Var(&mdmChunkSize, "mdm-chunk-size", resigner.DefaultMDMChunkSize, "Size in bytes of the chunks whose MD5s the MDM manifest lists")
+		cmd.Flags().StringVar(&entitlementsMerge, "entitlements-merge", "", "Combine -e with the profile's entitlements: replace (default), merge-prefer-user or merge-prefer-profile")
+		cmd.Flags().StringVar(&betaReports, "beta-reports", "", "TestFlight's beta-reports-active entitlement: auto (default, follows the profile), add or remove")
+		cmd.Flags().BoolVar(&autoStrip, "auto-strip", false, "Remove entitlements the provisioning profile cannot satisfy instead of asking about each")
+		cmd.Flags().BoolVar(&enterprise, "enterprise", false, "Require an In-House profile and report the date the app must be re-signed by")
+		cmd.Flags().StringToStringVar(&hooks, "hook", nil, "Run a script around a stage, e.g. after-sign=./upload-dsyms.sh (repeatable); it gets no secrets from the environment")
+		cmd.Flags().DurationVar(&hookTimeout, "hook-timeout", 0, "Fail if a hook script takes longer than this (default 5m)")
+		cmd.Flags().StringToStringVar(&componentIdentities, "component-identity", nil, "Sign nested components of a kind with another certificate, e.g. .xpc=\"Developer ID Application: Name\" (repeatable)")
+		cmd.Flags().StringVar(&policyFile, "policy", "", "YAML policy restricting certificates, team IDs, bundle IDs and entitlements; runs breaking it fail")
+		cmd.Flags().BoolVar(&skipVerify, "skip-verify", false, "Skip the verify stage, even when --verify or a preset asks for it")
+		cmd.Flags().BoolVar(&skipPackage, "skip-package", false, "Skip the package stage; the signed app is left in the kept workspace")
+		cmd.Flags().StringVar(&stopAfter, "stop-after", "", "End the run after this stage: "+strings.Join(resigner.DefaultPipeline().Names(), ", "))
+		cmd.Flags().BoolVar(&suffixOnConflict, "suffix-on-conflict", false, "Make the bundle ID unique to the team when --device has the app from another team")
 	}
 
+	rootCmd.Flags().BoolVar(&noGUI, "no-gui", false, "Never fall back to the GUI when no options are given")
+
 	rootCmd.AddCommand(resignCmd)
 }
 
 func runCLI() {
+	report := newRunReport(sourceIPA)
+	machine := outputFormat != formatText && isOutputFormat(outputFormat)
+
+	// Secrets must not reach the terminal or reports, even through errors
+	// raised before the resigner (which masks its own output) runs
+	var redactor resigner.Redactor
+	redactor.Add(archivePassword)
+
+	// remote holds the local copies of s3:// and gs:// locations, and
+	// recipe the files unpacked from --recipe
+	var remote *remoteRun
+	var recipe *recipeRun
+
+	// fail reports err in the selected format and exits
+	fail := func(res *resigner.Resigner, err error, usage bool) {
+		remote.close()
+		recipe.close()
+		err = redactor.RedactError(err)
+		if machine {
+			report.finish(res, err)
+			report.write(os.Stdout, outputFormat)
+			os.Exit(1)
+		}
+		if res != nil {
+			fmt.Printf("\n❌ Resign failed: %v\n", err)
+			printStageSummary(res)
+			printTroubleshootingHelp(err)
+		} else {
+			fmt.Printf("\n❌ Error: %v\n", err)
+			if usage {
+				fmt.Println()
+				printUsageExamples()
+			}
+		}
+		os.Exit(1)
+	}
+
+	if !isOutputFormat(outputFormat) {
+		fail(nil, fmt.Errorf("unknown output format %q (use one of: %s)", outputFormat, strings.Join(outputFormats, ", ")), false)
+	}
+
+	// Fill unset options from the preset before validating them
+	if presetName != "" {
+		if err := applyPresetFlags(presetName); err != nil {
+			fail(nil, err, false)
+		}
+	}
+	if recipeFile != "" {
+		var err error
+		if recipe, err = applyRecipeFlags(recipeFile); err != nil {
+			fail(nil, err, false)
+		}
+		defer recipe.close()
+	}
+
+	// The library never prints itself, so progress only reaches stdout
+	// when we ask for it. Structured formats own stdout, so their progress
+	// goes to stderr.
+	progress := os.Stdout
+	if outputFormat == formatJSON || outputFormat == formatJUnit {
+		progress = os.Stderr
+	}
+	logf := func(format string, args ...interface{}) {
+		if !quiet {
+			fmt.Fprintf(progress, format+"\n", args...)
+		}
+	}
+
+	// Cancel cleanly on Ctrl-C so in-flight transfers and codesign calls
+	// are killed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Look up referenced secrets before anything can print them
+	password, err := resolveSecret(ctx, "archive-password", archivePassword, archivePasswordRef)
+	if err != nil {
+		fail(nil, err, false)
+	}
+	archivePassword = password
+	redactor.Add(archivePassword)
+	password, err = resolveSecret(ctx, "p12-password", p12Password, p12PasswordRef)
+	if err != nil {
+		fail(nil, err, false)
+	}
+	p12Password = password
+	redactor.Add(p12Password)
+	if provisionBase64 == "-" && p12Base64 == "-" {
+		fail(nil, fmt.Errorf("only one of --provision-base64 and --p12-base64 can be read from stdin"), false)
+	}
+	profileData, err := decodeBase64("provision-base64", provisionBase64, os.Stdin)
+	if err != nil {
+		fail(nil, err, false)
+	}
+	p12Data, err := decodeBase64("p12-base64", p12Base64, os.Stdin)
+	if err != nil {
+		fail(nil, err, false)
+	}
+
+	// Fetch a remote source before it is validated like a local one
+	remote, err = prepareRemote(ctx, logf)
+	if err != nil {
+		fail(nil, err, false)
+	}
+	defer remote.close()
+
 	// Validate required flags
 	if err := validateCLIArguments(); err != nil {
-		fmt.Printf("\n❌ Error: %v\n\n", err)
-		printUsageExamples()
-		os.Exit(1)
+		fail(nil, err, true)
+	}
+	recipe.checkSource(logf)
+
+	if savePreset != "" {
+		if err := savePresetFlags(savePreset); err != nil {
+			fail(nil, fmt.Errorf("failed to save preset: %w", err), false)
+		}
+		if !machine {
+			fmt.Printf("💾 Saved preset %q\n", savePreset)
+		}
 	}
 
 	// Create config
 	config := resigner.Config{
 		SourceIPA:       sourceIPA,
 		Certificate:     certificate,
+		Keychain:        keychain,
 		Entitlements:    entitlements,
 		MobileProvision: mobileProvision,
 		BundleID:        bundleID,
+		TeamID:          teamID,
+		AdHoc:           adHoc,
+		DSYM:            dsymPath,
+		PrivacyManifest: privacyManifest,
+		ExpectSHA256:    expectSHA256,
+		ArchivePassword: archivePassword,
+		OutputDir:       outputDir,
+		Concurrency:     concurrency,
+		Verify:          verify,
+		SkipValid:       skipValid,
+		Timeout:         timeout,
+		CommandTimeout:  commandTimeout,
+		Fix:             fixBundle,
+		NoQuarantine:    noQuarantine,
+		InstallProfile:  installProfile,
+		CacheDir:        cacheDir,
+		InPlace:         inPlace,
+		AssumeYes:       assumeYes,
+
+		BundleFromProfile:      bundleFromProfile,
+		ForceBundleFromProfile: forceBundleFromProfile,
+		Device:                 device,
+		SuffixOnConflict:       suffixOnConflict,
+		Enterprise:             enterprise,
+		AutoStrip:              autoStrip,
+		ComponentIdentities:    componentIdentities,
+		MobileProvisionData:    profileData,
+		P12:                    p12Data,
+		P12Password:            p12Password,
+		Hooks:                  hooks,
+		HookTimeout:            hookTimeout,
+		EntitlementsMerge:      resigner.EntitlementsMerge(entitlementsMerge),
+		BetaReports:            resigner.BetaReports(betaReports),
+		DumpEntitlements:       dumpDir,
+		Compression:            resigner.Compression(compression),
+		MaxOutputSize:          int64(maxOutputSize),
+		WarnOutputSize:         int64(warnOutputSize),
+	}
+	if config.Policy, err = loadPolicy(policyFile); err != nil {
+		fail(nil, err, false)
+	}
+	pipeline, err := stagePipeline(skipVerify, skipPackage, stopAfter)
+	if err != nil {
+		fail(nil, err, false)
+	}
+	// Keep the signed app when no output will be written
+	config.KeepWorkspace = !hasStage(pipeline, resigner.StagePackage) && !inPlace
+	if mdmManifestURL != "" {
+		config.MDMManifest = &resigner.MDMManifest{
+			URL:              mdmManifestURL,
+			DisplayImageURL:  mdmDisplayImage,
+			FullSizeImageURL: mdmFullSizeImage,
+			Title:            mdmTitle,
+			ChunkSize:        mdmChunkSize,
+		}
 	}
 
-	// Create resigner with progress callback
-	r := resigner.NewResigner(config, func(message string) {
-		fmt.Println(message)
-	})
+	if exportRecipe != "" {
+		path := recipePath(exportRecipe)
+		if err := resigner.ExportRecipe(config, path); err != nil {
+			fail(nil, fmt.Errorf("failed to export recipe: %w", err), false)
+		}
+		logf("📦 Saved recipe %s", path)
+	}
+
+	// Create resigner
+	opts := []resigner.Option{resigner.WithEventHandler(report.observe), resigner.WithPipeline(pipeline)}
+	if !assumeYes && isInteractive() {
+		opts = append(opts, resigner.WithConfirm(promptConfirm), resigner.WithConflictResolver(promptConflict))
+	} else if !assumeYes {
+		// Nobody to ask: refuse destructive steps unless --yes allows them
+		opts = append(opts, resigner.WithConfirm(func(string) bool { return false }))
+	}
+	if !quiet {
+		eta := &etaEstimator{}
+		if inGitHubActions() {
+			// Structured github-actions output annotates from the report
+			grouper := &githubGrouper{w: progress, annotate: outputFormat == formatText}
+			opts = append(opts, resigner.WithEventHandler(func(event resigner.Event) {
+				grouper.Print(event, withETA(eta, event))
+			}))
+			defer grouper.close()
+		} else {
+			opts = append(opts, resigner.WithEventHandler(func(event resigner.Event) {
+				fmt.Fprintln(progress, withETA(eta, event))
+			}))
+		}
+	}
+	r := resigner.New(config, opts...)
 
 	// Run resign
-	if err := r.Resign(); err != nil {
-		fmt.Printf("\n❌ Resign failed: %v\n", err)
-		printTroubleshootingHelp(err)
-		os.Exit(1)
+	if _, err := r.ResignContext(ctx); err != nil {
+		stop()
+		fail(r, err, false)
+	}
+	uploaded, err := remote.upload(ctx, r.OutputPath(), logf)
+	if err != nil {
+		stop()
+		fail(r, err, false)
 	}
 
-	fmt.Println("\n✅ Successfully resigned IPA!")
+	if machine {
+		report.finish(r, nil)
+		report.OutputPath = uploaded
+		if err := report.write(os.Stdout, outputFormat); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write report: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if hasStage(pipeline, resigner.StagePackage) {
+		fmt.Println("\n✅ Successfully resigned IPA!")
+	} else {
+		stages := r.Result().Stages
+		fmt.Printf("\n✅ Stopped after the %s stage\n", stages[len(stages)-1].Name)
+	}
+	printStageSummary(r)
+}
+
+// printStageSummary prints the stage timings of a run unless --quiet
+// asked for only the result
+func printStageSummary(r *resigner.Resigner) {
+	result := r.Result()
+	if summary := stageSummary(result.Stages, result.Duration); summary != "" && !quiet {
+		fmt.Print("\n" + summary)
+	}
+	if result.Workspace != "" {
+		fmt.Printf("📂 Workspace kept at %s\n", result.Workspace)
+	}
+}
+
+// isInteractive reports whether stdin is a terminal we can prompt on
+func isInteractive() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// guiAllowed reports whether running without options may open the GUI:
+// from a terminal, or as the app bundle opened from Finder, but never in
+// CI or from a script without a terminal
+func guiAllowed() bool {
+	if os.Getenv("CI") != "" {
+		return false
+	}
+	if isInteractive() {
+		return true
+	}
+	exe, err := os.Executable()
+	return err == nil && strings.Contains(exe, ".app/Contents/MacOS/")
+}
+
+// promptConfirm asks a yes/no question on the terminal, defaulting to no
+func promptConfirm(prompt string) bool {
+	fmt.Fprintf(os.Stderr, "⚠️  %s. Continue? [y/N] ", prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// promptConflict asks what to do about an entitlement the provisioning
+// profile cannot satisfy, defaulting to keeping it
+func promptConflict(conflict resigner.EntitlementConflict) resigner.ConflictAction {
+	fmt.Fprintf(os.Stderr, "⚠️  Entitlement %s: %s. [s]trip, [k]eep or [a]bort? [k] ", conflict.Key, conflict.Reason)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return resigner.ConflictKeep
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "s", "strip":
+		return resigner.ConflictStrip
+	case "a", "abort":
+		return resigner.ConflictAbort
+	default:
+		return resigner.ConflictKeep
+	}
+}
+
+// isOutputFormat reports whether format is a valid --output-format value
+func isOutputFormat(format string) bool {
+	for _, f := range outputFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
 }
 
 // validateCLIArguments validates all CLI arguments and checks file existence
@@ -100,8 +519,24 @@ func validateCLIArguments() error {
 		return fmt.Errorf("source IPA path is required (use -s flag)")
 	}
 
-	if certificate == "" {
-		return fmt.Errorf("certificate is required (use -c flag)")
+	if adHoc {
+		if certificate != "" && certificate != "-" {
+			return fmt.Errorf("--adhoc cannot be combined with a certificate")
+		}
+		if mobileProvision != "" || provisionBase64 != "" {
+			return fmt.Errorf("--adhoc cannot be combined with a provisioning profile")
+		}
+		if p12Base64 != "" {
+			return fmt.Errorf("--adhoc cannot be combined with a p12 certificate")
+		}
+	} else if certificate == "" {
+		return fmt.Errorf("certificate is required (use -c flag, or --adhoc)")
+	}
+	if mobileProvision != "" && provisionBase64 != "" {
+		return fmt.Errorf("--provision and --provision-base64 cannot be combined")
+	}
+	if keychain != "" && p12Base64 != "" {
+		return fmt.Errorf("--keychain and --p12-base64 cannot be combined")
 	}
 
 	// Check if source file exists
@@ -124,6 +559,20 @@ func validateCLIArguments() error {
 		return fmt.Errorf("source file must be .ipa or .app, got: %s", sourceIPA)
 	}
 
+	// Catch broken downloads before anything else; the checksum itself is
+	// verified by the resigner so large files are only hashed once
+	if strings.HasSuffix(strings.ToLower(sourceIPA), ".ipa") {
+		if err := resigner.VerifyArchive(sourceIPA); err != nil {
+			return err
+		}
+	}
+
+	if inPlace {
+		if info, err := os.Stat(sourceIPA); err != nil || !info.IsDir() || !strings.HasSuffix(sourceIPA, ".app") {
+			return fmt.Errorf("--in-place requires an .app directory, got: %s", sourceIPA)
+		}
+	}
+
 	// Check optional files if provided
 	if entitlements != "" {
 		if _, err := os.Stat(entitlements); os.IsNotExist(err) {
@@ -135,6 +584,10 @@ func validateCLIArguments() error {
 		if len(entitlements) < 6 || entitlements[len(entitlements)-6:] != ".plist" {
 			return fmt.Errorf("entitlements file must be .plist, got: %s", entitlements)
 		}
+		// Parse it so malformed files fail here rather than inside codesign
+		if err := resigner.ValidateEntitlementsFile(entitlements); err != nil {
+			return err
+		}
 	}
 
 	if mobileProvision != "" {
@@ -149,33 +602,37 @@ func validateCLIArguments() error {
 		}
 	}
 
-	// Validate bundle ID format if provided
-	if bundleID != "" {
-		if len(bundleID) < 3 || !isValidBundleID(bundleID) {
-			return fmt.Errorf("invalid bundle ID format: %s (expected format: com.company.app)", bundleID)
+	if dsymPath != "" {
+		if _, err := os.Stat(dsymPath); os.IsNotExist(err) {
+			return fmt.Errorf("dSYM path does not exist: %s", dsymPath)
+		} else if err != nil {
+			return fmt.Errorf("cannot access dSYM path %s: %v", dsymPath, err)
 		}
 	}
 
-	return nil
-}
+	if privacyManifest != "" {
+		if _, err := resigner.ParsePrivacyManifest(privacyManifest); err != nil {
+			return fmt.Errorf("invalid privacy manifest: %v", err)
+		}
+	}
 
-// isValidBundleID checks if bundle ID has valid format
-func isValidBundleID(bundleID string) bool {
-	// Basic validation: should contain at least one dot and only valid characters
-	if len(bundleID) == 0 {
-		return false
+	// Validate bundle ID format if provided
+	if bundleID != "" {
+		if err := resigner.ValidateBundleID(bundleID); err != nil {
+			return fmt.Errorf("%v (expected format: com.company.app)", err)
+		}
+		if bundleFromProfile {
+			return fmt.Errorf("--bundle and --bundle-from-profile cannot be used together")
+		}
 	}
 
-	hasDot := false
-	for _, ch := range bundleID {
-		if ch == '.' {
-			hasDot = true
-		} else if !((ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') || ch == '-') {
-			return false
+	if teamID != "" {
+		if err := resigner.ValidateTeamID(teamID); err != nil {
+			return err
 		}
 	}
 
-	return hasDot
+	return nil
 }
 
 // printUsageExamples prints usage examples
@@ -198,14 +655,61 @@ func printUsageExamples() {
 	fmt.Println("Resign .app bundle:")
 	fmt.Println("  resignipa -s MyApp.app -c \"Apple Development: Name\"")
 	fmt.Println()
+	fmt.Println("Ad-hoc signing (no certificate or profile):")
+	fmt.Println("  resignipa -s app.ipa --adhoc")
+	fmt.Println()
+	fmt.Println("Simulator build, signed ad-hoc:")
+	fmt.Println("  resignipa -s MyApp.app -c -")
+	fmt.Println()
 	fmt.Println("Required:")
 	fmt.Println("  -s, --source       Path to .ipa or .app file")
-	fmt.Println("  -c, --certificate  Certificate name from Keychain")
+	fmt.Println("  -c, --certificate  Certificate name from Keychain (\"-\" for ad-hoc)")
 	fmt.Println()
 	fmt.Println("Optional:")
 	fmt.Println("  -p, --provision    Mobile provisioning file (.mobileprovision)")
+	fmt.Println("  --provision-base64 The profile as base64 (or RESIGNIPA_PROFILE_B64)")
+	fmt.Println("  --p12-base64       Certificate .p12 as base64, \"-\" for stdin (or RESIGNIPA_P12_B64)")
+	fmt.Println("                     Imported into a temporary keychain; also --p12-password")
 	fmt.Println("  -b, --bundle       New bundle identifier")
 	fmt.Println("  -e, --entitlements Custom entitlements file (.plist)")
+	fmt.Println("  --keychain PATH    Find the certificate in this keychain only")
+	fmt.Println("  -o, --output-dir   Directory for the resigned output")
+	fmt.Println("  --concurrency      Components signed in parallel (default 1)")
+	fmt.Println("  --verify           Verify the signature after signing")
+	fmt.Println("  --timeout          Limit for the whole run, e.g. 30m")
+	fmt.Println("  --command-timeout  Limit for each codesign/security call, e.g. 5m")
+	fmt.Println("  --fix              Repair common bundle defects before signing")
+	fmt.Println("  --no-quarantine    Remove the quarantine attribute from the output")
+	fmt.Println("  --install-profile  Install the provisioning profile for Xcode device installs")
+	fmt.Println("  --cache-dir DIR    Reuse the extracted IPA on repeat runs")
+	fmt.Println("  --compression      balanced, fast, max or store")
+	fmt.Println("  --hook WHEN-STAGE=SCRIPT")
+	fmt.Println("                     Run a script before or after a stage, e.g. after-sign=./check.sh")
+	fmt.Println("  --max-output-size  Fail if the IPA is larger, e.g. 150MB; also --warn-output-size")
+	fmt.Println("  --dump-entitlements DIR")
+	fmt.Println("                     Save each component's signed entitlements")
+	fmt.Println("  -y, --yes          Allow overwriting outputs and dropping entitlements")
+	fmt.Println("  --output-format    text, json, junit or github-actions")
+	fmt.Println("  --preset NAME      Use a saved preset for options not given")
+	fmt.Println("  --save-preset NAME Save these options as a preset")
+	fmt.Println("  --expect-sha256    Required SHA-256 digest of the source file")
+	fmt.Println("  --archive-password Password for an encrypted IPA")
+	fmt.Println("  --stop-after STAGE Stop after a stage (e.g. sign); also --skip-verify, --skip-package")
+	fmt.Println("  --archive-password-ref")
+	fmt.Println("                     Read it from env:, keychain:, op:// or vault:")
+	fmt.Println("  --dsym             dSYMs (.dSYM, folder or .zip) to check and package")
+	fmt.Println("  --privacy-manifest Merge a PrivacyInfo.xcprivacy into the app")
+	fmt.Println("  --in-place         Sign an .app directory without copying or packaging")
+	fmt.Println("  --adhoc            Sign ad-hoc without an identity or profile")
+	fmt.Println("  --team-id          Team ID for entitlements (detected if omitted)")
+	fmt.Println("  -q, --quiet        Only print the result and errors")
+	fmt.Println("  --bundle-from-profile")
+	fmt.Println("                     Set the bundle ID from the provisioning profile's app ID")
+	fmt.Println("  --force-bundle-from-profile")
+	fmt.Println("                     Use the profile's app ID when the bundle ID does not match")
+	fmt.Println()
+	fmt.Println("Every option can also be set with a RESIGNIPA_<OPTION> environment variable,")
+	fmt.Println("e.g. RESIGNIPA_CERTIFICATE or RESIGNIPA_ARCHIVE_PASSWORD; flags take precedence.")
 	fmt.Println()
 	fmt.Println("Find your certificate:")
 	fmt.Println("  security find-identity -v -p codesigning")
@@ -226,6 +730,11 @@ func printTroubleshootingHelp(err error) {
 		fmt.Println("• Check if certificate is expired")
 	}
 
+	if strings.Contains(errStr, "private key") {
+		fmt.Println("• Unlock the keychain: security unlock-keychain login.keychain-db")
+		fmt.Println("• Allow codesign to use the key (Keychain Access → key → Access Control)")
+	}
+
 	if strings.Contains(errStr, "provision") {
 		fmt.Println("• Check provisioning profile is valid")
 		fmt.Println("• Ensure profile matches the certificate")
@@ -237,6 +746,52 @@ func printTroubleshootingHelp(err error) {
 		fmt.Println("• Check entitlements file is valid XML/plist format")
 	}
 
+	if errors.Is(err, resigner.ErrTimeout) {
+		fmt.Println("• On a headless Mac, codesign may be waiting for a keychain prompt nobody can answer")
+		fmt.Println("• Unlock the keychain first: security unlock-keychain login.keychain-db")
+		fmt.Println("• Allow codesign without prompting: security set-key-partition-list -S apple-tool:,apple: -s login.keychain-db")
+	}
+
+	if errors.Is(err, resigner.ErrNotInHouse) {
+		fmt.Println("• Enterprise mode needs an In-House distribution profile from an Apple Developer Enterprise Program account")
+		fmt.Println("• Drop --enterprise to sign with development, ad-hoc or App Store profiles")
+	}
+
+	if errors.Is(err, resigner.ErrEntitlementConflict) {
+		fmt.Println("• Use a provisioning profile with the capability, or strip the entitlement")
+		fmt.Println("• Re-run with --auto-strip to remove every entitlement the profile lacks")
+	}
+
+	if errors.Is(err, resigner.ErrPolicy) {
+		fmt.Println("• This machine's signing policy does not allow the run; the error lists what it refused")
+		fmt.Println("• Ask whoever maintains the policy file to allow it, or sign with an allowed certificate and bundle ID")
+	}
+
+	if errors.Is(err, resigner.ErrSizeBudget) {
+		fmt.Println("• The error lists the largest parts of the IPA; slim those down or raise --max-output-size")
+		fmt.Println("• Use --warn-output-size instead to only be told about it")
+	}
+
+	if errors.Is(err, resigner.ErrNotConfirmed) {
+		fmt.Println("• A destructive step needs confirmation")
+		fmt.Println("• Re-run with --yes to allow it in non-interactive environments")
+	}
+
+	if !fixBundle && (strings.Contains(errStr, "unsealed contents") || strings.Contains(errStr, "bundle format")) {
+		fmt.Println("• The bundle contains files codesign rejects")
+		fmt.Println("• Re-run with --fix to remove junk files and broken symlinks")
+	}
+
+	if strings.Contains(errStr, "detritus") {
+		fmt.Println("• Some files still carry extended attributes codesign rejects")
+		fmt.Println("• Clear them all with: xattr -cr <App.app>")
+	}
+
+	if strings.Contains(errStr, "password") || strings.Contains(errStr, "encrypted") {
+		fmt.Println("• The IPA is password protected; pass --archive-password")
+		fmt.Println("• AES-encrypted archives must be re-exported with ZipCrypto")
+	}
+
 	if strings.Contains(errStr, "bundle") {
 		fmt.Println("• Bundle ID must match format: com.company.app")
 		fmt.Println("• If using provisioning profile, bundle ID must match")
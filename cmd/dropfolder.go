@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/resignipa/pkg/resigner"
+)
+
+// dropWatcher reports IPAs placed in a directory once they have stopped
+// changing, so half-copied files are never picked up
+type dropWatcher struct {
+	dir string
+	// settle is how long a file must stay unchanged before it is reported
+	settle time.Duration
+}
+
+// isDroppedIPA reports whether path is an IPA worth processing; hidden
+// files are skipped because browsers and Finder use them while copying
+func isDroppedIPA(path string) bool {
+	name := filepath.Base(path)
+	return !strings.HasPrefix(name, ".") && strings.EqualFold(filepath.Ext(name), ".ipa")
+}
+
+// Watch calls found for every IPA already in the directory and each one
+// added later, until ctx is cancelled
+func (w *dropWatcher) Watch(ctx context.Context, found func(path string)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	if err := watcher.Add(w.dir); err != nil {
+		return err
+	}
+
+	// Files changed since they were last seen, by path
+	pending := make(map[string]time.Time)
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		path := filepath.Join(w.dir, entry.Name())
+		if entry.Type().IsRegular() && isDroppedIPA(path) {
+			pending[path] = time.Time{}
+		}
+	}
+
+	ticker := time.NewTicker(w.settle / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !isDroppedIPA(event.Name) {
+				continue
+			}
+			if event.Has(fsnotify.Create) || event.Has(fsnotify.Write) {
+				pending[event.Name] = time.Now()
+			} else if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+				delete(pending, event.Name)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		case now := <-ticker.C:
+			for path, changed := range pending {
+				if now.Sub(changed) < w.settle {
+					continue
+				}
+				delete(pending, path)
+				if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+					found(path)
+				}
+			}
+		}
+	}
+}
+
+// resignDropped resigns a dropped IPA and files the source under
+// Processed/ or Failed/ in the drop folder
+func resignDropped(ctx context.Context, config resigner.Config, dropDir string, opts ...resigner.Option) error {
+	_, err := resigner.New(config, opts...).ResignContext(ctx)
+
+	folder := "Processed"
+	if err != nil {
+		folder = "Failed"
+	}
+	dest := filepath.Join(dropDir, folder)
+	if mkErr := os.MkdirAll(dest, 0755); mkErr == nil {
+		os.Rename(config.SourceIPA, filepath.Join(dest, filepath.Base(config.SourceIPA)))
+	}
+	return err
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// envPrefix starts the environment variable of every flag, e.g.
+// RESIGNIPA_CERTIFICATE for --certificate
+const envPrefix = "RESIGNIPA_"
+
+// envName returns the environment variable read for a flag
+func envName(flag string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// envAliases are further variables read for a flag when its own is unset,
+// named the way CI secrets commonly are
+var envAliases = map[string]string{
+	"provision-base64": "RESIGNIPA_PROFILE_B64",
+	"p12-base64":       "RESIGNIPA_P12_B64",
+}
+
+// envValue returns the value of a flag's environment variable or alias
+func envValue(flag string) (string, string) {
+	name := envName(flag)
+	if value := os.Getenv(name); value != "" {
+		return name, value
+	}
+	if alias, ok := envAliases[flag]; ok {
+		return alias, os.Getenv(alias)
+	}
+	return name, ""
+}
+
+// applyEnv sets every flag not given on the command line from its
+// environment variable, so CI can pass secrets without them appearing on
+// the command line or in the process list. Flags given explicitly win,
+// and presets only fill what both leave empty. Empty variables are
+// ignored.
+func applyEnv(flags *pflag.FlagSet) error {
+	var errs []error
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Changed || flag.Name == "help" {
+			return
+		}
+		name, value := envValue(flag.Name)
+		if value == "" {
+			return
+		}
+		if err := flags.Set(flag.Name, value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", name, err))
+		}
+	})
+	return errors.Join(errs...)
+}
+
+func init() {
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return applyEnv(cmd.Flags())
+	}
+}
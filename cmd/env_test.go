@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestApplyEnvAlias(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	var profile string
+	flags.StringVar(&profile, "provision-base64", "", "")
+
+	t.Setenv("RESIGNIPA_PROFILE_B64", "alias")
+	if err := applyEnv(flags); err != nil || profile != "alias" {
+		t.Errorf("applyEnv() = %q, %v, want the alias", profile, err)
+	}
+
+	// The flag's own variable wins over its alias
+	flags.Lookup("provision-base64").Changed = false
+	t.Setenv("RESIGNIPA_PROVISION_BASE64", "own")
+	if err := applyEnv(flags); err != nil || profile != "own" {
+		t.Errorf("applyEnv() = %q, %v, want the flag's variable", profile, err)
+	}
+}
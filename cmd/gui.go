@@ -1,10 +1,17 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"image/color"
+	"net/url"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
@@ -14,8 +21,27 @@ import (
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/resignipa/pkg/resigner"
+	"github.com/spf13/cobra"
 )
 
+var guiCmd = &cobra.Command{
+	Use:   "gui",
+	Short: "Launch the graphical interface",
+	Long: `Launch the ResignIPA window. Running resignipa without options does the
+same from a terminal, but never in CI or scripts; this command always opens it.
+
+Example:
+  resignipa gui`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		LaunchGUI()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(guiCmd)
+}
+
 // Professional compact theme
 type compactTheme struct {
 	fyne.Theme
@@ -91,21 +117,242 @@ func (c compactTheme) Size(name fyne.ThemeSizeName) float32 {
 
 // LaunchGUI starts the GUI application
 func LaunchGUI() {
-	myApp := app.New()
-	myApp.Settings().SetTheme(&compactTheme{})
+	// A stable ID lets fyne persist preferences between launches
+	myApp := app.NewWithID(guiAppID)
+	settings := loadSettings(myApp.Preferences())
+	applyTheme(myApp, settings.Theme)
 
 	window := myApp.NewWindow("ResignIPA")
 	window.Resize(fyne.NewSize(700, 750))
 	window.SetFixedSize(true) // Prevent resizing for consistent layout
 
+	// The quick actions work on the last finished run; its form survives
+	// restarts, its log only this session
+	var lastMu sync.Mutex
+	last, hasLast := decodeLastRun(myApp.Preferences().String(prefLastRun))
+	lastLog := ""
+	var resignAgainBtn, openOutputBtn, copyLastLogBtn *widget.Button
+	updateQuickActions := func() {
+		lastMu.Lock()
+		defer lastMu.Unlock()
+		for _, btn := range []*widget.Button{resignAgainBtn, openOutputBtn} {
+			if hasLast {
+				btn.Enable()
+			} else {
+				btn.Disable()
+			}
+		}
+		if lastLog != "" {
+			copyLastLogBtn.Enable()
+		} else {
+			copyLastLogBtn.Disable()
+		}
+	}
+
+	// Every tab is an independent job with its own form and log
+	jobs := newJobManager()
+	var tabs *container.DocTabs
+	var jobTabs []*jobTab
+	created := 0
+	addTab := func() *jobTab {
+		tab := newJobTab(window, &settings, jobs, func(item *container.TabItem) {
+			tabs.Refresh()
+		}, func(job *guiJob, run lastRun, log string) {
+			lastMu.Lock()
+			last, hasLast, lastLog = run, true, log
+			lastMu.Unlock()
+			myApp.Preferences().SetString(prefLastRun, run.encode())
+			updateQuickActions()
+
+			if settings.Notify {
+				state, err := job.status()
+				notifyJob(myApp, newJobNotification(run.Source, state, err, run.OutputPath))
+			}
+		})
+		jobTabs = append(jobTabs, tab)
+		created++
+		tab.item.Text = fmt.Sprintf("Job %d", created)
+		return tab
+	}
+	newTab := func() *container.TabItem {
+		return addTab().item
+	}
+	tabs = container.NewDocTabs(newTab())
+	tabs.CreateTab = newTab
+	tabs.CloseIntercept = func(item *container.TabItem) {
+		for _, tab := range jobTabs {
+			if tab.item != item {
+				continue
+			}
+			closeTab := func() {
+				for i := range jobTabs {
+					if jobTabs[i] == tab {
+						jobTabs = append(jobTabs[:i], jobTabs[i+1:]...)
+						break
+					}
+				}
+				tabs.Remove(item)
+			}
+			if tab.job == nil {
+				closeTab()
+				return
+			}
+			if state, _ := tab.job.status(); state != jobRunning {
+				closeTab()
+				return
+			}
+			dialog.ShowConfirm("Cancel job", "This job is still running. Cancel it and close the tab?", func(ok bool) {
+				if ok {
+					tab.job.Cancel()
+					closeTab()
+				}
+			}, window)
+			return
+		}
+	}
+
+	// Quitting cancels running jobs so their workspaces are cleaned up
+	window.SetCloseIntercept(func() {
+		if jobs.active() == 0 {
+			window.Close()
+			return
+		}
+		dialog.ShowConfirm("Quit", fmt.Sprintf("%d jobs are still running. Cancel them and quit?", jobs.active()), func(ok bool) {
+			if ok {
+				jobs.cancelAll()
+				jobs.wait()
+				window.Close()
+			}
+		}, window)
+	})
+
+	// Professional header with improved typography
+	title := canvas.NewText("ResignIPA", color.NRGBA{R: 0x00, G: 0x00, B: 0x00, A: 0xff}) // Bold black text
+	title.TextSize = 24
+	title.TextStyle = fyne.TextStyle{Bold: true}
+
+	subtitle := canvas.NewText("iOS IPA Resigning Tool", color.NRGBA{R: 0x66, G: 0x66, B: 0x66, A: 0xff}) // Medium gray text
+	subtitle.TextSize = 14
+	subtitle.TextStyle = fyne.TextStyle{}
+
+	// Settings live in a dialog so the main form stays minimal
+	settingsBtn := widget.NewButtonWithIcon("", theme.SettingsIcon(), func() {
+		showSettingsDialog(myApp, window, func(saved guiSettings) {
+			for _, tab := range jobTabs {
+				if tab.certEntry.Text == "" || tab.certEntry.Text == settings.DefaultCertificate {
+					tab.certEntry.SetText(saved.DefaultCertificate)
+				}
+			}
+			settings = saved
+		})
+	})
+
+	// Quick actions for repeated daily use
+	resignAgainBtn = widget.NewButtonWithIcon("Resign Again", theme.MediaReplayIcon(), func() {
+		lastMu.Lock()
+		run := last
+		lastMu.Unlock()
+		tab := addTab()
+		tabs.Append(tab.item)
+		tabs.Select(tab.item)
+		tab.fill(run)
+		tab.resign()
+	})
+	openOutputBtn = widget.NewButtonWithIcon("Open Output", theme.FolderOpenIcon(), func() {
+		lastMu.Lock()
+		folder := last.outputFolder()
+		lastMu.Unlock()
+		if err := myApp.OpenURL(&url.URL{Scheme: "file", Path: folder}); err != nil {
+			dialog.ShowError(fmt.Errorf("failed to open %s: %w", folder, err), window)
+		}
+	})
+	copyLastLogBtn = widget.NewButtonWithIcon("Copy Last Log", theme.ContentCopyIcon(), func() {
+		lastMu.Lock()
+		defer lastMu.Unlock()
+		window.Clipboard().SetContent(lastLog)
+	})
+	verifyBtn := widget.NewButtonWithIcon("Verify IPA...", theme.ConfirmIcon(), func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			source := reader.URI().Path()
+			reader.Close()
+			showVerifyResult(window, source)
+		}, window)
+	})
+	updateQuickActions()
+	quickActions := container.NewCenter(container.NewHBox(resignAgainBtn, openOutputBtn, copyLastLogBtn, verifyBtn))
+
+	aboutBtn := widget.NewButtonWithIcon("", theme.InfoIcon(), func() {
+		showAboutDialog(myApp, window)
+	})
+
+	// Header with proper spacing and divider
+	headerContent := container.NewBorder(nil, nil, nil, container.NewHBox(aboutBtn, settingsBtn),
+		container.NewVBox(
+			container.NewCenter(title),
+			container.NewCenter(subtitle),
+		),
+	)
+
+	// Add thin divider line below header
+	headerDivider := widget.NewSeparator()
+
+	header := container.NewVBox(
+		headerContent,
+		quickActions,
+		headerDivider,
+	)
+
+	content := container.NewBorder(
+		header,
+		nil,
+		nil,
+		nil,
+		tabs,
+	)
+
+	window.SetContent(content)
+	window.ShowAndRun()
+}
+
+// jobTab is one tab of the GUI: a form, its progress log and the job it
+// last started
+type jobTab struct {
+	item      *container.TabItem
+	certEntry *widget.Entry
+	job       *guiJob
+
+	// fill sets the form to a previous run and resign starts it, as the
+	// Resign button does
+	fill   func(run lastRun)
+	resign func()
+}
+
+// newJobTab builds a tab with an empty form; changed is called when the
+// tab's title changes with the state of its job, and finished with the
+// job, its run and its log once the job is over
+func newJobTab(window fyne.Window, settings *guiSettings, jobs *jobManager, changed func(*container.TabItem), finished func(job *guiJob, run lastRun, log string)) *jobTab {
+	tab := &jobTab{}
+
 	// Compact input fields with uniform sizing
 	sourceEntry := widget.NewEntry()
 	sourceEntry.SetPlaceHolder("Select IPA or APP file...")
 	sourceEntry.Resize(fyne.NewSize(600, 32))
 
+	outputEntry := widget.NewEntry()
+	outputEntry.SetPlaceHolder("Optional: output folder (default: Resigned/ next to source)")
+	outputEntry.SetText(settings.OutputDir)
+	outputEntry.Resize(fyne.NewSize(600, 32))
+
+	overwriteCheck := widget.NewCheck("Overwrite existing output", nil)
+
 	certEntry := widget.NewEntry()
 	certEntry.SetPlaceHolder("Certificate name from Keychain...")
+	certEntry.SetText(settings.DefaultCertificate)
 	certEntry.Resize(fyne.NewSize(600, 32))
+	tab.certEntry = certEntry
 
 	entitlementsEntry := widget.NewEntry()
 	entitlementsEntry.SetPlaceHolder("Optional: custom entitlements.plist")
@@ -128,9 +375,29 @@ func LaunchGUI() {
 	progressLogLabel.TextSize = 14
 	progressLogLabel.TextStyle = fyne.TextStyle{Bold: true}
 
+	// The pane is too small to read from, so the full log can be copied
+	// or saved for bug reports
+	log := &runLog{}
+	copyLogBtn := widget.NewButtonWithIcon("Copy Log", theme.ContentCopyIcon(), func() {
+		window.Clipboard().SetContent(log.String())
+	})
+	saveLogBtn := widget.NewButtonWithIcon("Save Log", theme.DocumentSaveIcon(), func() {
+		save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+			defer writer.Close()
+			if _, err := writer.Write([]byte(log.String())); err != nil {
+				dialog.ShowError(fmt.Errorf("failed to save log: %w", err), window)
+			}
+		}, window)
+		save.SetFileName("resignipa.log")
+		save.Show()
+	})
+
 	// Light blue header container
 	progressHeaderContainer := container.NewBorder(
-		nil, nil, nil, nil,
+		nil, nil, nil, container.NewHBox(copyLogBtn, saveLogBtn),
 		progressLogLabel,
 	)
 
@@ -159,6 +426,15 @@ func LaunchGUI() {
 	})
 	sourceBrowse.Resize(fyne.NewSize(40, 32))
 
+	outputBrowse := widget.NewButton("...", func() {
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err == nil && uri != nil {
+				outputEntry.SetText(uri.Path())
+			}
+		}, window)
+	})
+	outputBrowse.Resize(fyne.NewSize(40, 32))
+
 	entitlementsBrowse := widget.NewButton("...", func() {
 		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
 			if err == nil && reader != nil {
@@ -179,54 +455,127 @@ func LaunchGUI() {
 	})
 	provisionBrowse.Resize(fyne.NewSize(40, 32))
 
+	// Installed profiles have opaque UUID file names, so offer them by
+	// what they are for; the browse button covers profiles elsewhere
+	profilePaths := make(map[string]string)
+	var profileLabels []string
+	for _, profile := range resigner.InstalledProfiles() {
+		label := profileLabel(profile, time.Now())
+		if _, dup := profilePaths[label]; !dup {
+			profileLabels = append(profileLabels, label)
+		}
+		profilePaths[label] = profile.Path
+	}
+	profileSelect := widget.NewSelect(profileLabels, func(label string) {
+		if path, ok := profilePaths[label]; ok {
+			provisionEntry.SetText(path)
+		}
+	})
+	profileSelect.PlaceHolder = "Choose an installed profile..."
+	if len(profileLabels) == 0 {
+		profileSelect.PlaceHolder = "No installed profiles found"
+		profileSelect.Disable()
+	}
+
 	// Professional resign button
-	var resignBtn *widget.Button
+	var resignBtn, cancelBtn *widget.Button
+	cancelBtn = widget.NewButton("Cancel", func() {
+		if tab.job != nil {
+			cancelBtn.Disable()
+			tab.job.Cancel()
+		}
+	})
+	cancelBtn.Disable()
 	resignBtn = widget.NewButton("Resign IPA", func() {
 		// Enhanced validation
-		errors := validateGUIInputs(sourceEntry.Text, certEntry.Text, entitlementsEntry.Text, provisionEntry.Text, bundleEntry.Text)
+		cert := certEntry.Text
+		if cert == "" {
+			cert = settings.DefaultCertificate
+		}
+		errors := validateGUIInputs(sourceEntry.Text, cert, entitlementsEntry.Text, provisionEntry.Text, bundleEntry.Text, outputEntry.Text)
 		if len(errors) > 0 {
 			errorMsg := "Please fix the following errors:\n\n" + strings.Join(errors, "\n")
 			dialog.ShowError(fmt.Errorf(errorMsg), window)
 			return
 		}
 
-		// Disable button during operation
-		resignBtn.Disable()
-		resignBtn.SetText("Processing...")
+		config := resigner.Config{
+			SourceIPA:       sourceEntry.Text,
+			Certificate:     certEntry.Text,
+			Entitlements:    entitlementsEntry.Text,
+			MobileProvision: provisionEntry.Text,
+			BundleID:        bundleEntry.Text,
+			OutputDir:       outputEntry.Text,
+			Overwrite:       overwriteCheck.Checked,
+		}
+		settings.apply(&config)
+		policy, err := loadPolicy("")
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		config.Policy = policy
+		run := lastRun{
+			Source:       config.SourceIPA,
+			Certificate:  config.Certificate,
+			Entitlements: config.Entitlements,
+			Provision:    config.MobileProvision,
+			BundleID:     config.BundleID,
+			OutputDir:    config.OutputDir,
+			Overwrite:    config.Overwrite,
+		}
 
-		// Clear progress and show starting message
+		// Events arrive from signing goroutines, often hundreds a second;
+		// only the buffer's flushes touch the widget, and each parses just
+		// the new lines instead of the whole log
+		progress := newProgressBuffer(progressRefreshInterval, func(lines []string) {
+			appended := widget.NewRichTextFromMarkdown(strings.Join(lines, "\n"))
+			progressText.Segments = append(progressText.Segments, appended.Segments...)
+			progressText.Refresh()
+			progressScroll.ScrollToBottom()
+		})
+		eta := &etaEstimator{}
+		var r *resigner.Resigner
+		confirm := resigner.WithConfirm(func(prompt string) bool {
+			// Buffered so a dialog answered after cancelling does not block
+			answer := make(chan bool, 1)
+			dialog.ShowConfirm("Confirm", prompt+".\n\nContinue?", func(ok bool) {
+				answer <- ok
+			}, window)
+			select {
+			case ok := <-answer:
+				return ok
+			case <-r.Context().Done():
+				return false
+			}
+		})
+		r = resigner.New(config, confirm, resigner.WithEventHandler(log.observe), resigner.WithEventHandler(func(event resigner.Event) {
+			// Format message with emoji based on content
+			progress.Add(formatProgressMessage(withETA(eta, event)))
+		}))
+
+		// Clear the log and disable the button before the run can add to
+		// the one or re-enable the other
 		progressText.ParseMarkdown("**Starting resign process...**\n\n")
 		progressScroll.ScrollToTop()
-
-		// Run resign in goroutine
-		go func() {
-			defer func() {
-				resignBtn.Enable()
-				resignBtn.SetText("Resign IPA")
-			}()
-
-			config := resigner.Config{
-				SourceIPA:       sourceEntry.Text,
-				Certificate:     certEntry.Text,
-				Entitlements:    entitlementsEntry.Text,
-				MobileProvision: provisionEntry.Text,
-				BundleID:        bundleEntry.Text,
-			}
-
-			var logMessages []string
-			r := resigner.NewResigner(config, func(message string) {
-				// Format message with emoji based on content
-				formattedMsg := formatProgressMessage(message)
-				logMessages = append(logMessages, formattedMsg)
-
-				// Create markdown content
-				content := "**Progress Log**\n\n" + strings.Join(logMessages, "\n")
-				progressText.ParseMarkdown(content)
-				progressScroll.ScrollToBottom()
-			})
-
-			err := r.Resign()
-			if err != nil {
+		log.start(config)
+		name := filepath.Base(config.SourceIPA)
+		resignBtn.Disable()
+		resignBtn.SetText("Processing...")
+		cancelBtn.Enable()
+		tab.item.Text = jobTitle(name, jobRunning)
+		changed(tab.item)
+
+		job := jobs.start(config.SourceIPA, func(ctx context.Context) error {
+			_, err := r.ResignContext(ctx)
+			return err
+		}, func(job *guiJob) {
+			state, err := job.status()
+			switch state {
+			case jobCanceled:
+				progress.Add("\n\n**Canceled**\n")
+				progress.Flush()
+			case jobFailed:
 				errorMsg := fmt.Sprintf("\n\n**Error:** %v\n\n**Troubleshooting:**\n", err)
 				if strings.Contains(err.Error(), "certificate") {
 					errorMsg += "• Check certificate name matches Keychain exactly\n"
@@ -236,44 +585,39 @@ func LaunchGUI() {
 					errorMsg += "• Verify provisioning profile is valid\n"
 					errorMsg += "• Check profile matches certificate\n"
 				}
-				logMessages = append(logMessages, errorMsg)
-				content := "**Progress Log**\n\n" + strings.Join(logMessages, "\n")
-				progressText.ParseMarkdown(content)
-				dialog.ShowError(err, window)
-			} else {
-				successMsg := "\n\n**Success!** IPA has been resigned successfully!\n\n**Output:** Check the 'Resigned' folder.\n"
-				logMessages = append(logMessages, successMsg)
-				content := "**Progress Log**\n\n" + strings.Join(logMessages, "\n")
-				progressText.ParseMarkdown(content)
-				dialog.ShowInformation("Success", "IPA has been resigned successfully!\n\nCheck the 'Resigned' folder for your new file.", window)
+				progress.Add(errorMsg)
+				progress.Flush()
+				dialog.ShowError(fmt.Errorf("%s: %w", name, err), window)
+			default:
+				progress.Add(fmt.Sprintf("\n\n**Success!** IPA has been resigned successfully!\n\n**Output:** %s\n", r.OutputPath()))
+				progress.Flush()
+				dialog.ShowInformation("Success", fmt.Sprintf("%s has been resigned successfully!\n\nSaved to: %s", name, r.OutputPath()), window)
 			}
-			progressScroll.ScrollToBottom()
-		}()
-	})
-	resignBtn.Resize(fyne.NewSize(140, 32))
-
-	// Professional header with improved typography
-	title := canvas.NewText("ResignIPA", color.NRGBA{R: 0x00, G: 0x00, B: 0x00, A: 0xff}) // Bold black text
-	title.TextSize = 24
-	title.TextStyle = fyne.TextStyle{Bold: true}
-
-	subtitle := canvas.NewText("iOS IPA Resigning Tool", color.NRGBA{R: 0x66, G: 0x66, B: 0x66, A: 0xff}) // Medium gray text
-	subtitle.TextSize = 14
-	subtitle.TextStyle = fyne.TextStyle{}
-
-	// Header with proper spacing and divider
-	headerContent := container.NewVBox(
-		container.NewCenter(title),
-		container.NewCenter(subtitle),
-	)
 
-	// Add thin divider line below header
-	headerDivider := widget.NewSeparator()
+			tab.item.Text = jobTitle(name, state)
+			changed(tab.item)
+			cancelBtn.Disable()
+			resignBtn.Enable()
+			resignBtn.SetText("Resign IPA")
 
-	header := container.NewVBox(
-		headerContent,
-		headerDivider,
-	)
+			if state == jobSucceeded {
+				run.OutputPath = r.OutputPath()
+			}
+			finished(job, run, log.String())
+		})
+		tab.job = job
+	})
+	resignBtn.Resize(fyne.NewSize(140, 32))
+	tab.resign = resignBtn.OnTapped
+	tab.fill = func(run lastRun) {
+		sourceEntry.SetText(run.Source)
+		certEntry.SetText(run.Certificate)
+		entitlementsEntry.SetText(run.Entitlements)
+		provisionEntry.SetText(run.Provision)
+		bundleEntry.SetText(run.BundleID)
+		outputEntry.SetText(run.OutputDir)
+		overwriteCheck.SetChecked(run.Overwrite)
+	}
 
 	// Professional form layout with consistent styling
 	requiredLabel := canvas.NewText("Required Fields", color.NRGBA{R: 0x2c, G: 0x2c, B: 0x2c, A: 0xff})
@@ -286,6 +630,8 @@ func LaunchGUI() {
 		requiredLabel,
 		requiredDivider,
 		container.NewBorder(nil, nil, widget.NewLabel("Source:"), sourceBrowse, sourceEntry),
+		container.NewBorder(nil, nil, widget.NewLabel("Output:"), outputBrowse, outputEntry),
+		overwriteCheck,
 		container.NewBorder(nil, nil, widget.NewLabel("Certificate:"), nil, certEntry),
 	)
 
@@ -300,6 +646,7 @@ func LaunchGUI() {
 		optionalDivider,
 		container.NewBorder(nil, nil, widget.NewLabel("Entitlements:"), entitlementsBrowse, entitlementsEntry),
 		container.NewBorder(nil, nil, widget.NewLabel("Provision:"), provisionBrowse, provisionEntry),
+		container.NewBorder(nil, nil, widget.NewLabel("Installed:"), nil, profileSelect),
 		container.NewBorder(nil, nil, widget.NewLabel("Bundle ID:"), nil, bundleEntry),
 		// Add spacing after bundle ID field
 		container.NewVBox(),
@@ -311,14 +658,10 @@ func LaunchGUI() {
 		optionalSection,
 	)
 
+	// Shorter than a single form used to be, to make room for the tab
+	// bars and the quick actions
 	formScroll := container.NewVScroll(form)
-	formScroll.SetMinSize(fyne.NewSize(660, 380))
-
-	// Professional compact layout with proper spacing and padding
-	mainContent := container.NewVBox(
-		header,
-		formScroll,
-	)
+	formScroll.SetMinSize(fyne.NewSize(660, 260))
 
 	// Add spacing between form and progress log
 	spacingContainer := container.NewVBox()
@@ -328,22 +671,125 @@ func LaunchGUI() {
 		progressHeaderContainer,
 		progressHeaderDivider,
 		progressScroll,
-		container.NewCenter(resignBtn),
+		container.NewCenter(container.NewHBox(resignBtn, cancelBtn)),
 	)
 
-	content := container.NewBorder(
-		mainContent,
+	// The Inspect view shows what the selected source is before it is
+	// resigned; it inspects lazily, as reading a large IPA takes a moment
+	inspectText := widget.NewRichTextFromMarkdown("Select a source to inspect it.")
+	inspectText.Wrapping = fyne.TextWrapWord
+	inspectScroll := container.NewVScroll(inspectText)
+	var inspectMu sync.Mutex
+	var inspected string
+	inspect := func(force bool) {
+		source := sourceEntry.Text
+		inspectMu.Lock()
+		if source == inspected && !force {
+			inspectMu.Unlock()
+			return
+		}
+		inspected = source
+		inspectMu.Unlock()
+
+		if _, err := os.Stat(source); err != nil {
+			inspectText.ParseMarkdown("Select a source to inspect it.")
+			return
+		}
+		inspectText.ParseMarkdown("**Inspecting " + filepath.Base(source) + "...**")
+		go func() {
+			ins, err := resigner.Inspect(source)
+			inspectMu.Lock()
+			defer inspectMu.Unlock()
+			// A newer source may have been picked meanwhile
+			if inspected != source {
+				return
+			}
+			if err != nil {
+				inspectText.ParseMarkdown(fmt.Sprintf("**Error:** %v", err))
+				return
+			}
+			inspectText.ParseMarkdown(inspectionMarkdown(ins, time.Now()))
+			inspectScroll.ScrollToTop()
+		}()
+	}
+	refreshInspectBtn := widget.NewButtonWithIcon("Refresh", theme.ViewRefreshIcon(), func() {
+		inspect(true)
+	})
+
+	resignItem := container.NewTabItem("Resign", container.NewBorder(
+		formScroll,
 		bottomContent,
 		nil,
 		nil,
-	)
+	))
+	inspectItem := container.NewTabItem("Inspect", container.NewBorder(
+		nil,
+		container.NewCenter(refreshInspectBtn),
+		nil,
+		nil,
+		inspectScroll,
+	))
+	views := container.NewAppTabs(resignItem, inspectItem)
+	views.OnSelected = func(item *container.TabItem) {
+		if item == inspectItem {
+			inspect(false)
+		}
+	}
+	sourceEntry.OnChanged = func(string) {
+		if views.Selected() == inspectItem {
+			inspect(false)
+		}
+	}
 
-	window.SetContent(content)
-	window.ShowAndRun()
+	tab.item = container.NewTabItem("", views)
+	return tab
+}
+
+// showVerifyResult checks the signature of source in the background,
+// showing progress while it runs and the outcome once it is done
+func showVerifyResult(window fyne.Window, source string) {
+	name := filepath.Base(source)
+	progress := dialog.NewCustomWithoutButtons("Verifying "+name, widget.NewProgressBarInfinite(), window)
+	progress.Show()
+	go func() {
+		err := resigner.VerifySignature(context.Background(), source)
+		progress.Hide()
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("%s: %w", name, err), window)
+			return
+		}
+		dialog.ShowInformation("Verified", name+" is validly signed.", window)
+	}()
+}
+
+// notifyJob posts n, with a click-through revealing its output where
+// terminal-notifier is available on macOS
+func notifyJob(a fyne.App, n jobNotification) {
+	if runtime.GOOS == "darwin" {
+		if cmd := notifierCommand(n, exec.LookPath); cmd != nil && cmd.Start() == nil {
+			go cmd.Wait()
+			return
+		}
+	}
+	a.SendNotification(fyne.NewNotification(n.Title, n.Message))
+}
+
+// jobTitle is the tab title of a job for source in state
+func jobTitle(source, state string) string {
+	switch state {
+	case jobRunning:
+		return "⏳ " + source
+	case jobSucceeded:
+		return "✓ " + source
+	case jobFailed:
+		return "✗ " + source
+	default:
+		return source
+	}
 }
 
 // validateGUIInputs validates GUI inputs with detailed error messages
-func validateGUIInputs(source, cert, entitlements, provision, bundleID string) []string {
+func validateGUIInputs(source, cert, entitlements, provision, bundleID, output string) []string {
 	var errors []string
 
 	if source == "" {
@@ -368,6 +814,8 @@ func validateGUIInputs(source, cert, entitlements, provision, bundleID string) [
 			errors = append(errors, fmt.Sprintf("• Entitlements file does not exist: %s", entitlements))
 		} else if !strings.HasSuffix(strings.ToLower(entitlements), ".plist") {
 			errors = append(errors, "• Entitlements file must be .plist")
+		} else if err := resigner.ValidateEntitlementsFile(entitlements); err != nil {
+			errors = append(errors, fmt.Sprintf("• %v", err))
 		}
 	}
 
@@ -380,14 +828,29 @@ func validateGUIInputs(source, cert, entitlements, provision, bundleID string) [
 	}
 
 	if bundleID != "" {
-		if !isValidBundleID(bundleID) {
-			errors = append(errors, fmt.Sprintf("• Invalid bundle ID format: %s (expected: com.company.app)", bundleID))
+		if err := resigner.ValidateBundleID(bundleID); err != nil {
+			errors = append(errors, fmt.Sprintf("• %v (expected: com.company.app)", err))
+		}
+	}
+
+	if output != "" {
+		if info, err := os.Stat(output); err == nil && !info.IsDir() {
+			errors = append(errors, fmt.Sprintf("• Output path is not a folder: %s", output))
 		}
 	}
 
 	return errors
 }
 
+// profileLabel describes an installed profile for the profile picker
+func profileLabel(profile resigner.InstalledProfile, now time.Time) string {
+	expiry := "expires " + profile.ExpirationDate.Format("2006-01-02")
+	if profile.Expired(now) {
+		expiry = "EXPIRED " + profile.ExpirationDate.Format("2006-01-02")
+	}
+	return fmt.Sprintf("%s — %s (%s), %s", profile.Name, profile.AppID(), profile.TeamID(), expiry)
+}
+
 // formatProgressMessage formats progress messages with appropriate emojis
 func formatProgressMessage(message string) string {
 	msg := strings.TrimSpace(message)
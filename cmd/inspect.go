@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/resignipa/pkg/resigner"
+)
+
+// inspectionMarkdown renders an inspection for the GUI's Inspect tab,
+// flagging a certificate or profile that has expired by now
+func inspectionMarkdown(ins *resigner.Inspection, now time.Time) string {
+	var b strings.Builder
+	expiry := func(t time.Time) string {
+		if t.Before(now) {
+			return "**EXPIRED** " + t.Format("2006-01-02")
+		}
+		return fmt.Sprintf("%s (in %d days)", t.Format("2006-01-02"), int(t.Sub(now).Hours()/24))
+	}
+	orDash := func(s string) string {
+		if s == "" {
+			return "—"
+		}
+		return s
+	}
+
+	fmt.Fprintf(&b, "**App**\n\n")
+	fmt.Fprintf(&b, "- Name: %s\n", orDash(ins.Name))
+	fmt.Fprintf(&b, "- Bundle ID: %s\n", orDash(ins.BundleID))
+	fmt.Fprintf(&b, "- Version: %s (%s)\n", orDash(ins.Version), orDash(ins.Build))
+	fmt.Fprintf(&b, "- Minimum iOS: %s\n\n", orDash(ins.MinimumOS))
+
+	fmt.Fprintf(&b, "**Current signer**\n\n")
+	switch signature := ins.Signature; {
+	case !signature.Signed:
+		fmt.Fprintf(&b, "- Unsigned\n\n")
+	case signature.Authority == "":
+		fmt.Fprintf(&b, "- Ad-hoc signature (%s)\n\n", orDash(signature.Identifier))
+	default:
+		fmt.Fprintf(&b, "- %s\n", signature.Authority)
+		fmt.Fprintf(&b, "- Team: %s\n", orDash(signature.TeamID))
+		fmt.Fprintf(&b, "- Certificate expires: %s\n\n", expiry(signature.Expires))
+	}
+
+	fmt.Fprintf(&b, "**Embedded profile**\n\n")
+	if profile := ins.Profile; profile == nil {
+		fmt.Fprintf(&b, "- None\n\n")
+	} else {
+		fmt.Fprintf(&b, "- %s (%s)\n", profile.Name, orDash(profile.TeamID()))
+		fmt.Fprintf(&b, "- Expires: %s\n\n", expiry(profile.ExpirationDate))
+	}
+
+	fmt.Fprintf(&b, "**Components**\n\n")
+	b.WriteString("```\n")
+	b.WriteString(componentTree(ins.Components))
+	b.WriteString("```\n")
+	return b.String()
+}
+
+// componentTree lays components out as an indented tree with their sizes,
+// naming each relative to the component enclosing it
+func componentTree(components []resigner.InspectedComponent) string {
+	type row struct{ name, size string }
+	var rows []row
+	var parents []string
+	width := 0
+	for _, component := range components {
+		parents = append(parents[:component.Depth], component.Path)
+		name := component.Path
+		if component.Depth > 0 {
+			name = strings.TrimPrefix(name, parents[component.Depth-1]+"/")
+		} else {
+			name = path.Base(name)
+		}
+		name = strings.Repeat("  ", component.Depth) + name
+		width = max(width, len([]rune(name)))
+		rows = append(rows, row{name, formatBytes(component.Size)})
+	}
+
+	var b strings.Builder
+	for _, row := range rows {
+		fmt.Fprintf(&b, "%-*s  %9s\n", width, row.name, row.size)
+	}
+	return b.String()
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/resignipa/pkg/resigner"
+)
+
+func TestComponentTree(t *testing.T) {
+	tree := componentTree([]resigner.InspectedComponent{
+		{Path: "Test.app", Kind: ".app", Size: 5 << 20},
+		{Path: "Test.app/Frameworks/Kit.framework", Kind: ".framework", Depth: 1, Size: 2 << 20},
+		{Path: "Test.app/Frameworks/Kit.framework/libKit.dylib", Kind: ".dylib", Depth: 2, Size: 1 << 20},
+		{Path: "Test.app/PlugIns/Share.appex", Kind: ".appex", Depth: 1, Size: 512},
+	})
+	want := "" +
+		"Test.app                       5.0 MB\n" +
+		"  Frameworks/Kit.framework     2.0 MB\n" +
+		"    libKit.dylib               1.0 MB\n" +
+		"  PlugIns/Share.appex           512 B\n"
+	if tree != want {
+		t.Errorf("componentTree() =\n%s\nwant\n%s", tree, want)
+	}
+}
+
+func TestInspectionMarkdown(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ins := &resigner.Inspection{
+		Name:     "Test App",
+		BundleID: "com.example.app",
+		Version:  "2.1",
+		Build:    "42",
+		Signature: resigner.CodeSignature{
+			Signed:    true,
+			TeamID:    "ABCDE12345",
+			Authority: "Apple Distribution: Example Corp (ABCDE12345)",
+			Expires:   now.AddDate(0, 0, 30),
+		},
+		Profile: &resigner.Profile{
+			Name:           "Example Ad Hoc",
+			TeamIdentifier: []string{"ABCDE12345"},
+			ExpirationDate: now.AddDate(0, 0, -1),
+		},
+		Components: []resigner.InspectedComponent{{Path: "Test.app", Kind: ".app", Size: 2048}},
+	}
+
+	markdown := inspectionMarkdown(ins, now)
+	for _, want := range []string{
+		"- Version: 2.1 (42)",
+		"- Minimum iOS: —",
+		"- Apple Distribution: Example Corp (ABCDE12345)",
+		"- Certificate expires: 2025-01-31 (in 30 days)",
+		"- Example Ad Hoc (ABCDE12345)",
+		"- Expires: **EXPIRED** 2024-12-31",
+		"Test.app     2.0 KB",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("inspectionMarkdown() is missing %q:\n%s", want, markdown)
+		}
+	}
+
+	ins.Signature = resigner.CodeSignature{Signed: true, Identifier: "Test-55554944"}
+	ins.Profile = nil
+	markdown = inspectionMarkdown(ins, now)
+	for _, want := range []string{"- Ad-hoc signature (Test-55554944)", "**Embedded profile**\n\n- None"} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("inspectionMarkdown() is missing %q:\n%s", want, markdown)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// installOptions select what setup --install puts under Prefix. The layout
+// follows Homebrew's, so a formula can pass its own prefix.
+type installOptions struct {
+	Enabled     bool
+	Prefix      string
+	Completions bool
+	Man         bool
+}
+
+// install copies the binary into <prefix>/bin and, if asked, generates
+// shell completions and man pages next to it
+func (sc *SetupChecker) install(binaryPath string) error {
+	binDir := filepath.Join(sc.installOpts.Prefix, "bin")
+	target := filepath.Join(binDir, filepath.Base(binaryPath))
+	if err := installFile(binaryPath, target, 0755); err != nil {
+		return installError(err)
+	}
+	sc.logSuccess("Installed %s", target)
+
+	if sc.installOpts.Completions {
+		files, err := writeCompletions(rootCmd, sc.installOpts.Prefix)
+		if err != nil {
+			return installError(err)
+		}
+		for _, file := range files {
+			sc.logSuccess("Installed completion %s", file)
+		}
+	}
+
+	if sc.installOpts.Man {
+		dir := filepath.Join(sc.installOpts.Prefix, "share", "man", "man1")
+		count, err := writeManPages(rootCmd, dir, time.Now())
+		if err != nil {
+			return installError(err)
+		}
+		sc.logSuccess("Installed %d man pages in %s", count, dir)
+	}
+
+	if !strings.Contains(os.Getenv("PATH"), binDir) {
+		sc.logWarning("%s is not in your PATH", binDir)
+	}
+	return nil
+}
+
+// installError explains the usual cause of a failed install
+func installError(err error) error {
+	if os.IsPermission(err) {
+		return fmt.Errorf("%w (re-run with sudo, or choose a writable --prefix)", err)
+	}
+	return err
+}
+
+// installFile copies src to dst with mode, replacing dst atomically so a
+// running copy of the binary is never left half-written
+func installFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	return writeInstalled(dst, data, mode)
+}
+
+// writeInstalled writes data to dst with mode via a temporary file
+func writeInstalled(dst string, data []byte, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	tmp := dst + ".tmp"
+	if err := os.WriteFile(tmp, data, mode); err != nil {
+		return err
+	}
+	// WriteFile's mode is subject to the umask
+	if err := os.Chmod(tmp, mode); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// writeCompletions generates bash, zsh and fish completions into the
+// folders each shell (and Homebrew) loads them from
+func writeCompletions(root *cobra.Command, prefix string) ([]string, error) {
+	name := root.Name()
+	shells := []struct {
+		path string
+		gen  func(io.Writer) error
+	}{
+		{filepath.Join(prefix, "etc", "bash_completion.d", name), func(w io.Writer) error { return root.GenBashCompletionV2(w, true) }},
+		{filepath.Join(prefix, "share", "zsh", "site-functions", "_"+name), root.GenZshCompletion},
+		{filepath.Join(prefix, "share", "fish", "vendor_completions.d", name+".fish"), func(w io.Writer) error { return root.GenFishCompletion(w, true) }},
+	}
+
+	var files []string
+	for _, shell := range shells {
+		var buf bytes.Buffer
+		if err := shell.gen(&buf); err != nil {
+			return files, err
+		}
+		if err := writeInstalled(shell.path, buf.Bytes(), 0644); err != nil {
+			return files, err
+		}
+		files = append(files, shell.path)
+	}
+	return files, nil
+}
+
+// writeManPages writes a section 1 man page for root and every visible
+// subcommand, returning how many were written
+func writeManPages(root *cobra.Command, dir string, date time.Time) (int, error) {
+	count := 0
+	var walk func(cmd *cobra.Command) error
+	walk = func(cmd *cobra.Command) error {
+		if !cmd.IsAvailableCommand() && cmd != root {
+			return nil
+		}
+		var buf bytes.Buffer
+		writeManPage(&buf, cmd, date)
+		name := strings.ReplaceAll(cmd.CommandPath(), " ", "-") + ".1"
+		if err := writeInstalled(filepath.Join(dir, name), buf.Bytes(), 0644); err != nil {
+			return err
+		}
+		count++
+		for _, sub := range cmd.Commands() {
+			if err := walk(sub); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return count, walk(root)
+}
+
+// writeManPage renders cmd's help as roff
+func writeManPage(w io.Writer, cmd *cobra.Command, date time.Time) {
+	title := strings.ToUpper(strings.ReplaceAll(cmd.CommandPath(), " ", "-"))
+	fmt.Fprintf(w, ".TH %q 1 %q \"ResignIPA %s\" \"ResignIPA Manual\"\n", title, date.Format("2006-01-02"), version)
+
+	fmt.Fprintf(w, ".SH NAME\n%s \\- %s\n", manEscape(strings.ReplaceAll(cmd.CommandPath(), " ", "-")), manEscape(cmd.Short))
+	fmt.Fprintf(w, ".SH SYNOPSIS\n.B %s\n", manEscape(cmd.UseLine()))
+
+	description := cmd.Long
+	if description == "" {
+		description = cmd.Short
+	}
+	fmt.Fprintf(w, ".SH DESCRIPTION\n.nf\n%s\n.fi\n", manEscape(description))
+
+	if flags := cmd.NonInheritedFlags(); flags.HasAvailableFlags() {
+		fmt.Fprint(w, ".SH OPTIONS\n")
+		flags.VisitAll(func(flag *pflag.Flag) {
+			if flag.Hidden {
+				return
+			}
+			name := "\\-\\-" + flag.Name
+			if flag.Shorthand != "" {
+				name = "\\-" + flag.Shorthand + ", " + name
+			}
+			fmt.Fprintf(w, ".TP\n\\fB%s\\fR\n%s\n", name, manEscape(flag.Usage))
+		})
+	}
+
+	var related []string
+	if cmd.HasParent() {
+		related = append(related, strings.ReplaceAll(cmd.Parent().CommandPath(), " ", "-")+"(1)")
+	}
+	for _, sub := range cmd.Commands() {
+		if sub.IsAvailableCommand() {
+			related = append(related, strings.ReplaceAll(sub.CommandPath(), " ", "-")+"(1)")
+		}
+	}
+	if len(related) > 0 {
+		fmt.Fprintf(w, ".SH SEE ALSO\n%s\n", manEscape(strings.Join(related, ", ")))
+	}
+}
+
+// manEscape protects text from roff: backslashes, and lines that would
+// otherwise start a request
+func manEscape(text string) string {
+	text = strings.ReplaceAll(text, "\\", "\\e")
+	text = strings.ReplaceAll(text, "-", "\\-")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = "\\&" + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// States of a GUI job
+const (
+	jobRunning   = "running"
+	jobSucceeded = "succeeded"
+	jobFailed    = "failed"
+	jobCanceled  = "canceled"
+)
+
+// guiJob is one resign run started from a GUI tab
+type guiJob struct {
+	id     int
+	source string
+	cancel context.CancelFunc
+
+	mu    sync.Mutex
+	state string
+	err   error
+}
+
+// Cancel stops the job; it finishes as canceled once the run notices
+func (j *guiJob) Cancel() {
+	j.cancel()
+}
+
+// status returns the job's state and, once it failed, its error
+func (j *guiJob) status() (string, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.state, j.err
+}
+
+// jobManager runs the GUI's resign jobs concurrently, one per tab. Every
+// run works in a workspace of its own, so any jobs can run side by side.
+type jobManager struct {
+	mu      sync.Mutex
+	next    int
+	running map[int]*guiJob
+	wg      sync.WaitGroup
+}
+
+// newJobManager returns a manager with no jobs
+func newJobManager() *jobManager {
+	return &jobManager{running: make(map[int]*guiJob)}
+}
+
+// start runs run in the background for source, calling done with the job
+// once it has finished
+func (m *jobManager) start(source string, run func(ctx context.Context) error, done func(*guiJob)) *guiJob {
+	m.mu.Lock()
+	m.next++
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &guiJob{id: m.next, source: source, cancel: cancel, state: jobRunning}
+	m.running[job.id] = job
+	m.wg.Add(1)
+	m.mu.Unlock()
+
+	go func() {
+		defer m.wg.Done()
+		err := run(ctx)
+		// A cancelled run can fail with whatever the killed tool printed
+		canceled := ctx.Err() != nil
+		cancel()
+
+		job.mu.Lock()
+		switch {
+		case err == nil:
+			job.state = jobSucceeded
+		case canceled || errors.Is(err, context.Canceled):
+			job.state = jobCanceled
+		default:
+			job.state = jobFailed
+		}
+		job.err = err
+		job.mu.Unlock()
+
+		m.mu.Lock()
+		delete(m.running, job.id)
+		m.mu.Unlock()
+		if done != nil {
+			done(job)
+		}
+	}()
+	return job
+}
+
+// active returns the number of running jobs
+func (m *jobManager) active() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.running)
+}
+
+// cancelAll cancels every running job
+func (m *jobManager) cancelAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, job := range m.running {
+		job.Cancel()
+	}
+}
+
+// wait blocks until every job has finished, e.g. after cancelAll so their
+// workspaces are removed before the app quits
+func (m *jobManager) wait() {
+	m.wg.Wait()
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestJobManager(t *testing.T) {
+	dir := t.TempDir()
+	jobs := newJobManager()
+	finished := make(chan *guiJob, 3)
+	done := func(job *guiJob) { finished <- job }
+
+	// Apps from one folder run side by side, each in its own workspace
+	release := make(chan struct{})
+	blocked := jobs.start(filepath.Join(dir, "App.ipa"), func(ctx context.Context) error {
+		<-ctx.Done()
+		return errors.New("codesign: killed")
+	}, done)
+	ok := jobs.start(filepath.Join(dir, "Other.ipa"), func(ctx context.Context) error {
+		<-release
+		return nil
+	}, done)
+	if jobs.active() != 2 {
+		t.Errorf("active() = %d, want 2", jobs.active())
+	}
+
+	close(release)
+	if job := <-finished; job != ok {
+		t.Fatalf("job %d finished first, want %d", job.id, ok.id)
+	}
+	if state, _ := ok.status(); state != jobSucceeded {
+		t.Errorf("state = %s, want %s", state, jobSucceeded)
+	}
+
+	// A cancelled run counts as cancelled whatever error it ends with
+	jobs.cancelAll()
+	jobs.wait()
+	<-finished
+	if state, err := blocked.status(); state != jobCanceled || err == nil {
+		t.Errorf("status() = %s, %v; want %s with the run's error", state, err, jobCanceled)
+	}
+	if jobs.active() != 0 {
+		t.Errorf("active() = %d after every job finished", jobs.active())
+	}
+	jobs.start(filepath.Join(dir, "Third.ipa"), func(context.Context) error { return errors.New("boom") }, done)
+	if state, _ := (<-finished).status(); state != jobFailed {
+		t.Errorf("state = %s, want %s", state, jobFailed)
+	}
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/resignipa/pkg/command"
+)
+
+// partitionListCommand lets Apple's tools, codesign included, use the
+// signing keys in the login keychain without a permission prompt
+const partitionListCommand = "security set-key-partition-list -S apple-tool:,apple:,codesign: -s login.keychain-db"
+
+// keyCheckTimeout bounds the dry-sign; codesign waiting longer than this
+// is almost certainly blocked on a permission dialog
+const keyCheckTimeout = 15 * time.Second
+
+// keyAccess is the outcome of dry-signing with one identity
+type keyAccess int
+
+const (
+	keyUsable keyAccess = iota
+	// keyPartitionList is the errSecInternalComponent failure of keys
+	// imported without a partition list allowing codesign
+	keyPartitionList
+	// keyPrompts means codesign sat waiting, usually on a dialog
+	keyPrompts
+	keyFailed
+)
+
+// checkKeyAccess signs a scratch file with cert the way a resign would,
+// returning the outcome and codesign's output
+func checkKeyAccess(cert Certificate) (keyAccess, string) {
+	dir, err := os.MkdirTemp("", "resignipa-keycheck")
+	if err != nil {
+		return keyFailed, err.Error()
+	}
+	defer os.RemoveAll(dir)
+	scratch := filepath.Join(dir, "keycheck")
+	if err := os.WriteFile(scratch, []byte("resignipa"), 0644); err != nil {
+		return keyFailed, err.Error()
+	}
+
+	cmd := command.New("codesign", "-f", "-s", cert.Hash, scratch)
+	cmd.Timeout = keyCheckTimeout
+	result, err := cmd.Run(context.Background())
+	text := strings.TrimSpace(string(result.Combined))
+	switch {
+	case errors.Is(err, command.ErrTimeout):
+		return keyPrompts, text
+	case strings.Contains(text, "errSecInternalComponent"):
+		return keyPartitionList, text
+	case err != nil:
+		return keyFailed, text
+	}
+	return keyUsable, text
+}
+
+// verifyKeychainAccess dry-signs with every discovered identity so a
+// keychain that only works with someone at the screen is found now, not
+// by the first headless resign
+func (sc *SetupChecker) verifyKeychainAccess() {
+	needsFix := false
+	for _, cert := range sc.certificates {
+		access, output := checkKeyAccess(cert)
+		switch access {
+		case keyUsable:
+			sc.logSuccess("%s: private key usable without prompts", cert.Name)
+		case keyPartitionList:
+			sc.logError("%s: codesign failed with errSecInternalComponent", cert.Name)
+			sc.logInfo("  The key's partition list does not allow codesign (common after importing a .p12)")
+			needsFix = true
+		case keyPrompts:
+			sc.logWarning("%s: codesign is waiting for a keychain permission prompt", cert.Name)
+			sc.logInfo("  Headless and CI runs will hang; choose \"Always Allow\" or fix the partition list")
+			needsFix = true
+		default:
+			sc.logWarning("%s: test signing failed: %s", cert.Name, output)
+		}
+	}
+	if !needsFix {
+		return
+	}
+
+	sc.logInfo("")
+	sc.logInfo("Fix: %s", partitionListCommand)
+	if sc.jsonOutput || !isInteractive() || !promptConfirm("Run it now (security will ask for your login keychain password)") {
+		return
+	}
+	fields := strings.Fields(partitionListCommand)
+	cmd := exec.Command(fields[0], fields[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		sc.logError("security set-key-partition-list failed: %v", err)
+		return
+	}
+	sc.logSuccess("Partition list updated; codesign can now use your signing keys without prompting")
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"encoding/json"
+	"path/filepath"
+)
+
+// lastRun is the most recent GUI run, saved so the quick actions can
+// repeat it or open its output, also after a restart
+type lastRun struct {
+	Source       string `json:"source"`
+	Certificate  string `json:"certificate,omitempty"`
+	Entitlements string `json:"entitlements,omitempty"`
+	Provision    string `json:"provision,omitempty"`
+	BundleID     string `json:"bundleId,omitempty"`
+	OutputDir    string `json:"outputDir,omitempty"`
+	Overwrite    bool   `json:"overwrite,omitempty"`
+	// OutputPath is where the run saved the app; empty until it succeeds
+	OutputPath string `json:"outputPath,omitempty"`
+}
+
+// decodeLastRun reads a run saved by encode; ok is false when there is
+// none or it cannot be read, e.g. after an upgrade changed the format
+func decodeLastRun(data string) (run lastRun, ok bool) {
+	if data == "" {
+		return lastRun{}, false
+	}
+	if err := json.Unmarshal([]byte(data), &run); err != nil || run.Source == "" {
+		return lastRun{}, false
+	}
+	return run, true
+}
+
+// encode serializes the run for the app preferences
+func (r lastRun) encode() string {
+	data, _ := json.Marshal(r)
+	return string(data)
+}
+
+// outputFolder is the folder the run saved to or, if it has not finished,
+// would save to: the configured output folder, else Resigned/ next to the
+// source
+func (r lastRun) outputFolder() string {
+	switch {
+	case r.OutputPath != "":
+		return filepath.Dir(r.OutputPath)
+	case r.OutputDir != "":
+		return r.OutputDir
+	default:
+		return filepath.Join(filepath.Dir(r.Source), "Resigned")
+	}
+}
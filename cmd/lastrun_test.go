@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLastRunRoundTrip(t *testing.T) {
+	run := lastRun{
+		Source:      "/builds/App.ipa",
+		Certificate: "Apple Distribution: Example Corp",
+		BundleID:    "com.example.app",
+		Overwrite:   true,
+		OutputPath:  "/builds/Resigned/App.ipa",
+	}
+	got, ok := decodeLastRun(run.encode())
+	if !ok || got != run {
+		t.Errorf("decodeLastRun(encode()) = %+v, %v; want %+v", got, ok, run)
+	}
+
+	for _, data := range []string{"", "not json", `{"certificate":"x"}`} {
+		if _, ok := decodeLastRun(data); ok {
+			t.Errorf("decodeLastRun(%q) ok, want none", data)
+		}
+	}
+}
+
+func TestLastRunOutputFolder(t *testing.T) {
+	tests := []struct {
+		run  lastRun
+		want string
+	}{
+		{lastRun{Source: "/builds/App.ipa", OutputDir: "/out", OutputPath: "/out/App.ipa"}, "/out"},
+		{lastRun{Source: "/builds/App.ipa", OutputDir: "/out"}, "/out"},
+		{lastRun{Source: "/builds/App.ipa"}, filepath.Join("/builds", "Resigned")},
+	}
+	for _, tt := range tests {
+		if got := tt.run.outputFolder(); got != tt.want {
+			t.Errorf("%+v.outputFolder() = %q, want %q", tt.run, got, tt.want)
+		}
+	}
+}
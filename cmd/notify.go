@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// guiAppID identifies the GUI app, for its preferences and so clicking a
+// notification can bring it to the front
+const guiAppID = "com.resignipa.app"
+
+// jobNotification is what the GUI tells Notification Center about a
+// finished job
+type jobNotification struct {
+	Title   string
+	Message string
+	// Reveal is shown in Finder when the notification is clicked
+	Reveal string
+}
+
+// newJobNotification describes a job for source that finished in state,
+// saving to output when it succeeded
+func newJobNotification(source, state string, err error, output string) jobNotification {
+	name := filepath.Base(source)
+	switch state {
+	case jobSucceeded:
+		return jobNotification{Title: "IPA resigned", Message: name + " is ready", Reveal: output}
+	case jobCanceled:
+		return jobNotification{Title: "Resign canceled", Message: name}
+	default:
+		return jobNotification{Title: "Resign failed", Message: fmt.Sprintf("%s: %v", name, err)}
+	}
+}
+
+// notifierCommand returns the terminal-notifier invocation posting n with
+// a click-through, or nil when it is not installed (brew install
+// terminal-notifier); the GUI then falls back to a plain notification
+func notifierCommand(n jobNotification, lookPath func(string) (string, error)) *exec.Cmd {
+	notifier, err := lookPath("terminal-notifier")
+	if err != nil {
+		return nil
+	}
+	args := []string{"-title", "ResignIPA", "-subtitle", n.Title, "-message", n.Message}
+	if n.Reveal != "" {
+		args = append(args, "-execute", "/usr/bin/open -R "+shellQuote(n.Reveal))
+	} else {
+		args = append(args, "-activate", guiAppID)
+	}
+	return exec.Command(notifier, args...)
+}
+
+// shellQuote quotes s for /bin/sh, which runs terminal-notifier's -execute
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"errors"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestNewJobNotification(t *testing.T) {
+	n := newJobNotification("/builds/App.ipa", jobSucceeded, nil, "/builds/Resigned/App.ipa")
+	if n.Title != "IPA resigned" || n.Message != "App.ipa is ready" || n.Reveal != "/builds/Resigned/App.ipa" {
+		t.Errorf("succeeded: %+v", n)
+	}
+	n = newJobNotification("/builds/App.ipa", jobFailed, errors.New("codesign failed"), "")
+	if n.Title != "Resign failed" || n.Message != "App.ipa: codesign failed" || n.Reveal != "" {
+		t.Errorf("failed: %+v", n)
+	}
+}
+
+func TestNotifierCommand(t *testing.T) {
+	found := func(string) (string, error) { return "/opt/homebrew/bin/terminal-notifier", nil }
+
+	cmd := notifierCommand(jobNotification{Title: "IPA resigned", Message: "App.ipa is ready", Reveal: "/builds/it's here/App.ipa"}, found)
+	want := []string{"/opt/homebrew/bin/terminal-notifier", "-title", "ResignIPA", "-subtitle", "IPA resigned", "-message", "App.ipa is ready",
+		"-execute", `/usr/bin/open -R '/builds/it'\''s here/App.ipa'`}
+	if cmd == nil || !reflect.DeepEqual(cmd.Args, want) {
+		t.Errorf("notifierCommand() = %v, want %v", cmd, want)
+	}
+
+	cmd = notifierCommand(jobNotification{Title: "Resign failed", Message: "App.ipa"}, found)
+	if cmd == nil || cmd.Args[len(cmd.Args)-2] != "-activate" || cmd.Args[len(cmd.Args)-1] != guiAppID {
+		t.Errorf("notifierCommand() without output = %v, want -activate %s", cmd, guiAppID)
+	}
+
+	missing := func(string) (string, error) { return "", exec.ErrNotFound }
+	if cmd := notifierCommand(jobNotification{Title: "IPA resigned"}, missing); cmd != nil {
+		t.Errorf("notifierCommand() without terminal-notifier = %v, want nil", cmd)
+	}
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/resignipa/pkg/resigner"
+)
+
+// systemPolicyPath is where administrators of a shared signing Mac
+// install the policy every CLI and GUI run must follow
+var systemPolicyPath = "/Library/Application Support/ResignIPA/policy.yaml"
+
+// loadPolicy returns the signing policy of a run: the machine-wide one if
+// installed, else the one given with --policy, else none. A run cannot
+// swap the machine-wide policy for another, or it would be no guardrail.
+func loadPolicy(path string) (*resigner.Policy, error) {
+	if _, err := os.Stat(systemPolicyPath); err == nil {
+		if path != "" && !samePath(path, systemPolicyPath) {
+			return nil, fmt.Errorf("the machine-wide policy %s cannot be replaced with --policy %s", systemPolicyPath, path)
+		}
+		return resigner.LoadPolicy(systemPolicyPath)
+	}
+	if path == "" {
+		return nil, nil
+	}
+	return resigner.LoadPolicy(path)
+}
+
+// samePath reports whether a and b name the same file
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
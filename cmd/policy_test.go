@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	dir := t.TempDir()
+	own := filepath.Join(dir, "own.yaml")
+	os.WriteFile(own, []byte("teamIds: [ABCDE12345]\n"), 0644)
+	system := filepath.Join(dir, "system.yaml")
+	defer func(path string) { systemPolicyPath = path }(systemPolicyPath)
+
+	// Without a machine-wide policy, --policy is optional
+	systemPolicyPath = filepath.Join(dir, "missing.yaml")
+	if policy, err := loadPolicy(""); err != nil || policy != nil {
+		t.Errorf("loadPolicy(\"\") = %+v, %v; want none", policy, err)
+	}
+	if policy, err := loadPolicy(own); err != nil || len(policy.TeamIDs) != 1 {
+		t.Errorf("loadPolicy(own) = %+v, %v", policy, err)
+	}
+
+	// With one, it always applies and cannot be swapped
+	os.WriteFile(system, []byte("bundleIds: [\"com.example.*\"]\n"), 0644)
+	systemPolicyPath = system
+	for _, path := range []string{"", system} {
+		if policy, err := loadPolicy(path); err != nil || len(policy.BundleIDs) != 1 {
+			t.Errorf("loadPolicy(%q) = %+v, %v; want the machine-wide policy", path, policy, err)
+		}
+	}
+	if _, err := loadPolicy(own); err == nil {
+		t.Error("loadPolicy(own) should refuse to replace the machine-wide policy")
+	}
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/resignipa/pkg/resigner"
+)
+
+// preset is a named set of resign options reused across runs
+type preset struct {
+	Certificate     string `json:"certificate,omitempty"`
+	Keychain        string `json:"keychain,omitempty"`
+	MobileProvision string `json:"provision,omitempty"`
+	Entitlements    string `json:"entitlements,omitempty"`
+	BundleID        string `json:"bundleId,omitempty"`
+	TeamID          string `json:"teamId,omitempty"`
+	OutputDir       string `json:"outputDir,omitempty"`
+	AdHoc           bool   `json:"adhoc,omitempty"`
+	Verify          bool   `json:"verify,omitempty"`
+	// ArchivePasswordRef is a secret reference, never the password itself
+	ArchivePasswordRef string `json:"archivePasswordRef,omitempty"`
+}
+
+// presetStore is the on-disk collection of presets
+type presetStore struct {
+	Default string            `json:"default,omitempty"`
+	Presets map[string]preset `json:"presets"`
+}
+
+// presetsPath returns the file presets are stored in
+func presetsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "resignipa", "presets.json"), nil
+}
+
+// loadPresets reads the preset store, returning an empty one if none exists
+func loadPresets() (*presetStore, error) {
+	store := &presetStore{Presets: make(map[string]preset)}
+	path, err := presetsPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("invalid presets file %s: %w", path, err)
+	}
+	if store.Presets == nil {
+		store.Presets = make(map[string]preset)
+	}
+	return store, nil
+}
+
+// save writes the preset store to disk
+func (s *presetStore) save() error {
+	path, err := presetsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// get returns the named preset, or the default preset when name is empty
+func (s *presetStore) get(name string) (preset, string, error) {
+	if name == "" {
+		name = s.Default
+	}
+	if name == "" {
+		return preset{}, "", fmt.Errorf("no default preset; save one with --save-preset")
+	}
+	p, ok := s.Presets[name]
+	if !ok {
+		return preset{}, "", fmt.Errorf("unknown preset %q (available: %v)", name, s.names())
+	}
+	return p, name, nil
+}
+
+// names lists the stored presets alphabetically
+func (s *presetStore) names() []string {
+	var names []string
+	for name := range s.Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// config builds a resign configuration for source from the preset
+func (p preset) config(source string) resigner.Config {
+	return resigner.Config{
+		SourceIPA:       source,
+		Certificate:     p.Certificate,
+		Keychain:        p.Keychain,
+		Entitlements:    p.Entitlements,
+		MobileProvision: p.MobileProvision,
+		BundleID:        p.BundleID,
+		TeamID:          p.TeamID,
+		OutputDir:       p.OutputDir,
+		AdHoc:           p.AdHoc,
+		Verify:          p.Verify,
+	}
+}
+
+// fillEmpty sets *dst to value when it is empty
+func fillEmpty(dst *string, value string) {
+	if *dst == "" {
+		*dst = value
+	}
+}
+
+// applyPresetFlags fills options not given on the command line from the
+// named preset
+func applyPresetFlags(name string) error {
+	store, err := loadPresets()
+	if err != nil {
+		return err
+	}
+	p, _, err := store.get(name)
+	if err != nil {
+		return err
+	}
+	fillEmpty(&certificate, p.Certificate)
+	fillEmpty(&keychain, p.Keychain)
+	if provisionBase64 == "" {
+		fillEmpty(&mobileProvision, p.MobileProvision)
+	}
+	fillEmpty(&entitlements, p.Entitlements)
+	fillEmpty(&bundleID, p.BundleID)
+	fillEmpty(&teamID, p.TeamID)
+	fillEmpty(&outputDir, p.OutputDir)
+	if archivePassword == "" {
+		fillEmpty(&archivePasswordRef, p.ArchivePasswordRef)
+	}
+	adHoc = adHoc || p.AdHoc
+	verify = verify || p.Verify
+	return nil
+}
+
+// savePresetFlags stores the current command line options as a preset.
+// The first preset saved becomes the default.
+func savePresetFlags(name string) error {
+	store, err := loadPresets()
+	if err != nil {
+		return err
+	}
+	store.Presets[name] = preset{
+		Certificate:     certificate,
+		Keychain:        keychain,
+		MobileProvision: absPath(mobileProvision),
+		Entitlements:    absPath(entitlements),
+		BundleID:        bundleID,
+		TeamID:          teamID,
+		OutputDir:       absPath(outputDir),
+		AdHoc:           adHoc,
+		Verify:          verify,
+
+		ArchivePasswordRef: archivePasswordRef,
+	}
+	if store.Default == "" {
+		store.Default = name
+	}
+	return store.save()
+}
+
+// absPath makes a non-empty path absolute so presets work from any
+// directory
+func absPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/resignipa/pkg/resigner"
+	"github.com/spf13/cobra"
+)
+
+var privacyJSON bool
+
+var privacyCmd = &cobra.Command{
+	Use:   "privacy <app.ipa|App.app>",
+	Short: "Report the privacy manifests of an app and its SDKs",
+	Long: `List the PrivacyInfo.xcprivacy manifest of the app, its extensions and every
+embedded framework or resource bundle. Bundles without a manifest are flagged,
+since App Store Connect rejects builds whose SDKs use required-reason APIs
+without declaring them.
+
+To add declarations while resigning, pass --privacy-manifest to resign.
+
+Example:
+  resignipa privacy MyApp.ipa
+  resignipa -s MyApp.ipa -c "Cert" --privacy-manifest PrivacyInfo.xcprivacy`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		bundles, err := resigner.InspectPrivacyManifests(args[0])
+		if err != nil {
+			fmt.Printf("\n❌ Inspection failed: %v\n", err)
+			os.Exit(1)
+		}
+
+		if privacyJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			encoder.Encode(bundles)
+			return
+		}
+		for _, bundle := range bundles {
+			printPrivacyManifest(bundle)
+		}
+	},
+}
+
+// printPrivacyManifest renders one bundle's privacy declarations
+func printPrivacyManifest(bundle resigner.BundlePrivacy) {
+	manifest := bundle.Manifest
+	if manifest == nil {
+		fmt.Printf("\n⚠️  %s: no privacy manifest\n", bundle.Path)
+		return
+	}
+
+	fmt.Printf("\n📦 %s\n", bundle.Path)
+	fmt.Printf("   Tracking: %v\n", manifest.Tracking)
+	if len(manifest.TrackingDomains) > 0 {
+		fmt.Printf("   Tracking domains: %s\n", strings.Join(manifest.TrackingDomains, ", "))
+	}
+	for _, api := range manifest.AccessedAPITypes {
+		fmt.Printf("   API %s: %s\n", strings.TrimPrefix(api.Type, "NSPrivacyAccessedAPICategory"), strings.Join(api.Reasons, ", "))
+	}
+	for _, data := range manifest.CollectedDataTypes {
+		fmt.Printf("   Collects %s (linked: %v, tracking: %v)\n",
+			strings.TrimPrefix(data.Type, "NSPrivacyCollectedDataType"), data.Linked, data.Tracking)
+	}
+}
+
+func init() {
+	privacyCmd.Flags().BoolVar(&privacyJSON, "json", false, "Print the report as JSON")
+
+	rootCmd.AddCommand(privacyCmd)
+}
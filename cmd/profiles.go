@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"sort"
+	"time"
+
+	"github.com/resignipa/pkg/resigner"
+)
+
+// profileExpiryWarning is how soon before expiry setup flags a profile
+const profileExpiryWarning = 30 * 24 * time.Hour
+
+// auditProfiles lists the installed provisioning profiles by team and app
+// ID, flagging expired profiles and those about to expire, so newcomers
+// see what they can actually sign for
+func (sc *SetupChecker) auditProfiles(now time.Time) {
+	profiles := resigner.InstalledProfiles()
+	sc.systemInfo.ProfileCount = len(profiles)
+	if len(profiles) == 0 {
+		sc.logWarning("No provisioning profiles installed")
+		sc.logInfo("  Download them in Xcode (Settings → Accounts) or from https://developer.apple.com")
+		return
+	}
+
+	byTeam := make(map[string][]resigner.InstalledProfile)
+	for _, profile := range profiles {
+		team := profile.TeamID()
+		if profile.TeamName != "" {
+			team = profile.TeamName + " (" + team + ")"
+		}
+		byTeam[team] = append(byTeam[team], profile)
+	}
+	teams := make([]string, 0, len(byTeam))
+	for team := range byTeam {
+		teams = append(teams, team)
+	}
+	sort.Strings(teams)
+
+	expired, expiring := 0, 0
+	for _, team := range teams {
+		sc.logInfo("Team %s", team)
+		group := byTeam[team]
+		sort.SliceStable(group, func(i, j int) bool { return group[i].AppID() < group[j].AppID() })
+		for _, profile := range group {
+			expiry := profile.ExpirationDate.Format("2006-01-02")
+			switch {
+			case profile.Expired(now):
+				expired++
+				// Stale leftovers: they do not stop anything else from signing
+				sc.logWarning("%s — %s: expired %s", profile.AppID(), profile.Name, expiry)
+			case profile.ExpirationDate.Sub(now) < profileExpiryWarning:
+				expiring++
+				sc.logWarning("%s — %s: expires %s", profile.AppID(), profile.Name, expiry)
+			default:
+				sc.logSuccess("%s — %s: valid until %s", profile.AppID(), profile.Name, expiry)
+			}
+		}
+	}
+
+	sc.logSuccess("Found %d provisioning profile(s) for %d team(s)", len(profiles), len(teams))
+	if expired > 0 {
+		sc.logInfo("  %d expired profile(s) can be deleted from ~/Library/MobileDevice/Provisioning Profiles", expired)
+	}
+	if expiring > 0 {
+		sc.logInfo("  %d profile(s) expire within 30 days; renew them in Xcode or on developer.apple.com", expiring)
+	}
+}
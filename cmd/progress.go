@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/resignipa/pkg/resigner"
+)
+
+// etaEstimator turns indexed component events into a remaining-time estimate
+type etaEstimator struct {
+	start time.Time
+	first int
+}
+
+// Observe records an event and returns the estimated time left, if known.
+// Component events are emitted before the component is signed, so the
+// event for component i means i-1 components are done.
+func (e *etaEstimator) Observe(event resigner.Event) (time.Duration, bool) {
+	if event.Current == 0 || event.Total == 0 {
+		return 0, false
+	}
+
+	if e.start.IsZero() {
+		e.start = event.Time
+		e.first = event.Current
+		return 0, false
+	}
+
+	done := event.Current - e.first
+	if done <= 0 {
+		return 0, false
+	}
+
+	perComponent := event.Time.Sub(e.start) / time.Duration(done)
+	remaining := event.Total - event.Current + 1
+	return perComponent * time.Duration(remaining), true
+}
+
+// formatETA renders a remaining-time estimate for progress output
+func formatETA(remaining time.Duration) string {
+	if remaining < time.Second {
+		return "ETA <1s"
+	}
+	return fmt.Sprintf("ETA %s", remaining.Round(time.Second))
+}
+
+// withETA appends the estimate to an event's message when one is available
+func withETA(eta *etaEstimator, event resigner.Event) string {
+	if remaining, ok := eta.Observe(event); ok {
+		return fmt.Sprintf("%s (%s)", event.Message, formatETA(remaining))
+	}
+	return event.Message
+}
+
+// inGitHubActions reports whether we are running as a GitHub Actions step
+func inGitHubActions() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// githubGrouper folds the log of each signed component into a collapsible
+// ::group:: and, when annotate is set, turns warnings and errors into
+// workflow annotations
+type githubGrouper struct {
+	w        io.Writer
+	annotate bool
+	open     bool
+}
+
+// Print writes one progress line, opening and closing groups around
+// component events
+func (g *githubGrouper) Print(event resigner.Event, line string) {
+	switch {
+	case event.Component != "":
+		g.close()
+		fmt.Fprintf(g.w, "::group::%s\n", escapeWorkflowData(line))
+		g.open = true
+		return
+	case event.Type == resigner.EventError:
+		g.close()
+		if g.annotate {
+			fmt.Fprintf(g.w, "::error title=Resign failed::%s\n", escapeWorkflowData(event.Message))
+			return
+		}
+	case event.Type == resigner.EventWarning:
+		if g.annotate {
+			title := "ResignIPA"
+			if event.Warning != nil {
+				title += " " + string(event.Warning.Code)
+			}
+			fmt.Fprintf(g.w, "::warning title=%s::%s\n", title, escapeWorkflowData(event.Message))
+			return
+		}
+	case event.Type == resigner.EventOutput:
+		// Tool output belongs to the component being signed
+	default:
+		// A new phase ends the current component's group
+		g.close()
+	}
+	fmt.Fprintln(g.w, line)
+}
+
+// close ends the open group, if any
+func (g *githubGrouper) close() {
+	if g.open {
+		fmt.Fprintln(g.w, "::endgroup::")
+		g.open = false
+	}
+}
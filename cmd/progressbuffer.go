@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"sync"
+	"time"
+)
+
+// progressRefreshInterval is how often the GUI log redraws while events
+// arrive; fast enough to feel live, slow enough that apps with hundreds of
+// components do not spend the run re-laying out text
+const progressRefreshInterval = 100 * time.Millisecond
+
+// progressBuffer batches progress lines so the GUI redraws at most once
+// per interval however fast events arrive. flush gets the lines in order
+// and calls never overlap, so it is the only code touching the log
+// widget while a run is going.
+type progressBuffer struct {
+	interval time.Duration
+	flush    func(lines []string)
+
+	mu      sync.Mutex
+	pending []string
+	timer   *time.Timer
+
+	// flushMu keeps a timed flush and an explicit Flush from interleaving
+	flushMu sync.Mutex
+}
+
+// newProgressBuffer returns a buffer handing lines to flush at most once
+// per interval
+func newProgressBuffer(interval time.Duration, flush func(lines []string)) *progressBuffer {
+	return &progressBuffer{interval: interval, flush: flush}
+}
+
+// Add queues a line, scheduling a flush if none is pending
+func (b *progressBuffer) Add(line string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pending = append(b.pending, line)
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.interval, b.Flush)
+	}
+}
+
+// Flush hands every queued line to flush now, e.g. before showing the
+// result of a run
+func (b *progressBuffer) Flush() {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	lines := b.pending
+	b.pending = nil
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.mu.Unlock()
+
+	if len(lines) > 0 {
+		b.flush(lines)
+	}
+}
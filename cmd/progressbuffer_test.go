@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestProgressBufferBatches(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]string
+	b := newProgressBuffer(time.Hour, func(lines []string) {
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, lines)
+	})
+
+	for i := 0; i < 500; i++ {
+		b.Add(fmt.Sprintf("line %d", i))
+	}
+	b.Flush()
+	b.Flush()
+	b.Add("last")
+	b.Flush()
+
+	if len(batches) != 2 || len(batches[0]) != 500 || batches[0][499] != "line 499" {
+		t.Fatalf("flushed %d batches, want 500 lines then 1", len(batches))
+	}
+	if !reflect.DeepEqual(batches[1], []string{"last"}) {
+		t.Errorf("second batch = %v", batches[1])
+	}
+}
+
+func TestProgressBufferFlushesAfterInterval(t *testing.T) {
+	flushed := make(chan []string, 1)
+	b := newProgressBuffer(10*time.Millisecond, func(lines []string) { flushed <- lines })
+	b.Add("a")
+	b.Add("b")
+
+	select {
+	case lines := <-flushed:
+		if !reflect.DeepEqual(lines, []string{"a", "b"}) {
+			t.Errorf("flushed %v, want [a b]", lines)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("buffer never flushed")
+	}
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/resignipa/pkg/resigner"
+)
+
+// recipeRun holds the files unpacked from a --recipe archive
+type recipeRun struct {
+	// dir is the temporary directory the recipe's files are unpacked to
+	dir string
+	// source is the input the recipe was exported for
+	source resigner.RecipeSource
+}
+
+// applyRecipeFlags fills options not given on the command line from the
+// recipe at path, the way presets do
+func applyRecipeFlags(path string) (*recipeRun, error) {
+	dir, err := os.MkdirTemp("", "resignipa-recipe-")
+	if err != nil {
+		return nil, err
+	}
+	run := &recipeRun{dir: dir}
+	config, source, err := resigner.ImportRecipe(path, dir)
+	if err != nil {
+		run.close()
+		return nil, err
+	}
+	run.source = source
+
+	fillEmpty(&certificate, config.Certificate)
+	fillEmpty(&entitlements, config.Entitlements)
+	if provisionBase64 == "" {
+		fillEmpty(&mobileProvision, config.MobileProvision)
+	}
+	fillEmpty(&privacyManifest, config.PrivacyManifest)
+	fillEmpty(&bundleID, config.BundleID)
+	fillEmpty(&teamID, config.TeamID)
+	fillEmpty(&expectSHA256, config.ExpectSHA256)
+	fillEmpty(&entitlementsMerge, string(config.EntitlementsMerge))
+	fillEmpty(&betaReports, string(config.BetaReports))
+	fillEmpty(&compression, string(config.Compression))
+	if concurrency <= 1 && config.Concurrency > 1 {
+		concurrency = config.Concurrency
+	}
+	if timeout == 0 {
+		timeout = config.Timeout
+	}
+	if commandTimeout == 0 {
+		commandTimeout = config.CommandTimeout
+	}
+	if maxOutputSize == 0 {
+		maxOutputSize = sizeFlag(config.MaxOutputSize)
+	}
+	if warnOutputSize == 0 {
+		warnOutputSize = sizeFlag(config.WarnOutputSize)
+	}
+	if manifest := config.MDMManifest; manifest != nil {
+		fillEmpty(&mdmManifestURL, manifest.URL)
+		fillEmpty(&mdmDisplayImage, manifest.DisplayImageURL)
+		fillEmpty(&mdmFullSizeImage, manifest.FullSizeImageURL)
+		fillEmpty(&mdmTitle, manifest.Title)
+		if manifest.ChunkSize > 0 && mdmChunkSize == resigner.DefaultMDMChunkSize {
+			mdmChunkSize = manifest.ChunkSize
+		}
+	}
+	if len(componentIdentities) == 0 {
+		componentIdentities = config.ComponentIdentities
+	}
+	adHoc = adHoc || config.AdHoc
+	verify = verify || config.Verify
+	skipValid = skipValid || config.SkipValid
+	fixBundle = fixBundle || config.Fix
+	noQuarantine = noQuarantine || config.NoQuarantine
+	bundleFromProfile = bundleFromProfile || config.BundleFromProfile
+	forceBundleFromProfile = forceBundleFromProfile || config.ForceBundleFromProfile
+	suffixOnConflict = suffixOnConflict || config.SuffixOnConflict
+	enterprise = enterprise || config.Enterprise
+	autoStrip = autoStrip || config.AutoStrip
+	return run, nil
+}
+
+// checkSource notes when the source differs from the one the recipe was
+// exported for, which is expected when replaying it on a new build
+func (run *recipeRun) checkSource(logf func(format string, args ...interface{})) {
+	if run == nil || run.source.SHA256 == "" {
+		return
+	}
+	if sum, err := resigner.FileSHA256(sourceIPA); err == nil && sum != run.source.SHA256 {
+		logf("ℹ️  Source differs from the recipe's %s (SHA-256 %s)", run.source.Name, run.source.SHA256)
+	}
+}
+
+// close removes the unpacked files
+func (run *recipeRun) close() {
+	if run != nil {
+		os.RemoveAll(run.dir)
+	}
+}
+
+// recipePath returns the path --export-recipe writes to, adding the
+// recipe extension when it is missing
+func recipePath(path string) string {
+	if filepath.Ext(path) != resigner.RecipeExt {
+		path += resigner.RecipeExt
+	}
+	return path
+}
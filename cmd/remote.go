@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/resignipa/pkg/storage"
+)
+
+// remoteRun holds the local stand-ins for an s3:// or gs:// source and
+// output, so the resigner itself only ever sees local paths
+type remoteRun struct {
+	// dir is the temporary directory holding the download and outputs
+	dir string
+	// output is the folder outputs are uploaded to, if remote
+	output *storage.Location
+}
+
+// prepareRemote downloads a remote --source and points a remote
+// --output-dir at a local folder, rewriting both flags to local paths. It
+// returns nil when neither is remote.
+func prepareRemote(ctx context.Context, logf func(format string, args ...interface{})) (*remoteRun, error) {
+	if !storage.IsRemote(sourceIPA) && !storage.IsRemote(outputDir) {
+		return nil, nil
+	}
+	if inPlace {
+		return nil, fmt.Errorf("--in-place cannot be used with remote sources or outputs")
+	}
+
+	dir, err := os.MkdirTemp("", "resignipa-remote-")
+	if err != nil {
+		return nil, err
+	}
+	run := &remoteRun{dir: dir}
+
+	if storage.IsRemote(outputDir) {
+		output, err := storage.Parse(outputDir)
+		if err != nil {
+			run.close()
+			return nil, err
+		}
+		run.output = &output
+		outputDir = filepath.Join(dir, "output")
+	}
+
+	if storage.IsRemote(sourceIPA) {
+		source, err := storage.Parse(sourceIPA)
+		if err != nil {
+			run.close()
+			return nil, err
+		}
+		if !strings.HasSuffix(strings.ToLower(source.Key), ".ipa") {
+			run.close()
+			return nil, fmt.Errorf("remote source must be an .ipa, got: %s", sourceIPA)
+		}
+		local := filepath.Join(dir, "source", source.Base())
+		if err := os.MkdirAll(filepath.Dir(local), 0755); err != nil {
+			run.close()
+			return nil, err
+		}
+		logf("⬇️  Downloading %s", source)
+		if err := storage.Download(ctx, source, local); err != nil {
+			run.close()
+			return nil, err
+		}
+		sourceIPA = local
+	} else if run.output != nil && !strings.HasSuffix(strings.ToLower(sourceIPA), ".ipa") {
+		run.close()
+		return nil, fmt.Errorf("remote outputs need an .ipa source, got: %s", sourceIPA)
+	}
+	return run, nil
+}
+
+// upload copies every output of the run (the IPA and any dSYM archive) to
+// the remote output folder and returns the URL the IPA went to
+func (run *remoteRun) upload(ctx context.Context, outputPath string, logf func(format string, args ...interface{})) (string, error) {
+	if run == nil || run.output == nil {
+		return outputPath, nil
+	}
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		return "", err
+	}
+	uploaded := outputPath
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(outputDir, entry.Name())
+		target := run.output.Join(entry.Name())
+		logf("⬆️  Uploading %s", target)
+		if err := storage.Upload(ctx, path, target); err != nil {
+			return "", err
+		}
+		if path == outputPath {
+			uploaded = target.String()
+		}
+	}
+	return uploaded, nil
+}
+
+// close removes the local stand-ins
+func (run *remoteRun) close() {
+	if run != nil {
+		os.RemoveAll(run.dir)
+	}
+}
@@ -0,0 +1,301 @@
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/resignipa/pkg/resigner"
+)
+
+// Output formats accepted by --output-format
+const (
+	formatText   = "text"
+	formatJSON   = "json"
+	formatJUnit  = "junit"
+	formatGitHub = "github-actions"
+)
+
+// outputFormats lists the valid --output-format values
+var outputFormats = []string{formatText, formatJSON, formatJUnit, formatGitHub}
+
+// signedComponent records one component of a run for reports, with the
+// signature it ended up with
+type signedComponent struct {
+	Path        string        `json:"path"`
+	Duration    time.Duration `json:"-"`
+	Seconds     float64       `json:"durationSeconds"`
+	Failed      bool          `json:"failed,omitempty"`
+	Identifier  string        `json:"identifier,omitempty"`
+	CDHash      string        `json:"cdhash,omitempty"`
+	TeamID      string        `json:"teamId,omitempty"`
+	SigningTime string        `json:"signingTime,omitempty"`
+}
+
+// reportWarning is a warning with the code automation should match on;
+// "warnings" keeps the plain messages
+type reportWarning struct {
+	Code      string `json:"code"`
+	Component string `json:"component,omitempty"`
+	Message   string `json:"message"`
+}
+
+// reportStage is the time one pipeline stage took
+type reportStage struct {
+	Name    string  `json:"name"`
+	Seconds float64 `json:"durationSeconds"`
+}
+
+// runReport is the machine-readable summary of a CLI run. Its JSON form
+// is a stable contract for CI tooling: "outputPath" always names the
+// resigned IPA (or .app) on success.
+type runReport struct {
+	Status         string            `json:"status"`
+	Source         string            `json:"source"`
+	OutputPath     string            `json:"outputPath,omitempty"`
+	TeamID         string            `json:"teamId,omitempty"`
+	BundleID       string            `json:"bundleId,omitempty"`
+	ProfileUUID    string            `json:"profileUuid,omitempty"`
+	ProfileExpires string            `json:"profileExpires,omitempty"`
+	ResignBy       string            `json:"resignBy,omitempty"`
+	ManifestPath   string            `json:"manifestPath,omitempty"`
+	Seconds        float64           `json:"durationSeconds"`
+	Error          string            `json:"error,omitempty"`
+	Warnings       []string          `json:"warnings,omitempty"`
+	WarningDetails []reportWarning   `json:"warningDetails,omitempty"`
+	Components     []signedComponent `json:"components,omitempty"`
+	Stages         []reportStage     `json:"stages,omitempty"`
+	Workspace      string            `json:"workspace,omitempty"`
+
+	start     time.Time
+	lastStart time.Time
+}
+
+// newRunReport starts a report for source
+func newRunReport(source string) *runReport {
+	return &runReport{Source: source, start: time.Now()}
+}
+
+// observe records warnings and component timings from a progress event
+func (r *runReport) observe(event resigner.Event) {
+	switch {
+	case event.Type == resigner.EventWarning:
+		r.Warnings = append(r.Warnings, event.Message)
+	case event.Component != "":
+		r.closeComponent(event.Time)
+		r.Components = append(r.Components, signedComponent{Path: event.Component})
+		r.lastStart = event.Time
+	}
+}
+
+// closeComponent stores the duration of the component being signed
+func (r *runReport) closeComponent(now time.Time) {
+	if n := len(r.Components); n > 0 && r.Components[n-1].Duration == 0 {
+		r.Components[n-1].Duration = now.Sub(r.lastStart)
+		r.Components[n-1].Seconds = r.Components[n-1].Duration.Seconds()
+	}
+}
+
+// finish records the outcome of the run; res may be nil when the run
+// never started
+func (r *runReport) finish(res *resigner.Resigner, err error) {
+	now := time.Now()
+	r.closeComponent(now)
+	r.Seconds = now.Sub(r.start).Seconds()
+	if res != nil {
+		result := res.Result()
+		r.OutputPath = result.OutputPath
+		r.TeamID = result.TeamID
+		r.BundleID = result.BundleID
+		if result.Profile != nil {
+			r.ProfileUUID = result.Profile.UUID
+			r.ProfileExpires = result.Profile.ExpirationDate.Format(time.RFC3339)
+		}
+		r.ManifestPath = result.ManifestPath
+		if !result.ResignBy.IsZero() {
+			r.ResignBy = result.ResignBy.Format(time.RFC3339)
+		}
+		r.addSignatures(result.Components)
+		for _, stage := range result.Stages {
+			r.Stages = append(r.Stages, reportStage{Name: stage.Name, Seconds: stage.Duration.Seconds()})
+		}
+		r.Workspace = result.Workspace
+		for _, warning := range result.Warnings {
+			r.WarningDetails = append(r.WarningDetails, reportWarning{
+				Code:      string(warning.Code),
+				Component: warning.Component,
+				Message:   warning.Message,
+			})
+		}
+	}
+	if err != nil {
+		r.Status = "failure"
+		r.Error = err.Error()
+		for i := range r.Components {
+			if strings.Contains(r.Error, r.Components[i].Path) {
+				r.Components[i].Failed = true
+			}
+		}
+		return
+	}
+	r.Status = "success"
+}
+
+// addSignatures fills in the signature of each component signed
+func (r *runReport) addSignatures(signed []resigner.SignedComponent) {
+	for i := range r.Components {
+		path := filepath.ToSlash(r.Components[i].Path)
+		for _, sig := range signed {
+			if path != sig.Path && !strings.HasSuffix(path, "/"+sig.Path) {
+				continue
+			}
+			r.Components[i].Identifier = sig.Identifier
+			r.Components[i].CDHash = sig.CDHash
+			r.Components[i].TeamID = sig.TeamID
+			if !sig.SigningTime.IsZero() {
+				r.Components[i].SigningTime = sig.SigningTime.Format(time.RFC3339)
+			}
+			break
+		}
+	}
+}
+
+// write renders the report in the given format
+func (r *runReport) write(w io.Writer, format string) error {
+	switch format {
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case formatJUnit:
+		return r.writeJUnit(w)
+	case formatGitHub:
+		return r.writeGitHub(w)
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// JUnit XML schema, as understood by Jenkins and most CI systems
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Time       string          `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit renders one test case per signed component plus one for the
+// run as a whole
+func (r *runReport) writeJUnit(w io.Writer) error {
+	suite := junitTestSuite{
+		Name: "resignipa",
+		Time: fmt.Sprintf("%.3f", r.Seconds),
+		Properties: []junitProperty{
+			{Name: "source", Value: r.Source},
+			{Name: "outputPath", Value: r.OutputPath},
+			{Name: "teamId", Value: r.TeamID},
+		},
+	}
+	for _, component := range r.Components {
+		tc := junitTestCase{
+			ClassName: "resignipa.sign",
+			Name:      filepath.Base(component.Path),
+			Time:      fmt.Sprintf("%.3f", component.Seconds),
+		}
+		if component.Failed {
+			tc.Failure = &junitFailure{Message: "codesign failed", Text: r.Error}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	run := junitTestCase{
+		ClassName: "resignipa",
+		Name:      "resign " + filepath.Base(r.Source),
+		Time:      fmt.Sprintf("%.3f", r.Seconds),
+	}
+	if r.Error != "" {
+		run.Failure = &junitFailure{Message: r.Error, Text: strings.Join(append(r.Warnings, r.Error), "\n")}
+	}
+	suite.Cases = append(suite.Cases, run)
+
+	suite.Tests = len(suite.Cases)
+	for _, tc := range suite.Cases {
+		if tc.Failure != nil {
+			suite.Failures++
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// escapeWorkflowData escapes a GitHub Actions workflow command message
+func escapeWorkflowData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+// writeGitHub emits workflow command annotations and, when running in
+// GitHub Actions, step outputs
+func (r *runReport) writeGitHub(w io.Writer) error {
+	for _, warning := range r.Warnings {
+		fmt.Fprintf(w, "::warning title=ResignIPA::%s\n", escapeWorkflowData(warning))
+	}
+	if r.Error != "" {
+		fmt.Fprintf(w, "::error title=Resign failed::%s\n", escapeWorkflowData(r.Error))
+	} else {
+		fmt.Fprintf(w, "::notice title=Resigned IPA::%s\n", escapeWorkflowData(r.OutputPath))
+	}
+
+	outputFile := os.Getenv("GITHUB_OUTPUT")
+	if outputFile == "" {
+		return nil
+	}
+	f, err := os.OpenFile(outputFile, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "status=%s\noutput-path=%s\nteam-id=%s\n", r.Status, r.OutputPath, r.TeamID)
+	if err == nil && r.ResignBy != "" {
+		_, err = fmt.Fprintf(f, "resign-by=%s\n", r.ResignBy)
+	}
+	return err
+}
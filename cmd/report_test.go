@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/resignipa/pkg/resigner"
+)
+
+// updateGolden rewrites the golden files instead of comparing against them:
+//
+//	go test ./cmd -run Golden -update
+//
+// Review the diff before committing: the files are the machine-readable
+// contract CI pipelines parse, so any change to them is a breaking change
+// for someone.
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// checkGolden compares got with testdata/name
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("missing golden file, run with -update: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s changed; if intended, run with -update\n--- got\n%s\n--- want\n%s", path, got, want)
+	}
+}
+
+// goldenReport builds a report of a run with fixed timings, two signed
+// components and a warning, failing with err if it is not nil
+func goldenReport(err error) *runReport {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	r := newRunReport("/builds/App.ipa")
+	r.observe(resigner.Event{Type: resigner.EventInfo, Component: "/builds/tmp/Payload/App.app/Frameworks/Kit.framework", Time: start})
+	r.observe(resigner.Event{Type: resigner.EventWarning, Message: "Warning: Kit.framework requires iOS 17.0, newer than the app's 15.0", Time: start.Add(time.Second)})
+	r.observe(resigner.Event{Type: resigner.EventInfo, Component: "/builds/tmp/Payload/App.app", Time: start.Add(1500 * time.Millisecond)})
+	r.closeComponent(start.Add(4 * time.Second))
+	r.finish(nil, err)
+
+	r.Seconds = 12.5
+	r.WarningDetails = []reportWarning{{
+		Code:      string(resigner.WarningFrameworkIssue),
+		Component: "App.app/Frameworks/Kit.framework",
+		Message:   "Kit.framework requires iOS 17.0, newer than the app's 15.0",
+	}}
+	if err != nil {
+		return r
+	}
+	r.OutputPath = "/builds/Resigned/App.ipa"
+	r.TeamID = "ABCDE12345"
+	r.BundleID = "com.example.app"
+	r.ProfileUUID = "11111111-2222-3333-4444-555555555555"
+	r.ProfileExpires = "2025-05-01T00:00:00Z"
+	r.ResignBy = "2025-04-01T00:00:00Z"
+	r.ManifestPath = "/builds/Resigned/App.manifest.plist"
+	signingTime := time.Date(2024, 5, 1, 12, 0, 3, 0, time.UTC)
+	r.addSignatures([]resigner.SignedComponent{
+		{Path: "App.app/Frameworks/Kit.framework", Identifier: "com.example.Kit", CDHash: "aaaa", TeamID: "ABCDE12345", SigningTime: signingTime},
+		{Path: "App.app", Identifier: "com.example.app", CDHash: "bbbb", TeamID: "ABCDE12345", SigningTime: signingTime},
+	})
+	return r
+}
+
+func TestReportGolden(t *testing.T) {
+	failure := errors.New("failed to sign /builds/tmp/Payload/App.app: codesign failed: errSecInternalComponent")
+	tests := []struct {
+		golden string
+		format string
+		err    error
+	}{
+		{"report-success.json", formatJSON, nil},
+		{"report-failure.json", formatJSON, failure},
+		{"report-success.junit.xml", formatJUnit, nil},
+		{"report-failure.junit.xml", formatJUnit, failure},
+		{"report-success.github", formatGitHub, nil},
+		{"report-failure.github", formatGitHub, failure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			// Step outputs are part of the GitHub Actions contract
+			outputs := filepath.Join(t.TempDir(), "github-output")
+			t.Setenv("GITHUB_OUTPUT", outputs)
+
+			var buf bytes.Buffer
+			if err := goldenReport(tt.err).write(&buf, tt.format); err != nil {
+				t.Fatalf("write() failed: %v", err)
+			}
+			if tt.format == formatGitHub {
+				data, _ := os.ReadFile(outputs)
+				buf.WriteString("--- $GITHUB_OUTPUT\n")
+				buf.Write(data)
+			}
+			checkGolden(t, tt.golden, buf.Bytes())
+		})
+	}
+}
+
+func TestSetupReportGolden(t *testing.T) {
+	sc := &SetupChecker{
+		systemInfo: SystemInfo{
+			OS:           "darwin",
+			Architecture: "arm64",
+			XcodePath:    "/Applications/Xcode.app/Contents/Developer",
+			CertCount:    1,
+			ProfileCount: 2,
+			WorkingDir:   "/builds",
+		},
+		certificates: []Certificate{{Hash: "0123456789ABCDEF0123456789ABCDEF01234567", Name: "Apple Development: Jane Doe (ABCDE12345)", Type: "Development"}},
+		tools: []toolStatus{
+			{OptionalTool: resigner.OptionalTool{Name: "ideviceinstaller", Purpose: "lists the apps on a device", Install: "brew install ideviceinstaller"}},
+			{OptionalTool: resigner.OptionalTool{Name: "lipo", Purpose: "thins universal binaries", Install: "xcode-select --install"}, Available: true, Path: "/usr/bin/lipo"},
+		},
+	}
+	sc.phase = "tools"
+	sc.record(checkPass, "codesign found")
+	sc.record(checkWarn, "ideviceinstaller not found (optional)")
+	sc.phase = "certificates"
+	sc.record(checkFail, "No distribution certificate found")
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(sc.report(nil)); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "setup-report.json", buf.Bytes())
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/resignipa/pkg/resigner"
+)
+
+// runLog keeps the complete, unformatted log of a GUI run, including the
+// full codesign output carried by errors, so it can be copied or saved
+// when asking for help. The progress pane only shows a formatted view.
+type runLog struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+// start clears the log and records what the run was asked to do
+func (l *runLog) start(config resigner.Config) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = []string{
+		fmt.Sprintf("ResignIPA run started %s", time.Now().Format(time.RFC3339)),
+		"Source:       " + config.SourceIPA,
+		"Certificate:  " + config.Certificate,
+		"Provision:    " + config.MobileProvision,
+		"Entitlements: " + config.Entitlements,
+		"Bundle ID:    " + config.BundleID,
+		"",
+	}
+}
+
+// observe records an event; it is a resigner.EventHandler
+func (l *runLog) observe(event resigner.Event) {
+	l.add(fmt.Sprintf("%s [%s] %s", event.Time.Format("15:04:05"), event.Type, event.Message))
+}
+
+// add appends a line
+func (l *runLog) add(line string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, line)
+}
+
+// String returns the log as text
+func (l *runLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.lines) == 0 {
+		return ""
+	}
+	return strings.Join(l.lines, "\n") + "\n"
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/resignipa/pkg/secrets"
+)
+
+// resolveSecret returns value, or when it is empty the secret ref points
+// at, so passwords can come from the keychain or a vault instead of the
+// command line. flag names the option in errors.
+func resolveSecret(ctx context.Context, flag, value, ref string) (string, error) {
+	if ref == "" {
+		return value, nil
+	}
+	if value != "" {
+		return "", fmt.Errorf("--%s and --%s-ref cannot be combined", flag, flag)
+	}
+	if !secrets.IsRef(ref) {
+		return "", fmt.Errorf("--%s-ref %q is not a secret reference (use env:NAME, keychain:service/account, op://vault/item/field or vault:path#field)", flag, ref)
+	}
+	return secrets.Resolve(ctx, ref)
+}
+
+// decodeBase64 decodes a file given to flag as base64, as CI systems
+// store files in secrets; "-" reads it from stdin. Line breaks and other
+// whitespace, which base64 tools wrap their output with, are ignored.
+func decodeBase64(flag, value string, stdin io.Reader) ([]byte, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if value == "-" {
+		input, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read --%s from stdin: %w", flag, err)
+		}
+		value = string(input)
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+	if err != nil {
+		return nil, fmt.Errorf("--%s is not valid base64: %w", flag, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/resignipa/pkg/secrets"
+)
+
+func TestResolveSecret(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("RESIGNIPA_TEST_PASSWORD", "hunter2")
+
+	if got, err := resolveSecret(ctx, "archive-password", "plain", ""); err != nil || got != "plain" {
+		t.Errorf("resolveSecret() without a ref = %q, %v", got, err)
+	}
+	if got, err := resolveSecret(ctx, "archive-password", "", "env:RESIGNIPA_TEST_PASSWORD"); err != nil || got != "hunter2" {
+		t.Errorf("resolveSecret() = %q, %v", got, err)
+	}
+	if _, err := resolveSecret(ctx, "archive-password", "plain", "env:RESIGNIPA_TEST_PASSWORD"); err == nil {
+		t.Error("resolveSecret() with both a value and a ref succeeded")
+	}
+	if _, err := resolveSecret(ctx, "archive-password", "", "hunter2"); err == nil {
+		t.Error("resolveSecret() with a plain value as the ref succeeded")
+	}
+	if _, err := resolveSecret(ctx, "archive-password", "", "env:RESIGNIPA_TEST_UNSET"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("resolveSecret() of an unset variable = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDecodeBase64(t *testing.T) {
+	// base64 wraps its output at 76 columns
+	if got, err := decodeBase64("provision-base64", "PD94bWwg\ndmVyc2lvbj0iMS4wIj8+\n", nil); err != nil || string(got) != `<?xml version="1.0"?>` {
+		t.Errorf("decodeBase64() = %q, %v", got, err)
+	}
+	if got, err := decodeBase64("p12-base64", "-", strings.NewReader("cDEy\n")); err != nil || string(got) != "p12" {
+		t.Errorf("decodeBase64() from stdin = %q, %v", got, err)
+	}
+	if got, err := decodeBase64("provision-base64", "", nil); err != nil || got != nil {
+		t.Errorf("decodeBase64(\"\") = %q, %v", got, err)
+	}
+	if _, err := decodeBase64("p12-base64", "not base64!", nil); err == nil || !strings.Contains(err.Error(), "--p12-base64") {
+		t.Errorf("decodeBase64() of invalid base64 = %v, want an error naming the flag", err)
+	}
+}
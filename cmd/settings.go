@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"image/color"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/resignipa/pkg/resigner"
+)
+
+// Preference keys for GUI settings
+const (
+	prefDefaultCertificate = "defaultCertificate"
+	prefOutputDir          = "outputDir"
+	prefConcurrency        = "concurrency"
+	prefVerify             = "verifyAfterSign"
+	prefTheme              = "theme"
+	prefNotify             = "notifyWhenDone"
+	prefLastRun            = "lastRun"
+)
+
+// Theme names offered in the settings dialog
+const (
+	themeCompact = "Compact"
+	themeSystem  = "System"
+	themeDark    = "Dark"
+)
+
+// guiSettings are the defaults applied to every GUI run
+type guiSettings struct {
+	DefaultCertificate string
+	OutputDir          string
+	Concurrency        int
+	Verify             bool
+	Theme              string
+	// Notify posts a notification when a job finishes, for switching
+	// away during long signs
+	Notify bool
+}
+
+// loadSettings reads the settings from the app preferences
+func loadSettings(prefs fyne.Preferences) guiSettings {
+	return guiSettings{
+		DefaultCertificate: prefs.String(prefDefaultCertificate),
+		OutputDir:          prefs.String(prefOutputDir),
+		Concurrency:        prefs.IntWithFallback(prefConcurrency, 1),
+		Verify:             prefs.Bool(prefVerify),
+		Theme:              prefs.StringWithFallback(prefTheme, themeCompact),
+		Notify:             prefs.Bool(prefNotify),
+	}
+}
+
+// save persists the settings to the app preferences
+func (s guiSettings) save(prefs fyne.Preferences) {
+	prefs.SetString(prefDefaultCertificate, s.DefaultCertificate)
+	prefs.SetString(prefOutputDir, s.OutputDir)
+	prefs.SetInt(prefConcurrency, s.Concurrency)
+	prefs.SetBool(prefVerify, s.Verify)
+	prefs.SetString(prefTheme, s.Theme)
+	prefs.SetBool(prefNotify, s.Notify)
+}
+
+// apply fills the run configuration with the saved defaults
+func (s guiSettings) apply(config *resigner.Config) {
+	if config.Certificate == "" {
+		config.Certificate = s.DefaultCertificate
+	}
+	if config.OutputDir == "" {
+		config.OutputDir = s.OutputDir
+	}
+	config.Concurrency = s.Concurrency
+	config.Verify = s.Verify
+}
+
+// variantTheme forces the default theme into one variant
+type variantTheme struct {
+	fyne.Theme
+	variant fyne.ThemeVariant
+}
+
+func (v variantTheme) Color(name fyne.ThemeColorName, _ fyne.ThemeVariant) color.Color {
+	return v.Theme.Color(name, v.variant)
+}
+
+// applyTheme switches the app to the named theme
+func applyTheme(a fyne.App, name string) {
+	switch name {
+	case themeSystem:
+		a.Settings().SetTheme(theme.DefaultTheme())
+	case themeDark:
+		a.Settings().SetTheme(variantTheme{Theme: theme.DefaultTheme(), variant: theme.VariantDark})
+	default:
+		a.Settings().SetTheme(&compactTheme{})
+	}
+}
+
+// showSettingsDialog edits the saved defaults and calls onSave with the
+// new settings once they have been persisted
+func showSettingsDialog(a fyne.App, window fyne.Window, onSave func(guiSettings)) {
+	current := loadSettings(a.Preferences())
+
+	certEntry := widget.NewEntry()
+	certEntry.SetPlaceHolder("Certificate used when the field is empty")
+	certEntry.SetText(current.DefaultCertificate)
+
+	outputEntry := widget.NewEntry()
+	outputEntry.SetPlaceHolder("Default: 'Resigned' next to the source")
+	outputEntry.SetText(current.OutputDir)
+	outputBrowse := widget.NewButton("...", func() {
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err == nil && uri != nil {
+				outputEntry.SetText(uri.Path())
+			}
+		}, window)
+	})
+
+	concurrencySelect := widget.NewSelect([]string{"1", "2", "4", "8"}, nil)
+	concurrencySelect.SetSelected(strconv.Itoa(current.Concurrency))
+
+	verifyCheck := widget.NewCheck("Verify signature after signing", nil)
+	verifyCheck.SetChecked(current.Verify)
+
+	notifyCheck := widget.NewCheck("Notify when a job finishes", nil)
+	notifyCheck.SetChecked(current.Notify)
+
+	themeSelect := widget.NewRadioGroup([]string{themeCompact, themeSystem, themeDark}, nil)
+	themeSelect.Horizontal = true
+	themeSelect.SetSelected(current.Theme)
+
+	form := widget.NewForm(
+		widget.NewFormItem("Certificate", certEntry),
+		widget.NewFormItem("Output folder", container.NewBorder(nil, nil, nil, outputBrowse, outputEntry)),
+		widget.NewFormItem("Parallel signing", concurrencySelect),
+		widget.NewFormItem("", verifyCheck),
+		widget.NewFormItem("", notifyCheck),
+		widget.NewFormItem("Theme", themeSelect),
+	)
+
+	settingsDialog := dialog.NewCustomConfirm("Settings", "Save", "Cancel", form, func(ok bool) {
+		if !ok {
+			return
+		}
+		concurrency, err := strconv.Atoi(concurrencySelect.Selected)
+		if err != nil || concurrency < 1 {
+			concurrency = 1
+		}
+		settings := guiSettings{
+			DefaultCertificate: certEntry.Text,
+			OutputDir:          outputEntry.Text,
+			Concurrency:        concurrency,
+			Verify:             verifyCheck.Checked,
+			Theme:              themeSelect.Selected,
+			Notify:             notifyCheck.Checked,
+		}
+		settings.save(a.Preferences())
+		applyTheme(a, settings.Theme)
+		if onSave != nil {
+			onSave(settings)
+		}
+	}, window)
+	settingsDialog.Resize(fyne.NewSize(520, 360))
+	settingsDialog.Show()
+}
@@ -1,13 +1,18 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
+	"github.com/resignipa/pkg/command"
+	"github.com/resignipa/pkg/resigner"
 	"github.com/spf13/cobra"
 )
 
@@ -29,7 +34,18 @@ type SetupChecker struct {
 	requiredTools map[string]ToolRequirement
 	optionalTools map[string]ToolRequirement
 	certificates  []Certificate
+	tools         []toolStatus
 	systemInfo    SystemInfo
+	installOpts   installOptions
+	opts          setupOptions
+	binaryPath    string
+
+	// out receives the human-readable report; with jsonOutput it is
+	// discarded and checks are collected for the JSON report instead
+	out        io.Writer
+	jsonOutput bool
+	phase      string
+	checks     []setupCheck
 }
 
 // ToolRequirement represents a required or optional system tool
@@ -39,23 +55,25 @@ type ToolRequirement struct {
 	CheckFunc   func() (bool, string, error)
 	InstallHelp string
 	Critical    bool
+	BuildOnly   bool // only needed to build from source
 }
 
 // Certificate represents a code signing certificate
 type Certificate struct {
-	Hash string
-	Name string
-	Type string
+	Hash string `json:"hash"`
+	Name string `json:"name"`
+	Type string `json:"type"`
 }
 
 // SystemInfo contains system configuration details
 type SystemInfo struct {
-	OS           string
-	Architecture string
-	GoVersion    string
-	XcodePath    string
-	CertCount    int
-	WorkingDir   string
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	GoVersion    string `json:"goVersion,omitempty"`
+	XcodePath    string `json:"xcodePath,omitempty"`
+	CertCount    int    `json:"certificateCount"`
+	ProfileCount int    `json:"profileCount"`
+	WorkingDir   string `json:"workingDir"`
 }
 
 var setupCmd = &cobra.Command{
@@ -65,13 +83,42 @@ var setupCmd = &cobra.Command{
 - Operating system compatibility
 - Required development tools (Go, Xcode)
 - Code signing tools (codesign, security, PlistBuddy)
-- Available signing certificates
-- Project dependencies
+- Optional tools (ideviceinstaller, devicectl, ios-deploy, lipo, actool);
+  features needing a missing one are turned off, not failed
+- Available signing certificates, and that their private keys can be used
+  without keychain prompts
+- Installed provisioning profiles and their expiry
+- Project dependencies and build (with --build, for source checkouts)
 
 This command performs a complete environment audit and provides
-actionable feedback for any missing components.`,
+actionable feedback for any missing components.
+
+With --install it also installs the binary into <prefix>/bin without
+prompting, optionally with shell completions and man pages, using the same
+layout as Homebrew.
+
+Example:
+  resignipa setup
+  resignipa setup --checks-only
+  resignipa setup --build --install --completions --man
+  resignipa setup --install --prefix ~/.local
+  resignipa setup --json > readiness.json`,
 	Run: func(cmd *cobra.Command, args []string) {
 		checker := NewSetupChecker()
+		checker.installOpts = setupInstall
+		checker.opts = setupOpts
+		if setupJSON {
+			checker.jsonOutput = true
+			checker.out = io.Discard
+			err := checker.ExecuteFullSetup()
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			enc.Encode(checker.report(err))
+			if err != nil {
+				os.Exit(1)
+			}
+			return
+		}
 		if err := checker.ExecuteFullSetup(); err != nil {
 			fmt.Printf("%s✗ Setup failed: %v%s\n", colorRed, err, colorReset)
 			os.Exit(1)
@@ -79,7 +126,21 @@ actionable feedback for any missing components.`,
 	},
 }
 
+var (
+	setupInstall installOptions
+	setupOpts    setupOptions
+	setupJSON    bool
+)
+
 func init() {
+	setupCmd.Flags().BoolVar(&setupOpts.Build, "build", false, "Download dependencies and build the binary from source")
+	setupCmd.Flags().BoolVar(&setupOpts.ChecksOnly, "checks-only", false, "Only verify the environment; never build or install anything")
+	setupCmd.Flags().BoolVar(&setupInstall.Enabled, "install", false, "Install the binary into <prefix>/bin (the built one with --build, otherwise this one)")
+	setupCmd.Flags().StringVar(&setupInstall.Prefix, "prefix", "/usr/local", "Installation prefix for --install")
+	setupCmd.Flags().BoolVar(&setupInstall.Completions, "completions", false, "With --install, also install bash, zsh and fish completions")
+	setupCmd.Flags().BoolVar(&setupInstall.Man, "man", false, "With --install, also install man pages")
+	setupCmd.Flags().BoolVar(&setupJSON, "json", false, "Print a machine-readable report of every check instead of the colored output")
+
 	rootCmd.AddCommand(setupCmd)
 }
 
@@ -91,9 +152,11 @@ func NewSetupChecker() *SetupChecker {
 		requiredTools: make(map[string]ToolRequirement),
 		optionalTools: make(map[string]ToolRequirement),
 		certificates:  make([]Certificate, 0),
+		out:           os.Stdout,
 	}
 
 	checker.initializeToolRequirements()
+	checker.initializeOptionalTools()
 	return checker
 }
 
@@ -104,26 +167,25 @@ func (sc *SetupChecker) initializeToolRequirements() {
 			Name:    "Go",
 			Command: "go",
 			CheckFunc: func() (bool, string, error) {
-				cmd := exec.Command("go", "version")
-				output, err := cmd.CombinedOutput()
+				output, err := toolOutput("go", "version")
 				if err != nil {
 					return false, "", err
 				}
-				return true, strings.TrimSpace(string(output)), nil
+				return true, output, nil
 			},
 			InstallHelp: "Install from: https://golang.org/dl/ or run: brew install go",
 			Critical:    true,
+			BuildOnly:   true,
 		},
 		"xcode-select": {
 			Name:    "Xcode Command Line Tools",
 			Command: "xcode-select",
 			CheckFunc: func() (bool, string, error) {
-				cmd := exec.Command("xcode-select", "-p")
-				output, err := cmd.CombinedOutput()
+				output, err := toolOutput("xcode-select", "-p")
 				if err != nil {
 					return false, "", err
 				}
-				return true, strings.TrimSpace(string(output)), nil
+				return true, output, nil
 			},
 			InstallHelp: "Run: xcode-select --install",
 			Critical:    true,
@@ -132,12 +194,11 @@ func (sc *SetupChecker) initializeToolRequirements() {
 			Name:    "codesign",
 			Command: "codesign",
 			CheckFunc: func() (bool, string, error) {
-				cmd := exec.Command("which", "codesign")
-				output, err := cmd.CombinedOutput()
+				output, err := toolOutput("which", "codesign")
 				if err != nil {
 					return false, "", err
 				}
-				return true, strings.TrimSpace(string(output)), nil
+				return true, output, nil
 			},
 			InstallHelp: "Part of Xcode Command Line Tools",
 			Critical:    true,
@@ -146,12 +207,11 @@ func (sc *SetupChecker) initializeToolRequirements() {
 			Name:    "security",
 			Command: "security",
 			CheckFunc: func() (bool, string, error) {
-				cmd := exec.Command("which", "security")
-				output, err := cmd.CombinedOutput()
+				output, err := toolOutput("which", "security")
 				if err != nil {
 					return false, "", err
 				}
-				return true, strings.TrimSpace(string(output)), nil
+				return true, output, nil
 			},
 			InstallHelp: "Part of macOS system tools",
 			Critical:    true,
@@ -171,18 +231,124 @@ func (sc *SetupChecker) initializeToolRequirements() {
 	}
 }
 
-// ExecuteFullSetup runs the complete setup verification process
+// initializeOptionalTools sets up the optional tools, each looked up like
+// a run looks it up
+func (sc *SetupChecker) initializeOptionalTools() {
+	for _, tool := range resigner.OptionalTools {
+		name := tool.Name
+		sc.optionalTools[name] = ToolRequirement{
+			Name:    name,
+			Command: name,
+			CheckFunc: func() (bool, string, error) {
+				path, err := resigner.FindTool(name)
+				return err == nil, path, err
+			},
+			InstallHelp: tool.Install,
+		}
+	}
+}
+
+// setupOptions select which phases ExecuteFullSetup runs. Building from
+// source is opt-in, since most users run a prebuilt binary.
+type setupOptions struct {
+	Build      bool
+	ChecksOnly bool
+}
+
+// setupPhase is one selectable step of the setup process; a phase with an
+// empty title prints no section header of its own
+type setupPhase struct {
+	title   string
+	enabled func(sc *SetupChecker) bool
+	run     func(sc *SetupChecker) error
+}
+
+// setupPhases lists every phase in the order they run
+var setupPhases = []setupPhase{
+	{
+		run: (*SetupChecker).runSystemInfo,
+	},
+	{
+		title: "Checking Prerequisites",
+		run:   (*SetupChecker).runPrerequisites,
+	},
+	{
+		title: "Checking Optional Tools",
+		run:   func(sc *SetupChecker) error { sc.verifyOptionalTools(); return nil },
+	},
+	{
+		title:   "Managing Project Dependencies",
+		enabled: func(sc *SetupChecker) bool { return sc.buildEnabled() },
+		run:     (*SetupChecker).runDependencies,
+	},
+	{
+		title:   "Building Project",
+		enabled: func(sc *SetupChecker) bool { return sc.buildEnabled() },
+		run:     (*SetupChecker).runBuild,
+	},
+	{
+		title: "Discovering Signing Certificates",
+		run:   func(sc *SetupChecker) error { sc.discoverCertificates(); return nil },
+	},
+	{
+		title:   "Checking Keychain Access",
+		enabled: func(sc *SetupChecker) bool { return len(sc.certificates) > 0 },
+		run:     func(sc *SetupChecker) error { sc.verifyKeychainAccess(); return nil },
+	},
+	{
+		title: "Auditing Provisioning Profiles",
+		run:   func(sc *SetupChecker) error { sc.auditProfiles(time.Now()); return nil },
+	},
+	{
+		title:   "Installing",
+		enabled: func(sc *SetupChecker) bool { return sc.installOpts.Enabled && !sc.opts.ChecksOnly },
+		run:     (*SetupChecker).runInstall,
+	},
+}
+
+// buildEnabled reports whether setup builds the binary from source
+func (sc *SetupChecker) buildEnabled() bool {
+	return sc.opts.Build && !sc.opts.ChecksOnly
+}
+
+// ExecuteFullSetup runs the enabled setup phases in order, stopping at the
+// first one that fails
 func (sc *SetupChecker) ExecuteFullSetup() error {
+	if sc.opts.ChecksOnly && (sc.opts.Build || sc.installOpts.Enabled) {
+		return fmt.Errorf("--checks-only cannot be combined with --build or --install")
+	}
+
 	sc.printHeader()
 
-	// Phase 1: System Information
+	for _, phase := range setupPhases {
+		if phase.enabled != nil && !phase.enabled(sc) {
+			continue
+		}
+		if phase.title != "" {
+			sc.printSection(phase.title)
+		}
+		if err := phase.run(sc); err != nil {
+			return err
+		}
+	}
+
+	if !sc.opts.ChecksOnly {
+		sc.printFinalSummary(sc.binaryPath)
+	}
+	return nil
+}
+
+// runSystemInfo gathers and displays system information
+func (sc *SetupChecker) runSystemInfo() error {
 	if err := sc.gatherSystemInfo(); err != nil {
 		return fmt.Errorf("failed to gather system info: %w", err)
 	}
 	sc.displaySystemInfo()
+	return nil
+}
 
-	// Phase 2: Prerequisites Check
-	sc.printSection("Checking Prerequisites")
+// runPrerequisites verifies the operating system and required tools
+func (sc *SetupChecker) runPrerequisites() error {
 	sc.verifyOperatingSystem()
 	sc.verifyRequiredTools()
 
@@ -191,9 +357,11 @@ func (sc *SetupChecker) ExecuteFullSetup() error {
 		sc.displayErrorSummary()
 		return fmt.Errorf("prerequisites check failed")
 	}
+	return nil
+}
 
-	// Phase 3: Project Dependencies
-	sc.printSection("Managing Project Dependencies")
+// runDependencies downloads and tidies the Go modules
+func (sc *SetupChecker) runDependencies() error {
 	if err := sc.downloadDependencies(); err != nil {
 		sc.logError("Failed to download dependencies: %v", err)
 		return err
@@ -202,41 +370,60 @@ func (sc *SetupChecker) ExecuteFullSetup() error {
 	if err := sc.tidyDependencies(); err != nil {
 		sc.logWarning("go mod tidy had issues (may be acceptable): %v", err)
 	}
+	return nil
+}
 
-	// Phase 4: Build
-	sc.printSection("Building Project")
+// runBuild compiles the binary from source
+func (sc *SetupChecker) runBuild() error {
 	binaryPath, err := sc.buildProject()
 	if err != nil {
 		sc.logError("Build failed: %v", err)
 		return err
 	}
 	sc.logSuccess("Build successful: %s", binaryPath)
+	sc.binaryPath = binaryPath
+	return nil
+}
 
-	// Phase 5: Certificate Discovery
-	sc.printSection("Discovering Signing Certificates")
-	sc.discoverCertificates()
-
-	// Phase 6: Final Summary
-	sc.printFinalSummary(binaryPath)
-
+// runInstall installs the freshly built binary, or the running one when
+// setup did not build
+func (sc *SetupChecker) runInstall() error {
+	binaryPath := sc.binaryPath
+	if binaryPath == "" {
+		executable, err := os.Executable()
+		if err != nil {
+			sc.logError("Could not locate the running binary: %v", err)
+			return err
+		}
+		binaryPath = executable
+	}
+	if err := sc.install(binaryPath); err != nil {
+		sc.logError("Install failed: %v", err)
+		return err
+	}
 	return nil
 }
 
+// toolOutput runs a tool and returns its trimmed output, both streams
+// combined
+func toolOutput(name string, args ...string) (string, error) {
+	result, err := command.New(name, args...).Run(context.Background())
+	return strings.TrimSpace(string(result.Combined)), err
+}
+
 // gatherSystemInfo collects system configuration details
 func (sc *SetupChecker) gatherSystemInfo() error {
 	sc.systemInfo.OS = runtime.GOOS
 	sc.systemInfo.Architecture = runtime.GOARCH
 
 	// Get Go version
-	cmd := exec.Command("go", "version")
-	if output, err := cmd.CombinedOutput(); err == nil {
-		sc.systemInfo.GoVersion = strings.TrimSpace(string(output))
+	if output, err := toolOutput("go", "version"); err == nil {
+		sc.systemInfo.GoVersion = output
 	}
 
 	// Get Xcode path
-	cmd = exec.Command("xcode-select", "-p")
-	if output, err := cmd.CombinedOutput(); err == nil {
-		sc.systemInfo.XcodePath = strings.TrimSpace(string(output))
+	if output, err := toolOutput("xcode-select", "-p"); err == nil {
+		sc.systemInfo.XcodePath = output
 	}
 
 	// Get working directory
@@ -259,7 +446,7 @@ func (sc *SetupChecker) displaySystemInfo() {
 		sc.logInfo("Xcode Path: %s", sc.systemInfo.XcodePath)
 	}
 	sc.logInfo("Working Directory: %s", sc.systemInfo.WorkingDir)
-	fmt.Println()
+	fmt.Fprintln(sc.out)
 }
 
 // verifyOperatingSystem ensures the system is running macOS
@@ -275,10 +462,30 @@ func (sc *SetupChecker) verifyOperatingSystem() {
 // verifyRequiredTools checks for all required system tools
 func (sc *SetupChecker) verifyRequiredTools() {
 	for _, tool := range sc.requiredTools {
+		if tool.BuildOnly && !sc.buildEnabled() {
+			continue
+		}
 		sc.verifyTool(tool)
 	}
 }
 
+// verifyOptionalTools reports which optional tools are installed. A
+// missing one only warns, naming what it is needed for.
+func (sc *SetupChecker) verifyOptionalTools() {
+	for _, tool := range resigner.OptionalTools {
+		requirement := sc.optionalTools[tool.Name]
+		found, path, err := requirement.CheckFunc()
+		sc.tools = append(sc.tools, toolStatus{OptionalTool: tool, Available: found, Path: path})
+		if !found {
+			sc.logWarning("%s is not installed: %v", tool.Name, err)
+			sc.logInfo("  Needed if you want: %s", tool.Purpose)
+			continue
+		}
+		sc.logSuccess("%s is installed", tool.Name)
+		sc.logInfo("  Location: %s", path)
+	}
+}
+
 // verifyTool checks a single tool's availability
 func (sc *SetupChecker) verifyTool(tool ToolRequirement) {
 	exists, info, err := tool.CheckFunc()
@@ -304,11 +511,11 @@ func (sc *SetupChecker) verifyTool(tool ToolRequirement) {
 func (sc *SetupChecker) downloadDependencies() error {
 	sc.logInfo("Downloading Go dependencies...")
 
-	cmd := exec.Command("go", "mod", "download")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
+	cmd := command.New("go", "mod", "download")
+	cmd.OnLine = func(stream command.Stream, line string) {
+		fmt.Fprintln(sc.out, line)
+	}
+	if _, err := cmd.Run(context.Background()); err != nil {
 		return fmt.Errorf("go mod download failed: %w", err)
 	}
 
@@ -320,11 +527,8 @@ func (sc *SetupChecker) downloadDependencies() error {
 func (sc *SetupChecker) tidyDependencies() error {
 	sc.logInfo("Tidying dependencies...")
 
-	cmd := exec.Command("go", "mod", "tidy")
-	output, err := cmd.CombinedOutput()
-
-	if err != nil {
-		return fmt.Errorf("go mod tidy failed: %s", string(output))
+	if output, err := toolOutput("go", "mod", "tidy"); err != nil {
+		return fmt.Errorf("go mod tidy failed: %s", output)
 	}
 
 	sc.logSuccess("Dependencies tidied")
@@ -336,11 +540,8 @@ func (sc *SetupChecker) buildProject() (string, error) {
 	sc.logInfo("Compiling ResignIPA...")
 
 	outputPath := "resignipa"
-	cmd := exec.Command("go", "build", "-ldflags=-s -w", "-o", outputPath, "main.go")
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("build failed: %s", string(output))
+	if output, err := toolOutput("go", "build", "-ldflags=-s -w", "-o", outputPath, "main.go"); err != nil {
+		return "", fmt.Errorf("build failed: %s", output)
 	}
 
 	// Get binary info
@@ -356,25 +557,23 @@ func (sc *SetupChecker) buildProject() (string, error) {
 
 // discoverCertificates finds available code signing certificates
 func (sc *SetupChecker) discoverCertificates() {
-	cmd := exec.Command("security", "find-identity", "-v", "-p", "codesigning")
-	output, err := cmd.CombinedOutput()
-
+	output, err := toolOutput("security", "find-identity", "-v", "-p", "codesigning")
 	if err != nil {
 		sc.logWarning("Could not query certificates: %v", err)
 		return
 	}
 
-	lines := strings.Split(string(output), "\n")
 	certCount := 0
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.Contains(line, "Apple Development") || strings.Contains(line, "Apple Distribution") {
-			certCount++
-			if certCount <= 5 {
-				sc.logSuccess("Found: %s", line)
-			}
+	for _, identity := range resigner.ParseIdentities(output) {
+		if !strings.Contains(identity.Name, "Apple Development") && !strings.Contains(identity.Name, "Apple Distribution") {
+			continue
 		}
+		certCount++
+		if certCount <= 5 {
+			sc.logSuccess("Found: %s %q", identity.Hash, identity.Name)
+		}
+		certType, _, _ := strings.Cut(identity.Name, ":")
+		sc.certificates = append(sc.certificates, Certificate{Hash: identity.Hash, Name: identity.Name, Type: certType})
 	}
 
 	if certCount == 0 {
@@ -392,86 +591,92 @@ func (sc *SetupChecker) discoverCertificates() {
 
 // printFinalSummary displays the completion summary
 func (sc *SetupChecker) printFinalSummary(binaryPath string) {
-	fmt.Println()
+	fmt.Fprintln(sc.out)
 	sc.printSeparator('═')
 	sc.logSuccess("Setup Complete!")
 	sc.printSeparator('═')
-	fmt.Println()
+	fmt.Fprintln(sc.out)
 
-	fmt.Printf("%sNext Steps:%s\n\n", colorCyan, colorReset)
+	fmt.Fprintf(sc.out, "%sNext Steps:%s\n\n", colorCyan, colorReset)
 
-	fmt.Printf("  %s1. Run GUI mode:%s\n", colorBlue, colorReset)
-	fmt.Printf("     %s./resignipa%s\n\n", colorPurple, colorReset)
+	fmt.Fprintf(sc.out, "  %s1. Run GUI mode:%s\n", colorBlue, colorReset)
+	fmt.Fprintf(sc.out, "     %s./resignipa%s\n\n", colorPurple, colorReset)
 
-	fmt.Printf("  %s2. Run CLI mode:%s\n", colorBlue, colorReset)
-	fmt.Printf("     %s./resignipa -s /path/to/app.ipa -c \"Certificate Name\"%s\n\n", colorPurple, colorReset)
+	fmt.Fprintf(sc.out, "  %s2. Run CLI mode:%s\n", colorBlue, colorReset)
+	fmt.Fprintf(sc.out, "     %s./resignipa -s /path/to/app.ipa -c \"Certificate Name\"%s\n\n", colorPurple, colorReset)
 
-	fmt.Printf("  %s3. View help:%s\n", colorBlue, colorReset)
-	fmt.Printf("     %s./resignipa --help%s\n\n", colorPurple, colorReset)
+	fmt.Fprintf(sc.out, "  %s3. View help:%s\n", colorBlue, colorReset)
+	fmt.Fprintf(sc.out, "     %s./resignipa --help%s\n\n", colorPurple, colorReset)
 
-	fmt.Printf("  %s4. Install system-wide (optional):%s\n", colorBlue, colorReset)
-	fmt.Printf("     %ssudo make install%s\n\n", colorPurple, colorReset)
+	if !sc.installOpts.Enabled {
+		fmt.Fprintf(sc.out, "  %s4. Install system-wide (optional):%s\n", colorBlue, colorReset)
+		fmt.Fprintf(sc.out, "     %ssudo ./resignipa setup --install --completions --man%s\n\n", colorPurple, colorReset)
+	}
 
-	fmt.Printf("%sHappy Resigning! 🎉%s\n", colorGreen, colorReset)
+	fmt.Fprintf(sc.out, "%sHappy Resigning! 🎉%s\n", colorGreen, colorReset)
 }
 
 // Logging methods with color support
 
 func (sc *SetupChecker) printHeader() {
-	fmt.Println()
+	fmt.Fprintln(sc.out)
 	sc.printSeparator('═')
-	fmt.Printf("%s🚀 ResignIPA Setup Wizard%s\n", colorCyan, colorReset)
+	fmt.Fprintf(sc.out, "%s🚀 ResignIPA Setup Wizard%s\n", colorCyan, colorReset)
 	sc.printSeparator('═')
-	fmt.Println()
+	fmt.Fprintln(sc.out)
 }
 
 func (sc *SetupChecker) printSection(title string) {
-	fmt.Println()
+	sc.phase = title
+	fmt.Fprintln(sc.out)
 	sc.printSeparator('─')
-	fmt.Printf("%s%s%s\n", colorCyan, title, colorReset)
+	fmt.Fprintf(sc.out, "%s%s%s\n", colorCyan, title, colorReset)
 	sc.printSeparator('─')
-	fmt.Println()
+	fmt.Fprintln(sc.out)
 }
 
 func (sc *SetupChecker) printSeparator(char rune) {
-	fmt.Println(strings.Repeat(string(char), 70))
+	fmt.Fprintln(sc.out, strings.Repeat(string(char), 70))
 }
 
 func (sc *SetupChecker) logSuccess(format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
-	fmt.Printf("%s✓%s %s\n", colorGreen, colorReset, msg)
+	sc.record(checkPass, msg)
+	fmt.Fprintf(sc.out, "%s✓%s %s\n", colorGreen, colorReset, msg)
 }
 
 func (sc *SetupChecker) logError(format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
-	fmt.Printf("%s✗%s %s\n", colorRed, colorReset, msg)
+	sc.record(checkFail, msg)
+	fmt.Fprintf(sc.out, "%s✗%s %s\n", colorRed, colorReset, msg)
 	sc.output = append(sc.output, fmt.Sprintf("ERROR: %s", msg))
 }
 
 func (sc *SetupChecker) logWarning(format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
-	fmt.Printf("%s⚠%s %s\n", colorYellow, colorReset, msg)
+	sc.record(checkWarn, msg)
+	fmt.Fprintf(sc.out, "%s⚠%s %s\n", colorYellow, colorReset, msg)
 	sc.output = append(sc.output, fmt.Sprintf("WARNING: %s", msg))
 }
 
 func (sc *SetupChecker) logInfo(format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
-	fmt.Printf("  %s\n", msg)
+	fmt.Fprintf(sc.out, "  %s\n", msg)
 }
 
 func (sc *SetupChecker) displayErrorSummary() {
-	fmt.Println()
+	fmt.Fprintln(sc.out)
 	sc.logError("Prerequisites check failed. Please install missing components:")
-	fmt.Println()
+	fmt.Fprintln(sc.out)
 
 	for _, tool := range sc.requiredTools {
 		exists, _, err := tool.CheckFunc()
 		if !exists || err != nil {
-			fmt.Printf("  • %s%s%s\n", colorYellow, tool.Name, colorReset)
+			fmt.Fprintf(sc.out, "  • %s%s%s\n", colorYellow, tool.Name, colorReset)
 			if tool.InstallHelp != "" {
-				fmt.Printf("    %s\n", tool.InstallHelp)
+				fmt.Fprintf(sc.out, "    %s\n", tool.InstallHelp)
 			}
 		}
 	}
-	fmt.Println()
+	fmt.Fprintln(sc.out)
 }
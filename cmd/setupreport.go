@@ -0,0 +1,61 @@
+package cmd
+
+import "github.com/resignipa/pkg/resigner"
+
+// Statuses of a setup check
+const (
+	checkPass = "pass"
+	checkWarn = "warn"
+	checkFail = "fail"
+)
+
+// setupCheck is one result line of the setup report
+type setupCheck struct {
+	Phase   string `json:"phase"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// toolStatus is whether an optional tool was found
+type toolStatus struct {
+	resigner.OptionalTool
+	Available bool   `json:"available"`
+	Path      string `json:"path,omitempty"`
+}
+
+// setupReport is the machine-readable form of a setup run, printed by
+// setup --json so provisioning scripts can assert a build machine is
+// ready. "ready" is false whenever setup failed or any check failed.
+type setupReport struct {
+	Ready        bool          `json:"ready"`
+	Error        string        `json:"error,omitempty"`
+	System       SystemInfo    `json:"system"`
+	Certificates []Certificate `json:"certificates"`
+	Tools        []toolStatus  `json:"tools"`
+	Checks       []setupCheck  `json:"checks"`
+}
+
+// record keeps a check result for the JSON report
+func (sc *SetupChecker) record(status, message string) {
+	sc.checks = append(sc.checks, setupCheck{Phase: sc.phase, Status: status, Message: message})
+}
+
+// report summarizes the run; err is the error ExecuteFullSetup returned
+func (sc *SetupChecker) report(err error) setupReport {
+	report := setupReport{
+		Ready:        err == nil,
+		System:       sc.systemInfo,
+		Certificates: sc.certificates,
+		Tools:        sc.tools,
+		Checks:       sc.checks,
+	}
+	if err != nil {
+		report.Error = err.Error()
+	}
+	for _, check := range sc.checks {
+		if check.Status == checkFail {
+			report.Ready = false
+		}
+	}
+	return report
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import "github.com/resignipa/pkg/resigner"
+
+// sizeFlag is a byte count flag accepting sizes such as 150MB
+type sizeFlag int64
+
+func (s *sizeFlag) Set(value string) error {
+	size, err := resigner.ParseSize(value)
+	if err != nil {
+		return err
+	}
+	*s = sizeFlag(size)
+	return nil
+}
+
+func (s *sizeFlag) String() string {
+	if *s == 0 {
+		return ""
+	}
+	return resigner.FormatSize(int64(*s))
+}
+
+func (s *sizeFlag) Type() string {
+	return "size"
+}
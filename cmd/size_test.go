@@ -0,0 +1,16 @@
+package cmd
+
+import "testing"
+
+func TestSizeFlag(t *testing.T) {
+	var size sizeFlag
+	if err := size.Set("150MB"); err != nil || size != 150<<20 {
+		t.Errorf("Set(150MB) = %d, %v", size, err)
+	}
+	if size.String() != "150.0 MB" {
+		t.Errorf("String() = %q", size.String())
+	}
+	if err := size.Set("lots"); err == nil {
+		t.Error("Set() accepted an invalid size")
+	}
+}
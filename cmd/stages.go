@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/resignipa/pkg/resigner"
+)
+
+// stagePipeline returns the pipeline the stage flags ask for, or nil to
+// keep the default: --stop-after ends the run after a stage, and
+// --skip-verify and --skip-package drop those stages
+func stagePipeline(skipVerify, skipPackage bool, stopAfter string) (*resigner.Pipeline, error) {
+	if !skipVerify && !skipPackage && stopAfter == "" {
+		return nil, nil
+	}
+	pipeline := resigner.DefaultPipeline()
+	if stopAfter != "" {
+		var err error
+		if pipeline, err = pipeline.Until(stopAfter); err != nil {
+			return nil, fmt.Errorf("--stop-after: %w (stages: %s)", err, strings.Join(resigner.DefaultPipeline().Names(), ", "))
+		}
+	}
+	for stage, skip := range map[string]bool{resigner.StageVerify: skipVerify, resigner.StagePackage: skipPackage} {
+		if !skip || !hasStage(pipeline, stage) {
+			continue
+		}
+		var err error
+		if pipeline, err = pipeline.Without(stage); err != nil {
+			return nil, err
+		}
+	}
+	return pipeline, nil
+}
+
+// hasStage reports whether pipeline, nil meaning the default, runs the
+// named stage
+func hasStage(pipeline *resigner.Pipeline, name string) bool {
+	if pipeline == nil {
+		return true
+	}
+	for _, stage := range pipeline.Names() {
+		if stage == name {
+			return true
+		}
+	}
+	return false
+}
+
+// stageSummary renders how long each stage of a run took, for the end of
+// text output
+func stageSummary(stages []resigner.StageTiming, total time.Duration) string {
+	if len(stages) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("⏱  Stage timings:\n")
+	for _, stage := range stages {
+		fmt.Fprintf(&b, "   %-14s %s\n", stage.Name, stage.Duration.Round(time.Millisecond))
+	}
+	fmt.Fprintf(&b, "   %-14s %s\n", "total", total.Round(time.Millisecond))
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/resignipa/pkg/resigner"
+)
+
+func TestStagePipeline(t *testing.T) {
+	if pipeline, err := stagePipeline(false, false, ""); pipeline != nil || err != nil {
+		t.Errorf("stagePipeline() without flags = %v, %v, want the default", pipeline, err)
+	}
+
+	tests := []struct {
+		name        string
+		skipVerify  bool
+		skipPackage bool
+		stopAfter   string
+		want        []string
+	}{
+		{"skip verify", true, false, "", []string{"extract", "provision", "entitlements", "bundle-id", "privacy", "sign", "package"}},
+		{"skip both", true, true, "", []string{"extract", "provision", "entitlements", "bundle-id", "privacy", "sign"}},
+		{"stop after", false, false, "entitlements", []string{"extract", "provision", "entitlements"}},
+		{"stop after with skip", true, true, "verify", []string{"extract", "provision", "entitlements", "bundle-id", "privacy", "sign"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline, err := stagePipeline(tt.skipVerify, tt.skipPackage, tt.stopAfter)
+			if err != nil {
+				t.Fatalf("stagePipeline() failed: %v", err)
+			}
+			if got := pipeline.Names(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("stages = %v, want %v", got, tt.want)
+			}
+			if hasStage(pipeline, resigner.StagePackage) != (len(tt.want) == 7) {
+				t.Errorf("hasStage(package) = %v", hasStage(pipeline, resigner.StagePackage))
+			}
+		})
+	}
+
+	if _, err := stagePipeline(false, false, "upload"); err == nil {
+		t.Error("stagePipeline() with an unknown stage succeeded")
+	}
+}
+
+func TestStageSummary(t *testing.T) {
+	if got := stageSummary(nil, time.Second); got != "" {
+		t.Errorf("stageSummary() without stages = %q", got)
+	}
+	got := stageSummary([]resigner.StageTiming{
+		{Name: "extract", Duration: 1500 * time.Millisecond},
+		{Name: "sign", Duration: 3 * time.Second},
+	}, 4600*time.Millisecond)
+	want := "⏱  Stage timings:\n" +
+		"   extract        1.5s\n" +
+		"   sign           3s\n" +
+		"   total          4.6s\n"
+	if got != want {
+		t.Errorf("stageSummary() = %q, want %q", got, want)
+	}
+}
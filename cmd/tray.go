@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/driver/desktop"
+	"github.com/spf13/cobra"
+)
+
+var (
+	trayDir    string
+	trayPreset string
+)
+
+var trayCmd = &cobra.Command{
+	Use:   "tray",
+	Short: "Sit in the menu bar and resign IPAs dropped into a folder",
+	Long: `Run ResignIPA as a menu bar app. Every IPA placed in the drop folder is
+resigned with the default preset (or --preset) into Resigned/ inside the drop
+folder, the source is moved to Processed/ or Failed/, and a notification
+reports the result.
+
+Save a preset first, e.g.:
+  resignipa -s app.ipa -c "Apple Development: Name" -p dev.mobileprovision --save-preset dev
+
+Example:
+  resignipa tray
+  resignipa tray --dir ~/Desktop/Resign --preset qa`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runTray(); err != nil {
+			fmt.Printf("\n❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	trayCmd.Flags().StringVar(&trayDir, "dir", "", "Drop folder to watch (default: ~/ResignIPA Drop)")
+	trayCmd.Flags().StringVar(&trayPreset, "preset", "", "Preset to resign with (default: the default preset)")
+
+	rootCmd.AddCommand(trayCmd)
+}
+
+// runTray starts the menu bar app and blocks until it quits
+func runTray() error {
+	store, err := loadPresets()
+	if err != nil {
+		return err
+	}
+	p, name, err := store.get(trayPreset)
+	if err != nil {
+		return err
+	}
+
+	dir := trayDir
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		dir = filepath.Join(home, "ResignIPA Drop")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	a := app.NewWithID(guiAppID)
+	desk, ok := a.(desktop.App)
+	if !ok {
+		return fmt.Errorf("menu bar mode is not supported on this platform")
+	}
+
+	status := fyne.NewMenuItem(fmt.Sprintf("Idle — preset %q", name), nil)
+	status.Disabled = true
+	menu := fyne.NewMenu("ResignIPA",
+		status,
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Open Drop Folder", func() {
+			exec.Command("open", dir).Start()
+		}),
+	)
+	desk.SetSystemTrayMenu(menu)
+	setStatus := func(text string) {
+		status.Label = text
+		menu.Refresh()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.Lifecycle().SetOnStopped(cancel)
+
+	// Resign one IPA at a time in the order they were dropped
+	jobs := make(chan string, 64)
+	go func() {
+		for path := range jobs {
+			setStatus("Resigning " + filepath.Base(path))
+			config := p.config(path)
+			if config.OutputDir == "" {
+				// An explicit directory keeps earlier outputs from being cleared
+				config.OutputDir = filepath.Join(dir, "Resigned")
+			}
+			// Unattended: a re-dropped IPA replaces its earlier output
+			config.AssumeYes = true
+			err := resignDropped(ctx, config, dir)
+			if err != nil {
+				a.SendNotification(fyne.NewNotification("Resign failed", fmt.Sprintf("%s: %v", filepath.Base(path), err)))
+			} else {
+				a.SendNotification(fyne.NewNotification("IPA resigned", filepath.Base(path)))
+			}
+			setStatus(fmt.Sprintf("Idle — preset %q", name))
+		}
+	}()
+
+	watcher := &dropWatcher{dir: dir, settle: 2 * time.Second}
+	go func() {
+		defer close(jobs)
+		if err := watcher.Watch(ctx, func(path string) { jobs <- path }); err != nil {
+			a.SendNotification(fyne.NewNotification("Drop folder unavailable", err.Error()))
+		}
+	}()
+
+	a.Run()
+	return nil
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/resignipa/pkg/update"
+	"github.com/spf13/cobra"
+)
+
+// Build metadata, set with -ldflags "-X github.com/resignipa/cmd.version=..."
+// (see the Makefile)
+var (
+	version   = "dev"
+	commit    = "none"
+	buildDate = "unknown"
+)
+
+var checkUpdate bool
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the version and build information",
+	Long: `Print the version, commit and build date of this binary. With --check-update,
+also ask GitHub whether a newer release is available.
+
+Example:
+  resignipa version
+  resignipa version --check-update`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Printf("resignipa %s\n", version)
+		fmt.Printf("  commit:     %s\n", commit)
+		fmt.Printf("  built:      %s\n", buildDate)
+		fmt.Printf("  go:         %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+
+		if !checkUpdate {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		release, err := update.Latest(ctx, http.DefaultClient, update.Repository)
+		if err != nil {
+			fmt.Printf("\n❌ Update check failed: %v\n", err)
+			os.Exit(1)
+		}
+		if update.Newer(version, release.Tag) {
+			fmt.Printf("\n⬆️  A newer release is available: %s\n   %s\n", release.Tag, release.URL)
+		} else {
+			fmt.Printf("\n✅ Up to date (latest release: %s)\n", release.Tag)
+		}
+	},
+}
+
+// releaseNotesMarkdown describes release for the About dialog: its notes
+// when it is newer than current, otherwise that current is up to date
+func releaseNotesMarkdown(current string, release *update.Release) string {
+	if !update.Newer(current, release.Tag) {
+		return fmt.Sprintf("✅ Up to date (latest release: %s)", release.Tag)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s is available** (you have %s)\n\n", release.Tag, current)
+	if release.Name != "" && release.Name != release.Tag {
+		fmt.Fprintf(&b, "**%s**", release.Name)
+		if !release.PublishedAt.IsZero() {
+			fmt.Fprintf(&b, ", released %s", release.PublishedAt.Format("2006-01-02"))
+		}
+		b.WriteString("\n\n")
+	}
+	// GitHub stores release bodies with Windows line endings
+	notes := strings.TrimSpace(strings.ReplaceAll(release.Notes, "\r\n", "\n"))
+	if notes == "" {
+		notes = "No release notes."
+	}
+	b.WriteString(notes)
+	b.WriteString("\n")
+	return b.String()
+}
+
+func init() {
+	versionCmd.Flags().BoolVar(&checkUpdate, "check-update", false, "Check GitHub for a newer release")
+
+	rootCmd.Version = version
+	rootCmd.AddCommand(versionCmd)
+}
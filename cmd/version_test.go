@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/resignipa/pkg/update"
+)
+
+func TestReleaseNotesMarkdown(t *testing.T) {
+	release := &update.Release{
+		Tag:         "v1.4.0",
+		Name:        "Faster signing",
+		Notes:       "## Fixed\r\n- Frameworks signed twice\r\n",
+		PublishedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	got := releaseNotesMarkdown("v1.3.2", release)
+	want := "**v1.4.0 is available** (you have v1.3.2)\n\n**Faster signing**, released 2024-05-01\n\n## Fixed\n- Frameworks signed twice\n"
+	if got != want {
+		t.Errorf("releaseNotesMarkdown() = %q, want %q", got, want)
+	}
+
+	if got := releaseNotesMarkdown("v1.4.0", release); !strings.HasPrefix(got, "✅ Up to date") {
+		t.Errorf("releaseNotesMarkdown() for the latest version = %q", got)
+	}
+}
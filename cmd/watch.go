@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/resignipa/pkg/resigner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchDir    string
+	watchDest   string
+	watchPreset string
+	watchSettle time.Duration
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Resign every IPA placed in a directory",
+	Long: `Watch a directory and resign each IPA that appears in it with a saved preset.
+Resigned IPAs are written to the destination folder together with a
+<name>.log file recording the run; sources are moved to Processed/ or Failed/
+inside the watched directory. Runs until interrupted.
+
+Example:
+  resignipa watch --dir /incoming --preset qa --dest /outgoing`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runWatch(); err != nil {
+			fmt.Printf("\n❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	watchCmd.Flags().StringVar(&watchDir, "dir", "", "Directory to watch for IPAs (required)")
+	watchCmd.Flags().StringVar(&watchDest, "dest", "", "Destination for resigned IPAs and logs (default: <dir>/Resigned)")
+	watchCmd.Flags().StringVar(&watchPreset, "preset", "", "Preset to resign with (default: the default preset)")
+	watchCmd.Flags().DurationVar(&watchSettle, "settle", 2*time.Second, "How long a file must stay unchanged before it is processed")
+	watchCmd.MarkFlagRequired("dir")
+
+	rootCmd.AddCommand(watchCmd)
+}
+
+// runWatch processes dropped IPAs until interrupted
+func runWatch() error {
+	store, err := loadPresets()
+	if err != nil {
+		return err
+	}
+	p, name, err := store.get(watchPreset)
+	if err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(watchDir); err != nil || !info.IsDir() {
+		return fmt.Errorf("watch directory does not exist: %s", watchDir)
+	}
+	dest := watchDest
+	if dest == "" {
+		dest = filepath.Join(watchDir, "Resigned")
+	}
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("👀 Watching %s with preset %q; results go to %s\n", watchDir, name, dest)
+	watcher := &dropWatcher{dir: watchDir, settle: watchSettle}
+	return watcher.Watch(ctx, func(path string) {
+		config := p.config(path)
+		config.OutputDir = dest
+		// Unattended: a re-dropped IPA replaces its earlier output
+		config.AssumeYes = true
+
+		fmt.Printf("▶ %s\n", filepath.Base(path))
+		if err := resignWithLog(ctx, config); err != nil {
+			fmt.Printf("✗ %s: %v\n", filepath.Base(path), err)
+		} else {
+			fmt.Printf("✓ %s\n", filepath.Base(path))
+		}
+	})
+}
+
+// resignWithLog resigns one watched IPA, recording every event and the
+// result in <dest>/<name>.log
+func resignWithLog(ctx context.Context, config resigner.Config) error {
+	base := strings.TrimSuffix(filepath.Base(config.SourceIPA), filepath.Ext(config.SourceIPA))
+	logFile, err := os.Create(filepath.Join(config.OutputDir, base+".log"))
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	fmt.Fprintf(logFile, "Source: %s\nStarted: %s\n\n", config.SourceIPA, time.Now().Format(time.RFC3339))
+	err = resignDropped(ctx, config, filepath.Dir(config.SourceIPA),
+		resigner.WithEventHandler(func(event resigner.Event) {
+			fmt.Fprintf(logFile, "%s [%s] %s\n", event.Time.Format("15:04:05"), event.Type, event.Message)
+		}))
+
+	if err != nil {
+		fmt.Fprintf(logFile, "\nResult: FAILED: %v\n", err)
+	} else {
+		fmt.Fprintf(logFile, "\nResult: SUCCESS\n")
+	}
+	return err
+}
@@ -0,0 +1,218 @@
+// Package command runs external tools such as codesign and security. It
+// wraps os/exec with what every caller otherwise reimplements: output
+// delivered line by line while the tool runs, cancellation that reports
+// why the tool was stopped, timeouts, retries and control over the
+// environment.
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrTimeout is wrapped by the error of a command that exceeded its
+// Timeout
+var ErrTimeout = errors.New("timed out")
+
+// waitDelay is how long a stopped command's output may keep arriving,
+// e.g. from children it started, before it is abandoned
+const waitDelay = 2 * time.Second
+
+// Stream identifies the output a line was written to
+type Stream int
+
+const (
+	Stdout Stream = iota
+	Stderr
+)
+
+// String returns the stream's name
+func (s Stream) String() string {
+	if s == Stderr {
+		return "stderr"
+	}
+	return "stdout"
+}
+
+// Cmd is an external command. Set its fields before calling Run; a Cmd
+// may be run again, but not concurrently.
+type Cmd struct {
+	// Name is the program, looked up in PATH unless it contains a slash
+	Name string
+	Args []string
+	// Dir is the working directory; empty uses the current one
+	Dir string
+	// Env holds KEY=VALUE entries overriding the inherited environment
+	Env []string
+	// CleanEnv starts from an empty environment instead of the inherited
+	// one, so only Env is passed
+	CleanEnv bool
+	// Stdin is fed to the command. It is not rewound between retries.
+	Stdin io.Reader
+
+	// Timeout bounds each attempt; zero means no limit
+	Timeout time.Duration
+	// Retries is how many more times a failed command is run
+	Retries int
+	// RetryDelay is the pause before each retry
+	RetryDelay time.Duration
+	// Retryable reports whether a failure is worth retrying, given the
+	// error and the combined output of the attempt. Nil retries every
+	// failure. Canceled commands are never retried.
+	Retryable func(err error, output []byte) bool
+
+	// OnLine receives each line of output, without its newline, as the
+	// command writes it. Calls never overlap, even for lines written to
+	// both streams at once. Nil discards them.
+	OnLine func(stream Stream, line string)
+}
+
+// New returns a command running name with args
+func New(name string, args ...string) *Cmd {
+	return &Cmd{Name: name, Args: args}
+}
+
+// String returns the command line, for logs and errors
+func (c *Cmd) String() string {
+	return strings.Join(append([]string{c.Name}, c.Args...), " ")
+}
+
+// Result is the output of a finished command
+type Result struct {
+	Stdout []byte
+	Stderr []byte
+	// Combined interleaves both streams in the order lines were written
+	Combined []byte
+	// Attempts is how many times the command was run
+	Attempts int
+}
+
+// Run runs the command until it succeeds or its retries are used up. The
+// result is never nil; after a failure it holds the last attempt's
+// output. A command stopped because ctx ended fails with the reason,
+// e.g. a timeout, rather than "signal: killed".
+func (c *Cmd) Run(ctx context.Context) (*Result, error) {
+	result := &Result{}
+	for {
+		result.Attempts++
+		err := c.attempt(ctx, result)
+		if err == nil || result.Attempts > c.Retries || ctx.Err() != nil {
+			return result, err
+		}
+		if errors.Is(err, ErrTimeout) || (c.Retryable != nil && !c.Retryable(err, result.Combined)) {
+			return result, err
+		}
+		select {
+		case <-time.After(c.RetryDelay):
+		case <-ctx.Done():
+			return result, context.Cause(ctx)
+		}
+	}
+}
+
+// attempt runs the command once, replacing result's output
+func (c *Cmd) attempt(ctx context.Context, result *Result) error {
+	cancel := context.CancelFunc(func() {})
+	if c.Timeout > 0 {
+		ctx, cancel = context.WithTimeoutCause(ctx, c.Timeout,
+			fmt.Errorf("%s %w after %s", filepath.Base(c.Name), ErrTimeout, c.Timeout))
+	}
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, c.Name, c.Args...)
+	cmd.Dir = c.Dir
+	cmd.Stdin = c.Stdin
+	cmd.WaitDelay = waitDelay
+	if c.CleanEnv {
+		cmd.Env = append([]string{}, c.Env...)
+	} else if len(c.Env) > 0 {
+		cmd.Env = append(os.Environ(), c.Env...)
+	}
+
+	var stdout, stderr bytes.Buffer
+	out := &output{onLine: c.OnLine}
+	cmd.Stdout = out.writer(Stdout, &stdout)
+	cmd.Stderr = out.writer(Stderr, &stderr)
+	err := cmd.Run()
+	out.flush()
+
+	result.Stdout, result.Stderr, result.Combined = stdout.Bytes(), stderr.Bytes(), out.combined.Bytes()
+	if err != nil && ctx.Err() != nil {
+		if cause := context.Cause(ctx); cause != nil {
+			return cause
+		}
+	}
+	return err
+}
+
+// output splits both streams of one attempt into lines. exec copies each
+// stream from its own goroutine, so everything is guarded by mu.
+type output struct {
+	mu       sync.Mutex
+	onLine   func(Stream, string)
+	combined bytes.Buffer
+	partial  [2]bytes.Buffer
+}
+
+// writer returns the io.Writer for stream, which also keeps a copy in buf
+func (o *output) writer(stream Stream, buf *bytes.Buffer) io.Writer {
+	return streamWriter{o: o, stream: stream, buf: buf}
+}
+
+// flush delivers lines the command left unterminated
+func (o *output) flush() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for stream := range o.partial {
+		if partial := &o.partial[stream]; partial.Len() > 0 {
+			o.combined.Write(partial.Bytes())
+			o.deliver(Stream(stream), partial.String())
+			partial.Reset()
+		}
+	}
+}
+
+// deliver passes a complete line to onLine
+func (o *output) deliver(stream Stream, line string) {
+	if o.onLine != nil {
+		o.onLine(stream, strings.TrimSuffix(line, "\r"))
+	}
+}
+
+// streamWriter feeds one stream into output
+type streamWriter struct {
+	o      *output
+	stream Stream
+	buf    *bytes.Buffer
+}
+
+func (w streamWriter) Write(p []byte) (int, error) {
+	n, o := len(p), w.o
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	w.buf.Write(p)
+	partial := &o.partial[w.stream]
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			partial.Write(p)
+			break
+		}
+		partial.Write(p[:i])
+		o.combined.Write(partial.Bytes())
+		o.combined.WriteByte('\n')
+		o.deliver(w.stream, partial.String())
+		partial.Reset()
+		p = p[i+1:]
+	}
+	return n, nil
+}
@@ -0,0 +1,124 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// requireShell skips tests that script the command with /bin/sh
+func requireShell(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses /bin/sh")
+	}
+}
+
+func TestRunStreamsLines(t *testing.T) {
+	requireShell(t)
+	// The streams are copied concurrently, so only the order within each
+	// is defined
+	var mu sync.Mutex
+	lines := map[Stream][]string{}
+	cmd := New("/bin/sh", "-c", `echo one; echo two >&2; printf 'three\r\nfour'`)
+	cmd.OnLine = func(stream Stream, line string) {
+		mu.Lock()
+		defer mu.Unlock()
+		lines[stream] = append(lines[stream], line)
+	}
+	result, err := cmd.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+
+	want := map[Stream][]string{Stdout: {"one", "three", "four"}, Stderr: {"two"}}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("lines = %q, want %q", lines, want)
+	}
+	if string(result.Stdout) != "one\nthree\r\nfour" || string(result.Stderr) != "two\n" {
+		t.Errorf("Stdout = %q, Stderr = %q", result.Stdout, result.Stderr)
+	}
+	if len(result.Combined) != len(result.Stdout)+len(result.Stderr) || !strings.HasSuffix(string(result.Combined), "four") {
+		t.Errorf("Combined = %q", result.Combined)
+	}
+}
+
+func TestRunEnv(t *testing.T) {
+	requireShell(t)
+	t.Setenv("RESIGNIPA_INHERITED", "yes")
+	cmd := New("/bin/sh", "-c", `echo "$RESIGNIPA_INHERITED $RESIGNIPA_ADDED"`)
+	cmd.Env = []string{"RESIGNIPA_ADDED=added"}
+	if result, _ := cmd.Run(context.Background()); string(result.Stdout) != "yes added\n" {
+		t.Errorf("inherited environment = %q", result.Stdout)
+	}
+
+	cmd.CleanEnv = true
+	if result, _ := cmd.Run(context.Background()); string(result.Stdout) != " added\n" {
+		t.Errorf("clean environment = %q", result.Stdout)
+	}
+}
+
+func TestRunRetries(t *testing.T) {
+	requireShell(t)
+	// Fails until it has been run three times
+	counter := filepath.Join(t.TempDir(), "count")
+	script := `n=$(cat "$1" 2>/dev/null || echo 0); n=$((n+1)); echo $n > "$1"; echo "attempt $n"; [ $n -ge 3 ]`
+
+	cmd := New("/bin/sh", "-c", script, "sh", counter)
+	cmd.Retries = 2
+	result, err := cmd.Run(context.Background())
+	if err != nil || result.Attempts != 3 || string(result.Stdout) != "attempt 3\n" {
+		t.Errorf("Run() = %+v, %v, want success on the third attempt", result, err)
+	}
+
+	os.Remove(counter)
+	cmd.Retries = 1
+	if result, err := cmd.Run(context.Background()); err == nil || result.Attempts != 2 {
+		t.Errorf("Run() = %d attempts, %v, want failure after 2", result.Attempts, err)
+	}
+
+	os.Remove(counter)
+	cmd.Retries = 5
+	cmd.Retryable = func(err error, output []byte) bool { return false }
+	if result, _ := cmd.Run(context.Background()); result.Attempts != 1 {
+		t.Errorf("Run() made %d attempts of a failure that is not retryable", result.Attempts)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not available")
+	}
+	cmd := New("sleep", "5")
+	cmd.Timeout = 50 * time.Millisecond
+	cmd.Retries = 3
+	start := time.Now()
+	result, err := cmd.Run(context.Background())
+	if !errors.Is(err, ErrTimeout) || err.Error() != "sleep timed out after 50ms" {
+		t.Errorf("Run() = %v, want a timeout naming the command", err)
+	}
+	if result.Attempts != 1 || time.Since(start) > 2*time.Second {
+		t.Errorf("timed out command ran %d times for %v", result.Attempts, time.Since(start))
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	stopped := errors.New("stopped")
+	cancel(stopped)
+	if _, err := New("sleep", "5").Run(ctx); !errors.Is(err, stopped) {
+		t.Errorf("Run() with a canceled context = %v, want its cause", err)
+	}
+}
+
+func TestString(t *testing.T) {
+	if got := New("codesign", "--verify", "App.app").String(); got != "codesign --verify App.app" {
+		t.Errorf("String() = %q", got)
+	}
+}
@@ -0,0 +1,375 @@
+package resigner
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// archiveModTime is stamped on every archive entry so that packing the
+// same tree twice produces identical bytes
+var archiveModTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ExtractArchive extracts the zip archive src into dest. Entries that
+// would land outside dest, including through symlinks, are rejected;
+// symlinks and executable bits are preserved so bundles stay intact.
+func ExtractArchive(src, dest string) error {
+	return ExtractArchiveWithPassword(src, dest, "")
+}
+
+// ExtractArchiveWithPassword is ExtractArchive for archives whose entries
+// may be encrypted with traditional ZipCrypto
+func ExtractArchiveWithPassword(src, dest, password string) error {
+	reader, err := zip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+	for _, f := range reader.File {
+		if err := extractEntry(f, dest, password); err != nil {
+			if errors.Is(err, ErrPasswordRequired) || errors.Is(err, ErrWrongPassword) {
+				return err
+			}
+			return fmt.Errorf("failed to extract %s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// archivePath resolves an entry name below dest, rejecting escapes
+func archivePath(dest, name string) (string, error) {
+	clean := path.Clean(strings.ReplaceAll(name, `\`, "/"))
+	if clean == "." || path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", errEscape
+	}
+	return filepath.Join(dest, filepath.FromSlash(clean)), nil
+}
+
+// errEscape rejects an entry that would be written, or a link that would
+// lead, outside the destination
+var errEscape = errors.New("entry escapes destination")
+
+// maxLinkHops bounds how many links resolveInside follows, like the
+// kernel's ELOOP limit
+const maxLinkHops = 40
+
+// resolveInside follows rel from the directory dir below dest one
+// component at a time the way the kernel would, expanding symlinks
+// extracted earlier, and returns where it leads. It fails if any step
+// leaves dest. ".." is only followed out of a directory that exists, so
+// a link cannot be aimed through a path a later entry turns into a link.
+func resolveInside(dest, dir, rel string) (string, error) {
+	dest = filepath.Clean(dest)
+	current := filepath.Clean(dir)
+	pending := strings.Split(filepath.ToSlash(rel), "/")
+	missing := false
+	for hops := 0; len(pending) > 0; {
+		part := pending[0]
+		pending = pending[1:]
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			info, err := os.Stat(current)
+			if current == dest || missing || err != nil || !info.IsDir() {
+				return "", errEscape
+			}
+			current = filepath.Dir(current)
+			continue
+		}
+
+		next := filepath.Join(current, part)
+		info, err := os.Lstat(next)
+		switch {
+		case err != nil:
+			missing = true
+		case info.Mode()&fs.ModeSymlink != 0:
+			if hops++; hops > maxLinkHops {
+				return "", fmt.Errorf("too many levels of symlinks")
+			}
+			link, err := os.Readlink(next)
+			if err != nil {
+				return "", err
+			}
+			if path.IsAbs(filepath.ToSlash(link)) || filepath.IsAbs(link) {
+				return "", errEscape
+			}
+			// The link's target replaces it, relative to current
+			pending = append(strings.Split(filepath.ToSlash(link), "/"), pending...)
+			continue
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// extractEntry writes a single archive entry below dest. Entries may lead
+// through links extracted before them, as long as they stay below dest,
+// but never replace a link or write through one.
+func extractEntry(f *zip.File, dest, password string) error {
+	name, err := archivePath(dest, f.Name)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(dest, name)
+	if err != nil {
+		return err
+	}
+	parent, err := resolveInside(dest, dest, filepath.Dir(rel))
+	if err != nil {
+		return err
+	}
+	target := filepath.Join(parent, filepath.Base(name))
+	mode := f.Mode()
+	existing, err := os.Lstat(target)
+	exists := err == nil
+	if exists && existing.Mode()&fs.ModeSymlink != 0 {
+		return fmt.Errorf("%w through a symlink", errEscape)
+	}
+
+	if mode.IsDir() {
+		return os.MkdirAll(target, 0755)
+	}
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return err
+	}
+
+	rc, err := openEntry(f, password)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if mode&fs.ModeSymlink != 0 {
+		link, err := io.ReadAll(io.LimitReader(rc, 4096))
+		if err != nil {
+			return err
+		}
+		linkTarget := string(link)
+		if filepath.IsAbs(linkTarget) || path.IsAbs(linkTarget) {
+			return fmt.Errorf("absolute symlink %s", linkTarget)
+		}
+		// Replacing a path could change where links checked earlier lead
+		if exists {
+			return fmt.Errorf("symlink %s replaces an earlier entry", filepath.Base(name))
+		}
+		if _, err := resolveInside(dest, parent, linkTarget); err != nil {
+			return fmt.Errorf("symlink %s escapes destination", linkTarget)
+		}
+		return os.Symlink(linkTarget, target)
+	}
+
+	perm := mode.Perm()
+	if perm == 0 {
+		perm = 0644
+	}
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := copyBuffered(out, rc); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// CreateArchive writes the contents of the directory source to a zip
+// archive at target. Entries are added in lexical order with a fixed
+// timestamp, and symlinks are stored as links rather than followed.
+// Files are streamed, never held in memory, and archives past 4 GiB use
+// Zip64 records.
+func CreateArchive(source, target string) error {
+	_, err := createArchive(source, "", target, nil, "")
+	return err
+}
+
+// createArchive archives source with every entry name prefixed by prefix,
+// compressing files as the compression preset says. Files still matching
+// their entry in base are copied from it without being compressed again;
+// it returns how many were. The archive is written next to target and
+// renamed over it once complete, so a failure leaves target as it was.
+func createArchive(source, prefix, target string, base map[string]*zip.File, compression Compression) (reused int, err error) {
+	file, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+"-*")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Chmod(file.Name(), 0644)
+		}
+		if err == nil {
+			err = os.Rename(file.Name(), target)
+		}
+		if err != nil {
+			os.Remove(file.Name())
+		}
+	}()
+
+	archive := zip.NewWriter(file)
+	compression.register(archive)
+	err = filepath.WalkDir(source, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(source, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." && prefix == "" {
+			return nil
+		}
+		name := path.Join(prefix, filepath.ToSlash(rel))
+		if f := base[name]; f != nil && d.Type().IsRegular() {
+			ok, err := copyUnchangedEntry(archive, f, p, name, d)
+			if err != nil {
+				return err
+			}
+			if ok {
+				reused++
+				return nil
+			}
+		}
+		return addArchiveEntry(archive, p, name, d, compression.method(name))
+	})
+	if err != nil {
+		archive.Close()
+		return reused, err
+	}
+	return reused, archive.Close()
+}
+
+// newArchiveHeader describes the file info as the entry name
+func newArchiveHeader(info fs.FileInfo, name string) (*zip.FileHeader, error) {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return nil, err
+	}
+	header.Name = name
+	header.Modified = archiveModTime
+	return header, nil
+}
+
+// addArchiveEntry stores one file, directory or symlink; regular files are
+// written with method
+func addArchiveEntry(archive *zip.Writer, p, name string, d fs.DirEntry, method uint16) error {
+	info, err := d.Info()
+	if err != nil {
+		return err
+	}
+	header, err := newArchiveHeader(info, name)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.IsDir():
+		header.Name += "/"
+		header.Method = zip.Store
+		_, err := archive.CreateHeader(header)
+		return err
+	case info.Mode()&fs.ModeSymlink != 0:
+		link, err := os.Readlink(p)
+		if err != nil {
+			return err
+		}
+		header.Method = zip.Store
+		w, err := archive.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, link)
+		return err
+	case info.Mode().IsRegular():
+		header.Method = method
+		w, err := archive.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = copyBuffered(w, f)
+		return err
+	default:
+		// Sockets, devices and the like have no place in a bundle
+		return nil
+	}
+}
+
+// PackIPA packages dir as an IPA at output. dir is either an extracted
+// IPA root containing Payload/ or a single .app bundle, which is placed
+// under Payload/.
+func PackIPA(dir, output string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	if within(dir, output) {
+		return fmt.Errorf("output %s is inside %s, which would archive it into itself", output, dir)
+	}
+	if strings.ToLower(filepath.Ext(dir)) == ".app" {
+		_, err := createArchive(dir, path.Join("Payload", filepath.Base(dir)), output, nil, "")
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Payload")); err != nil {
+		return fmt.Errorf("%s has no Payload directory", dir)
+	}
+	return CreateArchive(dir, output)
+}
+
+// within reports whether path is dir or lies below it, after resolving
+// the symlinks of whatever part of each already exists
+func within(dir, path string) bool {
+	dir, path = resolveExisting(dir), resolveExisting(path)
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolveExisting makes path absolute and resolves the symlinks of its
+// longest existing prefix
+func resolveExisting(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved
+	}
+	parent := filepath.Dir(abs)
+	if parent == abs {
+		return abs
+	}
+	return filepath.Join(resolveExisting(parent), filepath.Base(abs))
+}
+
+// UnpackIPA extracts the IPA at ipa into dir, decrypting it with password
+// when it is encrypted
+func UnpackIPA(ipa, dir, password string) error {
+	if err := ExtractArchiveWithPassword(ipa, dir, password); err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Payload")); err != nil {
+		return fmt.Errorf("%s has no Payload directory", ipa)
+	}
+	return nil
+}
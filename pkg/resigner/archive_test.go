@@ -0,0 +1,327 @@
+package resigner
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTree creates a small bundle with an executable and a symlink
+func writeTree(t *testing.T, root string) {
+	t.Helper()
+	app := filepath.Join(root, "Payload", "Test.app")
+	if err := os.MkdirAll(filepath.Join(app, "Frameworks"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(app, "Test"), []byte("binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(app, "Info.plist"), []byte("plist"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("../Info.plist", filepath.Join(app, "Frameworks", "link")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	writeTree(t, src)
+
+	ipa := filepath.Join(root, "out.ipa")
+	if err := CreateArchive(src, ipa); err != nil {
+		t.Fatalf("CreateArchive() failed: %v", err)
+	}
+	dest := filepath.Join(root, "dest")
+	if err := UnpackIPA(ipa, dest, ""); err != nil {
+		t.Fatalf("UnpackIPA() failed: %v", err)
+	}
+
+	app := filepath.Join(dest, "Payload", "Test.app")
+	info, err := os.Stat(filepath.Join(app, "Test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0100 == 0 {
+		t.Errorf("executable bit lost: %v", info.Mode())
+	}
+	link, err := os.Readlink(filepath.Join(app, "Frameworks", "link"))
+	if err != nil {
+		t.Fatalf("symlink not preserved: %v", err)
+	}
+	if link != "../Info.plist" {
+		t.Errorf("symlink target = %q", link)
+	}
+}
+
+func TestCreateArchiveDeterministic(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	writeTree(t, src)
+
+	first := filepath.Join(root, "first.ipa")
+	second := filepath.Join(root, "second.ipa")
+	if err := CreateArchive(src, first); err != nil {
+		t.Fatal(err)
+	}
+	// Touch a file; timestamps must not leak into the archive
+	os.Chtimes(filepath.Join(src, "Payload", "Test.app", "Test"), archiveModTime, archiveModTime)
+	if err := CreateArchive(src, second); err != nil {
+		t.Fatal(err)
+	}
+
+	a, _ := os.ReadFile(first)
+	b, _ := os.ReadFile(second)
+	if !bytes.Equal(a, b) {
+		t.Error("Archiving the same tree twice produced different bytes")
+	}
+}
+
+func TestPackIPAFromApp(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root)
+
+	ipa := filepath.Join(root, "out.ipa")
+	if err := PackIPA(filepath.Join(root, "Payload", "Test.app"), ipa); err != nil {
+		t.Fatalf("PackIPA() failed: %v", err)
+	}
+	reader, err := zip.OpenReader(ipa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	if reader.File[0].Name != "Payload/Test.app/" {
+		t.Errorf("first entry = %q, want Payload/Test.app/", reader.File[0].Name)
+	}
+}
+
+func TestPackIPAOutput(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root)
+
+	// Packing into the tree being packed would archive the output itself
+	inside := filepath.Join(root, "Payload", "Test.app", "Test.ipa")
+	if err := PackIPA(root, inside); err == nil {
+		t.Error("PackIPA() into its own directory succeeded")
+	}
+	if _, err := os.Stat(inside); !os.IsNotExist(err) {
+		t.Errorf("output inside the directory written: %v", err)
+	}
+
+	// The archive is renamed into place, leaving nothing else behind
+	out := t.TempDir()
+	if err := PackIPA(root, filepath.Join(out, "Test.ipa")); err != nil {
+		t.Fatalf("PackIPA() failed: %v", err)
+	}
+	entries, _ := os.ReadDir(out)
+	if len(entries) != 1 || entries[0].Name() != "Test.ipa" {
+		t.Errorf("output directory holds %v, want only Test.ipa", entries)
+	}
+	if info, err := os.Stat(filepath.Join(out, "Test.ipa")); err != nil || info.Mode().Perm() != 0644 {
+		t.Errorf("output = %v, %v; want mode 0644", info, err)
+	}
+}
+
+func TestExtractArchiveRejectsEscapes(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   string
+		symlink string
+	}{
+		{"parent path", "../evil", ""},
+		{"absolute symlink", "Payload/link", "/etc/passwd"},
+		{"escaping symlink", "Payload/link", "../../outside"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			src := filepath.Join(root, "bad.zip")
+			f, err := os.Create(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w := zip.NewWriter(f)
+			header := &zip.FileHeader{Name: tt.entry}
+			content := "data"
+			if tt.symlink != "" {
+				header.SetMode(os.ModeSymlink | 0777)
+				content = tt.symlink
+			}
+			entry, _ := w.CreateHeader(header)
+			entry.Write([]byte(content))
+			w.Close()
+			f.Close()
+
+			if err := ExtractArchive(src, filepath.Join(root, "dest")); err == nil {
+				t.Error("Expected ExtractArchive to reject the entry")
+			}
+		})
+	}
+}
+
+// writeSpecZip writes a zip archive described by spec, one entry per
+// line: "name" for a file, "name/" for a directory and "name -> target"
+// for a symlink
+func writeSpecZip(path, spec string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for _, line := range strings.Split(spec, "\n") {
+		if line == "" {
+			continue
+		}
+		name, target, isLink := strings.Cut(line, " -> ")
+		header := &zip.FileHeader{Name: name}
+		content := "data"
+		if isLink {
+			header.SetMode(os.ModeSymlink | 0777)
+			content = target
+		}
+		entry, err := w.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		entry.Write([]byte(content))
+	}
+	return w.Close()
+}
+
+func TestExtractArchiveRejectsSymlinkChains(t *testing.T) {
+	// Each link is harmless read lexically, but really leads above the
+	// destination once the links before it are followed
+	for _, spec := range []string{
+		"Payload/l -> ../Payload\nPayload/l/m -> ../..\nPayload/m/escaped",
+		"Payload/l -> ../Payload\nPayload/l/m -> ../..\nPayload/m/x/",
+		// l/.. cleaned lexically is a no-op, but l points at its own folder
+		"Payload/l -> .\nPayload/App.app/l -> .\nPayload/App.app/embedded.mobileprovision -> l/../l/../../victim.txt",
+		// a does not exist yet, so a later entry could make it a link
+		"Payload/q/r/x -> a/../../../..\nPayload/q/r/a -> ../../..",
+		// a exists, but replacing it with a link would move x
+		"Payload/q/r/a/\nPayload/q/r/x -> a/../../../..\nPayload/q/r/a -> ../../..",
+		"Payload/App.app/Info.plist -> ../l\nPayload/App.app/Info.plist",
+	} {
+		root := t.TempDir()
+		src := filepath.Join(root, "bad.zip")
+		if err := writeSpecZip(src, spec); err != nil {
+			t.Fatal(err)
+		}
+		dest := filepath.Join(root, "a", "dest")
+		if err := ExtractArchive(src, dest); err == nil {
+			t.Errorf("ExtractArchive() accepted %q", spec)
+		}
+		entries, _ := os.ReadDir(root)
+		if len(entries) != 2 {
+			t.Errorf("%q wrote outside the destination: %v", spec, entries)
+		}
+	}
+}
+
+func TestExtractArchiveFollowsInnerSymlinks(t *testing.T) {
+	// Framework links may come before what they point at
+	root := t.TempDir()
+	src := filepath.Join(root, "framework.zip")
+	spec := "Payload/A.framework/A -> Versions/Current/A\nPayload/A.framework/Versions/Current -> A\nPayload/A.framework/Versions/A/A\nPayload/A.framework/Frameworks/B -> ../../A.framework/Versions/A\n"
+	if err := writeSpecZip(src, spec); err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(root, "dest")
+	if err := ExtractArchive(src, dest); err != nil {
+		t.Fatalf("ExtractArchive() failed: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dest, "Payload", "A.framework", "A")); err != nil || string(data) != "data" {
+		t.Errorf("framework binary through its links = %q, %v", data, err)
+	}
+}
+
+// FuzzArchivePath checks that no entry name resolves outside dest
+func FuzzArchivePath(f *testing.F) {
+	for _, name := range []string{"Payload/Test.app/Info.plist", "../evil", "/abs", `..\evil`, "a/../../b", "."} {
+		f.Add(name)
+	}
+	dest := filepath.Join(string(filepath.Separator)+"dest", "dir")
+	f.Fuzz(func(t *testing.T, name string) {
+		target, err := archivePath(dest, name)
+		if err != nil {
+			return
+		}
+		if rel, err := filepath.Rel(dest, target); err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			t.Errorf("archivePath(%q) = %q, outside %s", name, target, dest)
+		}
+	})
+}
+
+// FuzzExtractArchive extracts archives of files, directories and symlinks
+// described by writeSpecZip, checking that nothing is written outside the
+// destination and no extracted link leads out of it
+func FuzzExtractArchive(f *testing.F) {
+	f.Add("Payload/Test.app/Info.plist\nPayload/Test.app/Frameworks/")
+	f.Add("Payload/link -> ../../outside")
+	f.Add("Payload/l -> ../Payload\nPayload/l/m -> ../..\nPayload/m/escaped")
+	f.Add("Payload/a/\nPayload/a/b -> ..\nPayload/a/b/c")
+	f.Add("Payload/l -> .\nPayload/App.app/l -> .\nPayload/App.app/embedded.mobileprovision -> l/../l/../../victim.txt")
+	f.Fuzz(func(t *testing.T, spec string) {
+		root := t.TempDir()
+		src := filepath.Join(root, "in.zip")
+		if err := writeSpecZip(src, spec); err != nil {
+			return
+		}
+		dest := filepath.Join(root, "a", "b", "dest")
+		ExtractArchive(src, dest)
+
+		entries, _ := os.ReadDir(root)
+		if len(entries) != 2 {
+			t.Fatalf("%q wrote outside the destination: %v", spec, entries)
+		}
+		for _, dir := range []string{filepath.Join(root, "a"), filepath.Join(root, "a", "b")} {
+			if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+				t.Fatalf("%q wrote outside the destination: %v", spec, entries)
+			}
+		}
+		realDest, err := filepath.EvalSymlinks(dest)
+		if err != nil {
+			return
+		}
+		filepath.WalkDir(dest, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.Type()&fs.ModeSymlink == 0 {
+				return nil
+			}
+			if real, err := filepath.EvalSymlinks(path); err == nil && real != realDest && !strings.HasPrefix(real, realDest+string(filepath.Separator)) {
+				t.Errorf("%q: link %s leads to %s", spec, path, real)
+			}
+			return nil
+		})
+	})
+}
+
+// FuzzExtractArchiveData feeds arbitrary bytes to the extractor, with and
+// without a password, which must fail cleanly rather than crash or hang
+func FuzzExtractArchiveData(f *testing.F) {
+	root := f.TempDir()
+	src := filepath.Join(root, "src")
+	os.MkdirAll(filepath.Join(src, "Payload", "Test.app"), 0755)
+	os.WriteFile(filepath.Join(src, "Payload", "Test.app", "Info.plist"), []byte("plist"), 0644)
+	ipa := filepath.Join(root, "Test.ipa")
+	if err := CreateArchive(src, ipa); err != nil {
+		f.Fatal(err)
+	}
+	data, _ := os.ReadFile(ipa)
+	f.Add(data)
+	f.Add([]byte("PK\x03\x04garbage"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		root := t.TempDir()
+		src := filepath.Join(root, "in.ipa")
+		os.WriteFile(src, data, 0644)
+		ExtractArchive(src, filepath.Join(root, "dest"))
+		ExtractArchiveWithPassword(src, filepath.Join(root, "dest-password"), "secret")
+		IsEncryptedArchive(src)
+	})
+}
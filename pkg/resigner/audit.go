@@ -0,0 +1,224 @@
+package resigner
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"howett.net/plist"
+)
+
+// swiftInOSVersion is the first iOS release shipping the Swift runtime in
+// /usr/lib/swift; apps targeting older releases must embed it
+const swiftInOSVersion = "12.2"
+
+// Install names of the Swift runtime when linked from the OS and when
+// embedded in the app
+const (
+	systemSwiftCore   = "/usr/lib/swift/libswiftCore.dylib"
+	embeddedSwiftCore = "@rpath/libswiftCore.dylib"
+)
+
+// FrameworkInfo describes one embedded framework
+type FrameworkInfo struct {
+	// Path is the framework's location relative to the IPA or .app root
+	Path      string `json:"path"`
+	MinimumOS string `json:"minimum_os,omitempty"`
+	// SwiftRuntime is "system" or "embedded" for Swift frameworks, empty
+	// for Objective-C ones
+	SwiftRuntime string `json:"swift_runtime,omitempty"`
+}
+
+// AuditIssue is a framework problem likely to crash the app at launch
+type AuditIssue struct {
+	Framework string `json:"framework"`
+	Message   string `json:"message"`
+}
+
+// FrameworkAudit is the result of AuditFrameworks
+type FrameworkAudit struct {
+	App        string          `json:"app"`
+	MinimumOS  string          `json:"minimum_os,omitempty"`
+	Frameworks []FrameworkInfo `json:"frameworks"`
+	Issues     []AuditIssue    `json:"issues"`
+}
+
+// AuditFrameworks checks the frameworks embedded in the main app of an IPA
+// or .app directory against the app's deployment target: a framework
+// requiring a newer OS, or a Swift framework whose runtime the app does
+// not provide, loads fine on the developer's device and crashes at launch
+// everywhere else.
+func AuditFrameworks(source string) (*FrameworkAudit, error) {
+	fsys, root, close, err := openBundleFS(source)
+	if err != nil {
+		return nil, err
+	}
+	defer close()
+
+	var appDir, appRel string
+	err = walkBundles(fsys, root, []string{".app"}, func(dir, rel string) error {
+		if appDir == "" {
+			appDir, appRel = dir, rel
+		}
+		return fs.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+	if appDir == "" {
+		return nil, fmt.Errorf("no app bundle found in %s", source)
+	}
+	return auditApp(fsys, appDir, appRel)
+}
+
+// auditApp audits the app at dir; rel is its display path
+func auditApp(fsys fs.FS, dir, rel string) (*FrameworkAudit, error) {
+	audit := &FrameworkAudit{App: rel}
+	minimumOS, _, err := bundleMinimumOS(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	audit.MinimumOS = minimumOS
+
+	frameworksDir := path.Join(dir, "Frameworks")
+	entries, err := fs.ReadDir(fsys, frameworksDir)
+	if err != nil {
+		// No Frameworks folder means nothing to audit
+		return audit, nil
+	}
+
+	embedsSwift := false
+	for _, entry := range entries {
+		if entry.Name() == "libswiftCore.dylib" {
+			embedsSwift = true
+		}
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || path.Ext(entry.Name()) != ".framework" {
+			continue
+		}
+		frameworkDir := path.Join(frameworksDir, entry.Name())
+		frameworkMinOS, libraries, err := bundleMinimumOS(fsys, frameworkDir)
+		if err != nil {
+			return nil, err
+		}
+
+		info := FrameworkInfo{
+			Path:         path.Join(rel, "Frameworks", entry.Name()),
+			MinimumOS:    frameworkMinOS,
+			SwiftRuntime: swiftRuntime(libraries),
+		}
+		audit.Frameworks = append(audit.Frameworks, info)
+
+		if minimumOS != "" && frameworkMinOS != "" && compareVersions(frameworkMinOS, minimumOS) > 0 {
+			audit.Issues = append(audit.Issues, AuditIssue{
+				Framework: info.Path,
+				Message:   fmt.Sprintf("requires OS %s but the app supports %s", frameworkMinOS, minimumOS),
+			})
+		}
+		switch {
+		case info.SwiftRuntime == "embedded" && !embedsSwift:
+			audit.Issues = append(audit.Issues, AuditIssue{
+				Framework: info.Path,
+				Message:   "links the embedded Swift runtime, but the app does not ship libswiftCore.dylib",
+			})
+		case info.SwiftRuntime == "system" && minimumOS != "" && compareVersions(minimumOS, swiftInOSVersion) < 0 && !embedsSwift:
+			audit.Issues = append(audit.Issues, AuditIssue{
+				Framework: info.Path,
+				Message:   fmt.Sprintf("needs the OS Swift runtime (iOS %s+) but the app supports %s without embedding it", swiftInOSVersion, minimumOS),
+			})
+		}
+	}
+	return audit, nil
+}
+
+// bundleMinimumOS returns a bundle's deployment target and the libraries
+// its executable links. The target comes from the executable's load
+// commands, which is what the loader honours, falling back to the
+// Info.plist's MinimumOSVersion.
+func bundleMinimumOS(fsys fs.FS, dir string) (string, []string, error) {
+	data, err := fs.ReadFile(fsys, path.Join(dir, "Info.plist"))
+	if err != nil {
+		return "", nil, err
+	}
+	var info map[string]interface{}
+	if _, err := plist.Unmarshal(data, &info); err != nil {
+		return "", nil, fmt.Errorf("failed to parse %s/Info.plist: %w", dir, err)
+	}
+	minimumOS, _ := info["MinimumOSVersion"].(string)
+
+	executable, _ := info["CFBundleExecutable"].(string)
+	if executable == "" {
+		return minimumOS, nil, nil
+	}
+	binary, err := fs.ReadFile(fsys, path.Join(dir, executable))
+	if err != nil {
+		return "", nil, err
+	}
+	summary, err := readMachOSummary(bytes.NewReader(binary))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read Mach-O %s: %w", path.Join(dir, executable), err)
+	}
+	if summary.MinimumOS != "" {
+		minimumOS = summary.MinimumOS
+	}
+	return minimumOS, summary.Libraries, nil
+}
+
+// swiftRuntime classifies which Swift runtime a binary links, if any
+func swiftRuntime(libraries []string) string {
+	for _, library := range libraries {
+		switch library {
+		case systemSwiftCore:
+			return "system"
+		case embeddedSwiftCore:
+			return "embedded"
+		}
+	}
+	return ""
+}
+
+// compareVersions compares dotted version strings numerically, treating
+// missing components as zero
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// warnFrameworkIssues reports audit issues of the extracted app as
+// warnings; the audit itself failing is not worth stopping the run for
+func (r *Resigner) warnFrameworkIssues(appPath string) {
+	fsys, root, close, err := openBundleFS(appPath)
+	if err != nil {
+		return
+	}
+	defer close()
+
+	audit, err := auditApp(fsys, root, root)
+	if err != nil {
+		return
+	}
+	for _, issue := range audit.Issues {
+		r.warn(WarningFrameworkIssue, appPath, filepath.Join(appPath, filepath.FromSlash(issue.Framework)), "%s %s", path.Base(issue.Framework), issue.Message)
+	}
+}
@@ -0,0 +1,98 @@
+package resigner
+
+import (
+	"debug/macho"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// buildLinkedMachO returns a minimal arm64 iOS Mach-O with the given
+// deployment target (major, minor) that links libraries
+func buildLinkedMachO(major, minor uint32, libraries ...string) []byte {
+	le := binary.LittleEndian
+	var cmds []byte
+	for _, v := range []uint32{uint32(loadCmdBuildVersion), 24, uint32(PlatformIOS), major<<16 | minor<<8, 0x00110000, 0} {
+		cmds = le.AppendUint32(cmds, v)
+	}
+	for _, library := range libraries {
+		name := append([]byte(library), 0)
+		for len(name)%8 != 0 {
+			name = append(name, 0)
+		}
+		for _, v := range []uint32{uint32(macho.LoadCmdDylib), uint32(24 + len(name)), 24, 0, 0x10000, 0x10000} {
+			cmds = le.AppendUint32(cmds, v)
+		}
+		cmds = append(cmds, name...)
+	}
+
+	var data []byte
+	for _, v := range []uint32{macho.Magic64, uint32(macho.CpuArm64), 0, uint32(macho.TypeExec), uint32(1 + len(libraries)), uint32(len(cmds)), 0, 0} {
+		data = le.AppendUint32(data, v)
+	}
+	return append(data, cmds...)
+}
+
+// writeBundle creates a bundle directory with an Info.plist naming
+// binary as its executable
+func writeBundle(t *testing.T, dir string, binary []byte) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	name := strings.TrimSuffix(filepath.Base(dir), filepath.Ext(dir))
+	info := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0"><dict><key>CFBundleExecutable</key><string>%s</string></dict></plist>`, name)
+	os.WriteFile(filepath.Join(dir, "Info.plist"), []byte(info), 0644)
+	os.WriteFile(filepath.Join(dir, name), binary, 0755)
+}
+
+func TestAuditFrameworks(t *testing.T) {
+	app := filepath.Join(t.TempDir(), "Test.app")
+	writeBundle(t, app, buildLinkedMachO(12, 0))
+	frameworks := filepath.Join(app, "Frameworks")
+	writeBundle(t, filepath.Join(frameworks, "Fine.framework"), buildLinkedMachO(11, 0))
+	writeBundle(t, filepath.Join(frameworks, "Newer.framework"), buildLinkedMachO(15, 0))
+	writeBundle(t, filepath.Join(frameworks, "OldSwift.framework"), buildLinkedMachO(10, 0, embeddedSwiftCore))
+	writeBundle(t, filepath.Join(frameworks, "NewSwift.framework"), buildLinkedMachO(12, 0, systemSwiftCore))
+
+	audit, err := AuditFrameworks(app)
+	if err != nil {
+		t.Fatalf("AuditFrameworks() failed: %v", err)
+	}
+	if audit.MinimumOS != "12.0" || len(audit.Frameworks) != 4 {
+		t.Fatalf("AuditFrameworks() = %+v", audit)
+	}
+
+	flagged := make(map[string]string)
+	for _, issue := range audit.Issues {
+		flagged[filepath.Base(issue.Framework)] = issue.Message
+	}
+	for _, name := range []string{"Newer.framework", "OldSwift.framework", "NewSwift.framework"} {
+		if flagged[name] == "" {
+			t.Errorf("%s not flagged: %+v", name, audit.Issues)
+		}
+	}
+	if msg, ok := flagged["Fine.framework"]; ok {
+		t.Errorf("Fine.framework flagged: %s", msg)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"12.2", "12.2.0", 0},
+		{"12.10", "12.2", 1},
+		{"9.3", "10", -1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package resigner
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Large bundle benchmarks run against synthetic apps of each size listed
+// in -bundle-sizes, e.g.
+//
+//	go test -run '^$' -bench LargeBundle ./pkg/resigner/ -args -bundle-sizes 1GiB,4GiB
+//
+// The small default keeps `go test -bench .` quick; make bench uses real
+// sizes. Each size needs about four times its size in free disk space.
+var bundleSizes = flag.String("bundle-sizes", "64MiB", "comma-separated synthetic bundle sizes for BenchmarkLargeBundle")
+
+// parseBundleSize parses a size such as 512MiB or 4GiB
+func parseBundleSize(s string) (int64, error) {
+	units := []struct {
+		suffix string
+		scale  int64
+	}{{"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10}, {"B", 1}}
+	for _, unit := range units {
+		if number, ok := strings.CutSuffix(s, unit.suffix); ok {
+			n, err := strconv.ParseInt(number, 10, 64)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid bundle size %q", s)
+			}
+			return n * unit.scale, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid bundle size %q (want e.g. 512MiB or 4GiB)", s)
+}
+
+// writeSyntheticBundle creates root/Payload/Synthetic.app holding about size
+// bytes laid out like a large game: frameworks, extensions, compiled
+// resources, a few thousand small files and bulk incompressible assets
+func writeSyntheticBundle(b *testing.B, root string, size int64) string {
+	b.Helper()
+	app := filepath.Join(root, "Payload", "Synthetic.app")
+	write := func(path string, data []byte) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			b.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	// A Mach-O header is enough for discovery; nothing is signed
+	executable := append([]byte{0xcf, 0xfa, 0xed, 0xfe}, bytes.Repeat([]byte("text"), 1<<18)...)
+	bundle := func(dir, name string) {
+		write(filepath.Join(dir, "Info.plist"), []byte(plistHeader+`<plist version="1.0"><dict><key>CFBundleExecutable</key><string>`+name+`</string></dict></plist>`))
+		write(filepath.Join(dir, name), executable)
+	}
+
+	bundle(app, "Synthetic")
+	for i := 0; i < 8; i++ {
+		bundle(filepath.Join(app, "Frameworks", fmt.Sprintf("Lib%d.framework", i)), fmt.Sprintf("Lib%d", i))
+	}
+	for i := 0; i < 4; i++ {
+		bundle(filepath.Join(app, "PlugIns", fmt.Sprintf("Ext%d.appex", i)), fmt.Sprintf("Ext%d", i))
+	}
+	write(filepath.Join(app, "Assets.car"), executable)
+	for i := 0; i < 16; i++ {
+		write(filepath.Join(app, "Base.lproj", fmt.Sprintf("Screen%d.storyboardc", i), "Info.plist"), []byte(plistHeader))
+	}
+
+	// A tenth of the size goes to small compressible files, the rest to
+	// 8 MiB assets. The random block is larger than the deflate window,
+	// so repeating it stays incompressible.
+	small := bytes.Repeat([]byte("{\"level\": 1, \"tiles\": [0, 1, 2]}\n"), 2<<10)
+	for i := int64(0); i < size/10/int64(len(small)); i++ {
+		write(filepath.Join(app, "Levels", fmt.Sprintf("%02d", i%64), fmt.Sprintf("level%d.json", i)), small)
+	}
+	block := make([]byte, 1<<20)
+	rand.New(rand.NewSource(1)).Read(block)
+	asset := bytes.Repeat(block, 8)
+	for i := int64(0); i < size*9/10/int64(len(asset)); i++ {
+		write(filepath.Join(app, "Assets", fmt.Sprintf("pack%03d.pak", i)), asset)
+	}
+	return app
+}
+
+// BenchmarkLargeBundle measures the steps whose cost grows with the IPA:
+// extraction, component discovery, the workspace copy (cloned on APFS),
+// packaging from scratch and repackaging against the source. Results are
+// reported in MB/s so sizes and runs compare with benchstat.
+func BenchmarkLargeBundle(b *testing.B) {
+	for _, s := range strings.Split(*bundleSizes, ",") {
+		size, err := parseBundleSize(strings.TrimSpace(s))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(strings.TrimSpace(s), func(b *testing.B) {
+			work := b.TempDir()
+			src := filepath.Join(work, "src")
+			app := writeSyntheticBundle(b, src, size)
+			ipa := filepath.Join(work, "Synthetic.ipa")
+			if err := CreateArchive(src, ipa); err != nil {
+				b.Fatal(err)
+			}
+
+			// Each iteration writes to out, cleared untimed in between
+			out := filepath.Join(work, "out")
+			timed := func(b *testing.B, step func() error) {
+				b.SetBytes(size)
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					if err := os.RemoveAll(out); err != nil {
+						b.Fatal(err)
+					}
+					b.StartTimer()
+					if err := step(); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.StopTimer()
+				os.RemoveAll(out)
+			}
+
+			b.Run("extract", func(b *testing.B) {
+				timed(b, func() error { return ExtractArchive(ipa, out) })
+			})
+			b.Run("discover", func(b *testing.B) {
+				components := DefaultComponents()
+				timed(b, func() error {
+					_, err := components.find(app)
+					return err
+				})
+			})
+			b.Run("copy", func(b *testing.B) {
+				timed(b, func() error { return cloneOrCopyDir(app, out) })
+			})
+			b.Run("package", func(b *testing.B) {
+				timed(b, func() error { return CreateArchive(src, out) })
+			})
+			b.Run("repack", func(b *testing.B) {
+				timed(b, func() error {
+					_, err := RepackArchive(src, ipa, out)
+					return err
+				})
+			})
+		})
+	}
+}
+
+func TestParseBundleSize(t *testing.T) {
+	for input, want := range map[string]int64{"64MiB": 64 << 20, "4GiB": 4 << 30, "512KiB": 512 << 10, "100B": 100} {
+		if got, err := parseBundleSize(input); err != nil || got != want {
+			t.Errorf("parseBundleSize(%q) = %d, %v, want %d", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"", "4GB", "GiB", "-1MiB", "1.5GiB"} {
+		if _, err := parseBundleSize(input); err == nil {
+			t.Errorf("parseBundleSize(%q) succeeded", input)
+		}
+	}
+}
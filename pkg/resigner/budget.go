@@ -0,0 +1,175 @@
+package resigner
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrSizeBudget is returned when the output IPA is larger than
+// Config.MaxOutputSize
+var ErrSizeBudget = errors.New("over the size budget")
+
+// CellularDownloadLimit is the App Store download size above which iOS
+// asks before downloading over cellular. The IPA is measured before App
+// Store thinning, so exceeding it is a hint to check the thinned sizes,
+// not a certainty.
+const CellularDownloadLimit = 200 << 20
+
+// sizeOffenders is how many of the largest parts of an IPA are listed
+// when it is over a budget
+const sizeOffenders = 5
+
+// sizeUnits maps the suffixes ParseSize accepts to their multipliers
+var sizeUnits = map[string]int64{
+	"":   1,
+	"B":  1,
+	"K":  1 << 10,
+	"KB": 1 << 10,
+	"M":  1 << 20,
+	"MB": 1 << 20,
+	"G":  1 << 30,
+	"GB": 1 << 30,
+}
+
+// ParseSize reads a size such as "150MB", "1.5G" or "4096" (bytes). Units
+// are binary, as in FormatSize, and case-insensitive.
+func ParseSize(s string) (int64, error) {
+	upper := strings.ToUpper(strings.TrimSpace(s))
+	number := strings.TrimRight(upper, "KMGB")
+	multiplier, ok := sizeUnits[upper[len(number):]]
+	value, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if !ok || err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q (use e.g. 150MB, 1.5GB or a number of bytes)", s)
+	}
+	return int64(value * float64(multiplier)), nil
+}
+
+// FormatSize renders a size in bytes for humans, e.g. "150.0 MB"
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, suffix := float64(size)/unit, "KB"
+	for _, next := range []string{"MB", "GB", "TB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
+
+// SizeEntry is one part of an IPA and the compressed bytes it takes
+type SizeEntry struct {
+	// Path is e.g. "Frameworks/Kit.framework" or "Assets.car" inside the
+	// app, or a top-level folder of the IPA such as "SwiftSupport"
+	Path string
+	Size int64
+}
+
+// sizeGroup returns the part of the IPA an archive entry is counted
+// towards: the framework or extension it is in, else the app's top-level
+// file or folder
+func sizeGroup(name string) string {
+	parts := strings.Split(name, "/")
+	if len(parts) < 3 || parts[0] != "Payload" {
+		return parts[0]
+	}
+	inApp := parts[2:]
+	switch inApp[0] {
+	case "Frameworks", "PlugIns", "Extensions", "Watch", "AppClips":
+		if len(inApp) > 1 {
+			return inApp[0] + "/" + inApp[1]
+		}
+	}
+	return inApp[0]
+}
+
+// SizeBreakdown returns the compressed size of each part of an IPA,
+// largest first, as a stand-in for a thinning report
+func SizeBreakdown(ipa string) ([]SizeEntry, error) {
+	reader, err := zip.OpenReader(ipa)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	sizes := make(map[string]int64)
+	for _, file := range reader.File {
+		if !file.FileInfo().IsDir() {
+			sizes[sizeGroup(file.Name)] += int64(file.CompressedSize64)
+		}
+	}
+	entries := make([]SizeEntry, 0, len(sizes))
+	for path, size := range sizes {
+		entries = append(entries, SizeEntry{Path: path, Size: size})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Size != entries[j].Size {
+			return entries[i].Size > entries[j].Size
+		}
+		return entries[i].Path < entries[j].Path
+	})
+	return entries, nil
+}
+
+// offenders describes the largest entries, e.g. "Frameworks/Kit.framework
+// 80.0 MB, Assets.car 20.0 MB"
+func offenders(entries []SizeEntry) string {
+	if len(entries) > sizeOffenders {
+		entries = entries[:sizeOffenders]
+	}
+	parts := make([]string, len(entries))
+	for i, entry := range entries {
+		parts[i] = fmt.Sprintf("%s %s", entry.Path, FormatSize(entry.Size))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// checkSizeBudget compares the size on disk of the packaged IPA at output
+// with Config.MaxOutputSize and Config.WarnOutputSize, naming the largest
+// parts when it is over, and warns when an App Store build is too big to
+// download over cellular
+func (r *Resigner) checkSizeBudget(appPath, output string) error {
+	appStore := false
+	if r.needsProvisioning() {
+		profile, err := ParseProfile(filepath.Join(appPath, "embedded.mobileprovision"))
+		appStore = err == nil && profile.Kind() == ProfileAppStore
+	}
+	limit, warnAt := r.config.MaxOutputSize, r.config.WarnOutputSize
+	if limit <= 0 && warnAt <= 0 && !appStore {
+		return nil
+	}
+
+	info, err := os.Stat(output)
+	if err != nil {
+		return fmt.Errorf("failed to measure the output: %w", err)
+	}
+	size := info.Size()
+	entries, err := SizeBreakdown(output)
+	if err != nil {
+		return fmt.Errorf("failed to measure the output: %w", err)
+	}
+	r.logProgress(fmt.Sprintf("Output size: %s", FormatSize(size)))
+
+	switch {
+	case limit > 0 && size > limit:
+		return fmt.Errorf("%s is %s, %w of %s; largest parts: %s",
+			filepath.Base(output), FormatSize(size), ErrSizeBudget, FormatSize(limit), offenders(entries))
+	case warnAt > 0 && size > warnAt:
+		r.warn(WarningOutputSize, appPath, "", "%s is %s, over the %s warning budget; largest parts: %s",
+			filepath.Base(output), FormatSize(size), FormatSize(warnAt), offenders(entries))
+	}
+	if appStore && size > CellularDownloadLimit {
+		r.warn(WarningOutputSize, appPath, "", "%s is %s before thinning, above the %s cellular download limit; check the App Store thinning report. Largest parts: %s",
+			filepath.Base(output), FormatSize(size), FormatSize(CellularDownloadLimit), offenders(entries))
+	}
+	return nil
+}
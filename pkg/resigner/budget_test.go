@@ -0,0 +1,157 @@
+package resigner
+
+import (
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	for s, want := range map[string]int64{
+		"150MB":  150 << 20,
+		"150 mb": 150 << 20,
+		"1.5G":   3 << 29,
+		"512k":   512 << 10,
+		"4096":   4096,
+		"10B":    10,
+	} {
+		if got, err := ParseSize(s); err != nil || got != want {
+			t.Errorf("ParseSize(%q) = %d, %v, want %d", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "MB", "150TB", "-1MB", "lots"} {
+		if _, err := ParseSize(s); err == nil {
+			t.Errorf("ParseSize(%q) succeeded", s)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for size, want := range map[int64]string{
+		512:       "512 B",
+		1536:      "1.5 KB",
+		150 << 20: "150.0 MB",
+		3 << 29:   "1.5 GB",
+	} {
+		if got := FormatSize(size); got != want {
+			t.Errorf("FormatSize(%d) = %q, want %q", size, got, want)
+		}
+	}
+}
+
+func TestSizeGroup(t *testing.T) {
+	for name, want := range map[string]string{
+		"Payload/Test.app/Test":                                "Test",
+		"Payload/Test.app/Assets.car":                          "Assets.car",
+		"Payload/Test.app/Frameworks/Kit.framework/Kit":        "Frameworks/Kit.framework",
+		"Payload/Test.app/PlugIns/Share.appex/Share":           "PlugIns/Share.appex",
+		"Payload/Test.app/en.lproj/Localizable.strings":        "en.lproj",
+		"SwiftSupport/iphoneos/libswiftCore.dylib":             "SwiftSupport",
+		"Payload/Test.app/Frameworks/libswiftCore.dylib":       "Frameworks/libswiftCore.dylib",
+		"Payload/Test.app/_CodeSignature/CodeResources":        "_CodeSignature",
+		"Payload/Test.app/Watch/Watch.app/Watch":               "Watch/Watch.app",
+		"Payload/Test.app/PlugIns/Share.appex/Assets.car":      "PlugIns/Share.appex",
+		"Payload/Test.app/Frameworks/Kit.framework/Info.plist": "Frameworks/Kit.framework",
+	} {
+		if got := sizeGroup(name); got != want {
+			t.Errorf("sizeGroup(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// writeSizedIPA packages an app whose framework and assets take size
+// bytes each once compressed; random content does not compress
+func writeSizedIPA(t *testing.T, size int) string {
+	t.Helper()
+	src := t.TempDir()
+	app := filepath.Join(src, "Payload", "Test.app")
+	os.MkdirAll(filepath.Join(app, "Frameworks", "Kit.framework"), 0755)
+	random := func(n int) []byte {
+		data := make([]byte, n)
+		rand.Read(data)
+		return data
+	}
+	os.WriteFile(filepath.Join(app, "Frameworks", "Kit.framework", "Kit"), random(2*size), 0644)
+	os.WriteFile(filepath.Join(app, "Assets.car"), random(size), 0644)
+	os.WriteFile(filepath.Join(app, "Info.plist"), []byte("<plist/>"), 0644)
+
+	ipa := filepath.Join(t.TempDir(), "Test.ipa")
+	if err := CreateArchive(src, ipa); err != nil {
+		t.Fatal(err)
+	}
+	return ipa
+}
+
+func TestSizeBreakdown(t *testing.T) {
+	entries, err := SizeBreakdown(writeSizedIPA(t, 64<<10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, entry := range entries {
+		paths = append(paths, entry.Path)
+	}
+	if want := []string{"Frameworks/Kit.framework", "Assets.car", "Info.plist"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("SizeBreakdown() = %v, want %v", paths, want)
+	}
+	if entries[0].Size < 128<<10 {
+		t.Errorf("framework size = %d, want its compressed size", entries[0].Size)
+	}
+}
+
+func TestCheckSizeBudget(t *testing.T) {
+	ipa := writeSizedIPA(t, 64<<10)
+	app := filepath.Join(t.TempDir(), "Test.app")
+
+	r := New(Config{AdHoc: true, WarnOutputSize: 100 << 10})
+	if err := r.checkSizeBudget(app, ipa); err != nil {
+		t.Fatal(err)
+	}
+	warnings := r.Result().Warnings
+	if len(warnings) != 1 || warnings[0].Code != WarningOutputSize || !strings.Contains(warnings[0].Message, "Frameworks/Kit.framework") {
+		t.Errorf("warnings = %+v, want an output-size warning naming the framework", warnings)
+	}
+
+	r = New(Config{AdHoc: true, MaxOutputSize: 100 << 10})
+	if err := r.checkSizeBudget(app, ipa); !errors.Is(err, ErrSizeBudget) || !strings.Contains(err.Error(), "Assets.car") {
+		t.Errorf("checkSizeBudget() = %v, want ErrSizeBudget naming the largest parts", err)
+	}
+
+	r = New(Config{AdHoc: true, MaxOutputSize: 1 << 30})
+	if err := r.checkSizeBudget(app, ipa); err != nil || len(r.Result().Warnings) != 0 {
+		t.Errorf("checkSizeBudget() under budget = %v, warnings %+v", err, r.Result().Warnings)
+	}
+}
+
+func TestSizeBudgetKeepsPreviousOutput(t *testing.T) {
+	source := writeSizedIPA(t, 64<<10)
+	outDir := t.TempDir()
+	previous := filepath.Join(outDir, "Test.ipa")
+	if err := os.WriteFile(previous, []byte("last good build"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := New(Config{SourceIPA: source, OutputDir: outDir, Overwrite: true, AdHoc: true, MaxOutputSize: 100 << 10})
+	r.workspace = newWorkspace(filepath.Join(t.TempDir(), "tmp"))
+	if err := r.workspace.create(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExtractArchive(source, r.workspace.Extracted); err != nil {
+		t.Fatal(err)
+	}
+
+	app := filepath.Join(r.workspace.Extracted, "Payload", "Test.app")
+	if err := r.createResignedIPA(app); !errors.Is(err, ErrSizeBudget) {
+		t.Fatalf("createResignedIPA() = %v, want ErrSizeBudget", err)
+	}
+	if data, err := os.ReadFile(previous); err != nil || string(data) != "last good build" {
+		t.Errorf("previous output = %q, %v; want it untouched", data, err)
+	}
+	if r.outputPath != "" {
+		t.Errorf("outputPath = %q after a failed budget check", r.outputPath)
+	}
+}
@@ -0,0 +1,59 @@
+package resigner
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// openBundleFS exposes an IPA or .app directory as a read-only file
+// system rooted so that root names the top of the tree. The returned
+// close function releases the archive, if any.
+func openBundleFS(source string) (fsys fs.FS, root string, close func() error, err error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	if info.IsDir() {
+		abs, err := filepath.Abs(source)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		return os.DirFS(filepath.Dir(abs)), filepath.Base(abs), func() error { return nil }, nil
+	}
+
+	encrypted, err := IsEncryptedArchive(source)
+	if err != nil {
+		return nil, "", nil, &CorruptArchiveError{Path: source, Err: err}
+	}
+	if encrypted {
+		return nil, "", nil, fmt.Errorf("%s: %w (unpack it first)", source, ErrPasswordRequired)
+	}
+
+	reader, err := zip.OpenReader(source)
+	if err != nil {
+		return nil, "", nil, &CorruptArchiveError{Path: source, Err: err}
+	}
+	return reader, ".", reader.Close, nil
+}
+
+// walkBundles calls fn for every bundle directory under root whose
+// extension is one of exts, outer bundles first. rel is the bundle's path
+// with any leading Payload/ removed.
+func walkBundles(fsys fs.FS, root string, exts []string, fn func(dir, rel string) error) error {
+	return fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() || !slices.Contains(exts, path.Ext(p)) {
+			return nil
+		}
+		return fn(p, strings.TrimPrefix(p, "Payload/"))
+	})
+}
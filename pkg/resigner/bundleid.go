@@ -0,0 +1,78 @@
+package resigner
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	maxBundleIDLength = 255
+	maxLabelLength    = 63
+)
+
+// ValidateBundleID checks a CFBundleIdentifier against Apple's rules: a
+// reverse-DNS string of at least two dot-separated labels, each made of
+// ASCII letters, digits and hyphens (RFC 1034 style), not starting or
+// ending with a hyphen. Empty labels, so leading, trailing or doubled
+// dots, are rejected.
+func ValidateBundleID(bundleID string) error {
+	if bundleID == "" {
+		return fmt.Errorf("bundle ID is empty")
+	}
+	if len(bundleID) > maxBundleIDLength {
+		return fmt.Errorf("bundle ID %q is longer than %d characters", bundleID, maxBundleIDLength)
+	}
+
+	labels := strings.Split(bundleID, ".")
+	if len(labels) < 2 {
+		return fmt.Errorf("bundle ID %q must contain at least two dot-separated parts (e.g. com.company.app)", bundleID)
+	}
+
+	for _, label := range labels {
+		if err := validateBundleIDLabel(label); err != nil {
+			return fmt.Errorf("invalid bundle ID %q: %w", bundleID, err)
+		}
+	}
+	return nil
+}
+
+// validateBundleIDLabel checks a single dot-separated part of a bundle ID
+func validateBundleIDLabel(label string) error {
+	if label == "" {
+		return fmt.Errorf("empty component (leading, trailing or repeated dot)")
+	}
+	if len(label) > maxLabelLength {
+		return fmt.Errorf("component %q is longer than %d characters", label, maxLabelLength)
+	}
+	if label[0] == '-' || label[len(label)-1] == '-' {
+		return fmt.Errorf("component %q must not start or end with a hyphen", label)
+	}
+
+	for _, ch := range label {
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9', ch == '-':
+		case ch == '_':
+			return fmt.Errorf("component %q contains an underscore; use a hyphen instead", label)
+		default:
+			return fmt.Errorf("component %q contains invalid character %q", label, ch)
+		}
+	}
+	return nil
+}
+
+// IsWildcardAppID reports whether a profile app ID (without team prefix)
+// is a wildcard such as "*" or "com.company.*"
+func IsWildcardAppID(appID string) bool {
+	return strings.HasSuffix(appID, "*")
+}
+
+// MatchesAppID reports whether bundleID is covered by a provisioning profile
+// app ID (without team prefix). Explicit app IDs must match exactly;
+// wildcard app IDs match any bundle ID sharing their prefix.
+func MatchesAppID(bundleID, appID string) bool {
+	if !IsWildcardAppID(appID) {
+		return bundleID == appID
+	}
+	prefix := strings.TrimSuffix(appID, "*")
+	return prefix == "" || (strings.HasPrefix(bundleID, prefix) && len(bundleID) > len(prefix))
+}
@@ -0,0 +1,55 @@
+package resigner
+
+import "testing"
+
+func TestValidateBundleID(t *testing.T) {
+	tests := []struct {
+		bundleID string
+		wantErr  bool
+	}{
+		{"com.company.app", false},
+		{"com.company.my-app", false},
+		{"com.1password.ios", false},
+		{"io.X9.App2", false},
+		{"", true},
+		{"app", true},
+		{".com.company", true},
+		{"com.company.", true},
+		{"com..company", true},
+		{"com.company.my_app", true},
+		{"com.company.-app", true},
+		{"com.company.app-", true},
+		{"com.company.app name", true},
+		{"com.company.äpp", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.bundleID, func(t *testing.T) {
+			err := ValidateBundleID(tt.bundleID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateBundleID(%q) error = %v, wantErr %v", tt.bundleID, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatchesAppID(t *testing.T) {
+	tests := []struct {
+		bundleID string
+		appID    string
+		want     bool
+	}{
+		{"com.company.app", "com.company.app", true},
+		{"com.company.app", "com.company.other", false},
+		{"com.company.app", "*", true},
+		{"com.company.app", "com.company.*", true},
+		{"com.company", "com.company.*", false},
+		{"com.other.app", "com.company.*", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchesAppID(tt.bundleID, tt.appID); got != tt.want {
+			t.Errorf("MatchesAppID(%q, %q) = %v, want %v", tt.bundleID, tt.appID, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package resigner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cacheManifestName is the file in each cache entry recording what the
+// extracted tree must contain
+const cacheManifestName = "manifest.json"
+
+// cacheManifest records the content hash, mode or link target of every
+// file extracted from an IPA, so a cached workspace can be verified before
+// it is reused. It covers the whole IPA root, so folders next to Payload/,
+// such as SwiftSupport or MessagesApplicationSupport, are cached too.
+type cacheManifest struct {
+	Files map[string]cacheEntry `json:"files"`
+}
+
+// cacheEntry describes one file of a cached workspace
+type cacheEntry struct {
+	SHA256 string      `json:"sha256,omitempty"`
+	Mode   fs.FileMode `json:"mode"`
+	Link   string      `json:"link,omitempty"`
+}
+
+// buildCacheManifest hashes every file below root
+func buildCacheManifest(root string) (*cacheManifest, error) {
+	manifest := &cacheManifest{Files: make(map[string]cacheEntry)}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		entry, err := describeCacheFile(path, d)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		manifest.Files[filepath.ToSlash(rel)] = entry
+		return nil
+	})
+	return manifest, err
+}
+
+// describeCacheFile builds the manifest entry for one file or symlink
+func describeCacheFile(path string, d fs.DirEntry) (cacheEntry, error) {
+	info, err := d.Info()
+	if err != nil {
+		return cacheEntry{}, err
+	}
+	entry := cacheEntry{Mode: info.Mode()}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		entry.Link, err = os.Readlink(path)
+		return entry, err
+	}
+	entry.SHA256, err = FileSHA256(path)
+	return entry, err
+}
+
+// verify checks that the tree below root holds exactly the files of the
+// manifest, unchanged
+func (m *cacheManifest) verify(root string) error {
+	seen := 0
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		if rel == cacheManifestName {
+			return nil
+		}
+		want, ok := m.Files[filepath.ToSlash(rel)]
+		if !ok {
+			return fmt.Errorf("unexpected file %s", rel)
+		}
+		got, err := describeCacheFile(path, d)
+		if err != nil {
+			return err
+		}
+		if got != want {
+			return fmt.Errorf("%s has changed", rel)
+		}
+		seen++
+		return nil
+	})
+	if err == nil && seen != len(m.Files) {
+		err = fmt.Errorf("%d files are missing", len(m.Files)-seen)
+	}
+	return err
+}
+
+// readCacheManifest loads the manifest of a cache entry
+func readCacheManifest(entry string) (*cacheManifest, error) {
+	data, err := os.ReadFile(filepath.Join(entry, cacheManifestName))
+	if err != nil {
+		return nil, err
+	}
+	var manifest cacheManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// sourceCacheKey identifies the source archive by content
+func (r *Resigner) sourceCacheKey() (string, error) {
+	// Already verified against the source by verifySource
+	if r.config.ExpectSHA256 != "" {
+		return strings.ToLower(r.config.ExpectSHA256), nil
+	}
+	r.logProgress("Hashing source for the workspace cache")
+	return FileSHA256(r.config.SourceIPA)
+}
+
+// extractCached fills the workspace from Config.CacheDir, extracting the IPA
+// into the cache first if it has no valid entry for it. Cached files are
+// hard-linked (or cloned) into the workspace, so a hit costs little more
+// than verifying the cache.
+func (r *Resigner) extractCached() error {
+	key, err := r.sourceCacheKey()
+	if err != nil {
+		return err
+	}
+	entry := filepath.Join(r.config.CacheDir, key)
+
+	if manifest, err := readCacheManifest(entry); err == nil {
+		r.logProgress("Verifying cached workspace")
+		err := manifest.verify(entry)
+		if err == nil {
+			r.logProgress("Reusing cached workspace, skipping extraction")
+			return r.linkCacheEntry(entry)
+		}
+		r.warn(WarningCacheDamaged, "", "", "Cached workspace is damaged (%v), extracting again", err)
+		if err := os.RemoveAll(entry); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(r.config.CacheDir, 0755); err != nil {
+		return err
+	}
+	// Extract into a private staging entry so concurrent runs never see a
+	// half-written cache
+	stage, err := os.MkdirTemp(r.config.CacheDir, key+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stage)
+
+	r.logProgress("Extracting IPA file into cache...")
+	if err := ExtractArchiveWithPassword(r.config.SourceIPA, stage, r.config.ArchivePassword); err != nil {
+		return err
+	}
+	manifest, err := buildCacheManifest(stage)
+	if err != nil {
+		return fmt.Errorf("failed to index cached workspace: %w", err)
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(stage, cacheManifestName), data, 0644); err != nil {
+		return err
+	}
+
+	if err := r.linkCacheEntry(stage); err != nil {
+		return err
+	}
+	// Losing the race to another run is fine: its entry is equivalent
+	os.Rename(stage, entry)
+	return nil
+}
+
+// linkCacheEntry fills the workspace with everything a cache entry holds
+// besides its manifest
+func (r *Resigner) linkCacheEntry(entry string) error {
+	items, err := os.ReadDir(entry)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if item.Name() == cacheManifestName {
+			continue
+		}
+		if err := cloneOrLinkDir(filepath.Join(entry, item.Name()), filepath.Join(r.workspace.Extracted, item.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package resigner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractCached(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	writeTree(t, src)
+	ipa := filepath.Join(root, "Test.ipa")
+	if err := CreateArchive(src, ipa); err != nil {
+		t.Fatal(err)
+	}
+	cacheDir := filepath.Join(root, "cache")
+
+	// run extracts the IPA in a fresh workspace and returns its log
+	run := func() string {
+		t.Helper()
+		var log strings.Builder
+		r := New(Config{SourceIPA: ipa, CacheDir: cacheDir}, WithEventHandler(func(e Event) {
+			log.WriteString(e.Message + "\n")
+		}))
+		if err := r.setupDirectories(); err != nil {
+			t.Fatal(err)
+		}
+		defer r.closeWorkspace()
+		appPath, err := r.extractApp()
+		if err != nil {
+			t.Fatalf("extractApp() failed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(appPath, "Info.plist")); err != nil {
+			t.Errorf("workspace incomplete: %v", err)
+		}
+		return log.String()
+	}
+
+	if log := run(); !strings.Contains(log, "into cache") {
+		t.Errorf("first run did not populate the cache:\n%s", log)
+	}
+	if log := run(); !strings.Contains(log, "Reusing cached workspace") {
+		t.Errorf("second run did not reuse the cache:\n%s", log)
+	}
+
+	// Tampering with the cache must be noticed
+	key, _ := FileSHA256(ipa)
+	os.WriteFile(filepath.Join(cacheDir, key, "Payload", "Test.app", "Info.plist"), []byte("tampered"), 0644)
+	if log := run(); !strings.Contains(log, "damaged") || strings.Contains(log, "Reusing") {
+		t.Errorf("damaged cache was reused:\n%s", log)
+	}
+	if log := run(); !strings.Contains(log, "Reusing cached workspace") {
+		t.Errorf("cache was not rebuilt:\n%s", log)
+	}
+}
+
+func TestExtractCachedKeepsSupportFolders(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	writeTree(t, src)
+	support := filepath.Join(src, "MessagesApplicationSupport")
+	os.MkdirAll(support, 0755)
+	os.WriteFile(filepath.Join(support, "MessagesApplicationSupportStub"), []byte("stub"), 0755)
+	ipa := filepath.Join(root, "Test.ipa")
+	if err := CreateArchive(src, ipa); err != nil {
+		t.Fatal(err)
+	}
+
+	for run := 0; run < 2; run++ {
+		r := New(Config{SourceIPA: ipa, CacheDir: filepath.Join(root, "cache")})
+		if err := r.setupDirectories(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.extractApp(); err != nil {
+			t.Fatalf("extractApp() failed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(r.workspace.Extracted, "MessagesApplicationSupport", "MessagesApplicationSupportStub")); err != nil {
+			t.Errorf("run %d lost the support folder: %v", run+1, err)
+		}
+		r.closeWorkspace()
+	}
+}
@@ -0,0 +1,176 @@
+package resigner
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"howett.net/plist"
+)
+
+// Capability is one row of a capability report
+type Capability struct {
+	Name    string   `json:"name"`
+	Enabled bool     `json:"enabled"`
+	Details []string `json:"details,omitempty"`
+}
+
+// BundleCapabilities describes what one app or extension bundle is
+// entitled to do
+type BundleCapabilities struct {
+	// Path is the bundle's location relative to the IPA or .app root
+	Path              string                 `json:"path"`
+	BundleID          string                 `json:"bundle_id"`
+	Capabilities      []Capability           `json:"capabilities"`
+	UsageDescriptions map[string]string      `json:"usage_descriptions,omitempty"`
+	Entitlements      map[string]interface{} `json:"entitlements"`
+}
+
+// capabilityChecks maps capabilities to the entitlement or Info.plist key
+// that grants them
+var capabilityChecks = []struct {
+	name        string
+	entitlement string
+	infoKey     string
+}{
+	{name: "Push Notifications", entitlement: "aps-environment"},
+	{name: "HealthKit", entitlement: "com.apple.developer.healthkit"},
+	{name: "NFC Tag Reading", entitlement: "com.apple.developer.nfc.readersession.formats"},
+	{name: "App Groups", entitlement: "com.apple.security.application-groups"},
+	{name: "Background Modes", infoKey: "UIBackgroundModes"},
+	{name: "Associated Domains", entitlement: "com.apple.developer.associated-domains"},
+	{name: "iCloud", entitlement: "com.apple.developer.icloud-services"},
+	{name: "Keychain Sharing", entitlement: "keychain-access-groups"},
+	{name: "Apple Pay", entitlement: "com.apple.developer.in-app-payments"},
+	{name: "Sign in with Apple", entitlement: "com.apple.developer.applesignin"},
+	{name: "Siri", entitlement: "com.apple.developer.siri"},
+	{name: "Network Extensions", entitlement: "com.apple.developer.networking.networkextension"},
+	{name: "Wallet", entitlement: "com.apple.developer.pass-type-identifiers"},
+	{name: "Debuggable (get-task-allow)", entitlement: "get-task-allow"},
+}
+
+// InspectCapabilities reports the capabilities of every app and extension
+// bundle in an IPA or .app directory. Entitlements are read from the
+// embedded code signatures, so nothing is extracted or executed.
+func InspectCapabilities(source string) ([]BundleCapabilities, error) {
+	fsys, root, close, err := openBundleFS(source)
+	if err != nil {
+		return nil, err
+	}
+	defer close()
+
+	var bundles []BundleCapabilities
+	err = walkBundles(fsys, root, []string{".app", ".appex"}, func(dir, rel string) error {
+		if _, err := fs.Stat(fsys, path.Join(dir, "Info.plist")); err != nil {
+			return nil
+		}
+		bundle, err := bundleCapabilities(fsys, dir)
+		if err != nil {
+			return err
+		}
+		bundle.Path = rel
+		bundles = append(bundles, *bundle)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(bundles) == 0 {
+		return nil, fmt.Errorf("no app bundle found in %s", source)
+	}
+	return bundles, nil
+}
+
+// bundleCapabilities inspects a single bundle directory
+func bundleCapabilities(fsys fs.FS, dir string) (*BundleCapabilities, error) {
+	data, err := fs.ReadFile(fsys, path.Join(dir, "Info.plist"))
+	if err != nil {
+		return nil, err
+	}
+	var info map[string]interface{}
+	if _, err := plist.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse %s/Info.plist: %w", dir, err)
+	}
+
+	entitlements := make(map[string]interface{})
+	if executable, _ := info["CFBundleExecutable"].(string); executable != "" {
+		binary, err := fs.ReadFile(fsys, path.Join(dir, executable))
+		if err != nil {
+			return nil, err
+		}
+		xml, err := machoEntitlements(bytes.NewReader(binary))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read entitlements of %s: %w", path.Join(dir, executable), err)
+		}
+		if len(xml) > 0 {
+			if _, err := plist.Unmarshal(xml, &entitlements); err != nil {
+				return nil, fmt.Errorf("failed to parse entitlements of %s: %w", path.Join(dir, executable), err)
+			}
+		}
+	}
+
+	bundleID, _ := info["CFBundleIdentifier"].(string)
+	return &BundleCapabilities{
+		BundleID:          bundleID,
+		Capabilities:      capabilitiesFor(info, entitlements),
+		UsageDescriptions: usageDescriptions(info),
+		Entitlements:      entitlements,
+	}, nil
+}
+
+// capabilitiesFor evaluates capabilityChecks against a bundle's Info.plist
+// and entitlements
+func capabilitiesFor(info, entitlements map[string]interface{}) []Capability {
+	capabilities := make([]Capability, 0, len(capabilityChecks))
+	for _, check := range capabilityChecks {
+		var value interface{}
+		if check.entitlement != "" {
+			value = entitlements[check.entitlement]
+		} else {
+			value = info[check.infoKey]
+		}
+		enabled, details := describeValue(value)
+		capabilities = append(capabilities, Capability{Name: check.name, Enabled: enabled, Details: details})
+	}
+	return capabilities
+}
+
+// describeValue decides whether an entitlement value grants anything and
+// renders it for display
+func describeValue(value interface{}) (bool, []string) {
+	switch v := value.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	case string:
+		return v != "", []string{v}
+	case []interface{}:
+		details := make([]string, 0, len(v))
+		for _, item := range v {
+			details = append(details, fmt.Sprint(item))
+		}
+		return len(v) > 0, details
+	default:
+		return true, []string{fmt.Sprint(v)}
+	}
+}
+
+// usageDescriptions collects the privacy purpose strings (NS*UsageDescription
+// and friends) from an Info.plist
+func usageDescriptions(info map[string]interface{}) map[string]string {
+	var descriptions map[string]string
+	for key, value := range info {
+		text, ok := value.(string)
+		if !ok || !strings.HasSuffix(key, "UsageDescription") {
+			continue
+		}
+		if descriptions == nil {
+			descriptions = make(map[string]string)
+		}
+		descriptions[key] = text
+	}
+	return descriptions
+}
@@ -0,0 +1,141 @@
+package resigner
+
+import (
+	"debug/macho"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// buildSignedMachO returns a minimal 64-bit Mach-O whose code signature
+// carries the given entitlements plist
+func buildSignedMachO(entitlements string) []byte {
+	le, be := binary.LittleEndian, binary.BigEndian
+	var data []byte
+	for _, v := range []uint32{macho.Magic64, uint32(macho.CpuArm64), 0, uint32(macho.TypeExec), 1, 16, 0, 0} {
+		data = le.AppendUint32(data, v)
+	}
+
+	var sig []byte
+	for _, v := range []uint32{csMagicEmbeddedSignature, uint32(20 + 8 + len(entitlements)), 1, csSlotEntitlements, 20} {
+		sig = be.AppendUint32(sig, v)
+	}
+	sig = be.AppendUint32(sig, csMagicEmbeddedEntitlements)
+	sig = be.AppendUint32(sig, uint32(8+len(entitlements)))
+	sig = append(sig, entitlements...)
+
+	for _, v := range []uint32{uint32(loadCmdCodeSignature), 16, uint32(len(data) + 16), uint32(len(sig))} {
+		data = le.AppendUint32(data, v)
+	}
+	return append(data, sig...)
+}
+
+const testEntitlements = `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>aps-environment</key>
+	<string>production</string>
+	<key>com.apple.security.application-groups</key>
+	<array>
+		<string>group.com.example.shared</string>
+	</array>
+	<key>get-task-allow</key>
+	<false/>
+</dict>
+</plist>`
+
+const testCapabilitiesInfo = `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>CFBundleIdentifier</key>
+	<string>com.example.app</string>
+	<key>CFBundleExecutable</key>
+	<string>Test</string>
+	<key>UIBackgroundModes</key>
+	<array>
+		<string>audio</string>
+		<string>location</string>
+	</array>
+	<key>NSCameraUsageDescription</key>
+	<string>Scan documents</string>
+</dict>
+</plist>`
+
+func TestInspectCapabilities(t *testing.T) {
+	root := t.TempDir()
+	app := filepath.Join(root, "Payload", "Test.app")
+	if err := os.MkdirAll(app, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(app, "Info.plist"), []byte(testCapabilitiesInfo), 0644)
+	os.WriteFile(filepath.Join(app, "Test"), buildSignedMachO(testEntitlements), 0755)
+
+	ipa := filepath.Join(t.TempDir(), "Test.ipa")
+	if err := CreateArchive(root, ipa); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, source := range []string{app, ipa} {
+		bundles, err := InspectCapabilities(source)
+		if err != nil {
+			t.Fatalf("InspectCapabilities(%s) failed: %v", source, err)
+		}
+		if len(bundles) != 1 || bundles[0].Path != "Test.app" || bundles[0].BundleID != "com.example.app" {
+			t.Fatalf("InspectCapabilities(%s) = %+v", source, bundles)
+		}
+
+		got := make(map[string]Capability)
+		for _, capability := range bundles[0].Capabilities {
+			got[capability.Name] = capability
+		}
+		want := map[string]Capability{
+			"Push Notifications":          {Name: "Push Notifications", Enabled: true, Details: []string{"production"}},
+			"App Groups":                  {Name: "App Groups", Enabled: true, Details: []string{"group.com.example.shared"}},
+			"Background Modes":            {Name: "Background Modes", Enabled: true, Details: []string{"audio", "location"}},
+			"HealthKit":                   {Name: "HealthKit"},
+			"Debuggable (get-task-allow)": {Name: "Debuggable (get-task-allow)"},
+		}
+		for name, capability := range want {
+			if !reflect.DeepEqual(got[name], capability) {
+				t.Errorf("%s: got %+v, want %+v", name, got[name], capability)
+			}
+		}
+
+		if bundles[0].UsageDescriptions["NSCameraUsageDescription"] != "Scan documents" {
+			t.Errorf("UsageDescriptions = %v", bundles[0].UsageDescriptions)
+		}
+	}
+}
+
+func TestMachOEntitlementsUnsigned(t *testing.T) {
+	f, err := os.Open(buildMachO(t, macho.CpuArm64, PlatformIOS))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	entitlements, err := machoEntitlements(f)
+	if err != nil || entitlements != nil {
+		t.Errorf("machoEntitlements() = %q, %v; want nil, nil", entitlements, err)
+	}
+}
+
+func TestSignatureEntitlementsMalformed(t *testing.T) {
+	be := binary.BigEndian
+	var truncated []byte
+	for _, v := range []uint32{csMagicEmbeddedSignature, 20, 1, csSlotEntitlements, 400} {
+		truncated = be.AppendUint32(truncated, v)
+	}
+
+	for name, sig := range map[string][]byte{
+		"empty":           nil,
+		"wrong magic":     make([]byte, 12),
+		"offset past end": truncated,
+	} {
+		if _, err := signatureEntitlements(sig); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package resigner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Leftovers lists what resign runs may have left in dir, the directory of
+// a source: the "tmp-*" workspaces of interrupted runs (or the single
+// "tmp" older versions used) and the default "Resigned" output directory.
+// A workspace is only reported when it has the workspace layout (or the
+// app/ folder of older versions), so unrelated folders are kept.
+func Leftovers(dir string) []string {
+	var leftovers []string
+	tmpDirs, _ := filepath.Glob(filepath.Join(dir, workspacePrefix+"*"))
+	for _, tmpDir := range append([]string{filepath.Join(dir, "tmp")}, tmpDirs...) {
+		for _, layout := range []string{WorkspaceExtracted, "app"} {
+			if info, err := os.Stat(filepath.Join(tmpDir, layout)); err == nil && info.IsDir() {
+				leftovers = append(leftovers, tmpDir)
+				break
+			}
+		}
+	}
+	outDir := filepath.Join(dir, "Resigned")
+	if info, err := os.Stat(outDir); err == nil && info.IsDir() {
+		leftovers = append(leftovers, outDir)
+	}
+	return leftovers
+}
+
+// CacheEntries lists the cached workspaces in a Config.CacheDir, together
+// with staging directories abandoned by interrupted runs. Anything else in
+// the directory is left alone.
+func CacheEntries(cacheDir string) ([]string, error) {
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var found []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(cacheDir, entry.Name())
+		if strings.Contains(entry.Name(), ".tmp-") {
+			found = append(found, path)
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(path, cacheManifestName)); err == nil {
+			found = append(found, path)
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,64 @@
+package resigner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLeftovers(t *testing.T) {
+	dir := t.TempDir()
+	if got := Leftovers(dir); len(got) != 0 {
+		t.Errorf("Leftovers() of an empty directory = %v", got)
+	}
+
+	// A tmp directory without the workspace layout is not ours
+	os.MkdirAll(filepath.Join(dir, "tmp", "notes"), 0755)
+	os.MkdirAll(filepath.Join(dir, "Resigned"), 0755)
+	want := []string{filepath.Join(dir, "Resigned")}
+	if got := Leftovers(dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("Leftovers() = %v, want %v", got, want)
+	}
+
+	// Both the current layout and the app/ folder of older versions count
+	want = []string{filepath.Join(dir, "tmp"), filepath.Join(dir, "Resigned")}
+	for _, layout := range []string{WorkspaceExtracted, "app"} {
+		os.RemoveAll(filepath.Join(dir, "tmp"))
+		os.MkdirAll(filepath.Join(dir, "tmp", layout), 0755)
+		if got := Leftovers(dir); !reflect.DeepEqual(got, want) {
+			t.Errorf("Leftovers() with tmp/%s = %v, want %v", layout, got, want)
+		}
+	}
+
+	// Every per-run workspace is found
+	os.MkdirAll(filepath.Join(dir, "tmp-123", WorkspaceExtracted), 0755)
+	os.MkdirAll(filepath.Join(dir, "tmp-456", WorkspaceLogs), 0755)
+	want = []string{filepath.Join(dir, "tmp"), filepath.Join(dir, "tmp-123"), filepath.Join(dir, "Resigned")}
+	if got := Leftovers(dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("Leftovers() with per-run workspaces = %v, want %v", got, want)
+	}
+}
+
+func TestCacheEntries(t *testing.T) {
+	cacheDir := t.TempDir()
+	entry := filepath.Join(cacheDir, "abc123")
+	os.MkdirAll(entry, 0755)
+	os.WriteFile(filepath.Join(entry, cacheManifestName), []byte("{}"), 0644)
+	stage := filepath.Join(cacheDir, "def456.tmp-99")
+	os.MkdirAll(stage, 0755)
+	os.MkdirAll(filepath.Join(cacheDir, "unrelated"), 0755)
+	os.WriteFile(filepath.Join(cacheDir, "notes.txt"), nil, 0644)
+
+	got, err := CacheEntries(cacheDir)
+	if err != nil {
+		t.Fatalf("CacheEntries() failed: %v", err)
+	}
+	if want := []string{entry, stage}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CacheEntries() = %v, want %v", got, want)
+	}
+
+	if got, err := CacheEntries(filepath.Join(cacheDir, "missing")); err != nil || len(got) != 0 {
+		t.Errorf("CacheEntries() of a missing directory = %v, %v", got, err)
+	}
+}
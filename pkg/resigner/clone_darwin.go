@@ -0,0 +1,12 @@
+//go:build darwin
+
+package resigner
+
+import "golang.org/x/sys/unix"
+
+// cloneTree clones the file or directory tree src to dst with
+// clonefile(2). On APFS the clone shares blocks copy-on-write, so even a
+// multi-gigabyte bundle is duplicated instantly.
+func cloneTree(src, dst string) error {
+	return unix.Clonefile(src, dst, unix.CLONE_NOFOLLOW)
+}
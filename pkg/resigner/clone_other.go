@@ -0,0 +1,10 @@
+//go:build !darwin
+
+package resigner
+
+import "errors"
+
+// cloneTree is unsupported off macOS; callers fall back to copying
+func cloneTree(src, dst string) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,54 @@
+package resigner
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// compiledResourceExts are the build products Xcode compiles into the
+// bundle. They are sealed as ordinary resources and must reach the seal
+// byte for byte, so fix-ups never modify them or look inside them.
+var compiledResourceExts = map[string]bool{
+	".metallib":    true, // Metal shader library
+	".mlmodelc":    true, // compiled Core ML model
+	".car":         true, // compiled asset catalog
+	".nib":         true, // compiled interface
+	".storyboardc": true, // compiled storyboard
+	".momd":        true, // compiled Core Data model
+	".mom":         true,
+	".omo":         true,
+}
+
+// isCompiledResource reports whether path is a compiled resource, file or
+// directory
+func isCompiledResource(path string) bool {
+	return compiledResourceExts[strings.ToLower(filepath.Ext(path))]
+}
+
+// findCompiledResources lists the outermost compiled resources in appPath
+func findCompiledResources(appPath string) ([]string, error) {
+	var resources []string
+	err := filepath.WalkDir(appPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == appPath || !isCompiledResource(path) {
+			return err
+		}
+		resources = append(resources, path)
+		if d.IsDir() {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	return resources, err
+}
+
+// reportCompiledResources notes the compiled resources found, which are
+// left untouched and sealed as they are
+func (r *Resigner) reportCompiledResources(appPath string) {
+	resources, err := findCompiledResources(appPath)
+	if err != nil || len(resources) == 0 {
+		return
+	}
+	r.logProgress(fmt.Sprintf("Found %d compiled resources (Metal libraries, Core ML models, asset catalogs...); sealing them unchanged", len(resources)))
+}
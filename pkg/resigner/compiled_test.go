@@ -0,0 +1,48 @@
+package resigner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCompiledResourcesUntouched(t *testing.T) {
+	app := filepath.Join(t.TempDir(), "Test.app")
+	writeBundle(t, app, []byte("binary"))
+	model := filepath.Join(app, "Model.mlmodelc")
+	os.MkdirAll(filepath.Join(model, "Plugin.bundle"), 0755)
+	// Names FixBundle would otherwise treat as debris
+	os.WriteFile(filepath.Join(model, "._weights"), []byte("weights"), 0644)
+	os.WriteFile(filepath.Join(model, "Plugin.bundle", "Plugin"), buildLinkedMachO(12, 0), 0755)
+	library := filepath.Join(app, "default.metallib")
+	os.WriteFile(library, []byte("MTLB"), 0644)
+	os.WriteFile(filepath.Join(app, "._junk"), nil, 0644)
+
+	resources, err := findCompiledResources(app)
+	if err != nil {
+		t.Fatalf("findCompiledResources() failed: %v", err)
+	}
+	if want := []string{model, library}; !reflect.DeepEqual(resources, want) {
+		t.Errorf("findCompiledResources() = %v, want %v", resources, want)
+	}
+
+	fixes, err := FixBundle(app)
+	if err != nil {
+		t.Fatalf("FixBundle() failed: %v", err)
+	}
+	if len(fixes) != 1 {
+		t.Errorf("FixBundle() made %d fixes, want 1: %v", len(fixes), fixes)
+	}
+	if _, err := os.Stat(filepath.Join(model, "._weights")); err != nil {
+		t.Errorf("FixBundle() modified a compiled model: %v", err)
+	}
+
+	components, err := DefaultComponents().find(app)
+	if err != nil {
+		t.Fatalf("find() failed: %v", err)
+	}
+	if want := []string{app}; !reflect.DeepEqual(components, want) {
+		t.Errorf("find() = %v, want %v", components, want)
+	}
+}
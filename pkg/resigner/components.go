@@ -0,0 +1,134 @@
+package resigner
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ComponentFunc signs one component of the app with the entitlements at
+// entitlementsPath, which is empty when signing without explicit
+// entitlements
+type ComponentFunc func(r *Resigner, component, entitlementsPath string) error
+
+// ComponentHandler signs one kind of component
+type ComponentHandler struct {
+	// Bundle is set for kinds that are directories, such as .framework;
+	// otherwise the handler matches regular files, such as .dylib
+	Bundle bool
+	// Match, if set, must accept a path for it to be signed, so that e.g.
+	// resource-only bundles are skipped
+	Match func(path string) bool
+	Sign  ComponentFunc
+}
+
+// ComponentRegistry maps file extensions to the handlers that sign those
+// components, so new kinds can be supported without changing the signing
+// code. Registries are immutable; every method returns a modified copy.
+type ComponentRegistry struct {
+	handlers map[string]ComponentHandler
+}
+
+// CodesignComponent signs a component with codesign, the way every
+// built-in kind is signed
+func CodesignComponent(r *Resigner, component, entitlementsPath string) error {
+	return r.codesign(component, entitlementsPath)
+}
+
+// DefaultComponents returns the handlers of the component kinds signed by
+// default: app and extension bundles, frameworks, dylibs, and the .bundle
+// and .xpc directories that third-party SDKs ship executables in
+func DefaultComponents() *ComponentRegistry {
+	return &ComponentRegistry{handlers: map[string]ComponentHandler{
+		".app":       {Bundle: true, Sign: CodesignComponent},
+		".appex":     {Bundle: true, Sign: CodesignComponent},
+		".framework": {Bundle: true, Sign: CodesignComponent},
+		".dylib":     {Sign: CodesignComponent},
+		".bundle":    {Bundle: true, Match: HasExecutable, Sign: CodesignComponent},
+		".xpc":       {Bundle: true, Match: HasExecutable, Sign: CodesignComponent},
+	}}
+}
+
+// HasExecutable reports whether the bundle at path holds a Mach-O file at
+// its top level, as flat iOS bundles do, or in Contents/MacOS
+func HasExecutable(path string) bool {
+	for _, dir := range []string{path, filepath.Join(path, "Contents", "MacOS")} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.Type().IsRegular() && isMachOFile(filepath.Join(dir, entry.Name())) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Extensions returns the registered extensions in sorted order
+func (c *ComponentRegistry) Extensions() []string {
+	extensions := make([]string, 0, len(c.handlers))
+	for ext := range c.handlers {
+		extensions = append(extensions, ext)
+	}
+	sort.Strings(extensions)
+	return extensions
+}
+
+// With returns the registry with handler registered for ext, such as
+// ".xpc", replacing any handler it had
+func (c *ComponentRegistry) With(ext string, handler ComponentHandler) *ComponentRegistry {
+	handlers := c.copyHandlers()
+	handlers[strings.ToLower(ext)] = handler
+	return &ComponentRegistry{handlers: handlers}
+}
+
+// Without returns the registry minus the handlers of exts
+func (c *ComponentRegistry) Without(exts ...string) *ComponentRegistry {
+	handlers := c.copyHandlers()
+	for _, ext := range exts {
+		delete(handlers, strings.ToLower(ext))
+	}
+	return &ComponentRegistry{handlers: handlers}
+}
+
+// copyHandlers returns a copy of the handler map to modify
+func (c *ComponentRegistry) copyHandlers() map[string]ComponentHandler {
+	handlers := make(map[string]ComponentHandler, len(c.handlers))
+	for ext, handler := range c.handlers {
+		handlers[ext] = handler
+	}
+	return handlers
+}
+
+// handler returns the handler registered for path's extension
+func (c *ComponentRegistry) handler(path string) (ComponentHandler, bool) {
+	handler, ok := c.handlers[strings.ToLower(filepath.Ext(path))]
+	return handler, ok && handler.Sign != nil
+}
+
+// find lists every component of appPath that has a handler, including the
+// app itself; newSignGraph decides the order
+func (c *ComponentRegistry) find(appPath string) ([]string, error) {
+	var components []string
+	err := filepath.Walk(appPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// Code inside a compiled resource is sealed with it, not signed
+		if info.IsDir() && isCompiledResource(path) {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+		handler, ok := c.handler(path)
+		if ok && handler.Bundle == info.IsDir() && (handler.Match == nil || handler.Match(path)) {
+			components = append(components, path)
+		}
+		return nil
+	})
+	return components, err
+}
@@ -0,0 +1,80 @@
+package resigner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestComponentRegistry(t *testing.T) {
+	defaults := DefaultComponents()
+	if got, want := defaults.Extensions(), []string{".app", ".appex", ".bundle", ".dylib", ".framework", ".xpc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Extensions() = %v, want %v", got, want)
+	}
+
+	noop := func(*Resigner, string, string) error { return nil }
+	custom := defaults.With(".XPC", ComponentHandler{Bundle: true, Sign: noop}).Without(".dylib", ".bundle")
+	if got, want := custom.Extensions(), []string{".app", ".appex", ".framework", ".xpc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Extensions() = %v, want %v", got, want)
+	}
+	if got := len(defaults.Extensions()); got != 6 {
+		t.Errorf("With() and Without() modified the original registry: %v", defaults.Extensions())
+	}
+
+	app := filepath.Join(t.TempDir(), "Test.app")
+	service := filepath.Join(app, "XPCServices", "Helper.xpc")
+	dylib := filepath.Join(app, "Frameworks", "libz.dylib")
+	os.MkdirAll(service, 0755)
+	os.MkdirAll(filepath.Dir(dylib), 0755)
+	os.WriteFile(dylib, nil, 0644)
+	// A directory named like a file kind is not that kind
+	os.MkdirAll(filepath.Join(app, "Resources", "odd.dylib"), 0755)
+
+	got, err := custom.find(app)
+	if err != nil {
+		t.Fatalf("find() failed: %v", err)
+	}
+	if want := []string{app, service}; !reflect.DeepEqual(got, want) {
+		t.Errorf("find() = %v, want %v", got, want)
+	}
+
+	got, err = defaults.find(app)
+	if err != nil {
+		t.Fatalf("find() failed: %v", err)
+	}
+	if want := []string{app, dylib}; !reflect.DeepEqual(got, want) {
+		t.Errorf("find() = %v, want %v", got, want)
+	}
+}
+
+func TestFindExecutableBundles(t *testing.T) {
+	app := filepath.Join(t.TempDir(), "Test.app")
+	macho := buildLinkedMachO(12, 0)
+
+	// Only bundles carrying code are components
+	resources := filepath.Join(app, "SDK.bundle")
+	os.MkdirAll(resources, 0755)
+	os.WriteFile(filepath.Join(resources, "strings.json"), []byte("{}"), 0644)
+	plugin := filepath.Join(app, "Frameworks", "SDK.framework", "Plugin.bundle")
+	os.MkdirAll(plugin, 0755)
+	os.WriteFile(filepath.Join(plugin, "Plugin"), macho, 0755)
+	service := filepath.Join(app, "XPCServices", "Helper.xpc")
+	os.MkdirAll(filepath.Join(service, "Contents", "MacOS"), 0755)
+	os.WriteFile(filepath.Join(service, "Contents", "MacOS", "Helper"), macho, 0755)
+
+	components, err := DefaultComponents().find(app)
+	if err != nil {
+		t.Fatalf("find() failed: %v", err)
+	}
+	framework := filepath.Join(app, "Frameworks", "SDK.framework")
+	if want := []string{app, framework, plugin, service}; !reflect.DeepEqual(components, want) {
+		t.Errorf("find() = %v, want %v", components, want)
+	}
+
+	// The plugin is sealed before the framework containing it
+	want := [][]string{{plugin, service}, {framework}, {app}}
+	if got := newSignGraph(components).waves(); !reflect.DeepEqual(got, want) {
+		t.Errorf("waves() = %v, want %v", got, want)
+	}
+}
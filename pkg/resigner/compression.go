@@ -0,0 +1,90 @@
+package resigner
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// Compression is a preset trading the output IPA's size for packaging
+// speed
+type Compression string
+
+const (
+	// CompressionBalanced deflates every file at the usual level and
+	// reuses the source IPA's compressed data for unchanged files; it is
+	// the default
+	CompressionBalanced Compression = "balanced"
+	// CompressionFast deflates at the fastest level and stores assets
+	// that are already compressed, such as images, asset catalogs and
+	// movies, instead of deflating them again
+	CompressionFast Compression = "fast"
+	// CompressionMax deflates every file, unchanged ones included, at the
+	// best level for the smallest IPA
+	CompressionMax Compression = "max"
+	// CompressionStore writes every file uncompressed, the fastest to
+	// package and the largest
+	CompressionStore Compression = "store"
+)
+
+// Compressions lists the valid presets, default first
+var Compressions = []Compression{CompressionBalanced, CompressionFast, CompressionMax, CompressionStore}
+
+// precompressedExts are the extensions of files whose content is already
+// compressed, so deflating them again costs time for next to nothing
+var precompressedExts = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".heic": true, ".gif": true, ".webp": true,
+	".car": true,
+	".mp4": true, ".m4v": true, ".mov": true, ".m4a": true, ".mp3": true, ".aac": true,
+	".zip": true, ".gz": true, ".xz": true, ".lzfse": true,
+}
+
+// validate checks that c is empty or a known preset
+func (c Compression) validate() error {
+	if c == "" {
+		return nil
+	}
+	for _, known := range Compressions {
+		if c == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown compression %q (use one of: %v)", c, Compressions)
+}
+
+// method returns how the entry name is stored
+func (c Compression) method(name string) uint16 {
+	switch {
+	case c == CompressionStore:
+		return zip.Store
+	case c == CompressionFast && precompressedExts[strings.ToLower(path.Ext(name))]:
+		return zip.Store
+	}
+	return zip.Deflate
+}
+
+// reusesSource reports whether unchanged files keep the source IPA's
+// compressed data rather than being compressed with the preset
+func (c Compression) reusesSource() bool {
+	return c == "" || c == CompressionBalanced || c == CompressionFast
+}
+
+// register makes archive deflate at the preset's level. archive/zip's
+// own compressor, kept for the default, is what balanced means.
+func (c Compression) register(archive *zip.Writer) {
+	level := 0
+	switch c {
+	case CompressionFast:
+		level = flate.BestSpeed
+	case CompressionMax:
+		level = flate.BestCompression
+	default:
+		return
+	}
+	archive.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(w, level)
+	})
+}
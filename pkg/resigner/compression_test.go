@@ -0,0 +1,62 @@
+package resigner
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompressionPresets(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	writeTree(t, src)
+	app := filepath.Join(src, "Payload", "Test.app")
+	os.WriteFile(filepath.Join(app, "Icon.PNG"), bytes.Repeat([]byte("png"), 4096), 0644)
+	os.WriteFile(filepath.Join(app, "Localizable.strings"), bytes.Repeat([]byte("\"key\" = \"value\";\n"), 512), 0644)
+
+	sizes := make(map[Compression]int64)
+	for _, compression := range Compressions {
+		target := filepath.Join(root, string(compression)+".ipa")
+		if _, err := createArchive(src, "", target, nil, compression); err != nil {
+			t.Fatalf("%s: %v", compression, err)
+		}
+		if err := VerifyArchive(target); err != nil {
+			t.Errorf("%s: VerifyArchive() failed: %v", compression, err)
+		}
+		info, _ := os.Stat(target)
+		sizes[compression] = info.Size()
+
+		reader, err := zip.OpenReader(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		methods := make(map[string]uint16)
+		for _, f := range reader.File {
+			methods[filepath.Base(f.Name)] = f.Method
+		}
+		reader.Close()
+
+		wantIcon, wantStrings := uint16(zip.Deflate), uint16(zip.Deflate)
+		switch compression {
+		case CompressionFast:
+			wantIcon = zip.Store
+		case CompressionStore:
+			wantIcon, wantStrings = zip.Store, zip.Store
+		}
+		if methods["Icon.PNG"] != wantIcon || methods["Localizable.strings"] != wantStrings {
+			t.Errorf("%s: methods = %v", compression, methods)
+		}
+	}
+	if sizes[CompressionStore] <= sizes[CompressionFast] || sizes[CompressionFast] <= sizes[CompressionBalanced] {
+		t.Errorf("sizes = %v, want store > fast > balanced", sizes)
+	}
+
+	if err := Compression("zstd").validate(); err == nil {
+		t.Error("validate() accepted an unknown preset")
+	}
+	if !Compression("").reusesSource() || CompressionMax.reusesSource() || CompressionStore.reusesSource() {
+		t.Error("only balanced and fast should reuse the source's compressed data")
+	}
+}
@@ -0,0 +1,105 @@
+package resigner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrNotConfirmed is returned when the ConfirmFunc declined a destructive
+// step
+var ErrNotConfirmed = errors.New("not confirmed")
+
+// ConfirmFunc is asked before a destructive step, such as overwriting an
+// existing output; returning false aborts the run
+type ConfirmFunc func(prompt string) bool
+
+// confirm asks for permission to continue with a destructive step. Without
+// a ConfirmFunc there is nobody to ask, so the step goes ahead with a
+// warning; front ends that can prompt register one.
+func (r *Resigner) confirm(prompt string) error {
+	if r.config.AssumeYes {
+		r.warn(WarningAutoConfirmed, "", "", "%s (confirmed automatically)", prompt)
+		return nil
+	}
+	if r.confirmFunc == nil {
+		r.warn(WarningAutoConfirmed, "", "", "%s (no confirmation asked)", prompt)
+		return nil
+	}
+	if !r.confirmFunc(prompt) {
+		return fmt.Errorf("%s: %w", prompt, ErrNotConfirmed)
+	}
+	return nil
+}
+
+// outputTarget returns the path the resigned output will be written to
+func (r *Resigner) outputTarget(appPath string) string {
+	appName := filepath.Base(appPath)
+	if strings.ToLower(filepath.Ext(r.config.SourceIPA)) == ".ipa" {
+		appName = strings.TrimSuffix(appName, filepath.Ext(appName)) + ".ipa"
+	}
+	return filepath.Join(r.outputDir(), appName)
+}
+
+// confirmOverwrite asks before replacing an existing output
+func (r *Resigner) confirmOverwrite(target string) error {
+	if _, err := os.Lstat(target); err != nil {
+		return nil
+	}
+	if r.config.Overwrite {
+		r.logProgress(fmt.Sprintf("Replacing existing output %s", target))
+		return nil
+	}
+	return r.confirm(fmt.Sprintf("Output %s already exists and will be overwritten", target))
+}
+
+// strippedEntitlements lists the keys of original missing from updated.
+// Development entitlements are not capabilities, so losing them is not
+// worth asking about.
+func strippedEntitlements(original, updated map[string]interface{}) []string {
+	var stripped []string
+	for key := range original {
+		if _, ok := updated[key]; !ok && !isDevelopmentEntitlement(key) {
+			stripped = append(stripped, key)
+		}
+	}
+	sort.Strings(stripped)
+	return stripped
+}
+
+// confirmStrippedEntitlements asks before signing with entitlements that
+// drop capabilities the app is currently signed with
+func (r *Resigner) confirmStrippedEntitlements(appPath, entitlementsPath string) error {
+	if entitlementsPath == "" {
+		return nil
+	}
+	currentPath := filepath.Join(r.workspace.Entitlements, "current.plist")
+	if err := r.extractSignedEntitlements(appPath, currentPath); err != nil {
+		return nil
+	}
+	current, _, err := readPlistFile(currentPath)
+	if err != nil {
+		return nil
+	}
+	updated, _, err := readPlistFile(entitlementsPath)
+	if err != nil {
+		return err
+	}
+	for _, loss := range restrictedEntitlementLosses(current, updated, r.entitlementsSource()) {
+		r.warn(WarningEntitlementLost, appPath, appPath, "Restricted entitlement %s", loss)
+	}
+	stripped := strippedEntitlements(current, updated)
+	if len(stripped) == 0 {
+		return nil
+	}
+	if err := r.confirm(fmt.Sprintf("Signing removes entitlements the app currently has: %s", strings.Join(stripped, ", "))); err != nil {
+		return err
+	}
+	for _, key := range stripped {
+		r.warn(WarningEntitlementLost, appPath, appPath, "Entitlement %s is removed", key)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package resigner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStrippedEntitlements(t *testing.T) {
+	original := map[string]interface{}{
+		"application-identifier":                "TEAM.com.app",
+		"aps-environment":                       "production",
+		"com.apple.developer.healthkit":         true,
+		"com.apple.security.application-groups": []interface{}{"group.app"},
+		"get-task-allow":                        true,
+	}
+	updated := map[string]interface{}{
+		"application-identifier": "TEAM.com.app",
+		"aps-environment":        "development",
+	}
+
+	want := []string{"com.apple.developer.healthkit", "com.apple.security.application-groups"}
+	if got := strippedEntitlements(original, updated); !reflect.DeepEqual(got, want) {
+		t.Errorf("strippedEntitlements() = %v, want %v", got, want)
+	}
+}
+
+func TestRestrictedEntitlementLosses(t *testing.T) {
+	original := map[string]interface{}{
+		"com.apple.developer.carplay-audio":               true,
+		"com.apple.developer.networking.networkextension": []interface{}{"packet-tunnel-provider", "content-filter-provider"},
+		"com.apple.developer.pushkit.unrestricted-voip":   true,
+		"com.apple.developer.healthkit":                   true,
+	}
+	updated := map[string]interface{}{
+		"com.apple.developer.networking.networkextension": []interface{}{"packet-tunnel-provider"},
+		"com.apple.developer.pushkit.unrestricted-voip":   true,
+	}
+
+	losses := restrictedEntitlementLosses(original, updated, "the entitlements file app.plist")
+	if len(losses) != 2 {
+		t.Fatalf("restrictedEntitlementLosses() = %v, want 2 losses", losses)
+	}
+	if !strings.HasPrefix(losses[0], "com.apple.developer.carplay-audio is not in the entitlements being signed, taken from the entitlements file app.plist") {
+		t.Errorf("losses[0] = %q", losses[0])
+	}
+	if !strings.HasPrefix(losses[1], "com.apple.developer.networking.networkextension lacks content-filter-provider in the entitlements being signed, taken from the entitlements file app.plist") {
+		t.Errorf("losses[1] = %q", losses[1])
+	}
+}
+
+func TestConfirmOverwrite(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "Test.ipa")
+	appPath := filepath.Join(root, "tmp", WorkspaceExtracted, "Payload", "Test.app")
+	target := filepath.Join(root, "Resigned", "Test.ipa")
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(target, []byte("old"), 0644)
+
+	r := New(Config{SourceIPA: source})
+	if got := r.outputTarget(appPath); got != target {
+		t.Fatalf("outputTarget() = %q, want %q", got, target)
+	}
+	if err := r.confirmOverwrite(target); err != nil {
+		t.Errorf("without confirm: error = %v", err)
+	}
+	if warnings := r.Result().Warnings; len(warnings) != 1 || warnings[0].Code != WarningAutoConfirmed {
+		t.Errorf("without confirm: warnings = %+v, want one %s", warnings, WarningAutoConfirmed)
+	}
+
+	var prompts []string
+	declined := New(Config{SourceIPA: source}, WithConfirm(func(prompt string) bool {
+		prompts = append(prompts, prompt)
+		return false
+	}))
+	if err := declined.confirmOverwrite(target); !errors.Is(err, ErrNotConfirmed) || len(prompts) != 1 {
+		t.Errorf("declined: error = %v, prompts = %v", err, prompts)
+	}
+
+	accepted := New(Config{SourceIPA: source}, WithConfirm(func(string) bool { return true }))
+	if err := accepted.confirmOverwrite(target); err != nil {
+		t.Errorf("accepted: error = %v", err)
+	}
+	if err := New(Config{SourceIPA: source, AssumeYes: true}).confirmOverwrite(target); err != nil {
+		t.Errorf("AssumeYes: error = %v", err)
+	}
+	if err := New(Config{SourceIPA: source, Overwrite: true}).confirmOverwrite(target); err != nil {
+		t.Errorf("Overwrite: error = %v", err)
+	}
+	if err := r.confirmOverwrite(filepath.Join(root, "Resigned", "Other.ipa")); err != nil {
+		t.Errorf("missing output: error = %v", err)
+	}
+}
+
+func TestEntitlementsSource(t *testing.T) {
+	tests := []struct {
+		config Config
+		want   string
+	}{
+		{Config{}, "the provisioning profile"},
+		{Config{Entitlements: "/tmp/app.plist"}, "the entitlements file app.plist"},
+		{Config{Entitlements: "/tmp/app.plist", EntitlementsMerge: MergePreferUser}, "the entitlements file app.plist merged with the provisioning profile"},
+	}
+	for _, tt := range tests {
+		if got := New(tt.config).entitlementsSource(); got != tt.want {
+			t.Errorf("entitlementsSource() with %+v = %q, want %q", tt.config, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package resigner
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrEntitlementConflict is returned when a conflict between the
+// entitlements and the provisioning profile was resolved by aborting
+var ErrEntitlementConflict = errors.New("conflicts with the provisioning profile")
+
+// EntitlementConflict is an entitlement the provisioning profile cannot
+// satisfy; installing an app signed with it fails
+type EntitlementConflict struct {
+	Key string
+	// Values lists the values of an array entitlement the profile lacks;
+	// empty when the whole key is not allowed
+	Values []string
+	// Reason explains the conflict
+	Reason string
+}
+
+// ConflictAction is how a ConflictResolver settles a conflict
+type ConflictAction int
+
+const (
+	// ConflictKeep signs with the entitlement anyway, warning about it
+	ConflictKeep ConflictAction = iota
+	// ConflictStrip removes the entitlement, or just its disallowed values
+	ConflictStrip
+	// ConflictAbort stops the run with ErrEntitlementConflict
+	ConflictAbort
+)
+
+// ConflictResolver decides what to do about each entitlement the profile
+// cannot satisfy
+type ConflictResolver func(conflict EntitlementConflict) ConflictAction
+
+// entitlementConflicts lists the entitlements the profile's entitlements
+// do not allow, sorted by key. Profiles grant values with wildcards: "*"
+// allows anything and "TEAM.*" any value with that prefix.
+func entitlementConflicts(entitlements, allowed map[string]interface{}) []EntitlementConflict {
+	var conflicts []EntitlementConflict
+	for key, value := range entitlements {
+		grant, ok := allowed[key]
+		if !ok {
+			conflicts = append(conflicts, EntitlementConflict{Key: key, Reason: "not in the provisioning profile"})
+			continue
+		}
+		switch value := value.(type) {
+		case bool:
+			if granted, _ := grant.(bool); value && !granted {
+				conflicts = append(conflicts, EntitlementConflict{Key: key, Reason: "set to false in the provisioning profile"})
+			}
+		case string:
+			if !grantsValue(grant, value) {
+				conflicts = append(conflicts, EntitlementConflict{Key: key, Reason: fmt.Sprintf("%q is not allowed by the provisioning profile", value)})
+			}
+		case []interface{}:
+			var missing []string
+			for _, v := range value {
+				if s, ok := v.(string); ok && !grantsValue(grant, s) {
+					missing = append(missing, s)
+				}
+			}
+			if len(missing) > 0 {
+				conflicts = append(conflicts, EntitlementConflict{
+					Key:    key,
+					Values: missing,
+					Reason: fmt.Sprintf("%s not allowed by the provisioning profile", strings.Join(missing, ", ")),
+				})
+			}
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Key < conflicts[j].Key })
+	return conflicts
+}
+
+// grantsValue reports whether a profile entitlement value, a string or an
+// array of them, allows value
+func grantsValue(grant interface{}, value string) bool {
+	patterns := []interface{}{grant}
+	if list, ok := grant.([]interface{}); ok {
+		patterns = list
+	}
+	for _, pattern := range patterns {
+		p, ok := pattern.(string)
+		if !ok {
+			continue
+		}
+		if p == value || (strings.HasSuffix(p, "*") && strings.HasPrefix(value, strings.TrimSuffix(p, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripConflict removes what conflict objects to from entitlements
+func stripConflict(entitlements map[string]interface{}, conflict EntitlementConflict) {
+	if len(conflict.Values) == 0 {
+		delete(entitlements, conflict.Key)
+		return
+	}
+	drop := make(map[string]bool, len(conflict.Values))
+	for _, v := range conflict.Values {
+		drop[v] = true
+	}
+	var kept []interface{}
+	for _, v := range entitlements[conflict.Key].([]interface{}) {
+		if s, ok := v.(string); !ok || !drop[s] {
+			kept = append(kept, v)
+		}
+	}
+	if len(kept) == 0 {
+		delete(entitlements, conflict.Key)
+		return
+	}
+	entitlements[conflict.Key] = kept
+}
+
+// resolveEntitlementConflicts checks the entitlements at entitlementsPath
+// against the profile embedded in appPath. Each conflict is stripped
+// under Config.AutoStrip, otherwise settled by the ConflictResolver, and
+// kept with a warning when there is none.
+func (r *Resigner) resolveEntitlementConflicts(appPath, entitlementsPath string) error {
+	profile, err := ParseProfile(filepath.Join(appPath, "embedded.mobileprovision"))
+	if err != nil {
+		return nil
+	}
+	entitlements, format, err := readPlistFile(entitlementsPath)
+	if err != nil {
+		return err
+	}
+
+	stripped := 0
+	for _, conflict := range entitlementConflicts(entitlements, profile.Entitlements) {
+		action := ConflictKeep
+		switch {
+		case r.config.AutoStrip:
+			action = ConflictStrip
+		case r.conflictResolver != nil:
+			action = r.conflictResolver(conflict)
+		}
+		switch action {
+		case ConflictStrip:
+			r.logProgress(fmt.Sprintf("Stripping entitlement %s: %s", conflict.Key, conflict.Reason))
+			stripConflict(entitlements, conflict)
+			stripped++
+		case ConflictAbort:
+			return fmt.Errorf("entitlement %s: %s: %w", conflict.Key, conflict.Reason, ErrEntitlementConflict)
+		default:
+			r.warn(WarningEntitlementConflict, appPath, appPath, "Entitlement %s: %s; installing the app will fail", conflict.Key, conflict.Reason)
+		}
+	}
+	if stripped == 0 {
+		return nil
+	}
+	return writePlistFile(entitlementsPath, entitlements, format)
+}
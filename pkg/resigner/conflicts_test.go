@@ -0,0 +1,120 @@
+package resigner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"howett.net/plist"
+)
+
+// conflictProfile grants what a typical development profile does
+var conflictProfile = map[string]interface{}{
+	"application-identifier":                 "TEAM123456.*",
+	"keychain-access-groups":                 []interface{}{"TEAM123456.*"},
+	"get-task-allow":                         true,
+	"aps-environment":                        "development",
+	"com.apple.developer.associated-domains": "*",
+	"com.apple.security.application-groups":  []interface{}{"group.com.company.shared"},
+}
+
+func TestEntitlementConflicts(t *testing.T) {
+	entitlements := map[string]interface{}{
+		"application-identifier":                 "TEAM123456.com.company.app",
+		"keychain-access-groups":                 []interface{}{"TEAM123456.com.company.app"},
+		"get-task-allow":                         true,
+		"aps-environment":                        "production",
+		"com.apple.developer.associated-domains": []interface{}{"applinks:company.com"},
+		"com.apple.security.application-groups":  []interface{}{"group.com.company.shared", "group.com.other"},
+		"com.apple.developer.healthkit":          true,
+	}
+	var keys []string
+	for _, conflict := range entitlementConflicts(entitlements, conflictProfile) {
+		keys = append(keys, conflict.Key)
+	}
+	want := []string{"aps-environment", "com.apple.developer.healthkit", "com.apple.security.application-groups"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("conflicting keys = %v, want %v", keys, want)
+	}
+}
+
+func TestResolveEntitlementConflicts(t *testing.T) {
+	app := filepath.Join(t.TempDir(), "Test.app")
+	os.MkdirAll(app, 0755)
+	os.WriteFile(filepath.Join(app, "embedded.mobileprovision"), fakeProfile("TEAM123456.*"), 0644)
+	write := func() string {
+		path := filepath.Join(t.TempDir(), "entitlements.plist")
+		writePlistFile(path, map[string]interface{}{
+			"application-identifier":                "TEAM123456.com.company.app",
+			"get-task-allow":                        true,
+			"com.apple.developer.healthkit":         true,
+			"com.apple.security.application-groups": []interface{}{"group.com.other"},
+		}, plist.XMLFormat)
+		return path
+	}
+
+	// Without a resolver conflicts are kept, with a warning each
+	r := New(Config{})
+	path := write()
+	if err := r.resolveEntitlementConflicts(app, path); err != nil {
+		t.Fatal(err)
+	}
+	if warnings := r.Result().Warnings; len(warnings) != 2 || warnings[0].Code != WarningEntitlementConflict {
+		t.Errorf("warnings = %+v, want two entitlement-conflict", warnings)
+	}
+
+	// The resolver settles each key
+	var asked []string
+	r = New(Config{}, WithConflictResolver(func(conflict EntitlementConflict) ConflictAction {
+		asked = append(asked, conflict.Key)
+		if conflict.Key == "com.apple.developer.healthkit" {
+			return ConflictStrip
+		}
+		return ConflictKeep
+	}))
+	path = write()
+	if err := r.resolveEntitlementConflicts(app, path); err != nil {
+		t.Fatal(err)
+	}
+	entitlements, _, _ := readPlistFile(path)
+	if _, ok := entitlements["com.apple.developer.healthkit"]; ok || len(asked) != 2 {
+		t.Errorf("entitlements = %v after resolving %v", entitlements, asked)
+	}
+	if _, ok := entitlements["com.apple.security.application-groups"]; !ok {
+		t.Error("kept entitlement was removed")
+	}
+
+	// AutoStrip removes them all without asking
+	r = New(Config{AutoStrip: true}, WithConflictResolver(func(EntitlementConflict) ConflictAction {
+		t.Error("resolver asked despite AutoStrip")
+		return ConflictAbort
+	}))
+	path = write()
+	if err := r.resolveEntitlementConflicts(app, path); err != nil {
+		t.Fatal(err)
+	}
+	entitlements, _, _ = readPlistFile(path)
+	if len(entitlements) != 2 {
+		t.Errorf("entitlements after AutoStrip = %v", entitlements)
+	}
+
+	// Aborting fails the run
+	r = New(Config{}, WithConflictResolver(func(EntitlementConflict) ConflictAction { return ConflictAbort }))
+	if err := r.resolveEntitlementConflicts(app, write()); !errors.Is(err, ErrEntitlementConflict) {
+		t.Errorf("error = %v, want ErrEntitlementConflict", err)
+	}
+}
+
+func TestStripConflictKeepsAllowedValues(t *testing.T) {
+	entitlements := map[string]interface{}{"groups": []interface{}{"a", "b", "c"}}
+	stripConflict(entitlements, EntitlementConflict{Key: "groups", Values: []string{"b"}})
+	if got := entitlements["groups"]; !reflect.DeepEqual(got, []interface{}{"a", "c"}) {
+		t.Errorf("groups = %v", got)
+	}
+	stripConflict(entitlements, EntitlementConflict{Key: "groups", Values: []string{"a", "c"}})
+	if _, ok := entitlements["groups"]; ok {
+		t.Error("emptied array entitlement kept")
+	}
+}
@@ -0,0 +1,44 @@
+package resigner
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// copyBufferSize is large enough to keep syscalls cheap on multi-gigabyte
+// payloads while keeping the pool small
+const copyBufferSize = 256 << 10
+
+// copyBuffers recycles copy buffers across files and runs, so archiving a
+// large app does not allocate a fresh buffer per entry
+var copyBuffers = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, copyBufferSize)
+		return &buf
+	},
+}
+
+// copyBuffered copies src to dst through a pooled buffer. File-to-file
+// copies are left to io.Copy, which lets the kernel copy without one.
+func copyBuffered(dst io.Writer, src io.Reader) (int64, error) {
+	_, srcFile := src.(*os.File)
+	_, dstFile := dst.(*os.File)
+	if srcFile && dstFile {
+		return io.Copy(dst, src)
+	}
+
+	buf := copyBuffers.Get().(*[]byte)
+	defer copyBuffers.Put(buf)
+	// Hide ReadFrom and WriteTo, whose fallbacks allocate their own buffer
+	return io.CopyBuffer(struct{ io.Writer }{dst}, struct{ io.Reader }{src}, *buf)
+}
+
+// cloneOrCopyDir duplicates the directory src at dst, cloning it where
+// the file system supports it (APFS) and copying it otherwise
+func cloneOrCopyDir(src, dst string) error {
+	if err := cloneTree(src, dst); err == nil {
+		return nil
+	}
+	return copyDir(src, dst)
+}
@@ -0,0 +1,125 @@
+package resigner
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCopyBuffered(t *testing.T) {
+	data := bytes.Repeat([]byte("resign"), copyBufferSize)
+	var out bytes.Buffer
+	n, err := copyBuffered(&out, bytes.NewReader(data))
+	if err != nil || n != int64(len(data)) || !bytes.Equal(out.Bytes(), data) {
+		t.Errorf("copyBuffered() = %d, %v; copied %d of %d bytes", n, err, out.Len(), len(data))
+	}
+}
+
+func TestCopyFileRefusesSymlink(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "profile.mobileprovision")
+	victim := filepath.Join(root, "victim.txt")
+	os.WriteFile(src, []byte("profile"), 0644)
+	os.WriteFile(victim, []byte("victim"), 0644)
+	dst := filepath.Join(root, "embedded.mobileprovision")
+	if err := os.Symlink(victim, dst); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := copyFile(src, dst); err == nil {
+		t.Error("copyFile() wrote through a symlink")
+	}
+	if data, _ := os.ReadFile(victim); string(data) != "victim" {
+		t.Errorf("link target = %q, want it untouched", data)
+	}
+}
+
+func TestCloneOrCopyDir(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "Test.app")
+	writeBundle(t, src, []byte("binary"))
+	dst := filepath.Join(t.TempDir(), "Test.app")
+
+	if err := cloneOrCopyDir(src, dst); err != nil {
+		t.Fatalf("cloneOrCopyDir() failed: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(dst, "Test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("executable mode = %v, want 0755", info.Mode().Perm())
+	}
+}
+
+// writeBenchTree creates an app with count files of size bytes each
+func writeBenchTree(b *testing.B, count, size int) string {
+	b.Helper()
+	root := b.TempDir()
+	app := filepath.Join(root, "Payload", "Bench.app")
+	if err := os.MkdirAll(app, 0755); err != nil {
+		b.Fatal(err)
+	}
+	data := bytes.Repeat([]byte{0x5a}, size)
+	for i := 0; i < count; i++ {
+		if err := os.WriteFile(filepath.Join(app, fmt.Sprintf("asset%03d", i)), data, 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return root
+}
+
+// BenchmarkCopy compares io.Copy, which allocates a buffer per call when
+// neither side can shortcut it, with the pooled copy
+func BenchmarkCopy(b *testing.B) {
+	data := bytes.Repeat([]byte{0x5a}, 1<<20)
+	for _, bench := range []struct {
+		name string
+		copy func(io.Writer, io.Reader) (int64, error)
+	}{
+		{"io.Copy", io.Copy},
+		{"pooled", copyBuffered},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+			h := sha256.New()
+			for i := 0; i < b.N; i++ {
+				h.Reset()
+				// Like a zip entry writer, the hash has no ReadFrom
+				if _, err := bench.copy(h, struct{ io.Reader }{bytes.NewReader(data)}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCreateArchive(b *testing.B) {
+	root := writeBenchTree(b, 64, 256<<10)
+	target := filepath.Join(b.TempDir(), "bench.ipa")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := CreateArchive(root, target); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkExtractArchive(b *testing.B) {
+	ipa := filepath.Join(b.TempDir(), "bench.ipa")
+	if err := CreateArchive(writeBenchTree(b, 64, 256<<10), ipa); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := ExtractArchive(ipa, filepath.Join(b.TempDir(), "out")); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package resigner
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"howett.net/plist"
+)
+
+// InstalledApp is an app installed on a device
+type InstalledApp struct {
+	BundleID string
+	// TeamID is the team the installed copy was signed for, empty if the
+	// device did not report its entitlements
+	TeamID string
+	// Name is the localized display name shown on the home screen
+	Name string
+}
+
+// deviceApp is the part of an ideviceinstaller listing entry we use
+type deviceApp struct {
+	BundleID     string                 `plist:"CFBundleIdentifier"`
+	DisplayName  string                 `plist:"CFBundleDisplayName"`
+	Name         string                 `plist:"CFBundleName"`
+	Entitlements map[string]interface{} `plist:"Entitlements"`
+}
+
+// parseDeviceApps parses the XML listing printed by ideviceinstaller
+func parseDeviceApps(data []byte) ([]InstalledApp, error) {
+	var entries []deviceApp
+	if _, err := plist.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("cannot parse installed apps: %w", err)
+	}
+	apps := make([]InstalledApp, 0, len(entries))
+	for _, entry := range entries {
+		app := InstalledApp{BundleID: entry.BundleID, Name: entry.DisplayName}
+		if app.Name == "" {
+			app.Name = entry.Name
+		}
+		if team, ok := entry.Entitlements["com.apple.developer.team-identifier"].(string); ok {
+			app.TeamID = team
+		} else if appID, ok := entry.Entitlements["application-identifier"].(string); ok {
+			app.TeamID, _, _ = strings.Cut(appID, ".")
+		}
+		apps = append(apps, app)
+	}
+	return apps, nil
+}
+
+// deviceApps lists the user apps installed on the device with the given
+// UDID, using ideviceinstaller from libimobiledevice
+func (r *Resigner) deviceApps(udid string) ([]InstalledApp, error) {
+	output, err := r.command("ideviceinstaller", "-u", udid, "-l", "-o", "xml").Output()
+	if err != nil {
+		return nil, fmt.Errorf("cannot list apps on device %s: %w", udid, err)
+	}
+	return parseDeviceApps(output)
+}
+
+// conflictingApp returns the installed app that blocks installing
+// bundleID signed for team: one with the same identifier, which iOS
+// compares without regard to case, signed for a different team
+func conflictingApp(apps []InstalledApp, bundleID, team string) *InstalledApp {
+	for i, app := range apps {
+		if strings.EqualFold(app.BundleID, bundleID) && app.TeamID != "" && !strings.EqualFold(app.TeamID, team) {
+			return &apps[i]
+		}
+	}
+	return nil
+}
+
+// conflictSuffixedBundleID returns bundleID made unique to team
+func conflictSuffixedBundleID(bundleID, team string) string {
+	return bundleID + "." + strings.ToLower(team)
+}
+
+// checkDeviceConflict looks for a copy of the app from another team on
+// Config.Device, which would make the install fail. It suffixes the
+// bundle ID when SuffixOnConflict is set or the ConfirmFunc agrees, and
+// warns otherwise. Without ideviceinstaller the check is skipped; the run
+// warned about that when it started.
+func (r *Resigner) checkDeviceConflict(appPath string) error {
+	if r.config.Device == "" || r.missingTools["ideviceinstaller"] {
+		return nil
+	}
+	bundleID, err := readBundleIdentifier(filepath.Join(appPath, "Info.plist"))
+	if err != nil {
+		return err
+	}
+	team, _ := r.detectTeamID(appPath)
+	if team == "" {
+		r.warn(WarningDeviceUnchecked, "", "", "Cannot check device %s for conflicting apps without a team ID", r.config.Device)
+		return nil
+	}
+	apps, err := r.deviceApps(r.config.Device)
+	if err != nil {
+		r.warn(WarningDeviceUnchecked, "", "", "%v", err)
+		return nil
+	}
+	app := conflictingApp(apps, bundleID, team)
+	if app == nil {
+		r.logProgress(fmt.Sprintf("No conflicting install of %s on device %s", bundleID, r.config.Device))
+		return nil
+	}
+
+	suffixed := conflictSuffixedBundleID(bundleID, team)
+	conflict := fmt.Sprintf("%q (%s) is installed on device %s from team %s, which blocks installing it for team %s",
+		app.Name, app.BundleID, r.config.Device, app.TeamID, team)
+	suffix := r.config.SuffixOnConflict
+	if !suffix && !r.config.AssumeYes && r.confirmFunc != nil {
+		suffix = r.confirmFunc(fmt.Sprintf("%s. Change the bundle ID to %s", conflict, suffixed))
+	}
+	if !suffix {
+		r.warn(WarningDeviceConflict, appPath, appPath, "%s; delete it from the device or use another bundle ID", conflict)
+		return nil
+	}
+	r.logProgress(fmt.Sprintf("Changing bundle identifier to %s to install next to the existing app", suffixed))
+	return r.changeBundleID(appPath, suffixed)
+}
@@ -0,0 +1,90 @@
+package resigner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"howett.net/plist"
+)
+
+const deviceListing = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0"><array>
+<dict>
+	<key>CFBundleIdentifier</key><string>com.example.App</string>
+	<key>CFBundleDisplayName</key><string>Exemple</string>
+	<key>CFBundleName</key><string>Example</string>
+	<key>Entitlements</key><dict>
+		<key>application-identifier</key><string>OTHERTEAM1.com.example.App</string>
+	</dict>
+</dict>
+<dict>
+	<key>CFBundleIdentifier</key><string>com.example.tool</string>
+	<key>CFBundleName</key><string>Tool</string>
+	<key>Entitlements</key><dict>
+		<key>com.apple.developer.team-identifier</key><string>TEAM123456</string>
+	</dict>
+</dict>
+<dict>
+	<key>CFBundleIdentifier</key><string>com.apple.store</string>
+</dict>
+</array></plist>`
+
+func TestParseDeviceApps(t *testing.T) {
+	apps, err := parseDeviceApps([]byte(deviceListing))
+	if err != nil {
+		t.Fatalf("parseDeviceApps() failed: %v", err)
+	}
+	want := []InstalledApp{
+		{BundleID: "com.example.App", TeamID: "OTHERTEAM1", Name: "Exemple"},
+		{BundleID: "com.example.tool", TeamID: "TEAM123456", Name: "Tool"},
+		{BundleID: "com.apple.store"},
+	}
+	if !reflect.DeepEqual(apps, want) {
+		t.Errorf("parseDeviceApps() = %+v, want %+v", apps, want)
+	}
+	if _, err := parseDeviceApps([]byte("garbage")); err == nil {
+		t.Error("parseDeviceApps() accepted garbage")
+	}
+}
+
+func TestConflictingApp(t *testing.T) {
+	apps, _ := parseDeviceApps([]byte(deviceListing))
+	for _, test := range []struct {
+		bundleID, team string
+		want           string
+	}{
+		{"com.example.app", "TEAM123456", "com.example.App"},
+		{"com.example.app", "otherteam1", ""},
+		{"com.example.tool", "TEAM123456", ""},
+		{"com.apple.store", "TEAM123456", ""},
+		{"com.example.new", "TEAM123456", ""},
+	} {
+		got := ""
+		if app := conflictingApp(apps, test.bundleID, test.team); app != nil {
+			got = app.BundleID
+		}
+		if got != test.want {
+			t.Errorf("conflictingApp(%s, %s) = %q, want %q", test.bundleID, test.team, got, test.want)
+		}
+	}
+}
+
+func TestCheckDeviceConflictSkipsWithoutDevice(t *testing.T) {
+	app := filepath.Join(t.TempDir(), "Test.app")
+	os.MkdirAll(app, 0755)
+	writePlistFile(filepath.Join(app, "Info.plist"), map[string]interface{}{"CFBundleIdentifier": "com.example.app"}, plist.XMLFormat)
+
+	r := New(Config{})
+	if err := r.checkDeviceConflict(app); err != nil {
+		t.Fatalf("checkDeviceConflict() failed: %v", err)
+	}
+	if warnings := r.Result().Warnings; len(warnings) != 0 {
+		t.Errorf("warnings without a device = %+v", warnings)
+	}
+	if got := conflictSuffixedBundleID("com.example.app", "TEAM123456"); got != "com.example.app.team123456" {
+		t.Errorf("conflictSuffixedBundleID() = %q", got)
+	}
+}
@@ -0,0 +1,90 @@
+package resigner
+
+import (
+	"sort"
+	"strings"
+)
+
+// developmentEntitlements let a debugger attach to the app. App Store
+// Connect and notarization reject binaries signed with them.
+var developmentEntitlements = []string{
+	"get-task-allow",
+	"com.apple.security.get-task-allow",
+}
+
+// isDevelopmentEntitlement reports whether key is development-only
+func isDevelopmentEntitlement(key string) bool {
+	for _, development := range developmentEntitlements {
+		if key == development {
+			return true
+		}
+	}
+	return false
+}
+
+// distributionIdentityPrefixes start the names of identities whose
+// signatures are meant for distribution rather than development
+var distributionIdentityPrefixes = []string{
+	"Apple Distribution:",
+	"iPhone Distribution:",
+	"Developer ID Application:",
+}
+
+// isDistributionIdentity reports whether the run signs the app with a
+// distribution certificate
+func (r *Resigner) isDistributionIdentity() bool {
+	if r.isAdHoc() {
+		return false
+	}
+	name := r.signingIdentity.Name
+	if name == "" {
+		name = r.config.Certificate
+	}
+	for _, prefix := range distributionIdentityPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// enabledDevelopmentEntitlements lists the development-only keys set in
+// entitlements; keys set to false are harmless and left alone
+func enabledDevelopmentEntitlements(entitlements map[string]interface{}) []string {
+	var enabled []string
+	for _, key := range developmentEntitlements {
+		value, ok := entitlements[key]
+		if !ok {
+			continue
+		}
+		if on, isBool := value.(bool); !isBool || on {
+			enabled = append(enabled, key)
+		}
+	}
+	sort.Strings(enabled)
+	return enabled
+}
+
+// stripDevelopmentEntitlements removes development-only entitlements
+// before signing with a distribution certificate, a classic cause of App
+// Store validation failures, typically brought in by an entitlements
+// file written for debug builds
+func (r *Resigner) stripDevelopmentEntitlements(appPath, entitlementsPath string) error {
+	if entitlementsPath == "" || !r.isDistributionIdentity() {
+		return nil
+	}
+	entitlements, format, err := readPlistFile(entitlementsPath)
+	if err != nil {
+		return err
+	}
+	enabled := enabledDevelopmentEntitlements(entitlements)
+	if len(enabled) == 0 {
+		return nil
+	}
+	for _, key := range enabled {
+		delete(entitlements, key)
+		r.warn(WarningDevelopmentEntitlement, appPath, appPath,
+			"Removed development entitlement %s, which distribution builds must not have", key)
+	}
+	return writePlistFile(entitlementsPath, entitlements, format)
+}
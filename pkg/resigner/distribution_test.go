@@ -0,0 +1,73 @@
+package resigner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"howett.net/plist"
+)
+
+func TestIsDistributionIdentity(t *testing.T) {
+	for certificate, want := range map[string]bool{
+		"Apple Distribution: Example Corp (ABCDE12345)":       true,
+		"iPhone Distribution: Example Corp (ABCDE12345)":      true,
+		"Developer ID Application: Example Corp (ABCDE12345)": true,
+		"Apple Development: Jane Doe (ZZZZZ99999)":            false,
+		"iPhone Developer: Jane Doe (ZZZZZ99999)":             false,
+		"-": false,
+		"0123456789ABCDEF0123456789ABCDEF01234567": false,
+	} {
+		if got := New(Config{Certificate: certificate}).isDistributionIdentity(); got != want {
+			t.Errorf("isDistributionIdentity(%q) = %v, want %v", certificate, got, want)
+		}
+	}
+
+	// A certificate given by hash is judged by the identity it resolved to
+	r := New(Config{Certificate: "0123456789ABCDEF0123456789ABCDEF01234567"})
+	r.signingIdentity = SigningIdentity{Hash: r.config.Certificate, Name: "Apple Distribution: Example Corp (ABCDE12345)"}
+	if !r.isDistributionIdentity() {
+		t.Error("isDistributionIdentity() ignored the resolved identity")
+	}
+}
+
+func TestStripDevelopmentEntitlements(t *testing.T) {
+	app := filepath.Join(t.TempDir(), "Test.app")
+	writeBundle(t, app, buildLinkedMachO(15, 0))
+	write := func(entitlements map[string]interface{}) string {
+		path := filepath.Join(t.TempDir(), "entitlements.plist")
+		data, _ := plist.Marshal(entitlements, plist.XMLFormat)
+		os.WriteFile(path, data, 0644)
+		return path
+	}
+	debug := map[string]interface{}{
+		"application-identifier":            "ABCDE12345.com.example.app",
+		"get-task-allow":                    true,
+		"com.apple.security.get-task-allow": false,
+	}
+
+	path := write(debug)
+	if err := New(Config{Certificate: "Apple Development: Jane Doe (ZZZZZ99999)"}).stripDevelopmentEntitlements(app, path); err != nil {
+		t.Fatal(err)
+	}
+	if got, _, _ := readPlistFile(path); !reflect.DeepEqual(got, debug) {
+		t.Errorf("development signing changed entitlements to %v", got)
+	}
+
+	r := New(Config{Certificate: "Apple Distribution: Example Corp (ABCDE12345)"})
+	if err := r.stripDevelopmentEntitlements(app, path); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"application-identifier":            "ABCDE12345.com.example.app",
+		"com.apple.security.get-task-allow": false,
+	}
+	if got, _, _ := readPlistFile(path); !reflect.DeepEqual(got, want) {
+		t.Errorf("entitlements = %v, want %v", got, want)
+	}
+	warnings := r.Result().Warnings
+	if len(warnings) != 1 || warnings[0].Code != WarningDevelopmentEntitlement {
+		t.Errorf("warnings = %+v, want one development-entitlement warning", warnings)
+	}
+}
@@ -0,0 +1,48 @@
+// Package resigner re-signs iOS applications (.ipa archives and .app
+// bundles) with a new certificate, provisioning profile, bundle identifier
+// and entitlements.
+//
+// The package never writes to stdout or stderr. Progress is reported
+// through typed events delivered to the handler registered with
+// WithEventHandler (or the plain-text ProgressCallback accepted by
+// NewResigner), so embedding programs fully control presentation:
+//
+//	r := resigner.New(resigner.Config{
+//		SourceIPA:   "App.ipa",
+//		Certificate: "Apple Development: Jane Doe (TEAM123456)",
+//	}, resigner.WithEventHandler(func(e resigner.Event) {
+//		log.Println(e.Message)
+//	}))
+//	result, err := r.ResignContext(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	log.Println("Signed", result.BundleID, "for team", result.TeamID)
+//
+// Long-running operations accept a context.Context; cancelling it stops
+// the run between steps and kills any in-flight codesign/security process.
+//
+// A run is an ordered Pipeline of named stages (extract, provision,
+// entitlements, bundle-id, privacy, sign, verify, package). WithPipeline replaces
+// it, so callers can skip, reorder or insert stages for workflows such as
+// sign-only or package-only:
+//
+//	p, _ := resigner.DefaultPipeline().Without(resigner.StagePackage)
+//	r := resigner.New(config, resigner.WithPipeline(p))
+//
+// Which components are signed, and how, comes from a ComponentRegistry
+// mapping file extensions to handlers. WithComponents replaces it, so
+// callers can support a new kind of component:
+//
+//	c := resigner.DefaultComponents().With(".plugin", resigner.ComponentHandler{
+//		Bundle: true,
+//		Sign:   resigner.CodesignComponent,
+//	})
+//	r := resigner.New(config, resigner.WithComponents(c))
+//
+// Stages and component handlers find a run's artifacts through its
+// Workspace (State.Workspace, or Resigner.Workspace), whose directories
+// keep the same names across releases: Extracted/ holds the unpacked IPA,
+// Profiles/ the decoded provisioning profile, Entitlements/ the signing
+// entitlements, Output/ the IPA being packaged and Logs/ the event log.
+package resigner
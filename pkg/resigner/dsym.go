@@ -0,0 +1,121 @@
+package resigner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// dsymBinaries maps the UUID of every DWARF binary below root to its path
+func dsymBinaries(root string) (map[string]string, error) {
+	binaries := make(map[string]string)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Base(filepath.Dir(path)) != "DWARF" {
+			return nil
+		}
+		uuids, err := machoUUIDs(path)
+		if err != nil {
+			return nil
+		}
+		for _, uuid := range uuids {
+			binaries[uuid] = path
+		}
+		return nil
+	})
+	return binaries, err
+}
+
+// appBinaryUUIDs collects the UUIDs of every Mach-O file in the app bundle
+func appBinaryUUIDs(appPath string) (map[string]bool, error) {
+	uuids := make(map[string]bool)
+	err := filepath.Walk(appPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		found, err := machoUUIDs(path)
+		if err != nil {
+			// Not a Mach-O file
+			return nil
+		}
+		for _, uuid := range found {
+			uuids[uuid] = true
+		}
+		return nil
+	})
+	return uuids, err
+}
+
+// prepareDSYMs makes the configured dSYMs available as a directory,
+// extracting them first when a zip was given. A single .dSYM bundle is
+// copied into a folder so the package keeps its name.
+func (r *Resigner) prepareDSYMs() (string, error) {
+	dir := filepath.Join(r.workspace.Root, "dsym")
+	switch strings.ToLower(filepath.Ext(r.config.DSYM)) {
+	case ".zip":
+		if err := ExtractArchive(r.config.DSYM, dir); err != nil {
+			return "", fmt.Errorf("failed to extract dSYMs: %w", err)
+		}
+	case ".dsym":
+		if err := cloneOrCopyDir(r.config.DSYM, filepath.Join(dir, filepath.Base(r.config.DSYM))); err != nil {
+			return "", fmt.Errorf("failed to copy dSYM: %w", err)
+		}
+	default:
+		return r.config.DSYM, nil
+	}
+	return dir, nil
+}
+
+// handleDSYMs checks that the dSYMs still describe the signed binaries and
+// packages them next to the resigned output
+func (r *Resigner) handleDSYMs(appPath string) error {
+	if r.config.DSYM == "" {
+		return nil
+	}
+	r.logProgress("Checking dSYM UUIDs against signed binaries")
+
+	dsymDir, err := r.prepareDSYMs()
+	if err != nil {
+		return err
+	}
+	dsyms, err := dsymBinaries(dsymDir)
+	if err != nil {
+		return err
+	}
+	if len(dsyms) == 0 {
+		r.warn(WarningDSYM, "", "", "No dSYM binaries found in %s", r.config.DSYM)
+		return nil
+	}
+	binaries, err := appBinaryUUIDs(appPath)
+	if err != nil {
+		return err
+	}
+
+	var stale []string
+	for uuid := range dsyms {
+		if !binaries[uuid] {
+			stale = append(stale, uuid)
+		}
+	}
+	sort.Strings(stale)
+	for _, uuid := range stale {
+		r.warn(WarningDSYM, "", "", "dSYM %s (UUID %s) matches no binary in the app; a Mach-O edit invalidated it",
+			filepath.Base(dsyms[uuid]), uuid)
+	}
+	r.logProgress(fmt.Sprintf("%d of %d dSYM UUIDs match the signed binaries", len(dsyms)-len(stale), len(dsyms)))
+
+	appName := strings.TrimSuffix(filepath.Base(appPath), filepath.Ext(appPath))
+	outputPath := filepath.Join(r.outputDir(), appName+".dSYMs.zip")
+	if err := CreateArchive(dsymDir, outputPath); err != nil {
+		return fmt.Errorf("failed to package dSYMs: %w", err)
+	}
+	r.logProgress(fmt.Sprintf("dSYMs saved to: %s", outputPath))
+	return nil
+}
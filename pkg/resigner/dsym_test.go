@@ -0,0 +1,78 @@
+package resigner
+
+import (
+	"debug/macho"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeMachOWithUUID writes a minimal 64-bit Mach-O carrying an LC_UUID
+func writeMachOWithUUID(t *testing.T, path string, uuid byte) {
+	t.Helper()
+	le := binary.LittleEndian
+	var data []byte
+	for _, v := range []uint32{macho.Magic64, uint32(macho.CpuArm64), 0, uint32(macho.TypeExec), 1, 24, 0, 0} {
+		data = le.AppendUint32(data, v)
+	}
+	data = le.AppendUint32(data, uint32(loadCmdUUID))
+	data = le.AppendUint32(data, 24)
+	for i := 0; i < 16; i++ {
+		data = append(data, uuid)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMachOUUIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "binary")
+	writeMachOWithUUID(t, path, 0xab)
+
+	uuids, err := machoUUIDs(path)
+	if err != nil {
+		t.Fatalf("machoUUIDs() failed: %v", err)
+	}
+	want := "ABABABAB-ABAB-ABAB-ABAB-ABABABABABAB"
+	if len(uuids) != 1 || uuids[0] != want {
+		t.Errorf("machoUUIDs() = %v, want [%s]", uuids, want)
+	}
+}
+
+func TestHandleDSYMs(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "Test.ipa")
+	appPath := filepath.Join(root, "tmp", WorkspaceExtracted, "Payload", "Test.app")
+	writeMachOWithUUID(t, filepath.Join(appPath, "Test"), 0x01)
+
+	dsymDir := filepath.Join(root, "dSYMs")
+	writeMachOWithUUID(t, filepath.Join(dsymDir, "Test.app.dSYM", "Contents", "Resources", "DWARF", "Test"), 0x01)
+	writeMachOWithUUID(t, filepath.Join(dsymDir, "Old.framework.dSYM", "Contents", "Resources", "DWARF", "Old"), 0x02)
+	if err := os.MkdirAll(filepath.Join(root, "Resigned"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var warnings []string
+	r := New(Config{SourceIPA: source, DSYM: dsymDir}, WithEventHandler(func(e Event) {
+		if e.Type == EventWarning {
+			warnings = append(warnings, e.Message)
+		}
+	}))
+	r.workspace = newWorkspace(filepath.Join(root, "tmp"))
+
+	if err := r.handleDSYMs(appPath); err != nil {
+		t.Fatalf("handleDSYMs() failed: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "Old") {
+		t.Errorf("warnings = %v, want one for Old", warnings)
+	}
+	if _, err := os.Stat(filepath.Join(root, "Resigned", "Test.dSYMs.zip")); err != nil {
+		t.Errorf("dSYM package not written: %v", err)
+	}
+}
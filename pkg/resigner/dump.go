@@ -0,0 +1,86 @@
+package resigner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"howett.net/plist"
+)
+
+// dumpSuffix ends the name of every file dumpEntitlements writes
+const dumpSuffix = ".entitlements.plist"
+
+// signedEntitlements returns the entitlements component is signed with,
+// empty when it has none
+func (r *Resigner) signedEntitlements(component string) (map[string]interface{}, error) {
+	// The entitlements are on stdout; stderr names the executable, or
+	// why the signature could not be read
+	result, err := r.command("/usr/bin/codesign", "-d", "--entitlements", "-", "--xml", component).Cmd.Run(r.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s - %w", strings.TrimSpace(string(result.Stderr)), err)
+	}
+	entitlements := map[string]interface{}{}
+	if len(result.Stdout) > 0 {
+		if _, err := plist.Unmarshal(result.Stdout, &entitlements); err != nil {
+			return nil, fmt.Errorf("failed to decode signed entitlements: %w", err)
+		}
+	}
+	return entitlements, nil
+}
+
+// dumpPath returns where the entitlements of the component at rel, a path
+// from Result.Components, are written under dir. The tree of the app is
+// mirrored, e.g. Test.app/PlugIns/Share.appex.entitlements.plist.
+func dumpPath(dir, rel string) string {
+	return filepath.Join(dir, filepath.FromSlash(rel)+dumpSuffix)
+}
+
+// dumpEntitlements writes the entitlements every signed component ended up
+// with, read back from its signature rather than taken from what was
+// requested, to Config.DumpEntitlements
+func (r *Resigner) dumpEntitlements(appPath string) error {
+	dir := r.config.DumpEntitlements
+	if dir == "" {
+		return nil
+	}
+	r.emitMu.Lock()
+	signed := r.result.Components
+	r.emitMu.Unlock()
+
+	root := filepath.Dir(appPath)
+	components := make([]string, len(signed))
+	rels := make(map[string]string, len(signed))
+	for i, component := range signed {
+		components[i] = filepath.Join(root, filepath.FromSlash(component.Path))
+		rels[components[i]] = component.Path
+	}
+
+	var mu sync.Mutex
+	dumped := 0
+	err := r.signConcurrently(components, func(component string) error {
+		entitlements, err := r.signedEntitlements(component)
+		if err != nil {
+			r.warn(WarningSignatureUnreadable, appPath, component, "Cannot read the entitlements of %s: %v", filepath.Base(component), err)
+			return nil
+		}
+		path := dumpPath(dir, rels[component])
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := writePlistFile(path, entitlements, plist.XMLFormat); err != nil {
+			return err
+		}
+		mu.Lock()
+		dumped++
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to dump entitlements: %w", err)
+	}
+	r.logProgress(fmt.Sprintf("Dumped the entitlements of %d components to %s", dumped, dir))
+	return nil
+}
@@ -0,0 +1,19 @@
+package resigner
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDumpPath(t *testing.T) {
+	dir := filepath.Join("out", "entitlements")
+	for rel, want := range map[string]string{
+		"Test.app":                           filepath.Join(dir, "Test.app.entitlements.plist"),
+		"Test.app/PlugIns/Share.appex":       filepath.Join(dir, "Test.app", "PlugIns", "Share.appex.entitlements.plist"),
+		"Test.app/Frameworks/libswift.dylib": filepath.Join(dir, "Test.app", "Frameworks", "libswift.dylib.entitlements.plist"),
+	} {
+		if got := dumpPath(dir, rel); got != want {
+			t.Errorf("dumpPath(%q) = %q, want %q", rel, got, want)
+		}
+	}
+}
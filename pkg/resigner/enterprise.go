@@ -0,0 +1,81 @@
+package resigner
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// Provisioning profile kinds returned by Profile.Kind
+const (
+	ProfileDevelopment = "development"
+	ProfileAdHoc       = "ad-hoc"
+	ProfileAppStore    = "app-store"
+	ProfileInHouse     = "in-house"
+)
+
+// ErrNotInHouse is returned in enterprise mode when the provisioning
+// profile is not an In-House (enterprise distribution) profile
+var ErrNotInHouse = errors.New("not an In-House profile")
+
+// EnterpriseResignMargin is how long before the profile expires an
+// enterprise app should be re-signed, leaving time to roll the new build
+// out to every device before the old one stops launching
+const EnterpriseResignMargin = 30 * 24 * time.Hour
+
+// Kind returns the distribution method of the profile: in-house profiles
+// provision all devices, development and ad-hoc ones list devices (only
+// development allows debugging), and App Store ones neither
+func (p *Profile) Kind() string {
+	switch {
+	case p.ProvisionsAllDevices:
+		return ProfileInHouse
+	case len(p.ProvisionedDevices) > 0:
+		if debuggable, _ := p.Entitlements["get-task-allow"].(bool); debuggable {
+			return ProfileDevelopment
+		}
+		return ProfileAdHoc
+	default:
+		return ProfileAppStore
+	}
+}
+
+// ResignBy returns the date an enterprise app signed with the profile
+// should be re-signed by
+func (p *Profile) ResignBy() time.Time {
+	if p.ExpirationDate.IsZero() {
+		return time.Time{}
+	}
+	return p.ExpirationDate.Add(-EnterpriseResignMargin)
+}
+
+// checkEnterpriseProfile makes sure the profile embedded in appPath is an
+// In-House one and records when the app must be re-signed. In-house
+// profiles last a year at most; once the profile expires the app stops
+// launching on every device it was deployed to.
+func (r *Resigner) checkEnterpriseProfile(appPath string) error {
+	if !r.config.Enterprise {
+		return nil
+	}
+	profile, err := ParseProfile(filepath.Join(appPath, "embedded.mobileprovision"))
+	if err != nil {
+		return fmt.Errorf("enterprise mode needs a provisioning profile: %w", err)
+	}
+	if kind := profile.Kind(); kind != ProfileInHouse {
+		return fmt.Errorf("profile %q is a %s profile: %w", profile.Name, kind, ErrNotInHouse)
+	}
+	if profile.Expired(time.Now()) {
+		return fmt.Errorf("in-house profile %q expired on %s", profile.Name, profile.ExpirationDate.Format("2006-01-02"))
+	}
+
+	resignBy := profile.ResignBy()
+	r.emitMu.Lock()
+	r.result.ResignBy = resignBy
+	r.emitMu.Unlock()
+	r.logProgress(fmt.Sprintf("In-house profile %q is valid until %s", profile.Name, profile.ExpirationDate.Format("2006-01-02")))
+	r.warn(WarningEnterpriseExpiry, "", "",
+		"Installed copies stop launching when the in-house profile expires on %s; re-sign and redeploy by %s",
+		profile.ExpirationDate.Format("2006-01-02"), resignBy.Format("2006-01-02"))
+	return nil
+}
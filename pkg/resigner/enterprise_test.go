@@ -0,0 +1,63 @@
+package resigner
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// inHouseProfile returns fakeProfile turned into an In-House profile
+func inHouseProfile(appIdentifier string) []byte {
+	return bytes.Replace(fakeProfile(appIdentifier), []byte("<key>Entitlements</key>"),
+		[]byte("<key>ProvisionsAllDevices</key>\n\t<true/>\n\t<key>Entitlements</key>"), 1)
+}
+
+func TestProfileKind(t *testing.T) {
+	for _, test := range []struct {
+		profile Profile
+		want    string
+	}{
+		{Profile{ProvisionsAllDevices: true}, ProfileInHouse},
+		{Profile{ProvisionedDevices: []string{"udid"}, Entitlements: map[string]interface{}{"get-task-allow": true}}, ProfileDevelopment},
+		{Profile{ProvisionedDevices: []string{"udid"}}, ProfileAdHoc},
+		{Profile{}, ProfileAppStore},
+	} {
+		if got := test.profile.Kind(); got != test.want {
+			t.Errorf("Kind() of %+v = %s, want %s", test.profile, got, test.want)
+		}
+	}
+}
+
+func TestCheckEnterpriseProfile(t *testing.T) {
+	app := filepath.Join(t.TempDir(), "Test.app")
+	os.MkdirAll(app, 0755)
+	embedded := filepath.Join(app, "embedded.mobileprovision")
+
+	os.WriteFile(embedded, fakeProfile("TEAM123456.com.company.app"), 0644)
+	r := New(Config{Enterprise: true})
+	if err := r.checkEnterpriseProfile(app); !errors.Is(err, ErrNotInHouse) {
+		t.Errorf("checkEnterpriseProfile() with an App Store profile = %v, want ErrNotInHouse", err)
+	}
+
+	os.WriteFile(embedded, inHouseProfile("TEAM123456.com.company.app"), 0644)
+	r = New(Config{Enterprise: true})
+	if err := r.checkEnterpriseProfile(app); err != nil {
+		t.Fatalf("checkEnterpriseProfile() failed: %v", err)
+	}
+	result := r.Result()
+	if want := time.Date(2029, 12, 3, 3, 4, 5, 0, time.UTC); !result.ResignBy.Equal(want) {
+		t.Errorf("ResignBy = %s, want %s", result.ResignBy, want)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Code != WarningEnterpriseExpiry {
+		t.Errorf("warnings = %+v, want one enterprise-expiry", result.Warnings)
+	}
+
+	// Outside enterprise mode the profile kind does not matter
+	os.WriteFile(embedded, fakeProfile("TEAM123456.com.company.app"), 0644)
+	if err := New(Config{}).checkEnterpriseProfile(app); err != nil {
+		t.Errorf("checkEnterpriseProfile() without enterprise mode = %v", err)
+	}
+}
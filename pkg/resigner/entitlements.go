@@ -0,0 +1,256 @@
+package resigner
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"howett.net/plist"
+)
+
+// EntitlementsError describes a problem found in an entitlements plist
+type EntitlementsError struct {
+	Path string // file the entitlements were read from, if any
+	Line int    // 1-based line of the problem, 0 when unknown
+	Key  string // offending entitlement key, if any
+	Msg  string
+}
+
+func (e *EntitlementsError) Error() string {
+	var b strings.Builder
+	b.WriteString("invalid entitlements")
+	if e.Path != "" {
+		b.WriteString(" ")
+		b.WriteString(e.Path)
+	}
+	if e.Line > 0 {
+		fmt.Fprintf(&b, " (line %d)", e.Line)
+	}
+	b.WriteString(": ")
+	if e.Key != "" {
+		fmt.Fprintf(&b, "%s: ", e.Key)
+	}
+	b.WriteString(e.Msg)
+	return b.String()
+}
+
+// entitlementKind is the plist type an entitlement value must have
+type entitlementKind int
+
+const (
+	kindString entitlementKind = iota
+	kindBool
+	kindStringArray
+	kindStringOrArray
+)
+
+func (k entitlementKind) String() string {
+	switch k {
+	case kindString:
+		return "a string"
+	case kindBool:
+		return "a boolean"
+	case kindStringArray:
+		return "an array of strings"
+	default:
+		return "a string or an array of strings"
+	}
+}
+
+// knownEntitlements lists the value types of commonly used entitlements.
+// Keys not listed here are accepted with any type.
+var knownEntitlements = map[string]entitlementKind{
+	"application-identifier":                               kindString,
+	"beta-reports-active":                                  kindBool,
+	"get-task-allow":                                       kindBool,
+	"keychain-access-groups":                               kindStringArray,
+	"aps-environment":                                      kindString,
+	"com.apple.developer.team-identifier":                  kindString,
+	"com.apple.developer.associated-domains":               kindStringArray,
+	"com.apple.developer.applesignin":                      kindStringArray,
+	"com.apple.developer.default-data-protection":          kindString,
+	"com.apple.developer.game-center":                      kindBool,
+	"com.apple.developer.healthkit":                        kindBool,
+	"com.apple.developer.healthkit.access":                 kindStringArray,
+	"com.apple.developer.homekit":                          kindBool,
+	"com.apple.developer.icloud-container-identifiers":     kindStringArray,
+	"com.apple.developer.icloud-services":                  kindStringOrArray,
+	"com.apple.developer.in-app-payments":                  kindStringArray,
+	"com.apple.developer.networking.networkextension":      kindStringArray,
+	"com.apple.developer.nfc.readersession.formats":        kindStringArray,
+	"com.apple.developer.pass-type-identifiers":            kindStringArray,
+	"com.apple.developer.siri":                             kindBool,
+	"com.apple.developer.ubiquity-kvstore-identifier":      kindString,
+	"com.apple.developer.usernotifications.time-sensitive": kindBool,
+	"com.apple.external-accessory.wireless-configuration":  kindBool,
+	"com.apple.security.application-groups":                kindStringArray,
+}
+
+// ValidateEntitlementsFile checks that path holds a well-formed plist with a
+// dictionary root whose well-known keys have the expected value types
+func ValidateEntitlementsFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := ValidateEntitlements(data); err != nil {
+		var entErr *EntitlementsError
+		if errors.As(err, &entErr) {
+			entErr.Path = path
+		}
+		return err
+	}
+	return nil
+}
+
+// ValidateEntitlements validates raw entitlements plist data (XML or binary)
+func ValidateEntitlements(data []byte) error {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return &EntitlementsError{Msg: "file is empty"}
+	}
+
+	// XML plists are scanned first so syntax errors and bad keys can be
+	// reported with a line number; binary plists have no lines
+	var keyLines map[string]int
+	if !bytes.HasPrefix(data, []byte("bplist")) {
+		lines, err := scanEntitlementKeys(data)
+		if err != nil {
+			return err
+		}
+		keyLines = lines
+	}
+
+	var root interface{}
+	if _, err := plist.Unmarshal(data, &root); err != nil {
+		return &EntitlementsError{Msg: fmt.Sprintf("not a valid property list: %v", err)}
+	}
+
+	dict, ok := root.(map[string]interface{})
+	if !ok {
+		return &EntitlementsError{Msg: fmt.Sprintf("root element must be a dictionary, got %s", plistTypeName(root))}
+	}
+
+	keys := make([]string, 0, len(dict))
+	for key := range dict {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		kind, known := knownEntitlements[key]
+		if !known || entitlementHasKind(dict[key], kind) {
+			continue
+		}
+		return &EntitlementsError{
+			Line: keyLines[key],
+			Key:  key,
+			Msg:  fmt.Sprintf("must be %s, got %s", kind, plistTypeName(dict[key])),
+		}
+	}
+	return nil
+}
+
+// scanEntitlementKeys walks an XML plist, returning the line of each
+// top-level key or a positioned error when the XML is malformed
+func scanEntitlementKeys(data []byte) (map[string]int, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = true
+
+	lines := make(map[string]int)
+	depth := 0
+	inKey := false
+	var keyLine int
+	var keyText strings.Builder
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			var syntaxErr *xml.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				return nil, &EntitlementsError{Line: syntaxErr.Line, Msg: fmt.Sprintf("malformed XML: %s", syntaxErr.Msg)}
+			}
+			return nil, &EntitlementsError{Msg: fmt.Sprintf("malformed XML: %v", err)}
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			// plist > dict > key
+			if depth == 3 && t.Name.Local == "key" {
+				inKey = true
+				keyLine, _ = decoder.InputPos()
+				keyText.Reset()
+			}
+		case xml.CharData:
+			if inKey {
+				keyText.Write(t)
+			}
+		case xml.EndElement:
+			if inKey && t.Name.Local == "key" {
+				lines[keyText.String()] = keyLine
+				inKey = false
+			}
+			depth--
+		}
+	}
+}
+
+// entitlementHasKind reports whether value matches the expected kind
+func entitlementHasKind(value interface{}, kind entitlementKind) bool {
+	switch kind {
+	case kindString:
+		_, ok := value.(string)
+		return ok
+	case kindBool:
+		_, ok := value.(bool)
+		return ok
+	case kindStringArray:
+		return isStringArray(value)
+	default:
+		_, ok := value.(string)
+		return ok || isStringArray(value)
+	}
+}
+
+func isStringArray(value interface{}) bool {
+	items, ok := value.([]interface{})
+	if !ok {
+		return false
+	}
+	for _, item := range items {
+		if _, ok := item.(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// plistTypeName names a decoded plist value's type for error messages
+func plistTypeName(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "a dictionary"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case uint64, int64:
+		return "an integer"
+	case float64:
+		return "a real"
+	case []byte:
+		return "data"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
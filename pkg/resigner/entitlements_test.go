@@ -0,0 +1,129 @@
+package resigner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const plistHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+`
+
+func TestValidateEntitlements(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantErr  bool
+		wantLine int
+		wantKey  string
+	}{
+		{
+			name: "valid",
+			data: plistHeader + `<plist version="1.0">
+<dict>
+	<key>application-identifier</key>
+	<string>TEAM123456.com.example.app</string>
+	<key>get-task-allow</key>
+	<true/>
+	<key>keychain-access-groups</key>
+	<array>
+		<string>TEAM123456.*</string>
+	</array>
+	<key>com.example.custom</key>
+	<integer>1</integer>
+</dict>
+</plist>`,
+		},
+		{
+			name:    "empty file",
+			data:    "  \n",
+			wantErr: true,
+		},
+		{
+			name: "malformed XML",
+			data: plistHeader + `<plist version="1.0">
+<dict>
+	<key>get-task-allow</key>
+	<true/>
+</dic>
+</plist>`,
+			wantErr:  true,
+			wantLine: 7,
+		},
+		{
+			name: "array root",
+			data: plistHeader + `<plist version="1.0">
+<array>
+	<string>a</string>
+</array>
+</plist>`,
+			wantErr: true,
+		},
+		{
+			name: "wrong value type",
+			data: plistHeader + `<plist version="1.0">
+<dict>
+	<key>application-identifier</key>
+	<string>TEAM123456.com.example.app</string>
+	<key>get-task-allow</key>
+	<string>yes</string>
+</dict>
+</plist>`,
+			wantErr:  true,
+			wantLine: 7,
+			wantKey:  "get-task-allow",
+		},
+		{
+			name: "array with non-string items",
+			data: plistHeader + `<plist version="1.0">
+<dict>
+	<key>com.apple.security.application-groups</key>
+	<array>
+		<integer>1</integer>
+	</array>
+</dict>
+</plist>`,
+			wantErr: true,
+			wantKey: "com.apple.security.application-groups",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateEntitlements([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateEntitlements() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+
+			var entErr *EntitlementsError
+			if !errors.As(err, &entErr) {
+				t.Fatalf("Expected *EntitlementsError, got %T", err)
+			}
+			if tt.wantLine != 0 && entErr.Line != tt.wantLine {
+				t.Errorf("Line = %d, want %d (%v)", entErr.Line, tt.wantLine, err)
+			}
+			if entErr.Key != tt.wantKey {
+				t.Errorf("Key = %q, want %q", entErr.Key, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestValidateEntitlementsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.plist")
+	os.WriteFile(path, []byte("not a plist"), 0644)
+
+	err := ValidateEntitlementsFile(path)
+	if err == nil {
+		t.Fatal("Expected error for invalid file")
+	}
+	if !strings.Contains(err.Error(), path) {
+		t.Errorf("Error should mention the file path: %v", err)
+	}
+}
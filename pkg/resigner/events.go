@@ -0,0 +1,57 @@
+package resigner
+
+import "time"
+
+// EventType identifies the kind of progress event emitted during a resign
+type EventType int
+
+const (
+	// EventInfo is a regular progress message
+	EventInfo EventType = iota
+	// EventWarning reports a non-fatal problem; the run continues
+	EventWarning
+	// EventError reports the failure that aborted the run
+	EventError
+	// EventOutput is a line written by an external tool, such as
+	// codesign, while it runs; the message names the tool
+	EventOutput
+)
+
+// String returns a lower-case name for the event type
+func (t EventType) String() string {
+	switch t {
+	case EventInfo:
+		return "info"
+	case EventWarning:
+		return "warning"
+	case EventError:
+		return "error"
+	case EventOutput:
+		return "output"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is a single progress notification
+type Event struct {
+	Type    EventType
+	Message string
+	Time    time.Time
+
+	// Component is the path of the component being signed, if any
+	Component string
+	// Current and Total locate Component within the signing run
+	// (1-based); both are zero for events not tied to a component
+	Current int
+	Total   int
+
+	// Warning details a warning event; the message alone is kept for
+	// handlers that only print it
+	Warning *Warning
+}
+
+// EventHandler receives progress events. It is called synchronously, and
+// never concurrently, from the goroutine running Resign or one of its
+// signing workers, so it should return quickly.
+type EventHandler func(Event)
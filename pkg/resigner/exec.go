@@ -0,0 +1,69 @@
+package resigner
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/resignipa/pkg/command"
+)
+
+// ErrTimeout is wrapped by the error of a run or command that exceeded
+// Config.Timeout or Config.CommandTimeout
+var ErrTimeout = command.ErrTimeout
+
+// timedCmd is an external command bound to the run's context and, when
+// set, Config.CommandTimeout. A command killed because its context ended
+// fails with the reason, e.g. a timeout, rather than "signal: killed".
+type timedCmd struct {
+	*command.Cmd
+	r *Resigner
+}
+
+// command builds an external command bound to the run's context
+func (r *Resigner) command(name string, args ...string) *timedCmd {
+	cmd := command.New(name, args...)
+	cmd.Timeout = r.config.CommandTimeout
+	return &timedCmd{Cmd: cmd, r: r}
+}
+
+// streamed sends the command's output to the event handlers line by line
+// as it is written, for tools whose output is diagnostics rather than
+// data to parse
+func (c *timedCmd) streamed() *timedCmd {
+	tool := filepath.Base(c.Name)
+	c.OnLine = func(stream command.Stream, line string) {
+		if line != "" {
+			c.r.emitEvent(Event{Type: EventOutput, Message: fmt.Sprintf("%s: %s", tool, line)})
+		}
+	}
+	return c
+}
+
+// Run starts the command and waits for it to finish
+func (c *timedCmd) Run() error {
+	_, err := c.Cmd.Run(c.r.ctx)
+	return err
+}
+
+// Output runs the command and returns its standard output
+func (c *timedCmd) Output() ([]byte, error) {
+	result, err := c.Cmd.Run(c.r.ctx)
+	return result.Stdout, err
+}
+
+// CombinedOutput runs the command and returns its standard output and
+// standard error
+func (c *timedCmd) CombinedOutput() ([]byte, error) {
+	result, err := c.Cmd.Run(c.r.ctx)
+	return result.Combined, err
+}
+
+// withRunTimeout bounds ctx by Config.Timeout
+func (r *Resigner) withRunTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.config.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeoutCause(ctx, r.config.Timeout,
+		fmt.Errorf("resign %w after %s", ErrTimeout, r.config.Timeout))
+}
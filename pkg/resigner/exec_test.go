@@ -0,0 +1,74 @@
+package resigner
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandTimeout(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not available")
+	}
+	r := New(Config{CommandTimeout: 50 * time.Millisecond})
+	start := time.Now()
+	_, err := r.command("sleep", "5").CombinedOutput()
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("error = %v, want ErrTimeout", err)
+	}
+	if !strings.Contains(err.Error(), "sleep timed out after 50ms") {
+		t.Errorf("error = %q does not name the command", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("command ran for %v despite the timeout", elapsed)
+	}
+
+	// Commands finishing in time are unaffected
+	if err := r.command("sleep", "0").Run(); err != nil {
+		t.Errorf("quick command failed: %v", err)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	r := New(Config{Timeout: time.Millisecond})
+	ctx, cancel := r.withRunTimeout(context.Background())
+	defer cancel()
+	r.ctx = ctx
+	<-ctx.Done()
+
+	err := r.checkCanceled()
+	if !errors.Is(err, ErrTimeout) || err.Error() != "resign timed out after 1ms" {
+		t.Errorf("checkCanceled() = %v, want run timeout", err)
+	}
+}
+
+func TestStreamedCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	var events []Event
+	r := New(Config{}, WithEventHandler(func(event Event) { events = append(events, event) }))
+	output, err := r.command("sh", "-c", "echo signed; echo replacing existing signature >&2").streamed().CombinedOutput()
+	if err != nil {
+		t.Fatalf("command failed: %v", err)
+	}
+	if len(output) == 0 {
+		t.Error("streamed command returned no output")
+	}
+
+	var lines []string
+	for _, event := range events {
+		if event.Type != EventOutput {
+			t.Errorf("event type = %v, want output", event.Type)
+		}
+		lines = append(lines, event.Message)
+	}
+	sort.Strings(lines)
+	if got := strings.Join(lines, "|"); got != "sh: replacing existing signature|sh: signed" {
+		t.Errorf("output events = %q", got)
+	}
+}
@@ -0,0 +1,167 @@
+package resigner
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FixBundle repairs defects commonly found in IPAs from the wild that make
+// codesign fail or the signed app refuse to launch:
+//
+//   - __MACOSX folders, AppleDouble ("._") files and .DS_Store files left by
+//     Finder and zip tools
+//   - symlinks whose target does not exist
+//   - copies of the app archive (.ipa, or a zip of Payload/) zipped into the
+//     bundle by mistake
+//   - bundle executables that lost their executable bit
+//
+// Compiled resources, such as Metal libraries and Core ML models, are
+// never modified or looked into. It returns a description of every change
+// made.
+func FixBundle(appPath string) ([]string, error) {
+	var fixes []string
+	fixed := func(format string, args ...interface{}) {
+		fixes = append(fixes, fmt.Sprintf(format, args...))
+	}
+
+	err := filepath.WalkDir(appPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(appPath, path)
+		name := d.Name()
+
+		switch {
+		case path == appPath:
+			return nil
+		case isCompiledResource(path):
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		case d.IsDir() && name == "__MACOSX":
+			fixed("Removed %s", rel)
+			if err := os.RemoveAll(path); err != nil {
+				return err
+			}
+			return fs.SkipDir
+		case d.IsDir():
+			return nil
+		case name == ".DS_Store" || strings.HasPrefix(name, "._"):
+			fixed("Removed %s", rel)
+			return os.Remove(path)
+		case d.Type()&fs.ModeSymlink != 0:
+			if _, err := os.Stat(path); err != nil {
+				fixed("Removed broken symlink %s", rel)
+				return os.Remove(path)
+			}
+		case isAppArchive(path):
+			fixed("Removed nested archive %s", rel)
+			return os.Remove(path)
+		}
+		return nil
+	})
+	if err != nil {
+		return fixes, err
+	}
+
+	// Executables are fixed after the walk so removed debris is not
+	// mistaken for a bundle
+	err = filepath.WalkDir(appPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && isCompiledResource(path) {
+			return fs.SkipDir
+		}
+		if !d.IsDir() || !isSignableBundle(path) {
+			return nil
+		}
+		executable := bundleExecutable(path)
+		if executable == "" {
+			return nil
+		}
+		info, err := os.Stat(executable)
+		if err != nil || info.Mode()&0111 == 0111 {
+			return nil
+		}
+		rel, _ := filepath.Rel(appPath, executable)
+		fixed("Made %s executable", rel)
+		if err := breakHardLink(executable); err != nil {
+			return err
+		}
+		return os.Chmod(executable, info.Mode()|0755)
+	})
+	return fixes, err
+}
+
+// isSignableBundle reports whether path is a bundle with its own
+// executable
+func isSignableBundle(path string) bool {
+	switch filepath.Ext(path) {
+	case ".app", ".appex", ".framework", ".bundle", ".xpc":
+		return true
+	}
+	return false
+}
+
+// bundleExecutable returns the path of a bundle's CFBundleExecutable, or
+// "" if it has none
+func bundleExecutable(bundle string) string {
+	info, _, err := readPlistFile(filepath.Join(bundle, "Info.plist"))
+	if err != nil {
+		return ""
+	}
+	executable, _ := info["CFBundleExecutable"].(string)
+	if executable == "" {
+		return ""
+	}
+	return filepath.Join(bundle, executable)
+}
+
+// isAppArchive reports whether path is an IPA, or any zip holding an app,
+// rather than a resource that happens to be a zip
+func isAppArchive(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ipa":
+		return true
+	case ".zip":
+	default:
+		return false
+	}
+
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return false
+	}
+	defer reader.Close()
+	for _, f := range reader.File {
+		top := strings.SplitN(f.Name, "/", 2)[0]
+		if top == "Payload" || strings.HasSuffix(top, ".app") {
+			return true
+		}
+	}
+	return false
+}
+
+// fixBundle runs FixBundle when Config.Fix is set, reporting each repair
+func (r *Resigner) fixBundle(appPath string) error {
+	if !r.config.Fix {
+		return nil
+	}
+	fixes, err := FixBundle(appPath)
+	for _, fix := range fixes {
+		r.logProgress("Fix: " + fix)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fix bundle: %w", err)
+	}
+	if len(fixes) == 0 {
+		r.logProgress("Fix: no bundle defects found")
+	}
+	return nil
+}
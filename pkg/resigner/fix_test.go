@@ -0,0 +1,66 @@
+package resigner
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFixBundle(t *testing.T) {
+	app := filepath.Join(t.TempDir(), "Test.app")
+	writeBundle(t, app, []byte("binary"))
+	os.Chmod(filepath.Join(app, "Test"), 0644)
+
+	framework := filepath.Join(app, "Frameworks", "SDK.framework")
+	os.MkdirAll(filepath.Join(app, "__MACOSX"), 0755)
+	os.MkdirAll(framework, 0755)
+	os.WriteFile(filepath.Join(app, ".DS_Store"), nil, 0644)
+	os.WriteFile(filepath.Join(app, "._Info.plist"), nil, 0644)
+	os.Symlink("Versions/Current/SDK", filepath.Join(framework, "SDK"))
+	os.Symlink("../Info.plist", filepath.Join(app, "Frameworks", "good"))
+	os.WriteFile(filepath.Join(app, "Old.ipa"), nil, 0644)
+
+	// A zip holding an app is debris; one holding resources is not
+	writeZip(t, filepath.Join(app, "Payload.zip"), "Payload/Test.app/Info.plist")
+	writeZip(t, filepath.Join(app, "assets.zip"), "images/logo.png")
+
+	fixes, err := FixBundle(app)
+	if err != nil {
+		t.Fatalf("FixBundle() failed: %v", err)
+	}
+	if len(fixes) != 7 {
+		t.Errorf("FixBundle() made %d fixes, want 7: %v", len(fixes), fixes)
+	}
+
+	for _, gone := range []string{"__MACOSX", ".DS_Store", "._Info.plist", "Frameworks/SDK.framework/SDK", "Old.ipa", "Payload.zip"} {
+		if _, err := os.Lstat(filepath.Join(app, gone)); !os.IsNotExist(err) {
+			t.Errorf("%s was not removed", gone)
+		}
+	}
+	for _, kept := range []string{"Frameworks/good", "assets.zip"} {
+		if _, err := os.Lstat(filepath.Join(app, kept)); err != nil {
+			t.Errorf("%s was removed", kept)
+		}
+	}
+	if info, _ := os.Stat(filepath.Join(app, "Test")); info.Mode()&0111 == 0 {
+		t.Error("main executable is still not executable")
+	}
+}
+
+// writeZip creates a zip archive containing one empty entry
+func writeZip(t *testing.T, path, entry string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	if _, err := w.Create(entry); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,149 @@
+package resigner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/resignipa/pkg/command"
+)
+
+// ErrHook is wrapped by the error of a hook script that failed or timed
+// out
+var ErrHook = errors.New("hook failed")
+
+// DefaultHookTimeout bounds a hook script when Config.HookTimeout is zero
+const DefaultHookTimeout = 5 * time.Minute
+
+// hookEnv lists the variables a hook inherits. Everything else, such as
+// RESIGNIPA_ARCHIVE_PASSWORD or a CI system's tokens, is withheld.
+var hookEnv = []string{"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TMPDIR", "LANG", "LC_ALL"}
+
+// parseHook splits a Config.Hooks key such as "after-sign" into when the
+// hook runs and the stage it runs around
+func parseHook(name string) (when, stage string, err error) {
+	when, stage, _ = strings.Cut(name, "-")
+	if (when != "before" && when != "after") || stage == "" {
+		return "", "", fmt.Errorf("invalid hook %q (use before-<stage> or after-<stage>, e.g. after-sign)", name)
+	}
+	return when, stage, nil
+}
+
+// validateHooks checks that every hook names a known stage and an
+// executable script, resolving the scripts to absolute paths since hooks
+// run in the workspace
+func (r *Resigner) validateHooks() error {
+	r.hooks = make(map[string]string, len(r.config.Hooks))
+	known := append(DefaultPipeline().Names(), r.pipeline.Names()...)
+	for name, script := range r.config.Hooks {
+		_, stage, err := parseHook(name)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(known, stage) {
+			return fmt.Errorf("hook %s: unknown stage %q (stages: %s)", name, stage, strings.Join(r.pipeline.Names(), ", "))
+		}
+		path, err := filepath.Abs(script)
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("hook %s: %w", name, err)
+		}
+		if info.IsDir() || info.Mode().Perm()&0111 == 0 {
+			return fmt.Errorf("hook %s: %s is not an executable file", name, script)
+		}
+		r.hooks[name] = path
+	}
+	return nil
+}
+
+// hookEnvironment returns the environment of the hook name: the
+// allowlisted variables of hookEnv plus RESIGNIPA_* variables describing
+// the run. Values derived from the run are set even when empty, so a
+// script can tell "not yet known" from "not provided".
+func (r *Resigner) hookEnvironment(name, stage string, state *State) []string {
+	var env []string
+	for _, key := range hookEnv {
+		if value, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+value)
+		}
+	}
+	bundleID, _ := r.currentBundleID(state.AppPath)
+	return append(env,
+		"RESIGNIPA_HOOK="+name,
+		"RESIGNIPA_STAGE="+stage,
+		"RESIGNIPA_WORKSPACE="+state.Workspace.Root,
+		"RESIGNIPA_APP="+state.AppPath,
+		"RESIGNIPA_ENTITLEMENTS="+state.EntitlementsPath,
+		"RESIGNIPA_BUNDLE_ID="+bundleID,
+		"RESIGNIPA_OUTPUT="+r.outputPath,
+	)
+}
+
+// currentBundleID reads the bundle identifier of the app being signed,
+// once it has been extracted
+func (r *Resigner) currentBundleID(appPath string) (string, error) {
+	if appPath == "" {
+		return "", nil
+	}
+	return readBundleIdentifier(filepath.Join(appPath, "Info.plist"))
+}
+
+// runHook runs the hook script configured for name, if any. The script
+// runs as the signing user, with that user's access to files and
+// keychains, but is kept from the run's own credentials:
+//
+//   - its environment holds only hookEnv and the RESIGNIPA_* variables of
+//     hookEnvironment, so secrets passed through the environment stay out
+//   - the temporary keychain of Config.P12 is locked until it exits
+//   - its working directory is the run's workspace
+//   - the files of the extracted app share no hard links with the source
+//     or cache, so editing them in place cannot change those
+//   - it gets no stdin, and is killed after Config.HookTimeout
+//   - its output is captured line by line as EventOutput events, with
+//     secrets redacted, rather than written to the terminal
+//
+// A script exiting non-zero, or timing out, fails the run with ErrHook.
+func (r *Resigner) runHook(name, stage string, state *State) error {
+	script, ok := r.hooks[name]
+	if !ok {
+		return nil
+	}
+	r.logProgress(fmt.Sprintf("Running %s hook %s", name, filepath.Base(script)))
+	if state.AppPath != "" {
+		if err := breakHardLinks(state.AppPath); err != nil {
+			return fmt.Errorf("failed to prepare the app for the %s hook: %w", name, err)
+		}
+	}
+
+	cmd := command.New(script)
+	cmd.Dir = state.Workspace.Root
+	cmd.CleanEnv = true
+	cmd.Env = r.hookEnvironment(name, stage, state)
+	cmd.Timeout = r.config.HookTimeout
+	if cmd.Timeout <= 0 {
+		cmd.Timeout = DefaultHookTimeout
+	}
+	cmd.OnLine = func(stream command.Stream, line string) {
+		if line != "" {
+			r.emitEvent(Event{Type: EventOutput, Message: fmt.Sprintf("%s hook: %s", name, line)})
+		}
+	}
+	if err := r.lockSigningKeychain(); err != nil {
+		return fmt.Errorf("failed to lock the keychain for the %s hook: %w", name, err)
+	}
+	_, err := cmd.Run(r.ctx)
+	if unlockErr := r.unlockSigningKeychain(); unlockErr != nil && err == nil {
+		return fmt.Errorf("failed to unlock the keychain after the %s hook: %w", name, unlockErr)
+	}
+	if err != nil {
+		return fmt.Errorf("%s %w: %v", name, ErrHook, err)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package resigner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeHook writes an executable shell script and returns its path
+func writeHook(t *testing.T, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks are shell scripts")
+	}
+	path := filepath.Join(t.TempDir(), "hook.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseHook(t *testing.T) {
+	if when, stage, err := parseHook("after-bundle-id"); err != nil || when != "after" || stage != "bundle-id" {
+		t.Errorf("parseHook(after-bundle-id) = %q, %q, %v", when, stage, err)
+	}
+	for _, name := range []string{"sign", "during-sign", "after-", ""} {
+		if _, _, err := parseHook(name); err == nil {
+			t.Errorf("parseHook(%q) succeeded", name)
+		}
+	}
+}
+
+func TestValidateHooks(t *testing.T) {
+	script := writeHook(t, "true")
+	notExecutable := filepath.Join(t.TempDir(), "hook.sh")
+	os.WriteFile(notExecutable, []byte("#!/bin/sh\n"), 0644)
+
+	tests := []struct {
+		hooks   map[string]string
+		wantErr string
+	}{
+		{map[string]string{"after-sign": script, "before-package": script}, ""},
+		{map[string]string{"after-notarize": script}, "unknown stage"},
+		{map[string]string{"after-sign": notExecutable}, "not an executable"},
+		{map[string]string{"after-sign": filepath.Join(t.TempDir(), "missing.sh")}, "no such file"},
+	}
+	for _, tt := range tests {
+		r := New(Config{Hooks: tt.hooks})
+		err := r.validateHooks()
+		if tt.wantErr == "" {
+			if err != nil || len(r.hooks) != len(tt.hooks) {
+				t.Errorf("validateHooks(%v) = %v, hooks %v", tt.hooks, err, r.hooks)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("validateHooks(%v) = %v, want %q", tt.hooks, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRunHookSandbox(t *testing.T) {
+	t.Setenv("RESIGNIPA_ARCHIVE_PASSWORD", "hunter2")
+	t.Setenv("CI_TOKEN", "s3cret")
+	script := writeHook(t, `echo "$RESIGNIPA_HOOK $RESIGNIPA_STAGE $(pwd)"
+echo "password=${RESIGNIPA_ARCHIVE_PASSWORD:-unset} token=${CI_TOKEN:-unset}"
+echo "leaked hunter2" >&2
+cat`)
+
+	var output []string
+	r := New(Config{Hooks: map[string]string{"after-sign": script}},
+		WithEventHandler(func(e Event) {
+			if e.Type == EventOutput {
+				output = append(output, e.Message)
+			}
+		}), WithSecrets("hunter2"))
+	r.ctx = context.Background()
+	if err := r.validateHooks(); err != nil {
+		t.Fatal(err)
+	}
+	workspace, _ := filepath.EvalSymlinks(t.TempDir())
+	state := &State{Workspace: Workspace{Root: workspace}}
+	if err := r.runHook("after-sign", StageSign, state); err != nil {
+		t.Fatalf("runHook() failed: %v", err)
+	}
+
+	got := strings.Join(output, "\n")
+	for _, want := range []string{
+		"after-sign hook: after-sign sign " + workspace,
+		"after-sign hook: password=unset token=unset",
+		"after-sign hook: leaked [REDACTED]",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("hook output %q lacks %q", got, want)
+		}
+	}
+	if err := r.runHook("before-sign", StageSign, state); err != nil {
+		t.Errorf("runHook() of an unconfigured hook = %v", err)
+	}
+}
+
+func TestRunHookFailure(t *testing.T) {
+	for body, want := range map[string]string{
+		"exit 3":       "exit status 3",
+		"exec sleep 5": "timed out",
+	} {
+		r := New(Config{Hooks: map[string]string{"before-package": writeHook(t, body)}, HookTimeout: 100 * time.Millisecond})
+		r.ctx = context.Background()
+		if err := r.validateHooks(); err != nil {
+			t.Fatal(err)
+		}
+		err := r.runHook("before-package", StagePackage, &State{Workspace: Workspace{Root: t.TempDir()}})
+		if !errors.Is(err, ErrHook) || !strings.Contains(err.Error(), want) {
+			t.Errorf("runHook(%q) = %v, want ErrHook with %q", body, err, want)
+		}
+	}
+}
+
+func TestRunHookBreaksHardLinks(t *testing.T) {
+	script := writeHook(t, `echo modified > "$RESIGNIPA_APP/asset.car"`)
+	root := t.TempDir()
+	source := filepath.Join(root, "source.car")
+	app := filepath.Join(root, "Test.app")
+	os.MkdirAll(app, 0755)
+	os.WriteFile(source, []byte("source"), 0644)
+	if err := os.Link(source, filepath.Join(app, "asset.car")); err != nil {
+		t.Skipf("hard links unsupported: %v", err)
+	}
+
+	r := New(Config{Hooks: map[string]string{"before-sign": script}})
+	r.ctx = context.Background()
+	if err := r.validateHooks(); err != nil {
+		t.Fatal(err)
+	}
+	state := &State{Workspace: Workspace{Root: root}, AppPath: app}
+	if err := r.runHook("before-sign", StageSign, state); err != nil {
+		t.Fatalf("runHook() failed: %v", err)
+	}
+	if data, _ := os.ReadFile(source); string(data) != "source" {
+		t.Errorf("source changed to %q through a hard link", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(app, "asset.car")); string(data) != "modified\n" {
+		t.Errorf("app file = %q, want the hook's edit", data)
+	}
+}
+
+func TestRunHookLocksKeychain(t *testing.T) {
+	calls := fakeSecurity(t, "none")
+	for body, wantErr := range map[string]bool{"": false, "exit 3": true} {
+		os.Remove(calls)
+		script := writeHook(t, "echo hook >> "+calls+"\n"+body)
+		r := New(Config{Hooks: map[string]string{"after-sign": script}})
+		r.ctx = context.Background()
+		r.tempKeychain, r.tempKeychainPassword = "/tmp/signing.keychain-db", "secret"
+		if err := r.validateHooks(); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.runHook("after-sign", StageSign, &State{Workspace: Workspace{Root: t.TempDir()}}); (err != nil) != wantErr {
+			t.Errorf("runHook(%q) = %v", body, err)
+		}
+
+		// Unlocked again even when the hook fails
+		data, _ := os.ReadFile(calls)
+		want := "lock-keychain /tmp/signing.keychain-db\nhook\nunlock-keychain -p secret /tmp/signing.keychain-db\n"
+		if string(data) != want {
+			t.Errorf("calls around hook %q:\n%s\nwant:\n%s", body, data, want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package resigner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SigningIdentity is a code signing certificate with a private key in the
+// keychain
+type SigningIdentity struct {
+	// Hash is the certificate's SHA-1 fingerprint
+	Hash string
+	// Name is the certificate's common name
+	Name string
+}
+
+// identityLine matches an identity listed by security find-identity, e.g.
+// `  1) 0123...CDEF "Apple Development: Jane Doe (ABCDE12345)"`
+var identityLine = regexp.MustCompile(`^\s*\d+\)\s+([0-9A-Fa-f]{40})\s+"(.*)"`)
+
+// ParseIdentities reads the output of security find-identity, dropping
+// identities listed once per keychain
+func ParseIdentities(output string) []SigningIdentity {
+	var identities []SigningIdentity
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		m := identityLine.FindStringSubmatch(line)
+		if m == nil || seen[strings.ToUpper(m[1])] {
+			continue
+		}
+		seen[strings.ToUpper(m[1])] = true
+		identities = append(identities, SigningIdentity{Hash: strings.ToUpper(m[1]), Name: m[2]})
+	}
+	return identities
+}
+
+// matchIdentity picks the identity codesign would use for certificate: the
+// one with that SHA-1 hash, the one with exactly that name, or the only
+// one whose name contains it
+func matchIdentity(identities []SigningIdentity, certificate string) (SigningIdentity, error) {
+	var partial []SigningIdentity
+	for _, identity := range identities {
+		if strings.EqualFold(identity.Hash, certificate) || identity.Name == certificate {
+			return identity, nil
+		}
+		if strings.Contains(identity.Name, certificate) {
+			partial = append(partial, identity)
+		}
+	}
+
+	switch len(partial) {
+	case 0:
+		return SigningIdentity{}, fmt.Errorf("signing certificate %q not found in the keychain, or it is expired or untrusted", certificate)
+	case 1:
+		return partial[0], nil
+	}
+	names := make([]string, len(partial))
+	for i, identity := range partial {
+		names[i] = fmt.Sprintf("%q", identity.Name)
+	}
+	return SigningIdentity{}, fmt.Errorf("signing certificate %q is ambiguous: matches %s", certificate, strings.Join(names, ", "))
+}
+
+// preflightIdentity resolves the signing identity and signs a scratch file
+// with it before any real work, so a missing certificate or inaccessible
+// private key fails, or prompts for keychain access, once and up front
+// instead of on the first component minutes into the run. Components are
+// then signed by the identity's hash, which is unambiguous. The identities
+// of Config.ComponentIdentities are checked the same way.
+func (r *Resigner) preflightIdentity() error {
+	r.componentIdentities = make(map[string]SigningIdentity, len(r.config.ComponentIdentities))
+	exts := make([]string, 0, len(r.config.ComponentIdentities))
+	external := false
+	for ext, certificate := range r.config.ComponentIdentities {
+		exts = append(exts, ext)
+		if certificate == "-" {
+			r.componentIdentities[componentIdentityKey(ext)] = SigningIdentity{Hash: "-", Name: "-"}
+		} else {
+			external = true
+		}
+	}
+	if r.isAdHoc() && !external {
+		return nil
+	}
+	sort.Strings(exts)
+	r.logProgress("Checking signing identity")
+
+	cmd := r.command("security", "find-identity", "-v", "-p", "codesigning")
+	cmd.Args = append(cmd.Args, r.keychainArgs()...)
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("failed to list signing certificates: %w", err)
+	}
+	identities := ParseIdentities(string(output))
+
+	if !r.isAdHoc() {
+		identity, err := r.checkIdentity(identities, r.config.Certificate)
+		if err != nil {
+			return err
+		}
+		r.signingIdentity = identity
+		r.logProgress(fmt.Sprintf("Signing as %s (%s)", identity.Name, identity.Hash))
+	}
+	for _, ext := range exts {
+		certificate := r.config.ComponentIdentities[ext]
+		if certificate == "-" {
+			continue
+		}
+		identity, err := r.checkIdentity(identities, certificate)
+		if err != nil {
+			return fmt.Errorf("%s components: %w", componentIdentityKey(ext), err)
+		}
+		r.componentIdentities[componentIdentityKey(ext)] = identity
+		r.logProgress(fmt.Sprintf("Signing %s components as %s (%s)", componentIdentityKey(ext), identity.Name, identity.Hash))
+	}
+	return nil
+}
+
+// checkIdentity picks the identity for certificate and makes sure it can
+// sign
+func (r *Resigner) checkIdentity(identities []SigningIdentity, certificate string) (SigningIdentity, error) {
+	identity, err := matchIdentity(identities, certificate)
+	if err != nil {
+		return SigningIdentity{}, err
+	}
+
+	scratch := filepath.Join(r.workspace.Root, "identity-check")
+	if err := os.WriteFile(scratch, []byte("resignipa"), 0644); err != nil {
+		return SigningIdentity{}, err
+	}
+	defer os.Remove(scratch)
+	cmd := r.command("/usr/bin/codesign", "-f", "-s", identity.Hash)
+	cmd.Args = append(append(cmd.Args, r.codesignKeychainArgs()...), scratch)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return SigningIdentity{}, fmt.Errorf("certificate %q cannot sign, check that its private key is in an unlocked keychain: %s - %w",
+			identity.Name, strings.TrimSpace(string(output)), err)
+	}
+	return identity, nil
+}
+
+// componentIdentityKey normalizes a Config.ComponentIdentities key to the
+// lower-case extension with its dot, such as ".xpc"
+func componentIdentityKey(ext string) string {
+	ext = strings.ToLower(ext)
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// componentIdentity returns the identity Config.ComponentIdentities sets
+// for component, if any
+func (r *Resigner) componentIdentity(component string) (SigningIdentity, bool) {
+	if len(r.config.ComponentIdentities) == 0 {
+		return SigningIdentity{}, false
+	}
+	key := strings.ToLower(filepath.Ext(component))
+	if identity, ok := r.componentIdentities[key]; ok {
+		return identity, true
+	}
+	// Not resolved by preflightIdentity; codesign matches the name itself
+	for ext, certificate := range r.config.ComponentIdentities {
+		if componentIdentityKey(ext) == key {
+			return SigningIdentity{Hash: certificate, Name: certificate}, true
+		}
+	}
+	return SigningIdentity{}, false
+}
+
+// certificateName returns the common name of the signing certificate,
+// resolved by preflightIdentity when it has run
+func (r *Resigner) certificateName() string {
+	if r.signingIdentity.Name != "" {
+		return r.signingIdentity.Name
+	}
+	return r.config.Certificate
+}
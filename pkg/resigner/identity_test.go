@@ -0,0 +1,93 @@
+package resigner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const findIdentityOutput = `  1) 1111111111111111111111111111111111111111 "Apple Development: Jane Doe (ABCDE12345)"
+  2) 2222222222222222222222222222222222222222 "Apple Distribution: Example Inc (TEAM123456)"
+  3) 3333333333333333333333333333333333333333 "Apple Development: Jane Doe (ZYXWV98765)"
+  4) 1111111111111111111111111111111111111111 "Apple Development: Jane Doe (ABCDE12345)"
+     4 valid identities found
+`
+
+func TestParseIdentities(t *testing.T) {
+	identities := ParseIdentities(findIdentityOutput)
+	if len(identities) != 3 {
+		t.Fatalf("ParseIdentities() found %d identities, want 3: %v", len(identities), identities)
+	}
+	if identities[1].Name != "Apple Distribution: Example Inc (TEAM123456)" || identities[1].Hash != strings.Repeat("2", 40) {
+		t.Errorf("ParseIdentities()[1] = %+v", identities[1])
+	}
+}
+
+func TestMatchIdentity(t *testing.T) {
+	identities := ParseIdentities(findIdentityOutput)
+	tests := []struct {
+		certificate string
+		wantHash    string
+		wantErr     string
+	}{
+		{"Apple Distribution: Example Inc (TEAM123456)", strings.Repeat("2", 40), ""},
+		{"Apple Distribution", strings.Repeat("2", 40), ""},
+		{strings.Repeat("3", 40), strings.Repeat("3", 40), ""},
+		{"Apple Development: Jane Doe", "", "ambiguous"},
+		{"iPhone Developer", "", "not found"},
+	}
+
+	for _, tt := range tests {
+		identity, err := matchIdentity(identities, tt.certificate)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("matchIdentity(%q) error = %v, want %q", tt.certificate, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || identity.Hash != tt.wantHash {
+			t.Errorf("matchIdentity(%q) = %+v, %v, want hash %s", tt.certificate, identity, err, tt.wantHash)
+		}
+	}
+}
+
+func TestComponentIdentities(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "test.ipa")
+	if err := os.WriteFile(source, []byte("ipa"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		identities map[string]string
+		wantErr    bool
+	}{
+		{"xpc", map[string]string{".xpc": "Developer ID Application"}, false},
+		{"no dot, upper case", map[string]string{"XPC": "-"}, false},
+		{"app", map[string]string{".app": "Developer ID Application"}, true},
+		{"no handler", map[string]string{".systemextension": "Developer ID Application"}, true},
+		{"empty identity", map[string]string{".dylib": ""}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(Config{SourceIPA: source, Certificate: "Apple Development", ComponentIdentities: tt.identities})
+			if err := r.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	r := New(Config{Certificate: "Apple Development", ComponentIdentities: map[string]string{"xpc": "-", ".dylib": "Developer ID"}})
+	r.signingIdentity = SigningIdentity{Hash: strings.Repeat("1", 40), Name: "Apple Development"}
+	for component, want := range map[string]string{
+		"App.app/XPCServices/Helper.xpc":   "-",
+		"App.app/Frameworks/libKit.dylib":  "Developer ID",
+		"App.app/Frameworks/Kit.framework": strings.Repeat("1", 40),
+		"App.app":                          strings.Repeat("1", 40),
+	} {
+		if got := r.identityFor(component); got != want {
+			t.Errorf("identityFor(%q) = %q, want %q", component, got, want)
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package resigner
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"howett.net/plist"
+)
+
+// Inspection summarizes an IPA or .app so it can be sanity-checked before
+// resigning: what the app is, who signed it, which profile it carries and
+// what it is made of
+type Inspection struct {
+	// Path is the main app's location relative to the IPA or .app root
+	Path      string `json:"path"`
+	Name      string `json:"name"`
+	BundleID  string `json:"bundle_id"`
+	Version   string `json:"version"`
+	Build     string `json:"build"`
+	MinimumOS string `json:"minimum_os,omitempty"`
+
+	Signature CodeSignature `json:"signature"`
+	// Profile is the embedded provisioning profile, nil if there is none
+	Profile *Profile `json:"profile,omitempty"`
+
+	// Components lists the app and everything signed inside it, outer
+	// components first
+	Components []InspectedComponent `json:"components"`
+}
+
+// CodeSignature describes how a binary is currently signed
+type CodeSignature struct {
+	Signed     bool   `json:"signed"`
+	Identifier string `json:"identifier,omitempty"`
+	TeamID     string `json:"team_id,omitempty"`
+	// Authority is the signing certificate's common name; empty for
+	// ad-hoc signatures
+	Authority string `json:"authority,omitempty"`
+	// Expires is when the signing certificate expires; zero for ad-hoc
+	// signatures
+	Expires time.Time `json:"expires"`
+}
+
+// InspectedComponent is one node of an app's component tree
+type InspectedComponent struct {
+	// Path is relative to the IPA or .app root, e.g.
+	// "Test.app/Frameworks/Kit.framework"
+	Path string `json:"path"`
+	// Kind is the component's extension, e.g. ".framework"
+	Kind string `json:"kind"`
+	// Depth is the number of components enclosing this one
+	Depth int `json:"depth"`
+	// Size is the uncompressed size in bytes, nested components included
+	Size int64 `json:"size"`
+}
+
+// Inspect summarizes the app in an IPA or .app directory. Everything is
+// read in place, so nothing is extracted or executed.
+func Inspect(source string) (*Inspection, error) {
+	fsys, root, close, err := openBundleFS(source)
+	if err != nil {
+		return nil, err
+	}
+	defer close()
+
+	components, dirs, err := inspectComponents(fsys, root)
+	if err != nil {
+		return nil, err
+	}
+	if len(components) == 0 || components[0].Kind != ".app" {
+		return nil, fmt.Errorf("no app bundle found in %s", source)
+	}
+	app := dirs[0]
+
+	data, err := fs.ReadFile(fsys, path.Join(app, "Info.plist"))
+	if err != nil {
+		return nil, err
+	}
+	var info map[string]interface{}
+	if _, err := plist.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse %s/Info.plist: %w", app, err)
+	}
+	str := func(key string) string {
+		s, _ := info[key].(string)
+		return s
+	}
+
+	inspection := &Inspection{
+		Path:       components[0].Path,
+		Name:       str("CFBundleDisplayName"),
+		BundleID:   str("CFBundleIdentifier"),
+		Version:    str("CFBundleShortVersionString"),
+		Build:      str("CFBundleVersion"),
+		MinimumOS:  str("MinimumOSVersion"),
+		Components: components,
+	}
+	if inspection.Name == "" {
+		inspection.Name = str("CFBundleName")
+	}
+
+	if executable := str("CFBundleExecutable"); executable != "" {
+		binary, err := fs.ReadFile(fsys, path.Join(app, executable))
+		if err != nil {
+			return nil, err
+		}
+		sig, err := machoSignature(bytes.NewReader(binary))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signature of %s: %w", path.Join(app, executable), err)
+		}
+		if sig != nil {
+			signature, err := parseCodeSignature(sig)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read signature of %s: %w", path.Join(app, executable), err)
+			}
+			inspection.Signature = *signature
+		}
+	}
+
+	if data, err := fs.ReadFile(fsys, path.Join(app, "embedded.mobileprovision")); err == nil {
+		profile, err := ParseProfileData(data)
+		if err != nil {
+			return nil, err
+		}
+		inspection.Profile = profile
+	}
+	return inspection, nil
+}
+
+// inspectComponents lists the components under root with their sizes,
+// along with the directory each was found at
+func inspectComponents(fsys fs.FS, root string) ([]InspectedComponent, []string, error) {
+	var components []InspectedComponent
+	var dirs []string
+	index := make(map[string]int)
+	var files []string
+	sizes := make(map[string]int64)
+
+	err := fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := strings.ToLower(path.Ext(p))
+		if d.IsDir() {
+			if !isInspectedBundle(fsys, p, ext) {
+				return nil
+			}
+		} else {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			files = append(files, p)
+			sizes[p] = info.Size()
+			if ext != ".dylib" {
+				return nil
+			}
+		}
+
+		depth := 0
+		for dir := path.Dir(p); dir != "."; dir = path.Dir(dir) {
+			if _, ok := index[dir]; ok {
+				depth++
+			}
+		}
+		// Loose dylibs outside the app, e.g. in SwiftSupport, are not its code
+		if depth == 0 && !d.IsDir() {
+			return nil
+		}
+		index[p] = len(components)
+		dirs = append(dirs, p)
+		components = append(components, InspectedComponent{
+			Path:  strings.TrimPrefix(p, "Payload/"),
+			Kind:  ext,
+			Depth: depth,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Every file counts towards each component enclosing it
+	for _, file := range files {
+		for p := file; p != "."; p = path.Dir(p) {
+			if i, ok := index[p]; ok {
+				components[i].Size += sizes[file]
+			}
+		}
+	}
+	return components, dirs, nil
+}
+
+// isInspectedBundle reports whether the directory p belongs in the
+// component tree. As when signing, .bundle and .xpc directories only count
+// when they hold an executable.
+func isInspectedBundle(fsys fs.FS, p, ext string) bool {
+	switch ext {
+	case ".app", ".appex", ".framework":
+		return true
+	case ".bundle", ".xpc":
+		data, err := fs.ReadFile(fsys, path.Join(p, "Info.plist"))
+		if err != nil {
+			return false
+		}
+		var info map[string]interface{}
+		if _, err := plist.Unmarshal(data, &info); err != nil {
+			return false
+		}
+		executable, _ := info["CFBundleExecutable"].(string)
+		return executable != ""
+	}
+	return false
+}
+
+// parseCodeSignature reads the identifier, team and signing certificate
+// from an embedded signature superblob
+func parseCodeSignature(sig []byte) (*CodeSignature, error) {
+	signature := &CodeSignature{Signed: true}
+
+	directory, err := signatureBlob(sig, csSlotCodeDirectory, csMagicCodeDirectory)
+	if err != nil {
+		return nil, err
+	}
+	if directory != nil {
+		signature.Identifier, signature.TeamID = codeDirectoryIDs(directory)
+	}
+
+	wrapper, err := signatureBlob(sig, csSlotCMSSignature, csMagicBlobWrapper)
+	if err != nil {
+		return nil, err
+	}
+	// Ad-hoc signatures carry an empty wrapper
+	if len(wrapper) <= 8 {
+		return signature, nil
+	}
+	leaf, err := cmsSigner(wrapper[8:])
+	if err != nil {
+		return nil, err
+	}
+	if leaf != nil {
+		signature.Authority = leaf.Subject.CommonName
+		signature.Expires = leaf.NotAfter
+		if signature.TeamID == "" {
+			for _, ou := range leaf.Subject.OrganizationalUnit {
+				if teamIDPattern.MatchString(ou) {
+					signature.TeamID = ou
+				}
+			}
+		}
+	}
+	return signature, nil
+}
+
+// CodeDirectory fields from <Security/CodeDirectory.h>
+const (
+	codeDirectoryIdentOffset = 20
+	codeDirectoryTeamOffset  = 48
+	// codeDirectoryTeamVersion is the first version with a team ID
+	codeDirectoryTeamVersion = 0x20200
+)
+
+// codeDirectoryIDs returns the signing identifier and team ID recorded in
+// a CodeDirectory blob
+func codeDirectoryIDs(directory []byte) (identifier, team string) {
+	be := binary.BigEndian
+	cString := func(offset uint32) string {
+		if offset == 0 || int(offset) >= len(directory) {
+			return ""
+		}
+		s := directory[offset:]
+		if end := bytes.IndexByte(s, 0); end >= 0 {
+			s = s[:end]
+		}
+		return string(s)
+	}
+
+	if len(directory) >= codeDirectoryIdentOffset+4 {
+		identifier = cString(be.Uint32(directory[codeDirectoryIdentOffset:]))
+	}
+	if len(directory) >= codeDirectoryTeamOffset+4 && be.Uint32(directory[8:]) >= codeDirectoryTeamVersion {
+		team = cString(be.Uint32(directory[codeDirectoryTeamOffset:]))
+	}
+	return identifier, team
+}
+
+// cmsContentInfo and cmsSignedData are the parts of a CMS signature
+// (RFC 5652) needed to find the certificates it carries
+type cmsContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	// Content is explicitly tagged [0]; its Bytes hold the SignedData
+	Content asn1.RawValue `asn1:"tag:0"`
+}
+
+type cmsSignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	EncapContentInfo asn1.RawValue
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+// cmsSigner returns the signing certificate of a CMS signature: the one
+// certificate that is not a CA, as the chain Apple embeds holds the leaf
+// followed by its intermediates
+func cmsSigner(der []byte) (*x509.Certificate, error) {
+	var content cmsContentInfo
+	if _, err := asn1.Unmarshal(der, &content); err != nil {
+		return nil, fmt.Errorf("failed to parse CMS signature: %w", err)
+	}
+	var signed cmsSignedData
+	if _, err := asn1.Unmarshal(content.Content.Bytes, &signed); err != nil {
+		return nil, fmt.Errorf("failed to parse CMS signed data: %w", err)
+	}
+	if len(signed.Certificates.Bytes) == 0 {
+		return nil, nil
+	}
+	certificates, err := x509.ParseCertificates(signed.Certificates.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing certificates: %w", err)
+	}
+	for _, cert := range certificates {
+		if !cert.IsCA {
+			return cert, nil
+		}
+	}
+	return nil, nil
+}
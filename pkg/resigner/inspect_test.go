@@ -0,0 +1,254 @@
+package resigner
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"debug/macho"
+	"encoding/asn1"
+	"encoding/binary"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// buildSignatureMachO returns a minimal 64-bit Mach-O whose code signature
+// superblob holds blobs in the given slots
+func buildSignatureMachO(slots []uint32, blobs ...[]byte) []byte {
+	le, be := binary.LittleEndian, binary.BigEndian
+	var data []byte
+	for _, v := range []uint32{macho.Magic64, uint32(macho.CpuArm64), 0, uint32(macho.TypeExec), 1, 16, 0, 0} {
+		data = le.AppendUint32(data, v)
+	}
+
+	offset := 12 + 8*len(slots)
+	var index, body []byte
+	for i, slot := range slots {
+		index = be.AppendUint32(index, slot)
+		index = be.AppendUint32(index, uint32(offset+len(body)))
+		body = append(body, blobs[i]...)
+	}
+	var sig []byte
+	for _, v := range []uint32{csMagicEmbeddedSignature, uint32(offset + len(body)), uint32(len(slots))} {
+		sig = be.AppendUint32(sig, v)
+	}
+	sig = append(append(sig, index...), body...)
+
+	for _, v := range []uint32{uint32(loadCmdCodeSignature), 16, uint32(len(data) + 16), uint32(len(sig))} {
+		data = le.AppendUint32(data, v)
+	}
+	return append(data, sig...)
+}
+
+// buildCodeDirectory returns a CodeDirectory blob recording identifier
+// and team
+func buildCodeDirectory(identifier, team string) []byte {
+	be := binary.BigEndian
+	directory := make([]byte, codeDirectoryTeamOffset+4)
+	strings := append(append([]byte(identifier), 0), append([]byte(team), 0)...)
+	be.PutUint32(directory[0:], csMagicCodeDirectory)
+	be.PutUint32(directory[4:], uint32(len(directory)+len(strings)))
+	be.PutUint32(directory[8:], 0x20400)
+	be.PutUint32(directory[codeDirectoryIdentOffset:], uint32(len(directory)))
+	be.PutUint32(directory[codeDirectoryTeamOffset:], uint32(len(directory)+len(identifier)+1))
+	return append(directory, strings...)
+}
+
+// buildCMSWrapper returns a blob wrapper holding a CMS signature that
+// carries a CA certificate followed by a leaf issued to commonName
+func buildCMSWrapper(t *testing.T, commonName, team string, notAfter time.Time) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate := func(serial int64, subject pkix.Name, ca bool) []byte {
+		template := &x509.Certificate{
+			SerialNumber:          big.NewInt(serial),
+			Subject:               subject,
+			NotBefore:             notAfter.AddDate(-1, 0, 0),
+			NotAfter:              notAfter,
+			IsCA:                  ca,
+			BasicConstraintsValid: true,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+	certs := certificate(1, pkix.Name{CommonName: "Apple Worldwide Developer Relations Certification Authority"}, true)
+	certs = append(certs, certificate(2, pkix.Name{CommonName: commonName, OrganizationalUnit: []string{team}}, false)...)
+
+	set := asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true}
+	signedData, err := asn1.Marshal(struct {
+		Version          int
+		DigestAlgorithms asn1.RawValue
+		EncapContentInfo []asn1.ObjectIdentifier
+		Certificates     asn1.RawValue
+		SignerInfos      asn1.RawValue
+	}{
+		Version:          1,
+		DigestAlgorithms: set,
+		EncapContentInfo: []asn1.ObjectIdentifier{{1, 2, 840, 113549, 1, 7, 1}},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certs},
+		SignerInfos:      set,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cms, err := asn1.Marshal(struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue
+	}{
+		ContentType: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2},
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signedData},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wrapper := binary.BigEndian.AppendUint32(nil, csMagicBlobWrapper)
+	wrapper = binary.BigEndian.AppendUint32(wrapper, uint32(8+len(cms)))
+	return append(wrapper, cms...)
+}
+
+const testInspectInfo = `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>CFBundleExecutable</key>
+	<string>Test</string>
+	<key>CFBundleIdentifier</key>
+	<string>com.example.app</string>
+	<key>CFBundleName</key>
+	<string>Test</string>
+	<key>CFBundleDisplayName</key>
+	<string>Test App</string>
+	<key>CFBundleShortVersionString</key>
+	<string>2.1</string>
+	<key>CFBundleVersion</key>
+	<string>42</string>
+	<key>MinimumOSVersion</key>
+	<string>15.0</string>
+</dict>
+</plist>`
+
+func TestInspect(t *testing.T) {
+	expires := time.Date(2027, 3, 4, 0, 0, 0, 0, time.UTC)
+	executable := buildSignatureMachO(
+		[]uint32{csSlotCodeDirectory, csSlotCMSSignature},
+		buildCodeDirectory("com.example.app", "ABCDE12345"),
+		buildCMSWrapper(t, "Apple Distribution: Example Corp (ABCDE12345)", "ABCDE12345", expires),
+	)
+
+	root := t.TempDir()
+	app := filepath.Join(root, "Payload", "Test.app")
+	frameworks := filepath.Join(app, "Frameworks")
+	writeBundle(t, filepath.Join(frameworks, "Kit.framework"), buildLinkedMachO(15, 0))
+	writeBundle(t, filepath.Join(app, "PlugIns", "Share.appex"), buildLinkedMachO(15, 0))
+	os.WriteFile(filepath.Join(frameworks, "libswiftCore.dylib"), buildLinkedMachO(15, 0), 0755)
+	os.MkdirAll(filepath.Join(app, "Resources.bundle"), 0755)
+	os.WriteFile(filepath.Join(app, "Resources.bundle", "image.png"), make([]byte, 1000), 0644)
+	os.WriteFile(filepath.Join(app, "Info.plist"), []byte(testInspectInfo), 0644)
+	os.WriteFile(filepath.Join(app, "Test"), executable, 0755)
+	os.WriteFile(filepath.Join(app, "embedded.mobileprovision"), fakeProfile("ABCDE12345.com.example.app"), 0644)
+	// Swift libraries shipped beside the app are not part of it
+	os.MkdirAll(filepath.Join(root, "SwiftSupport", "iphoneos"), 0755)
+	os.WriteFile(filepath.Join(root, "SwiftSupport", "iphoneos", "libswiftCore.dylib"), buildLinkedMachO(15, 0), 0755)
+
+	ipa := filepath.Join(t.TempDir(), "Test.ipa")
+	if err := CreateArchive(root, ipa); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, source := range []string{app, ipa} {
+		inspection, err := Inspect(source)
+		if err != nil {
+			t.Fatalf("Inspect(%s) failed: %v", source, err)
+		}
+		if inspection.Path != "Test.app" || inspection.Name != "Test App" || inspection.BundleID != "com.example.app" ||
+			inspection.Version != "2.1" || inspection.Build != "42" || inspection.MinimumOS != "15.0" {
+			t.Errorf("Inspect(%s) = %+v", source, inspection)
+		}
+
+		signature := inspection.Signature
+		if !signature.Signed || signature.Identifier != "com.example.app" || signature.TeamID != "ABCDE12345" ||
+			signature.Authority != "Apple Distribution: Example Corp (ABCDE12345)" || !signature.Expires.Equal(expires) {
+			t.Errorf("Inspect(%s).Signature = %+v", source, signature)
+		}
+		if inspection.Profile == nil || inspection.Profile.Name != "Test Profile" {
+			t.Errorf("Inspect(%s).Profile = %+v", source, inspection.Profile)
+		}
+
+		want := []struct {
+			path  string
+			depth int
+		}{
+			{"Test.app", 0},
+			{"Test.app/Frameworks/Kit.framework", 1},
+			{"Test.app/Frameworks/libswiftCore.dylib", 1},
+			{"Test.app/PlugIns/Share.appex", 1},
+		}
+		if len(inspection.Components) != len(want) {
+			t.Fatalf("Inspect(%s).Components = %+v", source, inspection.Components)
+		}
+		var nested int64
+		for i, component := range inspection.Components {
+			if component.Path != want[i].path || component.Depth != want[i].depth {
+				t.Errorf("Components[%d] = %+v, want %s at depth %d", i, component, want[i].path, want[i].depth)
+			}
+			if i > 0 {
+				nested += component.Size
+			}
+		}
+		if kit := inspection.Components[1]; kit.Size <= int64(len(buildLinkedMachO(15, 0))) {
+			t.Errorf("Kit.framework size = %d, want binary and Info.plist", kit.Size)
+		}
+		// The resource bundle and executable count towards the app only
+		if size, min := inspection.Components[0].Size, nested+1000+int64(len(executable)); size < min {
+			t.Errorf("Test.app size = %d, want at least %d", size, min)
+		}
+	}
+}
+
+func TestInspectAdHoc(t *testing.T) {
+	app := filepath.Join(t.TempDir(), "Test.app")
+	os.MkdirAll(app, 0755)
+	os.WriteFile(filepath.Join(app, "Info.plist"), []byte(testInspectInfo), 0644)
+	emptyWrapper := binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, csMagicBlobWrapper), 8)
+	os.WriteFile(filepath.Join(app, "Test"), buildSignatureMachO(
+		[]uint32{csSlotCodeDirectory, csSlotCMSSignature},
+		buildCodeDirectory("Test-55554944", ""),
+		emptyWrapper,
+	), 0755)
+
+	inspection, err := Inspect(app)
+	if err != nil {
+		t.Fatalf("Inspect() failed: %v", err)
+	}
+	if s := inspection.Signature; !s.Signed || s.Identifier != "Test-55554944" || s.Authority != "" || s.TeamID != "" {
+		t.Errorf("Signature = %+v, want ad-hoc", s)
+	}
+	if inspection.Profile != nil {
+		t.Errorf("Profile = %+v, want nil", inspection.Profile)
+	}
+}
+
+func TestInspectUnsigned(t *testing.T) {
+	app := filepath.Join(t.TempDir(), "Test.app")
+	os.MkdirAll(app, 0755)
+	os.WriteFile(filepath.Join(app, "Info.plist"), []byte(testInspectInfo), 0644)
+	os.WriteFile(filepath.Join(app, "Test"), buildLinkedMachO(15, 0), 0755)
+
+	inspection, err := Inspect(app)
+	if err != nil {
+		t.Fatalf("Inspect() failed: %v", err)
+	}
+	if inspection.Signature.Signed {
+		t.Errorf("Signature = %+v, want unsigned", inspection.Signature)
+	}
+}
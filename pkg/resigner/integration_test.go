@@ -0,0 +1,223 @@
+//go:build integration && darwin
+
+package resigner
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"howett.net/plist"
+)
+
+// The integration tests sign a fixture app for real with a throwaway
+// self-signed identity. They need macOS with codesign, security and
+// openssl, and run with:
+//
+//	go test -tags integration ./pkg/resigner/
+//
+// Trusting the certificate for code signing may need an administrator;
+// the tests skip, rather than fail, where that is refused.
+
+const (
+	integrationCertName = "ResignIPA Integration Test"
+	integrationTeamID   = "RESIGNTEST"
+	integrationPassword = "resignipa-integration"
+)
+
+// runTool executes a command, failing the test with its output on error
+func runTool(t *testing.T, name string, args ...string) string {
+	t.Helper()
+	output, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s %s failed: %v\n%s", name, strings.Join(args, " "), err, output)
+	}
+	return string(output)
+}
+
+// setupIdentity creates a temporary keychain holding a self-signed code
+// signing identity and puts it on the search list for the test's duration
+func setupIdentity(t *testing.T) {
+	t.Helper()
+	for _, tool := range []string{"security", "codesign", "openssl"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s not available: %v", tool, err)
+		}
+	}
+
+	dir := t.TempDir()
+	keychain := filepath.Join(dir, "integration.keychain-db")
+	key := filepath.Join(dir, "key.pem")
+	cert := filepath.Join(dir, "cert.pem")
+	config := filepath.Join(dir, "openssl.cnf")
+	os.WriteFile(config, []byte(`[req]
+distinguished_name = dn
+x509_extensions = ext
+prompt = no
+[dn]
+CN = `+integrationCertName+`
+OU = `+integrationTeamID+`
+[ext]
+basicConstraints = critical,CA:false
+keyUsage = critical,digitalSignature
+extendedKeyUsage = critical,codeSigning
+`), 0644)
+	runTool(t, "openssl", "req", "-x509", "-newkey", "rsa:2048", "-nodes", "-days", "1",
+		"-config", config, "-keyout", key, "-out", cert)
+
+	runTool(t, "security", "create-keychain", "-p", integrationPassword, keychain)
+	t.Cleanup(func() { exec.Command("security", "delete-keychain", keychain).Run() })
+	runTool(t, "security", "set-keychain-settings", keychain)
+	runTool(t, "security", "unlock-keychain", "-p", integrationPassword, keychain)
+	runTool(t, "security", "import", key, "-k", keychain, "-T", "/usr/bin/codesign")
+	runTool(t, "security", "import", cert, "-k", keychain)
+	runTool(t, "security", "set-key-partition-list", "-S", "apple-tool:,apple:,codesign:",
+		"-s", "-k", integrationPassword, keychain)
+
+	// Search the temporary keychain first, restoring the list afterwards
+	var searchList []string
+	for _, line := range strings.Split(runTool(t, "security", "list-keychains", "-d", "user"), "\n") {
+		if line = strings.Trim(strings.TrimSpace(line), `"`); line != "" {
+			searchList = append(searchList, line)
+		}
+	}
+	runTool(t, "security", append([]string{"list-keychains", "-d", "user", "-s", keychain}, searchList...)...)
+	t.Cleanup(func() {
+		exec.Command("security", append([]string{"list-keychains", "-d", "user", "-s"}, searchList...)...).Run()
+	})
+
+	if err := exec.Command("security", "add-trusted-cert", "-r", "trustRoot", "-p", "codeSign", "-k", keychain, cert).Run(); err != nil {
+		t.Skipf("cannot trust the test certificate for code signing (try sudo): %v", err)
+	}
+	t.Cleanup(func() { exec.Command("security", "remove-trusted-cert", cert).Run() })
+
+	identities := runTool(t, "security", "find-identity", "-v", "-p", "codesigning", keychain)
+	if !strings.Contains(identities, integrationCertName) {
+		t.Skipf("test identity is not valid for code signing:\n%s", identities)
+	}
+}
+
+// writeFixtureIPA builds an IPA holding an app with an extension and a
+// framework, using a system binary as every executable
+func writeFixtureIPA(t *testing.T) string {
+	t.Helper()
+	binary, err := os.ReadFile("/usr/bin/true")
+	if err != nil {
+		t.Skipf("no fixture binary: %v", err)
+	}
+
+	root := t.TempDir()
+	app := filepath.Join(root, "src", "Payload", "Fixture.app")
+	bundles := map[string]map[string]interface{}{
+		app: {
+			"CFBundleIdentifier":  "com.example.fixture",
+			"CFBundleExecutable":  "Fixture",
+			"CFBundlePackageType": "APPL",
+		},
+		filepath.Join(app, "PlugIns", "Widget.appex"): {
+			"CFBundleIdentifier":  "com.example.fixture.widget",
+			"CFBundleExecutable":  "Widget",
+			"CFBundlePackageType": "XPC!",
+		},
+		filepath.Join(app, "Frameworks", "Kit.framework"): {
+			"CFBundleIdentifier":  "com.example.kit",
+			"CFBundleExecutable":  "Kit",
+			"CFBundlePackageType": "FMWK",
+		},
+	}
+	for dir, info := range bundles {
+		os.MkdirAll(dir, 0755)
+		if err := writePlistFile(filepath.Join(dir, "Info.plist"), info, plist.XMLFormat); err != nil {
+			t.Fatal(err)
+		}
+		os.WriteFile(filepath.Join(dir, info["CFBundleExecutable"].(string)), binary, 0755)
+	}
+
+	ipa := filepath.Join(root, "Fixture.ipa")
+	if err := CreateArchive(filepath.Join(root, "src"), ipa); err != nil {
+		t.Fatal(err)
+	}
+	return ipa
+}
+
+func TestIntegrationResign(t *testing.T) {
+	setupIdentity(t)
+	ipa := writeFixtureIPA(t)
+
+	entitlements := filepath.Join(t.TempDir(), "entitlements.plist")
+	writePlistFile(entitlements, map[string]interface{}{
+		"application-identifier": "OLDTEAM123.com.example.fixture",
+		"get-task-allow":         true,
+	}, plist.XMLFormat)
+
+	outDir, dumpDir := t.TempDir(), t.TempDir()
+	r := New(Config{
+		SourceIPA:        ipa,
+		Certificate:      integrationCertName,
+		Entitlements:     entitlements,
+		BundleID:         "com.example.resigned",
+		OutputDir:        outDir,
+		Verify:           true,
+		DumpEntitlements: dumpDir,
+	}, WithEventHandler(func(e Event) { t.Log(e.Message) }))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	result, err := r.ResignContext(ctx)
+	if err != nil {
+		t.Fatalf("ResignContext() failed: %v", err)
+	}
+	if result.TeamID != integrationTeamID {
+		t.Errorf("Result.TeamID = %q, want %q", result.TeamID, integrationTeamID)
+	}
+	if result.BundleID != "com.example.resigned" || result.OutputPath != r.OutputPath() {
+		t.Errorf("Result = %+v", result)
+	}
+
+	// Check the packaged result, not the workspace
+	extracted := t.TempDir()
+	if err := ExtractArchive(r.OutputPath(), extracted); err != nil {
+		t.Fatalf("cannot extract output: %v", err)
+	}
+	app := filepath.Join(extracted, "Payload", "Fixture.app")
+	runTool(t, "codesign", "--verify", "--deep", "--strict", app)
+
+	details := runTool(t, "codesign", "-dvv", app)
+	if !strings.Contains(details, "Authority="+integrationCertName) {
+		t.Errorf("app not signed by the test identity:\n%s", details)
+	}
+	if id, _ := readBundleIdentifier(filepath.Join(app, "Info.plist")); id != "com.example.resigned" {
+		t.Errorf("bundle ID = %q, want com.example.resigned", id)
+	}
+	if id, _ := readBundleIdentifier(filepath.Join(app, "PlugIns", "Widget.appex", "Info.plist")); id != "com.example.resigned.widget" {
+		t.Errorf("extension bundle ID = %q, want com.example.resigned.widget", id)
+	}
+
+	components := result.Components
+	if len(components) != 3 || components[2].Path != "Fixture.app" {
+		t.Fatalf("Result().Components = %+v, want the framework, extension and app", components)
+	}
+	for _, component := range components {
+		if len(component.CDHash) != 40 || component.TeamID != integrationTeamID || component.SigningTime.IsZero() {
+			t.Errorf("signature of %s not recorded: %+v", component.Path, component)
+		}
+	}
+
+	signed := runTool(t, "codesign", "-d", "--entitlements", "-", "--xml", app)
+	if !strings.Contains(signed, integrationTeamID+".com.example.fixture") {
+		t.Errorf("entitlements not rewritten for the signing team:\n%s", signed)
+	}
+	for _, component := range components {
+		if _, err := os.Stat(dumpPath(dumpDir, component.Path)); err != nil {
+			t.Errorf("entitlements of %s not dumped: %v", component.Path, err)
+		}
+	}
+	dumped, _, err := readPlistFile(dumpPath(dumpDir, "Fixture.app"))
+	if err != nil || dumped["application-identifier"] != integrationTeamID+".com.example.resigned" {
+		t.Errorf("dumped app entitlements = %v, %v", dumped, err)
+	}
+}
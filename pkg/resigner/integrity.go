@@ -0,0 +1,111 @@
+package resigner
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ChecksumMismatchError is returned when a file's SHA-256 differs from the
+// expected digest
+type ChecksumMismatchError struct {
+	Path     string
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("SHA-256 mismatch for %s: expected %s, got %s", e.Path, e.Expected, e.Actual)
+}
+
+// CorruptArchiveError is returned when an IPA is truncated or is not a
+// valid zip archive
+type CorruptArchiveError struct {
+	Path   string
+	Reason string
+	Err    error
+}
+
+func (e *CorruptArchiveError) Error() string {
+	return fmt.Sprintf("%s is corrupt: %s (the download may be incomplete; fetch it again)", e.Path, e.Reason)
+}
+
+func (e *CorruptArchiveError) Unwrap() error {
+	return e.Err
+}
+
+// FileSHA256 returns the hex-encoded SHA-256 digest of the file at path
+func FileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := copyBuffered(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// VerifySHA256 checks the file at path against the hex digest expected
+func VerifySHA256(path, expected string) error {
+	expected = strings.ToLower(strings.TrimSpace(expected))
+	if len(expected) != sha256.Size*2 {
+		return fmt.Errorf("invalid SHA-256 digest %q: must be %d hex characters", expected, sha256.Size*2)
+	}
+	if _, err := hex.DecodeString(expected); err != nil {
+		return fmt.Errorf("invalid SHA-256 digest %q: %w", expected, err)
+	}
+
+	actual, err := FileSHA256(path)
+	if err != nil {
+		return err
+	}
+	if actual != expected {
+		return &ChecksumMismatchError{Path: path, Expected: expected, Actual: actual}
+	}
+	return nil
+}
+
+// VerifyArchive checks that the zip archive at path is structurally
+// complete: the central directory is readable and every entry's data lies
+// within the file. It does not decompress entries.
+func VerifyArchive(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		return &CorruptArchiveError{Path: path, Reason: "file is empty"}
+	}
+
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		reason := "not a zip archive or truncated"
+		if errors.Is(err, zip.ErrFormat) {
+			reason = "zip directory is missing, the file is truncated"
+		}
+		return &CorruptArchiveError{Path: path, Reason: reason, Err: err}
+	}
+	defer reader.Close()
+
+	if len(reader.File) == 0 {
+		return &CorruptArchiveError{Path: path, Reason: "archive has no entries"}
+	}
+	for _, f := range reader.File {
+		offset, err := f.DataOffset()
+		if err != nil {
+			return &CorruptArchiveError{Path: path, Reason: fmt.Sprintf("entry %s is damaged", f.Name), Err: err}
+		}
+		if offset+int64(f.CompressedSize64) > info.Size() {
+			return &CorruptArchiveError{Path: path, Reason: fmt.Sprintf("entry %s extends past the end of the file", f.Name)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package resigner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifySHA256(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	const digest = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+	if err := VerifySHA256(path, strings.ToUpper(digest)); err != nil {
+		t.Errorf("VerifySHA256() failed for matching digest: %v", err)
+	}
+
+	var mismatch *ChecksumMismatchError
+	if err := VerifySHA256(path, strings.Repeat("0", 64)); !errors.As(err, &mismatch) {
+		t.Errorf("VerifySHA256() error = %v, want ChecksumMismatchError", err)
+	}
+	if err := VerifySHA256(path, "abc"); err == nil || errors.As(err, &mismatch) {
+		t.Errorf("VerifySHA256() error = %v, want invalid digest error", err)
+	}
+}
+
+func TestVerifyArchive(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	writeTree(t, src)
+	ipa := filepath.Join(root, "good.ipa")
+	if err := CreateArchive(src, ipa); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyArchive(ipa); err != nil {
+		t.Errorf("VerifyArchive() failed for valid archive: %v", err)
+	}
+
+	data, _ := os.ReadFile(ipa)
+	truncated := filepath.Join(root, "truncated.ipa")
+	os.WriteFile(truncated, data[:len(data)/2], 0644)
+	empty := filepath.Join(root, "empty.ipa")
+	os.WriteFile(empty, nil, 0644)
+
+	for _, path := range []string{truncated, empty} {
+		var corrupt *CorruptArchiveError
+		if err := VerifyArchive(path); !errors.As(err, &corrupt) {
+			t.Errorf("VerifyArchive(%s) error = %v, want CorruptArchiveError", filepath.Base(path), err)
+		}
+	}
+}
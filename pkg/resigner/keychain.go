@@ -0,0 +1,45 @@
+package resigner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// resolveKeychain returns the path of the keychain named by keychain: a
+// path to the file, or the name of one in ~/Library/Keychains, with or
+// without the -db suffix macOS adds
+func resolveKeychain(keychain string) (string, error) {
+	candidates := []string{keychain}
+	if !filepath.IsAbs(keychain) && filepath.Base(keychain) == keychain {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir := filepath.Join(home, "Library", "Keychains")
+			candidates = append(candidates, filepath.Join(dir, keychain), filepath.Join(dir, keychain+"-db"))
+		}
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return filepath.Abs(candidate)
+		}
+	}
+	return "", fmt.Errorf("keychain does not exist: %s", keychain)
+}
+
+// keychainArgs returns the arguments limiting a security lookup to
+// Config.Keychain, which security takes as a trailing keychain; none
+// searches the user's keychain search list
+func (r *Resigner) keychainArgs() []string {
+	if r.keychain == "" {
+		return nil
+	}
+	return []string{r.keychain}
+}
+
+// codesignKeychainArgs returns the arguments making codesign look for the
+// signing identity in Config.Keychain only
+func (r *Resigner) codesignKeychainArgs() []string {
+	if r.keychain == "" {
+		return nil
+	}
+	return []string{"--keychain", r.keychain}
+}
@@ -0,0 +1,65 @@
+package resigner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveKeychain(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	keychains := filepath.Join(home, "Library", "Keychains")
+	os.MkdirAll(keychains, 0755)
+	ci := filepath.Join(keychains, "ci.keychain-db")
+	os.WriteFile(ci, []byte("kych"), 0600)
+	local := filepath.Join(t.TempDir(), "build.keychain")
+	os.WriteFile(local, []byte("kych"), 0600)
+
+	for keychain, want := range map[string]string{
+		local:               local,
+		"ci.keychain":       ci,
+		"ci.keychain-db":    ci,
+		"missing.keychain":  "",
+		filepath.Dir(local): "",
+	} {
+		got, err := resolveKeychain(keychain)
+		if want == "" {
+			if err == nil {
+				t.Errorf("resolveKeychain(%q) = %q, want an error", keychain, got)
+			}
+			continue
+		}
+		if err != nil || got != want {
+			t.Errorf("resolveKeychain(%q) = %q, %v, want %q", keychain, got, err, want)
+		}
+	}
+}
+
+func TestKeychainConfig(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "test.ipa")
+	os.WriteFile(source, []byte("ipa"), 0644)
+	keychain := filepath.Join(t.TempDir(), "build.keychain")
+	os.WriteFile(keychain, []byte("kych"), 0600)
+
+	r := New(Config{SourceIPA: source, Certificate: "Apple Distribution", Keychain: keychain})
+	if err := r.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.keychainArgs(); !reflect.DeepEqual(got, []string{keychain}) {
+		t.Errorf("keychainArgs() = %q", got)
+	}
+	if got := r.codesignKeychainArgs(); !reflect.DeepEqual(got, []string{"--keychain", keychain}) {
+		t.Errorf("codesignKeychainArgs() = %q", got)
+	}
+	if got := New(Config{}).codesignKeychainArgs(); got != nil {
+		t.Errorf("codesignKeychainArgs() without a keychain = %q", got)
+	}
+
+	err := New(Config{SourceIPA: source, AdHoc: true, Keychain: keychain}).validate()
+	if err == nil || !strings.Contains(err.Error(), "ad-hoc") {
+		t.Errorf("validate() with an ad-hoc keychain = %v", err)
+	}
+}
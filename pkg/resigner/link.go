@@ -0,0 +1,118 @@
+package resigner
+
+import (
+	"encoding/binary"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// cloneOrLinkDir duplicates the .app at src into the workspace at dst for
+// modification: cloned where the file system supports it, otherwise
+// hard-linked file by file (see linkOrCopyDir)
+func cloneOrLinkDir(src, dst string) error {
+	if err := cloneTree(src, dst); err == nil {
+		return nil
+	}
+	return linkOrCopyDir(src, dst)
+}
+
+// linkOrCopyDir duplicates the directory src at dst, hard-linking files
+// the resign never rewrites and copying the rest, so only files that
+// change cost disk space. Files that cannot be linked, for example across
+// devices, are copied.
+func linkOrCopyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case rewrittenFile(path, info):
+			return copyFileMode(path, target, info.Mode().Perm())
+		}
+		if err := os.Link(path, target); err == nil {
+			return nil
+		}
+		return copyFileMode(path, target, info.Mode().Perm())
+	})
+}
+
+// rewrittenFile reports whether a resign may modify path in place, which
+// would write through a hard link into the source: code signatures,
+// plists and profiles we edit, and every executable or Mach-O binary
+// codesign rewrites
+func rewrittenFile(path string, info fs.FileInfo) bool {
+	switch info.Name() {
+	case "Info.plist", "embedded.mobileprovision", privacyManifestName:
+		return true
+	}
+	if filepath.Base(filepath.Dir(path)) == "_CodeSignature" || info.Mode()&0111 != 0 {
+		return true
+	}
+	return isMachOFile(path)
+}
+
+// isMachOFile reports whether path starts with a thin or universal Mach-O
+// magic number, in either byte order
+func isMachOFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	var magic [4]byte
+	if _, err := io.ReadFull(f, magic[:]); err != nil {
+		return false
+	}
+	switch binary.BigEndian.Uint32(magic[:]) {
+	case 0xfeedface, 0xfeedfacf, 0xcefaedfe, 0xcffaedfe, 0xcafebabe, 0xbebafeca:
+		return true
+	}
+	return false
+}
+
+// breakHardLink gives path an inode of its own if it shares one, so it can
+// be modified without touching the file it was linked from
+func breakHardLink(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil || !info.Mode().IsRegular() || linkCount(info) < 2 {
+		return err
+	}
+
+	tmp := path + ".unlink"
+	if err := copyFileMode(path, tmp, info.Mode().Perm()); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// breakHardLinks runs breakHardLink on every file under dir
+func breakHardLinks(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		return breakHardLink(path)
+	})
+}
@@ -0,0 +1,62 @@
+package resigner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLinkOrCopyDir(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "Test.app")
+	writeBundle(t, src, buildLinkedMachO(15, 0))
+	os.WriteFile(filepath.Join(src, "asset.car"), []byte("resource"), 0644)
+	os.Mkdir(filepath.Join(src, "_CodeSignature"), 0755)
+	os.WriteFile(filepath.Join(src, "_CodeSignature", "CodeResources"), []byte("seal"), 0644)
+	os.Symlink("asset.car", filepath.Join(src, "link"))
+
+	dst := filepath.Join(t.TempDir(), "Test.app")
+	if err := linkOrCopyDir(src, dst); err != nil {
+		t.Fatalf("linkOrCopyDir() failed: %v", err)
+	}
+
+	for name, wantLinked := range map[string]bool{
+		"asset.car":                    true,
+		"Info.plist":                   false,
+		"Test":                         false,
+		"_CodeSignature/CodeResources": false,
+	} {
+		a, err := os.Stat(filepath.Join(src, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.Stat(filepath.Join(dst, name))
+		if err != nil {
+			t.Fatalf("%s missing from copy: %v", name, err)
+		}
+		if os.SameFile(a, b) != wantLinked {
+			t.Errorf("%s: linked = %v, want %v", name, os.SameFile(a, b), wantLinked)
+		}
+	}
+	if link, err := os.Readlink(filepath.Join(dst, "link")); err != nil || link != "asset.car" {
+		t.Errorf("symlink = %q, %v", link, err)
+	}
+}
+
+func TestBreakHardLink(t *testing.T) {
+	dir := t.TempDir()
+	original := filepath.Join(dir, "original")
+	linked := filepath.Join(dir, "linked")
+	os.WriteFile(original, []byte("source"), 0644)
+	if err := os.Link(original, linked); err != nil {
+		t.Skipf("hard links unsupported: %v", err)
+	}
+
+	if err := breakHardLink(linked); err != nil {
+		t.Fatalf("breakHardLink() failed: %v", err)
+	}
+	os.WriteFile(linked, []byte("modified"), 0644)
+
+	if data, _ := os.ReadFile(original); string(data) != "source" {
+		t.Errorf("original changed to %q through the link", data)
+	}
+}
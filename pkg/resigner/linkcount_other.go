@@ -0,0 +1,10 @@
+//go:build !darwin && !linux
+
+package resigner
+
+import "io/fs"
+
+// linkCount reports a single link where the count is not available
+func linkCount(info fs.FileInfo) uint64 {
+	return 1
+}
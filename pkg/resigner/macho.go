@@ -0,0 +1,331 @@
+package resigner
+
+import (
+	"debug/macho"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+// Mach-O load commands not defined by debug/macho
+const (
+	loadCmdVersionMinIPhoneOS macho.LoadCmd = 0x25
+	loadCmdBuildVersion       macho.LoadCmd = 0x32
+)
+
+// Platform identifies the OS a Mach-O slice was built for, as recorded in
+// its LC_BUILD_VERSION load command
+type Platform uint32
+
+// Platforms from <mach-o/loader.h>
+const (
+	PlatformUnknown          Platform = 0
+	PlatformMacOS            Platform = 1
+	PlatformIOS              Platform = 2
+	PlatformTVOS             Platform = 3
+	PlatformWatchOS          Platform = 4
+	PlatformMacCatalyst      Platform = 6
+	PlatformIOSSimulator     Platform = 7
+	PlatformTVOSSimulator    Platform = 8
+	PlatformWatchOSSimulator Platform = 9
+	PlatformVisionOS         Platform = 11
+	PlatformVisionOSSim      Platform = 12
+)
+
+// IsSimulator reports whether the platform is one of the simulators
+func (p Platform) IsSimulator() bool {
+	switch p {
+	case PlatformIOSSimulator, PlatformTVOSSimulator, PlatformWatchOSSimulator, PlatformVisionOSSim:
+		return true
+	}
+	return false
+}
+
+// machoPlatforms returns the platform of every slice in a thin or
+// universal Mach-O binary
+func machoPlatforms(path string) ([]Platform, error) {
+	if fat, err := macho.OpenFat(path); err == nil {
+		defer fat.Close()
+		var platforms []Platform
+		for _, arch := range fat.Arches {
+			platforms = append(platforms, slicePlatform(arch.File))
+		}
+		return platforms, nil
+	} else if !errors.Is(err, macho.ErrNotFat) {
+		return nil, err
+	}
+
+	f, err := macho.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return []Platform{slicePlatform(f)}, nil
+}
+
+// slicePlatform reads the build platform of a single Mach-O slice. Old
+// binaries without LC_BUILD_VERSION only say "iPhoneOS"; on Intel that
+// means the simulator.
+func slicePlatform(f *macho.File) Platform {
+	for _, load := range f.Loads {
+		raw := load.Raw()
+		if len(raw) < 12 {
+			continue
+		}
+		switch macho.LoadCmd(f.ByteOrder.Uint32(raw)) {
+		case loadCmdBuildVersion:
+			return Platform(f.ByteOrder.Uint32(raw[8:]))
+		case loadCmdVersionMinIPhoneOS:
+			if f.Cpu == macho.Cpu386 || f.Cpu == macho.CpuAmd64 {
+				return PlatformIOSSimulator
+			}
+			return PlatformIOS
+		}
+	}
+	return PlatformUnknown
+}
+
+// IsSimulatorBinary reports whether any slice of the Mach-O binary at path
+// targets a simulator
+func IsSimulatorBinary(path string) (bool, error) {
+	platforms, err := machoPlatforms(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read Mach-O %s: %w", path, err)
+	}
+	for _, platform := range platforms {
+		if platform.IsSimulator() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// IsSimulatorApp reports whether the .app bundle at appPath was built for
+// a simulator, judged by its main executable. Sticker packs have none and
+// are never simulator builds.
+func IsSimulatorApp(appPath string) (bool, error) {
+	info, _, err := readPlistFile(filepath.Join(appPath, "Info.plist"))
+	if err != nil {
+		return false, err
+	}
+	executable, _ := info["CFBundleExecutable"].(string)
+	if executable == "" {
+		return false, nil
+	}
+	return IsSimulatorBinary(filepath.Join(appPath, executable))
+}
+
+// loadCmdUUID is LC_UUID, which identifies a build for symbolication
+const loadCmdUUID macho.LoadCmd = 0x1b
+
+// machoUUIDs returns the LC_UUID of every slice in a thin or universal
+// Mach-O binary, formatted like dwarfdump does
+func machoUUIDs(path string) ([]string, error) {
+	var files []*macho.File
+	if fat, err := macho.OpenFat(path); err == nil {
+		defer fat.Close()
+		for _, arch := range fat.Arches {
+			files = append(files, arch.File)
+		}
+	} else if !errors.Is(err, macho.ErrNotFat) {
+		return nil, err
+	} else {
+		f, err := macho.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		files = append(files, f)
+	}
+
+	var uuids []string
+	for _, f := range files {
+		for _, load := range f.Loads {
+			raw := load.Raw()
+			if len(raw) >= 24 && macho.LoadCmd(f.ByteOrder.Uint32(raw)) == loadCmdUUID {
+				u := raw[8:24]
+				uuids = append(uuids, fmt.Sprintf("%X-%X-%X-%X-%X", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16]))
+			}
+		}
+	}
+	return uuids, nil
+}
+
+// loadCmdCodeSignature is LC_CODE_SIGNATURE, which points at the embedded
+// signature superblob
+const loadCmdCodeSignature macho.LoadCmd = 0x1d
+
+// Code signature blob magics and slots from <Security/CSCommon.h>
+const (
+	csMagicEmbeddedSignature    = 0xfade0cc0
+	csMagicEmbeddedEntitlements = 0xfade7171
+	csMagicCodeDirectory        = 0xfade0c02
+	csMagicBlobWrapper          = 0xfade0b01
+	csSlotCodeDirectory         = 0
+	csSlotEntitlements          = 5
+	csSlotCMSSignature          = 0x10000
+
+	// maxSignatureSize bounds how much of a binary we read as a signature
+	maxSignatureSize = 64 << 20
+)
+
+var errMalformedSignature = errors.New("malformed code signature")
+
+// machoEntitlements returns the entitlements plist embedded in the code
+// signature of a thin or universal Mach-O binary, or nil if it is unsigned
+// or signed without entitlements
+func machoEntitlements(r io.ReaderAt) ([]byte, error) {
+	sig, err := machoSignature(r)
+	if err != nil || sig == nil {
+		return nil, err
+	}
+	return signatureEntitlements(sig)
+}
+
+// machoSignature returns the embedded signature superblob of a thin or
+// universal Mach-O binary, or nil if it is unsigned. Slices are signed
+// together, so the first one speaks for all of them.
+func machoSignature(r io.ReaderAt) ([]byte, error) {
+	if fat, err := macho.NewFatFile(r); err == nil {
+		arch := fat.Arches[0]
+		return sliceSignature(arch.File, io.NewSectionReader(r, int64(arch.Offset), int64(arch.Size)))
+	} else if !errors.Is(err, macho.ErrNotFat) {
+		return nil, err
+	}
+
+	f, err := macho.NewFile(r)
+	if err != nil {
+		return nil, err
+	}
+	return sliceSignature(f, r)
+}
+
+// sliceSignature reads the signature superblob of a single Mach-O slice; r
+// is positioned at the start of the slice
+func sliceSignature(f *macho.File, r io.ReaderAt) ([]byte, error) {
+	for _, load := range f.Loads {
+		raw := load.Raw()
+		if len(raw) < 16 || macho.LoadCmd(f.ByteOrder.Uint32(raw)) != loadCmdCodeSignature {
+			continue
+		}
+		offset, size := f.ByteOrder.Uint32(raw[8:]), f.ByteOrder.Uint32(raw[12:])
+		if size > maxSignatureSize {
+			return nil, errMalformedSignature
+		}
+		sig := make([]byte, size)
+		if _, err := r.ReadAt(sig, int64(offset)); err != nil {
+			return nil, fmt.Errorf("failed to read code signature: %w", err)
+		}
+		return sig, nil
+	}
+	return nil, nil
+}
+
+// signatureEntitlements finds the entitlements blob in an embedded
+// signature superblob
+func signatureEntitlements(sig []byte) ([]byte, error) {
+	blob, err := signatureBlob(sig, csSlotEntitlements, csMagicEmbeddedEntitlements)
+	if err != nil || blob == nil {
+		return nil, err
+	}
+	return blob[8:], nil
+}
+
+// signatureBlob returns the blob in slot of an embedded signature
+// superblob, header included, or nil if the slot is empty. Signature
+// structures are always big-endian.
+func signatureBlob(sig []byte, slot, magic uint32) ([]byte, error) {
+	be := binary.BigEndian
+	if len(sig) < 12 || be.Uint32(sig) != csMagicEmbeddedSignature {
+		return nil, errMalformedSignature
+	}
+
+	count := int(be.Uint32(sig[8:]))
+	for i := 0; i < count; i++ {
+		entry := 12 + 8*i
+		if entry+8 > len(sig) {
+			return nil, errMalformedSignature
+		}
+		if be.Uint32(sig[entry:]) != slot {
+			continue
+		}
+
+		offset := int(be.Uint32(sig[entry+4:]))
+		if offset+8 > len(sig) || be.Uint32(sig[offset:]) != magic {
+			return nil, errMalformedSignature
+		}
+		length := int(be.Uint32(sig[offset+4:]))
+		if length < 8 || offset+length > len(sig) {
+			return nil, errMalformedSignature
+		}
+		return sig[offset : offset+length], nil
+	}
+	return nil, nil
+}
+
+// LC_VERSION_MIN_* commands other than iPhoneOS, used by older tvOS,
+// watchOS and macOS binaries
+const (
+	loadCmdVersionMinMacOS   macho.LoadCmd = 0x24
+	loadCmdVersionMinTVOS    macho.LoadCmd = 0x2f
+	loadCmdVersionMinWatchOS macho.LoadCmd = 0x30
+)
+
+// machoSummary is what the framework audit needs from a binary
+type machoSummary struct {
+	// MinimumOS is the deployment target, empty if the binary has none
+	MinimumOS string
+	// Libraries are the install names of the dylibs it links
+	Libraries []string
+}
+
+// readMachOSummary reads the deployment target and linked libraries of a
+// thin or universal Mach-O binary, preferring the arm64 slice
+func readMachOSummary(r io.ReaderAt) (*machoSummary, error) {
+	var f *macho.File
+	if fat, err := macho.NewFatFile(r); err == nil {
+		f = fat.Arches[0].File
+		for _, arch := range fat.Arches {
+			if arch.Cpu == macho.CpuArm64 {
+				f = arch.File
+			}
+		}
+	} else if !errors.Is(err, macho.ErrNotFat) {
+		return nil, err
+	} else if f, err = macho.NewFile(r); err != nil {
+		return nil, err
+	}
+
+	libraries, err := f.ImportedLibraries()
+	if err != nil {
+		return nil, err
+	}
+	return &machoSummary{MinimumOS: sliceMinimumOS(f), Libraries: libraries}, nil
+}
+
+// sliceMinimumOS returns the deployment target of a single Mach-O slice
+func sliceMinimumOS(f *macho.File) string {
+	for _, load := range f.Loads {
+		raw := load.Raw()
+		if len(raw) < 16 {
+			continue
+		}
+		switch macho.LoadCmd(f.ByteOrder.Uint32(raw)) {
+		case loadCmdBuildVersion:
+			return formatMachOVersion(f.ByteOrder.Uint32(raw[12:]))
+		case loadCmdVersionMinIPhoneOS, loadCmdVersionMinMacOS, loadCmdVersionMinTVOS, loadCmdVersionMinWatchOS:
+			return formatMachOVersion(f.ByteOrder.Uint32(raw[8:]))
+		}
+	}
+	return ""
+}
+
+// formatMachOVersion renders a version packed as xxxx.yy.zz nibbles
+func formatMachOVersion(v uint32) string {
+	if v&0xff != 0 {
+		return fmt.Sprintf("%d.%d.%d", v>>16, (v>>8)&0xff, v&0xff)
+	}
+	return fmt.Sprintf("%d.%d", v>>16, (v>>8)&0xff)
+}
@@ -0,0 +1,82 @@
+package resigner
+
+import (
+	"debug/macho"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"howett.net/plist"
+)
+
+// buildMachO writes a minimal 64-bit Mach-O with a single LC_BUILD_VERSION
+func buildMachO(t *testing.T, cpu macho.Cpu, platform Platform) string {
+	t.Helper()
+	le := binary.LittleEndian
+	var data []byte
+	for _, v := range []uint32{macho.Magic64, uint32(cpu), 0, uint32(macho.TypeExec), 1, 24, 0, 0} {
+		data = le.AppendUint32(data, v)
+	}
+	for _, v := range []uint32{uint32(loadCmdBuildVersion), 24, uint32(platform), 0x000f0000, 0x00110000, 0} {
+		data = le.AppendUint32(data, v)
+	}
+
+	path := filepath.Join(t.TempDir(), "binary")
+	if err := os.WriteFile(path, data, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestIsSimulatorBinary(t *testing.T) {
+	tests := []struct {
+		name     string
+		cpu      macho.Cpu
+		platform Platform
+		want     bool
+	}{
+		{"device", macho.CpuArm64, PlatformIOS, false},
+		{"arm64 simulator", macho.CpuArm64, PlatformIOSSimulator, true},
+		{"x86_64 simulator", macho.CpuAmd64, PlatformIOSSimulator, true},
+		{"watch simulator", macho.CpuArm64, PlatformWatchOSSimulator, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := IsSimulatorBinary(buildMachO(t, tt.cpu, tt.platform))
+			if err != nil {
+				t.Fatalf("IsSimulatorBinary() failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsSimulatorBinary() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSimulatorBinaryNotMachO(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "text")
+	os.WriteFile(path, []byte("not a binary"), 0644)
+	if _, err := IsSimulatorBinary(path); err == nil {
+		t.Error("Expected error for non Mach-O file")
+	}
+}
+
+func TestIsSimulatorApp(t *testing.T) {
+	app := filepath.Join(t.TempDir(), "Test.app")
+	os.MkdirAll(app, 0755)
+	binary := buildMachO(t, macho.CpuArm64, PlatformIOSSimulator)
+	os.Rename(binary, filepath.Join(app, "Test"))
+	infoPlist := filepath.Join(app, "Info.plist")
+	writePlistFile(infoPlist, map[string]interface{}{"CFBundleExecutable": "Test"}, plist.XMLFormat)
+	if simulator, err := IsSimulatorApp(app); err != nil || !simulator {
+		t.Errorf("IsSimulatorApp() of a simulator build = %v, %v", simulator, err)
+	}
+
+	// A sticker pack has no executable to judge by
+	writePlistFile(infoPlist, map[string]interface{}{"LSApplicationLaunchProhibited": true}, plist.XMLFormat)
+	if simulator, err := IsSimulatorApp(app); err != nil || simulator {
+		t.Errorf("IsSimulatorApp() of a sticker pack = %v, %v", simulator, err)
+	}
+}
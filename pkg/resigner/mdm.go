@@ -0,0 +1,173 @@
+package resigner
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"howett.net/plist"
+)
+
+// DefaultMDMChunkSize is the size of the chunks whose MD5s an MDM
+// manifest lists, the value Apple's own tools use
+const DefaultMDMChunkSize = 10 << 20
+
+// MDMManifest configures the manifest written for MDM InstallApplication
+// commands (the same format as itms-services over-the-air installs)
+type MDMManifest struct {
+	// URL is where the MDM server will serve the IPA; required
+	URL string `json:"url"`
+	// DisplayImageURL and FullSizeImageURL are optional 57x57 and 512x512
+	// PNG icons shown while the app installs
+	DisplayImageURL  string `json:"displayImageUrl,omitempty"`
+	FullSizeImageURL string `json:"fullSizeImageUrl,omitempty"`
+	// Title is shown during the install; defaults to the app's name
+	Title string `json:"title,omitempty"`
+	// ChunkSize is the size of each MD5-hashed chunk; defaults to
+	// DefaultMDMChunkSize
+	ChunkSize int64 `json:"chunkSize,omitempty"`
+}
+
+// mdmAsset is an entry of a manifest item's assets
+type mdmAsset struct {
+	Kind       string   `plist:"kind"`
+	URL        string   `plist:"url"`
+	MD5Size    int64    `plist:"md5-size,omitempty"`
+	MD5s       []string `plist:"md5s,omitempty"`
+	NeedsShine *bool    `plist:"needs-shine,omitempty"`
+}
+
+// mdmMetadata describes the app a manifest installs
+type mdmMetadata struct {
+	BundleIdentifier string `plist:"bundle-identifier"`
+	BundleVersion    string `plist:"bundle-version"`
+	Kind             string `plist:"kind"`
+	Title            string `plist:"title"`
+	SizeInBytes      int64  `plist:"sizeInBytes"`
+}
+
+type mdmItem struct {
+	Assets   []mdmAsset  `plist:"assets"`
+	Metadata mdmMetadata `plist:"metadata"`
+}
+
+type mdmManifest struct {
+	Items []mdmItem `plist:"items"`
+}
+
+// MDMManifestPath returns the path the manifest of ipaPath is written to,
+// App.manifest.plist next to App.ipa
+func MDMManifestPath(ipaPath string) string {
+	return strings.TrimSuffix(ipaPath, filepath.Ext(ipaPath)) + ".manifest.plist"
+}
+
+// WriteMDMManifest writes the InstallApplication manifest of the IPA at
+// ipaPath, whose app is described by infoPlist, to manifestPath
+func WriteMDMManifest(ipaPath, infoPlist, manifestPath string, options MDMManifest) error {
+	if options.URL == "" {
+		return fmt.Errorf("MDM manifest needs the URL the IPA is served from")
+	}
+	info, _, err := readPlistFile(infoPlist)
+	if err != nil {
+		return err
+	}
+	bundleID, _ := info["CFBundleIdentifier"].(string)
+	version := firstString(info, "CFBundleShortVersionString", "CFBundleVersion")
+	title := options.Title
+	if title == "" {
+		title = firstString(info, "CFBundleDisplayName", "CFBundleName", "CFBundleExecutable")
+	}
+	chunkSize := options.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultMDMChunkSize
+	}
+
+	md5s, size, err := chunkMD5s(ipaPath, chunkSize)
+	if err != nil {
+		return err
+	}
+	item := mdmItem{
+		Assets: []mdmAsset{{Kind: "software-package", URL: options.URL, MD5Size: chunkSize, MD5s: md5s}},
+		Metadata: mdmMetadata{
+			BundleIdentifier: bundleID,
+			BundleVersion:    version,
+			Kind:             "software",
+			Title:            title,
+			SizeInBytes:      size,
+		},
+	}
+	noShine := false
+	if options.DisplayImageURL != "" {
+		item.Assets = append(item.Assets, mdmAsset{Kind: "display-image", URL: options.DisplayImageURL, NeedsShine: &noShine})
+	}
+	if options.FullSizeImageURL != "" {
+		item.Assets = append(item.Assets, mdmAsset{Kind: "full-size-image", URL: options.FullSizeImageURL, NeedsShine: &noShine})
+	}
+
+	data, err := plist.MarshalIndent(mdmManifest{Items: []mdmItem{item}}, plist.XMLFormat, "\t")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", manifestPath, err)
+	}
+	return os.WriteFile(manifestPath, data, 0644)
+}
+
+// firstString returns the first of keys holding a non-empty string in dict
+func firstString(dict map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if value, _ := dict[key].(string); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// chunkMD5s returns the hex MD5 of every chunkSize bytes of the file at
+// path, and the file's size
+func chunkMD5s(path string, chunkSize int64) ([]string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	var md5s []string
+	var size int64
+	for {
+		hash := md5.New()
+		n, err := io.CopyN(hash, f, chunkSize)
+		if n > 0 {
+			md5s = append(md5s, hex.EncodeToString(hash.Sum(nil)))
+			size += n
+		}
+		if err == io.EOF {
+			return md5s, size, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+}
+
+// writeMDMManifest writes the manifest next to the packaged IPA when
+// Config.MDMManifest asks for one
+func (r *Resigner) writeMDMManifest(appPath string) error {
+	if r.config.MDMManifest == nil {
+		return nil
+	}
+	if r.config.InPlace || strings.ToLower(filepath.Ext(r.outputPath)) != ".ipa" {
+		return fmt.Errorf("an MDM manifest needs an IPA output")
+	}
+	manifestPath := MDMManifestPath(r.outputPath)
+	if err := WriteMDMManifest(r.outputPath, filepath.Join(appPath, "Info.plist"), manifestPath, *r.config.MDMManifest); err != nil {
+		return fmt.Errorf("failed to write MDM manifest: %w", err)
+	}
+	r.emitMu.Lock()
+	r.result.ManifestPath = manifestPath
+	r.emitMu.Unlock()
+	r.logProgress(fmt.Sprintf("MDM manifest saved to: %s", manifestPath))
+	return nil
+}
@@ -0,0 +1,78 @@
+package resigner
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"howett.net/plist"
+)
+
+func TestWriteMDMManifest(t *testing.T) {
+	dir := t.TempDir()
+	ipa := filepath.Join(dir, "App.ipa")
+	data := bytes.Repeat([]byte("0123456789"), 25)
+	os.WriteFile(ipa, data, 0644)
+	infoPlist := filepath.Join(dir, "Info.plist")
+	writePlistFile(infoPlist, map[string]interface{}{
+		"CFBundleIdentifier":         "com.example.app",
+		"CFBundleShortVersionString": "2.1",
+		"CFBundleVersion":            "210",
+		"CFBundleName":               "Example",
+	}, plist.XMLFormat)
+
+	manifestPath := MDMManifestPath(ipa)
+	if manifestPath != filepath.Join(dir, "App.manifest.plist") {
+		t.Errorf("MDMManifestPath() = %s", manifestPath)
+	}
+	err := WriteMDMManifest(ipa, infoPlist, manifestPath, MDMManifest{
+		URL:             "https://mdm.example.com/App.ipa",
+		DisplayImageURL: "https://mdm.example.com/icon57.png",
+		ChunkSize:       100,
+	})
+	if err != nil {
+		t.Fatalf("WriteMDMManifest() failed: %v", err)
+	}
+
+	manifest, _, err := readPlistFile(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := manifest["items"].([]interface{})[0].(map[string]interface{})
+	metadata := item["metadata"].(map[string]interface{})
+	wantMetadata := map[string]interface{}{
+		"bundle-identifier": "com.example.app",
+		"bundle-version":    "2.1",
+		"kind":              "software",
+		"title":             "Example",
+		"sizeInBytes":       uint64(250),
+	}
+	if !reflect.DeepEqual(metadata, wantMetadata) {
+		t.Errorf("metadata = %v, want %v", metadata, wantMetadata)
+	}
+
+	assets := item["assets"].([]interface{})
+	if len(assets) != 2 {
+		t.Fatalf("assets = %v, want the package and display image", assets)
+	}
+	pkg := assets[0].(map[string]interface{})
+	var want []interface{}
+	for _, chunk := range [][]byte{data[:100], data[100:200], data[200:]} {
+		sum := md5.Sum(chunk)
+		want = append(want, hex.EncodeToString(sum[:]))
+	}
+	if pkg["kind"] != "software-package" || pkg["md5-size"] != uint64(100) || !reflect.DeepEqual(pkg["md5s"], want) {
+		t.Errorf("software-package asset = %v, want md5s %v", pkg, want)
+	}
+	if image := assets[1].(map[string]interface{}); image["kind"] != "display-image" || image["needs-shine"] != false {
+		t.Errorf("display-image asset = %v", image)
+	}
+
+	if err := WriteMDMManifest(ipa, infoPlist, manifestPath, MDMManifest{}); err == nil {
+		t.Error("WriteMDMManifest() without a URL succeeded")
+	}
+}
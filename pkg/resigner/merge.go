@@ -0,0 +1,99 @@
+package resigner
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+)
+
+// EntitlementsMerge is how Config.Entitlements is combined with the
+// entitlements of the provisioning profile
+type EntitlementsMerge string
+
+const (
+	// MergeReplace signs with the given entitlements alone, ignoring the
+	// profile's; it is the default
+	MergeReplace EntitlementsMerge = "replace"
+	// MergePreferUser adds the profile's entitlements the given file
+	// lacks, keeping the file's value for keys both have
+	MergePreferUser EntitlementsMerge = "merge-prefer-user"
+	// MergePreferProfile adds the profile's entitlements and lets its
+	// values win for keys both have
+	MergePreferProfile EntitlementsMerge = "merge-prefer-profile"
+)
+
+// EntitlementsMerges lists the valid strategies, default first
+var EntitlementsMerges = []EntitlementsMerge{MergeReplace, MergePreferUser, MergePreferProfile}
+
+// validate checks that m is empty or a known strategy
+func (m EntitlementsMerge) validate() error {
+	if m == "" {
+		return nil
+	}
+	for _, known := range EntitlementsMerges {
+		if m == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown entitlements merge strategy %q (use one of: %v)", m, EntitlementsMerges)
+}
+
+// mergeEntitlements combines user with the profile's entitlements under
+// strategy, returning the result and a line for every key it differs from
+// user in
+func mergeEntitlements(user, profile map[string]interface{}, strategy EntitlementsMerge) (map[string]interface{}, []string) {
+	merged := make(map[string]interface{}, len(user)+len(profile))
+	for key, value := range user {
+		merged[key] = value
+	}
+	keys := make([]string, 0, len(profile))
+	for key := range profile {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var diff []string
+	for _, key := range keys {
+		value, exists := user[key]
+		switch {
+		case !exists:
+			merged[key] = profile[key]
+			diff = append(diff, fmt.Sprintf("+ %s = %v (from profile)", key, profile[key]))
+		case strategy == MergePreferProfile && !reflect.DeepEqual(value, profile[key]):
+			merged[key] = profile[key]
+			diff = append(diff, fmt.Sprintf("~ %s = %v (profile replaces %v)", key, profile[key], value))
+		}
+	}
+	return merged, diff
+}
+
+// mergeProfileEntitlements applies Config.EntitlementsMerge to the
+// entitlements copied from Config.Entitlements to entitlementsPath,
+// logging how the result differs from the file
+func (r *Resigner) mergeProfileEntitlements(appPath, entitlementsPath string) error {
+	strategy := r.config.EntitlementsMerge
+	if strategy == "" || strategy == MergeReplace || !r.needsProvisioning() {
+		return nil
+	}
+	profile, err := ParseProfile(filepath.Join(appPath, "embedded.mobileprovision"))
+	if err != nil {
+		r.warn(WarningProfileIgnored, appPath, appPath, "Cannot merge profile entitlements (%s): %v", strategy, err)
+		return nil
+	}
+	user, format, err := readPlistFile(entitlementsPath)
+	if err != nil {
+		return err
+	}
+
+	merged, diff := mergeEntitlements(user, profile.Entitlements, strategy)
+	if len(diff) == 0 {
+		r.logProgress(fmt.Sprintf("Merged profile entitlements (%s): no changes", strategy))
+		return nil
+	}
+	r.logProgress(fmt.Sprintf("Merged profile entitlements (%s):", strategy))
+	for _, line := range diff {
+		r.logProgress("  " + line)
+	}
+	return writePlistFile(entitlementsPath, merged, format)
+}
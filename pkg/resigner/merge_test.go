@@ -0,0 +1,63 @@
+package resigner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeEntitlements(t *testing.T) {
+	user := map[string]interface{}{
+		"application-identifier": "ABCDE12345.com.example.app",
+		"aps-environment":        "development",
+	}
+	profile := map[string]interface{}{
+		"application-identifier": "ABCDE12345.com.example.app",
+		"aps-environment":        "production",
+		"get-task-allow":         false,
+	}
+
+	tests := []struct {
+		strategy EntitlementsMerge
+		want     map[string]interface{}
+		diff     []string
+	}{
+		{MergePreferUser, map[string]interface{}{
+			"application-identifier": "ABCDE12345.com.example.app",
+			"aps-environment":        "development",
+			"get-task-allow":         false,
+		}, []string{"+ get-task-allow = false (from profile)"}},
+		{MergePreferProfile, map[string]interface{}{
+			"application-identifier": "ABCDE12345.com.example.app",
+			"aps-environment":        "production",
+			"get-task-allow":         false,
+		}, []string{
+			"~ aps-environment = production (profile replaces development)",
+			"+ get-task-allow = false (from profile)",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			got, diff := mergeEntitlements(user, profile, tt.strategy)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("merged = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(diff, tt.diff) {
+				t.Errorf("diff = %q, want %q", diff, tt.diff)
+			}
+		})
+	}
+	if user["get-task-allow"] != nil {
+		t.Error("mergeEntitlements() modified the user entitlements")
+	}
+}
+
+func TestEntitlementsMergeValidate(t *testing.T) {
+	for _, m := range append(EntitlementsMerges, "") {
+		if err := m.validate(); err != nil {
+			t.Errorf("validate(%q) = %v", m, err)
+		}
+	}
+	if err := EntitlementsMerge("merge").validate(); err == nil {
+		t.Error("validate() accepted an unknown strategy")
+	}
+}
@@ -0,0 +1,96 @@
+package resigner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// messagesExtensionPoint identifies iMessage app and sticker pack
+// extensions
+const messagesExtensionPoint = "com.apple.message-payload-provider"
+
+// IPA root folders, next to Payload/, holding the stubs App Store Connect
+// requires of apps with an iMessage extension. Every such app needs the
+// extension's; only iMessage-only apps and sticker packs, whose own
+// executable is a stub, need the app's.
+const (
+	messagesAppSupportDir       = "MessagesApplicationSupport"
+	messagesExtensionSupportDir = "MessagesApplicationExtensionSupport"
+)
+
+// payloadApp returns the app bundle in an extracted Payload directory,
+// skipping debris such as .DS_Store or __MACOSX that archivers leave next
+// to it
+func payloadApp(payloadDir string) (string, error) {
+	entries, err := os.ReadDir(payloadDir)
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && strings.EqualFold(filepath.Ext(entry.Name()), ".app") {
+			return filepath.Join(payloadDir, entry.Name()), nil
+		}
+	}
+	return "", fmt.Errorf("no app found in Payload directory")
+}
+
+// isMessagesExtension reports whether the .appex at path extends Messages
+func isMessagesExtension(path string) bool {
+	info, _, err := readPlistFile(filepath.Join(path, "Info.plist"))
+	if err != nil {
+		return false
+	}
+	extension, _ := info["NSExtension"].(map[string]interface{})
+	point, _ := extension["NSExtensionPointIdentifier"].(string)
+	return point == messagesExtensionPoint
+}
+
+// isMessagesOnlyApp reports whether the app only hosts extensions: a
+// sticker pack or iMessage-only app whose main executable is a stub, or
+// missing, and which cannot be launched from the home screen
+func isMessagesOnlyApp(appPath string) bool {
+	info, _, err := readPlistFile(filepath.Join(appPath, "Info.plist"))
+	if err != nil {
+		return false
+	}
+	prohibited, _ := info["LSApplicationLaunchProhibited"].(bool)
+	executable, _ := info["CFBundleExecutable"].(string)
+	return prohibited || executable == "" || executable == "MessagesApplicationStub"
+}
+
+// checkMessagesSupport reports iMessage apps and sticker packs, warning
+// when an IPA lacks the support folders its upload will need
+func (r *Resigner) checkMessagesSupport(appPath string) {
+	plugins, err := os.ReadDir(filepath.Join(appPath, "PlugIns"))
+	if err != nil {
+		return
+	}
+	found := false
+	for _, plugin := range plugins {
+		if filepath.Ext(plugin.Name()) == ".appex" && isMessagesExtension(filepath.Join(appPath, "PlugIns", plugin.Name())) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return
+	}
+
+	supportDirs := []string{messagesExtensionSupportDir}
+	if isMessagesOnlyApp(appPath) {
+		r.logProgress("iMessage-only app or sticker pack detected: signing the stub app and its extensions")
+		supportDirs = append([]string{messagesAppSupportDir}, supportDirs...)
+	} else {
+		r.logProgress("iMessage extension detected")
+	}
+	if strings.ToLower(filepath.Ext(r.config.SourceIPA)) != ".ipa" || r.config.InPlace {
+		return
+	}
+	for _, dir := range supportDirs {
+		if _, err := os.Stat(filepath.Join(r.workspace.Extracted, dir)); err != nil {
+			r.warn(WarningPackageLayout, "", "", "IPA has no %s folder; App Store Connect will reject the upload", dir)
+		}
+	}
+}
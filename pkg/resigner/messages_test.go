@@ -0,0 +1,80 @@
+package resigner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"howett.net/plist"
+)
+
+func TestPayloadApp(t *testing.T) {
+	payload := t.TempDir()
+	os.WriteFile(filepath.Join(payload, ".DS_Store"), nil, 0644)
+	os.MkdirAll(filepath.Join(payload, "__MACOSX"), 0755)
+	if _, err := payloadApp(payload); err == nil {
+		t.Error("payloadApp() found an app in a Payload without one")
+	}
+
+	os.MkdirAll(filepath.Join(payload, "Stickers.app"), 0755)
+	if got, err := payloadApp(payload); err != nil || got != filepath.Join(payload, "Stickers.app") {
+		t.Errorf("payloadApp() = %q, %v", got, err)
+	}
+}
+
+func TestCheckMessagesSupport(t *testing.T) {
+	root := t.TempDir()
+	app := filepath.Join(root, "Payload", "Stickers.app")
+	extension := filepath.Join(app, "PlugIns", "Stickers StickerPackExtension.appex")
+	os.MkdirAll(extension, 0755)
+	writePlistFile(filepath.Join(app, "Info.plist"), map[string]interface{}{
+		"CFBundleIdentifier":            "com.example.stickers",
+		"LSApplicationLaunchProhibited": true,
+	}, plist.XMLFormat)
+	writePlistFile(filepath.Join(extension, "Info.plist"), map[string]interface{}{
+		"NSExtension": map[string]interface{}{"NSExtensionPointIdentifier": messagesExtensionPoint},
+	}, plist.XMLFormat)
+	os.MkdirAll(filepath.Join(root, "MessagesApplicationSupport"), 0755)
+
+	var log strings.Builder
+	r := New(Config{SourceIPA: "Stickers.ipa"}, WithEventHandler(func(e Event) {
+		log.WriteString(e.Message + "\n")
+	}))
+	r.workspace.Extracted = root
+	r.checkMessagesSupport(app)
+
+	if !strings.Contains(log.String(), "sticker pack detected") {
+		t.Errorf("sticker pack not reported:\n%s", log.String())
+	}
+	if !strings.Contains(log.String(), "no MessagesApplicationExtensionSupport folder") {
+		t.Errorf("missing support folder not reported:\n%s", log.String())
+	}
+	if strings.Contains(log.String(), "no MessagesApplicationSupport folder") {
+		t.Errorf("present support folder reported missing:\n%s", log.String())
+	}
+}
+
+func TestCheckMessagesSupportFullApp(t *testing.T) {
+	root := t.TempDir()
+	app := filepath.Join(root, "Payload", "Chat.app")
+	extension := filepath.Join(app, "PlugIns", "Chat MessagesExtension.appex")
+	os.MkdirAll(extension, 0755)
+	writePlistFile(filepath.Join(app, "Info.plist"), map[string]interface{}{
+		"CFBundleIdentifier": "com.example.chat",
+		"CFBundleExecutable": "Chat",
+	}, plist.XMLFormat)
+	writePlistFile(filepath.Join(extension, "Info.plist"), map[string]interface{}{
+		"NSExtension": map[string]interface{}{"NSExtensionPointIdentifier": messagesExtensionPoint},
+	}, plist.XMLFormat)
+
+	r := New(Config{SourceIPA: "Chat.ipa"})
+	r.workspace.Extracted = root
+	r.checkMessagesSupport(app)
+
+	// An app with its own executable needs no MessagesApplicationSupport
+	warnings := r.Result().Warnings
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Message, "no MessagesApplicationExtensionSupport folder") {
+		t.Errorf("warnings = %+v, want only the extension support folder", warnings)
+	}
+}
@@ -0,0 +1,132 @@
+package resigner
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// bundleReferenceKeys are Info.plist keys, as paths through nested
+// dictionaries, by which a nested bundle names another bundle of the app:
+// a watch app its iPhone app, and a WatchKit extension its watch app
+var bundleReferenceKeys = [][]string{
+	{"WKCompanionAppBundleIdentifier"},
+	{"NSExtension", "NSExtensionAttributes", "WKAppBundleIdentifier"},
+}
+
+// bundleReferenceEntitlements hold TEAM.<bundle ID> values tying an App
+// Clip and its parent app together
+var bundleReferenceEntitlements = []string{
+	"com.apple.developer.parent-application-identifiers",
+	"com.apple.developer.associated-appclip-app-identifiers",
+}
+
+// renameNestedBundles gives the extensions, watch apps and App Clips of
+// appPath identifiers derived from the new main bundle ID, then rewrites
+// the keys by which the bundles refer to each other so the whole family
+// stays consistent
+func (r *Resigner) renameNestedBundles(appPath string, components []string, entitlementsPath string) {
+	if r.bundleID == "" {
+		return
+	}
+	renames := make(map[string]string)
+	if r.originalBundleID != "" {
+		renames[r.originalBundleID] = r.bundleID
+	}
+
+	extraCounter := 0
+	var bundles []string
+	for _, component := range components {
+		ext := filepath.Ext(component)
+		if component == appPath || (ext != ".appex" && ext != ".app") {
+			continue
+		}
+		bundles = append(bundles, component)
+		infoPlist := filepath.Join(component, "Info.plist")
+		nestedID, _ := readBundleIdentifier(infoPlist)
+		newBundleID := r.derivedBundleID(nestedID, extraCounter)
+		if ext == ".appex" {
+			r.logProgress(fmt.Sprintf("Changing .appex bundle identifier with: %s", newBundleID))
+		} else {
+			r.logProgress(fmt.Sprintf("Changing nested app bundle identifier with: %s", newBundleID))
+		}
+		if err := setBundleIdentifier(infoPlist, newBundleID); err != nil {
+			r.warn(WarningPlistEdit, appPath, component, "Failed to change bundle ID for %s: %v", filepath.Base(component), err)
+		} else if nestedID != "" {
+			renames[nestedID] = newBundleID
+		}
+		extraCounter++
+	}
+
+	for _, bundle := range bundles {
+		infoPlist := filepath.Join(bundle, "Info.plist")
+		changed, err := rewriteBundleReferences(infoPlist, renames)
+		if err != nil {
+			r.warn(WarningPlistEdit, appPath, bundle, "Failed to update bundle references in %s: %v", filepath.Base(bundle), err)
+			continue
+		}
+		for _, key := range changed {
+			r.logProgress(fmt.Sprintf("Updated %s in %s", key, filepath.Base(bundle)))
+		}
+	}
+
+	if entitlementsPath != "" {
+		if err := rewriteEntitlementReferences(entitlementsPath, renames); err != nil {
+			r.warn(WarningPlistEdit, appPath, appPath, "Failed to update App Clip identifiers: %v", err)
+		}
+	}
+}
+
+// rewriteBundleReferences replaces the bundle identifiers in renames
+// wherever infoPlist refers to them, returning the keys it changed
+func rewriteBundleReferences(infoPlist string, renames map[string]string) ([]string, error) {
+	info, format, err := readPlistFile(infoPlist)
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for _, path := range bundleReferenceKeys {
+		dict := info
+		for _, key := range path[:len(path)-1] {
+			dict, _ = dict[key].(map[string]interface{})
+		}
+		key := path[len(path)-1]
+		current, _ := dict[key].(string)
+		if renamed, ok := renames[current]; ok && renamed != current {
+			dict[key] = renamed
+			changed = append(changed, strings.Join(path, "."))
+		}
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+	return changed, writePlistFile(infoPlist, info, format)
+}
+
+// rewriteEntitlementReferences replaces the bundle identifiers in renames
+// in the TEAM.<bundle ID> values of the App Clip entitlements
+func rewriteEntitlementReferences(entitlementsPath string, renames map[string]string) error {
+	entitlements, format, err := readPlistFile(entitlementsPath)
+	if err != nil {
+		return err
+	}
+	changed := false
+	for _, key := range bundleReferenceEntitlements {
+		values, _ := entitlements[key].([]interface{})
+		for i, value := range values {
+			identifier, _ := value.(string)
+			team, bundleID, found := strings.Cut(identifier, ".")
+			if !found {
+				continue
+			}
+			if renamed, ok := renames[bundleID]; ok && renamed != bundleID {
+				values[i] = team + "." + renamed
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return writePlistFile(entitlementsPath, entitlements, format)
+}
@@ -0,0 +1,78 @@
+package resigner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"howett.net/plist"
+)
+
+func TestRenameNestedBundles(t *testing.T) {
+	app := filepath.Join(t.TempDir(), "Host.app")
+	watchApp := filepath.Join(app, "Watch", "Watch.app")
+	watchExt := filepath.Join(watchApp, "PlugIns", "WatchExt.appex")
+	clip := filepath.Join(app, "AppClips", "Clip.app")
+	infos := map[string]map[string]interface{}{
+		app: {"CFBundleIdentifier": "com.old.app"},
+		watchApp: {
+			"CFBundleIdentifier":             "com.old.app.watchkitapp",
+			"WKCompanionAppBundleIdentifier": "com.old.app",
+		},
+		watchExt: {
+			"CFBundleIdentifier": "com.old.app.watchkitapp.ext",
+			"NSExtension": map[string]interface{}{
+				"NSExtensionAttributes": map[string]interface{}{"WKAppBundleIdentifier": "com.old.app.watchkitapp"},
+			},
+		},
+		clip: {"CFBundleIdentifier": "com.old.app.Clip"},
+	}
+	for dir, info := range infos {
+		os.MkdirAll(dir, 0755)
+		writePlistFile(filepath.Join(dir, "Info.plist"), info, plist.XMLFormat)
+	}
+	entitlementsPath := filepath.Join(t.TempDir(), "entitlements.plist")
+	writePlistFile(entitlementsPath, map[string]interface{}{
+		"com.apple.developer.parent-application-identifiers": []interface{}{"TEAM123.com.old.app"},
+	}, plist.XMLFormat)
+
+	r := New(Config{})
+	if err := r.changeBundleID(app, "com.new.app"); err != nil {
+		t.Fatal(err)
+	}
+	components, err := DefaultComponents().find(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.renameNestedBundles(app, components, entitlementsPath)
+
+	read := func(dir string) map[string]interface{} {
+		t.Helper()
+		info, _, err := readPlistFile(filepath.Join(dir, "Info.plist"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info
+	}
+	if got := read(watchApp); got["CFBundleIdentifier"] != "com.new.app.watchkitapp" || got["WKCompanionAppBundleIdentifier"] != "com.new.app" {
+		t.Errorf("watch app Info.plist = %v", got)
+	}
+	ext := read(watchExt)
+	attributes := ext["NSExtension"].(map[string]interface{})["NSExtensionAttributes"].(map[string]interface{})
+	if ext["CFBundleIdentifier"] != "com.new.app.watchkitapp.ext" || attributes["WKAppBundleIdentifier"] != "com.new.app.watchkitapp" {
+		t.Errorf("watch extension Info.plist = %v", ext)
+	}
+	if got := read(clip)["CFBundleIdentifier"]; got != "com.new.app.Clip" {
+		t.Errorf("App Clip CFBundleIdentifier = %v", got)
+	}
+
+	entitlements, _, err := readPlistFile(entitlementsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{"TEAM123.com.new.app"}
+	if got := entitlements["com.apple.developer.parent-application-identifiers"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("parent-application-identifiers = %v, want %v", got, want)
+	}
+}
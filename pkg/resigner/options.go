@@ -0,0 +1,85 @@
+package resigner
+
+import (
+	"fmt"
+	"io"
+)
+
+// Option customizes a Resigner created with New
+type Option func(*Resigner)
+
+// WithEventHandler registers a handler that receives every typed event
+func WithEventHandler(handler EventHandler) Option {
+	return func(r *Resigner) {
+		if handler != nil {
+			r.handlers = append(r.handlers, handler)
+		}
+	}
+}
+
+// WithProgress registers a plain-text progress callback. It is a
+// convenience wrapper around WithEventHandler that only forwards messages.
+func WithProgress(callback ProgressCallback) Option {
+	return func(r *Resigner) {
+		r.callback = callback
+	}
+}
+
+// WithOutput mirrors every progress message, one per line, to w. Without
+// this option (or a handler) the resigner produces no output at all, so
+// embedding programs can keep stdout clean for machine-readable data.
+func WithOutput(w io.Writer) Option {
+	if w == nil {
+		return func(*Resigner) {}
+	}
+	return WithEventHandler(func(event Event) {
+		fmt.Fprintln(w, event.Message)
+	})
+}
+
+// WithPipeline replaces the stages run by ResignContext, e.g. to skip
+// packaging or insert a custom step. A nil pipeline keeps the default.
+func WithPipeline(pipeline *Pipeline) Option {
+	return func(r *Resigner) {
+		if pipeline != nil {
+			r.pipeline = pipeline
+		}
+	}
+}
+
+// WithComponents replaces the handlers used to find and sign components,
+// e.g. to sign an extra bundle type. A nil registry keeps the default.
+func WithComponents(registry *ComponentRegistry) Option {
+	return func(r *Resigner) {
+		if registry != nil {
+			r.components = registry
+		}
+	}
+}
+
+// WithSecrets registers values, such as keychain or p12 passwords and
+// API tokens, that are masked in every event and in the returned error.
+// Config.ArchivePassword and Config.P12Password are always masked.
+func WithSecrets(secrets ...string) Option {
+	return func(r *Resigner) {
+		r.redactor.Add(secrets...)
+	}
+}
+
+// WithConflictResolver registers the function settling entitlements the
+// provisioning profile cannot satisfy. Without it (and without
+// Config.AutoStrip) they are kept, each with a warning.
+func WithConflictResolver(resolve ConflictResolver) Option {
+	return func(r *Resigner) {
+		r.conflictResolver = resolve
+	}
+}
+
+// WithConfirm registers the function asked before destructive steps;
+// declining fails the run with ErrNotConfirmed. Without it such steps go
+// ahead with a WarningAutoConfirmed.
+func WithConfirm(confirm ConfirmFunc) Option {
+	return func(r *Resigner) {
+		r.confirmFunc = confirm
+	}
+}
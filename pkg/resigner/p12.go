@@ -0,0 +1,157 @@
+package resigner
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/resignipa/pkg/command"
+)
+
+// signingKeychainName is the temporary keychain Config.P12 is imported
+// into, in a private directory of its own
+const signingKeychainName = "signing.keychain-db"
+
+// signingKeychainPrefix starts the names of the temporary directories
+// holding signing keychains; the ID of the process owning one follows
+const signingKeychainPrefix = "resignipa-keychain-"
+
+// validateP12 checks Config.P12 can be combined with the other options
+func (r *Resigner) validateP12() error {
+	if r.config.P12 == nil {
+		return nil
+	}
+	if r.isAdHoc() {
+		return fmt.Errorf("p12 certificate cannot be used with ad-hoc signing")
+	}
+	if r.config.Keychain != "" {
+		return fmt.Errorf("p12 certificate and keychain cannot both be given")
+	}
+	return nil
+}
+
+// createSigningKeychain imports Config.P12 into a new keychain and signs
+// from it alone, the way CI runners without a provisioned login keychain
+// are set up. The keychain is left out of the user's search list, has a
+// random password and never locks on a timeout; it is only locked while
+// hooks run. It lives in a 0700 directory outside the workspace;
+// deleteSigningKeychain removes both when the run ends.
+//
+// security only takes passwords as arguments, so the keychain and p12
+// passwords are visible in the process list while each command runs.
+func (r *Resigner) createSigningKeychain() error {
+	if r.config.P12 == nil {
+		return nil
+	}
+	r.logProgress("Importing the p12 certificate into a temporary keychain")
+	r.removeStaleKeychains()
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	password := hex.EncodeToString(secret)
+	r.redactor.Add(password)
+
+	dir, err := os.MkdirTemp("", fmt.Sprintf("%s%d-", signingKeychainPrefix, os.Getpid()))
+	if err != nil {
+		return fmt.Errorf("failed to create the keychain directory: %w", err)
+	}
+	keychain := filepath.Join(dir, signingKeychainName)
+	if err := r.security("create-keychain", "-p", password, keychain); err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+	r.tempKeychain = keychain
+	r.tempKeychainPassword = password
+
+	// security import only reads files, so the p12 is on disk just long
+	// enough to be imported
+	p12 := filepath.Join(dir, "signing.p12")
+	if err := os.WriteFile(p12, r.config.P12, 0600); err != nil {
+		return fmt.Errorf("failed to write p12 certificate: %w", err)
+	}
+	defer shredFile(p12)
+
+	for _, args := range [][]string{
+		// No arguments removes the auto-lock timeout
+		{"set-keychain-settings", keychain},
+		{"unlock-keychain", "-p", password, keychain},
+		{"import", p12, "-k", keychain, "-f", "pkcs12", "-P", r.config.P12Password, "-T", "/usr/bin/codesign"},
+		// Let codesign use the key without a prompt nobody can answer
+		{"set-key-partition-list", "-S", "apple-tool:,apple:,codesign:", "-s", "-k", password, keychain},
+	} {
+		if err := r.security(args...); err != nil {
+			return err
+		}
+	}
+	r.keychain = keychain
+	return nil
+}
+
+// lockSigningKeychain locks the keychain of Config.P12 while a hook runs.
+// Hooks run as the signing user, so an unlocked keychain would let them
+// sign with its identity; its password never reaches them. Keychains
+// given with Config.Keychain, and the login keychain, are left as they are.
+func (r *Resigner) lockSigningKeychain() error {
+	if r.tempKeychain == "" {
+		return nil
+	}
+	return r.security("lock-keychain", r.tempKeychain)
+}
+
+// unlockSigningKeychain unlocks the keychain lockSigningKeychain locked
+func (r *Resigner) unlockSigningKeychain() error {
+	if r.tempKeychain == "" {
+		return nil
+	}
+	return r.security("unlock-keychain", "-p", r.tempKeychainPassword, r.tempKeychain)
+}
+
+// security runs a security subcommand, failing with its output
+func (r *Resigner) security(args ...string) error {
+	if output, err := r.command("security", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("security %s failed: %s - %w", args[0], strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// deleteSigningKeychain removes the keychain createSigningKeychain made,
+// with the private key in it. It runs after the run's context may have
+// ended, so it is not bound to it.
+func (r *Resigner) deleteSigningKeychain() {
+	if r.tempKeychain == "" {
+		return
+	}
+	if _, err := command.New("security", "delete-keychain", r.tempKeychain).Run(context.Background()); err != nil {
+		r.warn(WarningGeneral, "", "", "Cannot delete the temporary keychain %s: %v", r.tempKeychain, err)
+	}
+	os.RemoveAll(filepath.Dir(r.tempKeychain))
+	if r.keychain == r.tempKeychain {
+		r.keychain = ""
+	}
+	r.tempKeychain = ""
+	r.tempKeychainPassword = ""
+}
+
+// removeStaleKeychains deletes the signing keychains left behind by runs
+// that were killed before deleteSigningKeychain, such as with SIGKILL.
+// Keychains of live processes, this one included, are in use and kept.
+func (r *Resigner) removeStaleKeychains() {
+	dirs, _ := filepath.Glob(filepath.Join(os.TempDir(), signingKeychainPrefix+"*"))
+	for _, dir := range dirs {
+		owner, _, _ := strings.Cut(strings.TrimPrefix(filepath.Base(dir), signingKeychainPrefix), "-")
+		pid, err := strconv.Atoi(owner)
+		if err != nil || pid <= 0 || processAlive(pid) {
+			continue
+		}
+		r.logProgress("Removing the temporary keychain of an interrupted run: " + dir)
+		// Already gone if the run died before creating it
+		r.security("delete-keychain", filepath.Join(dir, signingKeychainName))
+		os.RemoveAll(dir)
+	}
+}
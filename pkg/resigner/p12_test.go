@@ -0,0 +1,139 @@
+package resigner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeSecurity puts a security stand-in on PATH that records its
+// arguments, one call per line, and fails the subcommand named fail
+func fakeSecurity(t *testing.T, fail string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as security")
+	}
+	bin := t.TempDir()
+	calls := filepath.Join(bin, "calls")
+	script := "#!/bin/sh\necho \"$*\" >> " + calls + "\n" +
+		"if [ \"$1\" = " + fail + " ]; then echo 'wrong password' >&2; exit 1; fi\n" +
+		"if [ \"$1\" = create-keychain ]; then touch \"$4\"; fi\n" +
+		"if [ \"$1\" = delete-keychain ]; then rm -f \"$2\"; fi\n"
+	os.WriteFile(filepath.Join(bin, "security"), []byte(script), 0755)
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return calls
+}
+
+func TestValidateP12(t *testing.T) {
+	for _, tt := range []struct {
+		config Config
+		want   string
+	}{
+		{Config{Certificate: "Apple Distribution", P12: []byte("p12")}, ""},
+		{Config{AdHoc: true, P12: []byte("p12")}, "ad-hoc"},
+		{Config{Certificate: "Apple Distribution", P12: []byte("p12"), Keychain: "ci.keychain"}, "cannot both be given"},
+	} {
+		err := New(tt.config).validateP12()
+		if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("validateP12() = %v, want %q", err, tt.want)
+		}
+	}
+}
+
+func TestSigningKeychain(t *testing.T) {
+	calls := fakeSecurity(t, "none")
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	root := t.TempDir()
+	r := New(Config{Certificate: "Apple Distribution", P12: []byte("p12"), P12Password: "hunter2"})
+	r.workspace = newWorkspace(root)
+	if err := r.createSigningKeychain(); err != nil {
+		t.Fatalf("createSigningKeychain() failed: %v", err)
+	}
+
+	// Private to the user and out of the workspace
+	keychain := r.tempKeychain
+	dir := filepath.Dir(keychain)
+	if filepath.Dir(dir) != tmp || filepath.Base(keychain) != signingKeychainName {
+		t.Fatalf("temporary keychain = %q, want %s in a directory of %s", keychain, signingKeychainName, tmp)
+	}
+	if info, err := os.Stat(dir); err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("keychain directory mode = %v, %v; want 0700", info, err)
+	}
+	if got := r.codesignKeychainArgs(); len(got) != 2 || got[1] != keychain {
+		t.Errorf("codesignKeychainArgs() = %q, want the temporary keychain", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "signing.p12")); !os.IsNotExist(err) {
+		t.Errorf("p12 left on disk: %v", err)
+	}
+	data, _ := os.ReadFile(calls)
+	log := string(data)
+	for _, want := range []string{"create-keychain -p ", "unlock-keychain -p ", "import " + filepath.Join(dir, "signing.p12") + " -k " + keychain + " -f pkcs12 -P hunter2", "set-key-partition-list "} {
+		if !strings.Contains(log, want) {
+			t.Errorf("security calls lack %q:\n%s", want, log)
+		}
+	}
+	if strings.Contains(log, "list-keychains") {
+		t.Error("temporary keychain was added to the search list")
+	}
+
+	r.closeWorkspace()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("temporary keychain survived the run: %v", err)
+	}
+	if r.keychain != "" || r.tempKeychain != "" {
+		t.Errorf("keychain = %q, tempKeychain = %q after the run", r.keychain, r.tempKeychain)
+	}
+}
+
+func TestSigningKeychainImportFails(t *testing.T) {
+	calls := fakeSecurity(t, "import")
+	t.Setenv("TMPDIR", t.TempDir())
+	root := t.TempDir()
+	r := New(Config{Certificate: "Apple Distribution", P12: []byte("p12"), P12Password: "hunter2"})
+	r.workspace = newWorkspace(root)
+	err := r.createSigningKeychain()
+	if err == nil || !strings.Contains(err.Error(), "wrong password") {
+		t.Fatalf("createSigningKeychain() = %v, want the import error", err)
+	}
+	if strings.Contains(r.redactor.Redact("import -P hunter2"), "hunter2") {
+		t.Error("p12 password is not masked")
+	}
+
+	// The keychain made before the failure is still deleted
+	keychain := r.tempKeychain
+	r.closeWorkspace()
+	if data, _ := os.ReadFile(calls); keychain == "" || !strings.Contains(string(data), "delete-keychain "+keychain) {
+		t.Errorf("temporary keychain %q not deleted:\n%s", keychain, data)
+	}
+}
+
+func TestRemoveStaleKeychains(t *testing.T) {
+	calls := fakeSecurity(t, "none")
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	// No process has the largest possible ID, while this one is alive
+	stale := filepath.Join(tmp, signingKeychainPrefix+"2147483647-1")
+	live := filepath.Join(tmp, fmt.Sprintf("%s%d-1", signingKeychainPrefix, os.Getpid()))
+	other := filepath.Join(tmp, signingKeychainPrefix+"unknown")
+	for _, dir := range []string{stale, live, other} {
+		os.MkdirAll(dir, 0700)
+		os.WriteFile(filepath.Join(dir, signingKeychainName), []byte("keychain"), 0600)
+	}
+
+	New(Config{}).removeStaleKeychains()
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale keychain kept: %v", err)
+	}
+	for _, dir := range []string{live, other} {
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("%s removed: %v", filepath.Base(dir), err)
+		}
+	}
+	if data, _ := os.ReadFile(calls); string(data) != "delete-keychain "+filepath.Join(stale, signingKeychainName)+"\n" {
+		t.Errorf("security calls = %q, want only the stale keychain deleted", data)
+	}
+}
@@ -0,0 +1,303 @@
+package resigner
+
+import (
+	"fmt"
+)
+
+// Names of the built-in stages, in their default order
+const (
+	StageExtract      = "extract"
+	StageProvision    = "provision"
+	StageEntitlements = "entitlements"
+	StageBundleID     = "bundle-id"
+	StagePrivacy      = "privacy"
+	StageSign         = "sign"
+	StageVerify       = "verify"
+	StagePackage      = "package"
+)
+
+// State is the working data passed from stage to stage
+type State struct {
+	// WorkDir is the run's temporary directory, removed afterwards.
+	//
+	// Deprecated: use Workspace.Root.
+	WorkDir string
+	// Workspace is the layout of the run's temporary directory
+	Workspace Workspace
+	// AppPath is the .app bundle being signed, set by the extract stage
+	AppPath string
+	// EntitlementsPath is the entitlements plist used for signing, set by
+	// the entitlements stage; components are signed without explicit
+	// entitlements when it is empty
+	EntitlementsPath string
+}
+
+// StageFunc performs one step of a resign run
+type StageFunc func(r *Resigner, state *State) error
+
+// Stage is a named step of a Pipeline
+type Stage struct {
+	Name string
+	Run  StageFunc
+}
+
+// Pipeline is an ordered list of stages run by ResignContext. Pipelines
+// are immutable; every method returns a modified copy.
+type Pipeline struct {
+	stages []Stage
+}
+
+// NewPipeline creates a pipeline running stages in the given order
+func NewPipeline(stages ...Stage) *Pipeline {
+	return &Pipeline{stages: append([]Stage(nil), stages...)}
+}
+
+// DefaultPipeline returns the stages of a regular resign: extract the app,
+// install the profile, prepare entitlements, apply the bundle ID, add
+// the privacy manifest, sign, verify the signature if asked and package
+// the output
+func DefaultPipeline() *Pipeline {
+	return NewPipeline(
+		Stage{Name: StageExtract, Run: (*Resigner).stageExtract},
+		Stage{Name: StageProvision, Run: (*Resigner).stageProvision},
+		Stage{Name: StageEntitlements, Run: (*Resigner).stageEntitlements},
+		Stage{Name: StageBundleID, Run: (*Resigner).stageBundleID},
+		Stage{Name: StagePrivacy, Run: (*Resigner).stagePrivacy},
+		Stage{Name: StageSign, Run: (*Resigner).stageSign},
+		Stage{Name: StageVerify, Run: (*Resigner).stageVerify},
+		Stage{Name: StagePackage, Run: (*Resigner).stagePackage},
+	)
+}
+
+// Stages returns a copy of the pipeline's stages
+func (p *Pipeline) Stages() []Stage {
+	return append([]Stage(nil), p.stages...)
+}
+
+// Names returns the stage names in order
+func (p *Pipeline) Names() []string {
+	names := make([]string, len(p.stages))
+	for i, stage := range p.stages {
+		names[i] = stage.Name
+	}
+	return names
+}
+
+// index returns the position of the named stage
+func (p *Pipeline) index(name string) (int, error) {
+	for i, stage := range p.stages {
+		if stage.Name == name {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("unknown stage %q", name)
+}
+
+// Without returns the pipeline minus the named stages
+func (p *Pipeline) Without(names ...string) (*Pipeline, error) {
+	skip := make(map[string]bool)
+	for _, name := range names {
+		if _, err := p.index(name); err != nil {
+			return nil, err
+		}
+		skip[name] = true
+	}
+	var stages []Stage
+	for _, stage := range p.stages {
+		if !skip[stage.Name] {
+			stages = append(stages, stage)
+		}
+	}
+	return NewPipeline(stages...), nil
+}
+
+// Until returns the pipeline up to and including the named stage, for
+// runs that only need part of the work, e.g. signing without packaging
+func (p *Pipeline) Until(name string) (*Pipeline, error) {
+	i, err := p.index(name)
+	if err != nil {
+		return nil, err
+	}
+	return NewPipeline(p.stages[:i+1]...), nil
+}
+
+// InsertBefore returns the pipeline with stage added before the named one
+func (p *Pipeline) InsertBefore(name string, stage Stage) (*Pipeline, error) {
+	i, err := p.index(name)
+	if err != nil {
+		return nil, err
+	}
+	return p.insert(i, stage), nil
+}
+
+// InsertAfter returns the pipeline with stage added after the named one
+func (p *Pipeline) InsertAfter(name string, stage Stage) (*Pipeline, error) {
+	i, err := p.index(name)
+	if err != nil {
+		return nil, err
+	}
+	return p.insert(i+1, stage), nil
+}
+
+// Replace returns the pipeline with the named stage swapped for stage
+func (p *Pipeline) Replace(name string, stage Stage) (*Pipeline, error) {
+	i, err := p.index(name)
+	if err != nil {
+		return nil, err
+	}
+	stages := p.Stages()
+	stages[i] = stage
+	return NewPipeline(stages...), nil
+}
+
+// insert places stage at position i
+func (p *Pipeline) insert(i int, stage Stage) *Pipeline {
+	stages := make([]Stage, 0, len(p.stages)+1)
+	stages = append(stages, p.stages[:i]...)
+	stages = append(stages, stage)
+	stages = append(stages, p.stages[i:]...)
+	return NewPipeline(stages...)
+}
+
+// Built-in stages
+
+// stageExtract verifies and unpacks the source, repairs it if asked,
+// strips codesign-breaking xattrs, detects simulator builds, audits
+// embedded frameworks and diagnoses MDM app wrapping
+func (r *Resigner) stageExtract(state *State) error {
+	// Catch broken downloads before extracting anything
+	if err := r.verifySource(); err != nil {
+		return err
+	}
+
+	appPath, err := r.extractApp()
+	if err != nil {
+		return fmt.Errorf("failed to extract app: %w", err)
+	}
+	state.AppPath = appPath
+
+	if err := r.fixBundle(appPath); err != nil {
+		return err
+	}
+	if err := r.stripDetritus(appPath); err != nil {
+		return err
+	}
+
+	// Simulator builds need no provisioning profile
+	simulator, err := IsSimulatorApp(appPath)
+	if err != nil {
+		r.warn(WarningGeneral, appPath, "", "Could not tell whether this is a simulator build, assuming a device build: %v", err)
+	}
+	r.simulator = simulator
+	if simulator {
+		r.logProgress("Simulator build detected: provisioning profile not required")
+	}
+
+	r.checkMessagesSupport(appPath)
+	r.reportCompiledResources(appPath)
+
+	// Flag frameworks that will crash the app at launch regardless of
+	// how well it is signed
+	r.warnFrameworkIssues(appPath)
+	return r.checkAppWrapping(appPath)
+}
+
+// stageProvision embeds the provisioning profile and, in enterprise mode,
+// checks it is an In-House one
+func (r *Resigner) stageProvision(state *State) error {
+	if err := r.handleMobileProvision(state.AppPath); err != nil {
+		return fmt.Errorf("failed to handle mobile provision: %w", err)
+	}
+	return r.checkEnterpriseProfile(state.AppPath)
+}
+
+// stageEntitlements prepares the entitlements to sign with and matches
+// beta-reports-active to the profile
+func (r *Resigner) stageEntitlements(state *State) error {
+	entitlementsPath, err := r.extractEntitlements(state.AppPath)
+	if err != nil {
+		return fmt.Errorf("failed to extract entitlements: %w", err)
+	}
+	state.EntitlementsPath = entitlementsPath
+	return r.applyBetaReports(state.AppPath, entitlementsPath)
+}
+
+// stageBundleID applies the bundle ID, checks it against the target
+// device and reconciles it, and the team, with the provisioning profile
+func (r *Resigner) stageBundleID(state *State) error {
+	if err := r.handleBundleID(state.AppPath); err != nil {
+		return fmt.Errorf("failed to handle bundle ID: %w", err)
+	}
+	if err := r.checkDeviceConflict(state.AppPath); err != nil {
+		return fmt.Errorf("failed to check device: %w", err)
+	}
+	if !r.needsProvisioning() || state.EntitlementsPath == "" {
+		return nil
+	}
+
+	// Reconcile the bundle ID with the profile's app ID
+	if err := r.resolveApplicationIdentifier(state.AppPath, state.EntitlementsPath); err != nil {
+		return fmt.Errorf("failed to resolve application identifier: %w", err)
+	}
+
+	// Make team-scoped entitlements agree with the signing team
+	if err := r.applyTeamID(state.AppPath, state.EntitlementsPath); err != nil {
+		return fmt.Errorf("failed to apply team ID: %w", err)
+	}
+
+	// Settle entitlements the profile cannot grant before codesign
+	// embeds them
+	return r.resolveEntitlementConflicts(state.AppPath, state.EntitlementsPath)
+}
+
+// stagePrivacy merges the configured privacy manifest into the app
+func (r *Resigner) stagePrivacy(state *State) error {
+	if err := r.injectPrivacyManifest(state.AppPath); err != nil {
+		return fmt.Errorf("failed to inject privacy manifest: %w", err)
+	}
+	return nil
+}
+
+// stageSign removes development entitlements from distribution
+// signatures, enforces the signing policy and confirms dropped
+// entitlements, then signs every component and dumps the entitlements
+// they were signed with if asked
+func (r *Resigner) stageSign(state *State) error {
+	if err := r.stripDevelopmentEntitlements(state.AppPath, state.EntitlementsPath); err != nil {
+		return err
+	}
+	if err := r.enforcePolicy(state.AppPath, state.EntitlementsPath); err != nil {
+		return err
+	}
+	if err := r.confirmStrippedEntitlements(state.AppPath, state.EntitlementsPath); err != nil {
+		return err
+	}
+	if err := r.signComponents(state.AppPath, state.EntitlementsPath); err != nil {
+		return fmt.Errorf("failed to sign components: %w", err)
+	}
+	return r.dumpEntitlements(state.AppPath)
+}
+
+// stageVerify checks the signature with codesign when Config.Verify is set
+func (r *Resigner) stageVerify(state *State) error {
+	if !r.config.Verify {
+		return nil
+	}
+	return r.verifySignature(state.AppPath)
+}
+
+// stagePackage writes the output, which createResignedIPA checks against
+// the size budget, then its MDM manifest and its dSYMs, clearing
+// quarantine if asked
+func (r *Resigner) stagePackage(state *State) error {
+	if err := r.createResignedIPA(state.AppPath); err != nil {
+		return fmt.Errorf("failed to create resigned IPA: %w", err)
+	}
+	if err := r.writeMDMManifest(state.AppPath); err != nil {
+		return err
+	}
+	if err := r.handleDSYMs(state.AppPath); err != nil {
+		return fmt.Errorf("failed to handle dSYMs: %w", err)
+	}
+	return r.removeQuarantine()
+}
@@ -0,0 +1,168 @@
+package resigner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"howett.net/plist"
+)
+
+func TestDefaultPipeline(t *testing.T) {
+	want := []string{StageExtract, StageProvision, StageEntitlements, StageBundleID, StagePrivacy, StageSign, StageVerify, StagePackage}
+	if got := DefaultPipeline().Names(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+}
+
+func TestPipelineEditing(t *testing.T) {
+	custom := Stage{Name: "custom", Run: func(*Resigner, *State) error { return nil }}
+	base := DefaultPipeline()
+
+	p, err := base.Without(StageProvision, StageVerify, StagePackage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err = p.InsertAfter(StageSign, custom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{StageExtract, StageEntitlements, StageBundleID, StagePrivacy, StageSign, "custom"}
+	if got := p.Names(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+
+	p, err = p.InsertBefore(StageExtract, Stage{Name: "first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Names()[0] != "first" {
+		t.Errorf("InsertBefore() did not prepend: %v", p.Names())
+	}
+
+	p, err = p.Replace("custom", Stage{Name: "replaced"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := p.Names(); names[len(names)-1] != "replaced" {
+		t.Errorf("Replace() = %v", names)
+	}
+
+	if len(base.Names()) != len(DefaultPipeline().Names()) {
+		t.Error("Editing modified the original pipeline")
+	}
+	if _, err := base.Without("missing"); err == nil {
+		t.Error("Expected error for unknown stage")
+	}
+
+	until, err := base.Until(StageSign)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{StageExtract, StageProvision, StageEntitlements, StageBundleID, StagePrivacy, StageSign}
+	if got := until.Names(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Until() = %v, want %v", got, want)
+	}
+	if _, err := base.Until("missing"); err == nil {
+		t.Error("Expected error for unknown stage")
+	}
+}
+
+func TestCustomPipeline(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "test.ipa")
+	if err := os.WriteFile(source, []byte("ipa"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var ran []string
+	record := func(name string) Stage {
+		return Stage{Name: name, Run: func(r *Resigner, state *State) error {
+			if state.WorkDir == "" {
+				t.Errorf("stage %s has no work dir", name)
+			}
+			ran = append(ran, name)
+			r.Warn(Warning{Message: name})
+			return nil
+		}}
+	}
+	failure := errors.New("stop")
+	fail := Stage{Name: "fail", Run: func(*Resigner, *State) error { return failure }}
+
+	r := New(Config{SourceIPA: source, Certificate: "Test"},
+		WithPipeline(NewPipeline(record("one"), record("two"), fail, record("three"))))
+	result, err := r.Resign()
+	if !errors.Is(err, failure) {
+		t.Fatalf("Resign() error = %v, want %v", err, failure)
+	}
+	if !reflect.DeepEqual(ran, []string{"one", "two"}) {
+		t.Errorf("ran = %v", ran)
+	}
+
+	// A failed run still reports how far it got
+	if result == nil {
+		t.Fatal("Resign() returned no result")
+	}
+	var stages []string
+	for _, stage := range result.Stages {
+		stages = append(stages, stage.Name)
+	}
+	if !reflect.DeepEqual(stages, []string{"one", "two", "fail"}) {
+		t.Errorf("Result.Stages = %v", stages)
+	}
+	want := []Warning{{Code: WarningGeneral, Message: "one"}, {Code: WarningGeneral, Message: "two"}}
+	if !reflect.DeepEqual(result.Warnings, want) {
+		t.Errorf("Result.Warnings = %v", result.Warnings)
+	}
+	if result.Duration <= 0 || result.OutputPath != "" {
+		t.Errorf("Result = %+v", result)
+	}
+}
+
+func TestResignerReuse(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "test.ipa")
+	if err := os.WriteFile(source, []byte("ipa"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each run renames the app it is given when a bundle ID is set
+	var appID string
+	var original, renamed []string
+	rename := Stage{Name: "rename", Run: func(r *Resigner, state *State) error {
+		app := filepath.Join(state.WorkDir, "Test.app")
+		os.MkdirAll(app, 0755)
+		writePlistFile(filepath.Join(app, "Info.plist"), map[string]interface{}{"CFBundleIdentifier": appID}, plist.XMLFormat)
+		if r.simulator {
+			t.Error("simulator build carried over from the previous run")
+		}
+		r.simulator = true
+		if r.config.BundleID != "" {
+			if err := r.changeBundleID(app, r.config.BundleID); err != nil {
+				return err
+			}
+		}
+		original = append(original, r.originalBundleID)
+		renamed = append(renamed, r.bundleID)
+		return nil
+	}}
+
+	r := New(Config{SourceIPA: source, Certificate: "Test"}, WithPipeline(NewPipeline(rename)))
+	for _, run := range []struct{ appID, bundleID string }{
+		{"com.first.app", "com.new.first"},
+		{"com.second.app", "com.new.second"},
+		{"com.third.app", ""},
+	} {
+		appID, r.config.BundleID = run.appID, run.bundleID
+		if _, err := r.Resign(); err != nil {
+			t.Fatalf("Resign() of %s failed: %v", run.appID, err)
+		}
+	}
+
+	if want := []string{"com.first.app", "com.second.app", ""}; !reflect.DeepEqual(original, want) {
+		t.Errorf("original bundle IDs = %q, want %q", original, want)
+	}
+	if want := []string{"com.new.first", "com.new.second", ""}; !reflect.DeepEqual(renamed, want) {
+		t.Errorf("new bundle IDs = %q, want %q", renamed, want)
+	}
+}
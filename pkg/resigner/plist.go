@@ -0,0 +1,93 @@
+package resigner
+
+import (
+	"fmt"
+	"os"
+
+	"howett.net/plist"
+)
+
+// readPlistFile decodes a dictionary plist, returning it with its on-disk
+// format so it can be written back unchanged
+func readPlistFile(path string) (map[string]interface{}, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var dict map[string]interface{}
+	format, err := plist.Unmarshal(data, &dict)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if dict == nil {
+		dict = make(map[string]interface{})
+	}
+	return dict, format, nil
+}
+
+// writePlistFile encodes dict to path in the given plist format; path
+// must not be a symlink
+func writePlistFile(path string, dict map[string]interface{}, format int) error {
+	if err := refuseSymlink(path); err != nil {
+		return err
+	}
+	var data []byte
+	var err error
+	if format == plist.XMLFormat {
+		data, err = plist.MarshalIndent(dict, format, "\t")
+	} else {
+		data, err = plist.Marshal(dict, format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// readBundleIdentifier returns CFBundleIdentifier from an Info.plist
+func readBundleIdentifier(infoPlist string) (string, error) {
+	info, _, err := readPlistFile(infoPlist)
+	if err != nil {
+		return "", err
+	}
+	bundleID, _ := info["CFBundleIdentifier"].(string)
+	if bundleID == "" {
+		return "", fmt.Errorf("%s has no CFBundleIdentifier", infoPlist)
+	}
+	return bundleID, nil
+}
+
+// InfoPlistError is returned when a key of an Info.plist cannot be set
+type InfoPlistError struct {
+	Path string
+	Key  string
+	Err  error
+}
+
+func (e *InfoPlistError) Error() string {
+	return fmt.Sprintf("cannot set %s in %s: %v", e.Key, e.Path, e.Err)
+}
+
+func (e *InfoPlistError) Unwrap() error {
+	return e.Err
+}
+
+// setBundleIdentifier sets CFBundleIdentifier in an Info.plist, adding the
+// key when it is missing. The plist keeps its format.
+func setBundleIdentifier(infoPlist, bundleID string) error {
+	info, format, err := readPlistFile(infoPlist)
+	if err != nil {
+		return &InfoPlistError{Path: infoPlist, Key: "CFBundleIdentifier", Err: err}
+	}
+	if current, ok := info["CFBundleIdentifier"]; ok {
+		if _, isString := current.(string); !isString {
+			return &InfoPlistError{Path: infoPlist, Key: "CFBundleIdentifier", Err: fmt.Errorf("existing value is a %T, not a string", current)}
+		}
+	}
+	info["CFBundleIdentifier"] = bundleID
+	if err := writePlistFile(infoPlist, info, format); err != nil {
+		return &InfoPlistError{Path: infoPlist, Key: "CFBundleIdentifier", Err: err}
+	}
+	return nil
+}
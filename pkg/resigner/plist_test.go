@@ -0,0 +1,89 @@
+package resigner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"howett.net/plist"
+)
+
+func TestSetBundleIdentifier(t *testing.T) {
+	dir := t.TempDir()
+	infoPlist := filepath.Join(dir, "Info.plist")
+
+	// Characters PlistBuddy would need quoted are written as-is, and a
+	// binary plist stays binary
+	writePlistFile(infoPlist, map[string]interface{}{"CFBundleIdentifier": "com.old.app"}, plist.BinaryFormat)
+	if err := setBundleIdentifier(infoPlist, "com.new.app-'beta' \\x"); err != nil {
+		t.Fatalf("setBundleIdentifier() failed: %v", err)
+	}
+	info, format, err := readPlistFile(infoPlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info["CFBundleIdentifier"]; got != "com.new.app-'beta' \\x" {
+		t.Errorf("CFBundleIdentifier = %v", got)
+	}
+	if format != plist.BinaryFormat {
+		t.Errorf("format = %d, want binary", format)
+	}
+
+	// A missing key is added
+	writePlistFile(infoPlist, map[string]interface{}{"CFBundleName": "Test"}, plist.XMLFormat)
+	if err := setBundleIdentifier(infoPlist, "com.new.app"); err != nil {
+		t.Fatalf("setBundleIdentifier() failed: %v", err)
+	}
+	if got, _ := readBundleIdentifier(infoPlist); got != "com.new.app" {
+		t.Errorf("CFBundleIdentifier = %q, want com.new.app", got)
+	}
+
+	// A value of the wrong type, or an unreadable file, is a typed error
+	writePlistFile(infoPlist, map[string]interface{}{"CFBundleIdentifier": 42}, plist.XMLFormat)
+	var plistErr *InfoPlistError
+	if err := setBundleIdentifier(infoPlist, "com.new.app"); !errors.As(err, &plistErr) {
+		t.Errorf("setBundleIdentifier() of a non-string value = %v, want *InfoPlistError", err)
+	}
+	if err := setBundleIdentifier(filepath.Join(dir, "missing.plist"), "com.new.app"); !errors.As(err, &plistErr) || !os.IsNotExist(errors.Unwrap(err)) {
+		t.Errorf("setBundleIdentifier() of a missing file = %v", err)
+	}
+}
+
+// FuzzSetBundleIdentifier edits arbitrary Info.plist data, which must fail
+// cleanly or leave the new identifier readable
+func FuzzSetBundleIdentifier(f *testing.F) {
+	for _, format := range []int{plist.XMLFormat, plist.BinaryFormat, plist.OpenStepFormat} {
+		data, _ := plist.Marshal(map[string]interface{}{"CFBundleIdentifier": "com.old.app"}, format)
+		f.Add(data, "com.new.app")
+	}
+	f.Add([]byte("<plist><array/></plist>"), "com.new.app")
+	f.Add([]byte("bplist00"), "")
+	f.Fuzz(func(t *testing.T, data []byte, id string) {
+		infoPlist := filepath.Join(t.TempDir(), "Info.plist")
+		os.WriteFile(infoPlist, data, 0644)
+		if err := setBundleIdentifier(infoPlist, id); err != nil || ValidateBundleID(id) != nil {
+			return
+		}
+		if got, err := readBundleIdentifier(infoPlist); got != id {
+			t.Errorf("readBundleIdentifier() = %q, %v, want %q", got, err, id)
+		}
+	})
+}
+
+func TestWritePlistFileRefusesSymlink(t *testing.T) {
+	dir := t.TempDir()
+	victim := filepath.Join(dir, "victim.plist")
+	writePlistFile(victim, map[string]interface{}{"CFBundleIdentifier": "com.victim"}, plist.XMLFormat)
+	infoPlist := filepath.Join(dir, "Info.plist")
+	if err := os.Symlink(victim, infoPlist); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := setBundleIdentifier(infoPlist, "com.new.app"); err == nil {
+		t.Error("setBundleIdentifier() wrote through a symlink")
+	}
+	if got, _ := readBundleIdentifier(victim); got != "com.victim" {
+		t.Errorf("link target CFBundleIdentifier = %q, want it untouched", got)
+	}
+}
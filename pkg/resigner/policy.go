@@ -0,0 +1,233 @@
+package resigner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrPolicy is returned when a run breaks its signing policy
+var ErrPolicy = errors.New("not allowed by the signing policy")
+
+// Policy restricts what a shared signing machine may sign, so engineers
+// cannot sign with someone else's certificate or for an unexpected app.
+// Every list is optional and an empty one allows anything. Entries are
+// patterns in path.Match syntax: "com.example.*" allows every bundle ID
+// starting with "com.example.".
+type Policy struct {
+	// Certificates are the identities that may sign, by name or SHA-1
+	// hash; "-" allows ad-hoc signing
+	Certificates []string `yaml:"certificates"`
+	// TeamIDs are the teams apps may be signed for
+	TeamIDs []string `yaml:"teamIds"`
+	// BundleIDs are the bundle IDs the app and its extensions may have
+	BundleIDs []string `yaml:"bundleIds"`
+	// Entitlements limits the entitlement keys apps are signed with
+	Entitlements EntitlementPolicy `yaml:"entitlements"`
+}
+
+// EntitlementPolicy limits entitlement keys. Keys set to false do not
+// count, so denying get-task-allow still allows it to be turned off.
+type EntitlementPolicy struct {
+	// Allow, if set, lists the only keys that may be used
+	Allow []string `yaml:"allow"`
+	// Deny lists keys that may never be used, even if allowed
+	Deny []string `yaml:"deny"`
+}
+
+// LoadPolicy reads a YAML policy file. Unknown keys are rejected so a
+// misspelt rule cannot silently allow everything.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	var policy Policy
+	if err := decoder.Decode(&policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy %s: %w", path, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %w", path, err)
+	}
+	return &policy, nil
+}
+
+// Validate checks that every pattern is well-formed
+func (p *Policy) Validate() error {
+	rules := map[string][]string{
+		"certificates":       p.Certificates,
+		"teamIds":            p.TeamIDs,
+		"bundleIds":          p.BundleIDs,
+		"entitlements.allow": p.Entitlements.Allow,
+		"entitlements.deny":  p.Entitlements.Deny,
+	}
+	for rule, patterns := range rules {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("%s: bad pattern %q", rule, pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// matchesAny reports whether value matches one of patterns
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// identityTeamPattern finds the team ID Apple puts at the end of signing
+// identity names, e.g. "Apple Distribution: Example Corp (ABCDE12345)"
+var identityTeamPattern = regexp.MustCompile(`\(([A-Z0-9]{10})\)$`)
+
+// enforcePolicy checks the finished app against Config.Policy just before
+// signing, when the bundle IDs, team and entitlements are final. Every
+// violation is reported, not just the first.
+func (r *Resigner) enforcePolicy(appPath, entitlementsPath string) error {
+	policy := r.config.Policy
+	if policy == nil {
+		return nil
+	}
+	r.logProgress("Checking signing policy")
+	var violations []string
+
+	if len(policy.Certificates) > 0 {
+		for _, identity := range r.policyIdentities() {
+			if !matchesAny(policy.Certificates, identity.Name) && !matchesAny(policy.Certificates, identity.Hash) {
+				violations = append(violations, fmt.Sprintf("certificate %q", identity.Name))
+			}
+		}
+	}
+
+	var entitlements map[string]interface{}
+	if entitlementsPath != "" {
+		var err error
+		if entitlements, _, err = readPlistFile(entitlementsPath); err != nil {
+			return err
+		}
+	}
+
+	// Ad-hoc signatures have no team
+	if len(policy.TeamIDs) > 0 && !r.isAdHoc() {
+		team := r.policyTeam(entitlements)
+		switch {
+		case team == "":
+			violations = append(violations, "team ID could not be determined")
+		case !matchesAny(policy.TeamIDs, team):
+			violations = append(violations, fmt.Sprintf("team ID %s", team))
+		}
+	}
+
+	if len(policy.BundleIDs) > 0 {
+		bundleIDs, err := r.policyBundleIDs(appPath)
+		if err != nil {
+			return err
+		}
+		for _, bundleID := range bundleIDs {
+			if !matchesAny(policy.BundleIDs, bundleID) {
+				violations = append(violations, fmt.Sprintf("bundle ID %s", bundleID))
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(entitlements))
+	for key, value := range entitlements {
+		if enabled, ok := value.(bool); !ok || enabled {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if matchesAny(policy.Entitlements.Deny, key) ||
+			(len(policy.Entitlements.Allow) > 0 && !matchesAny(policy.Entitlements.Allow, key)) {
+			violations = append(violations, fmt.Sprintf("entitlement %s", key))
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("%s: %w", strings.Join(violations, ", "), ErrPolicy)
+	}
+	return nil
+}
+
+// policyIdentities returns every identity the run signs with
+func (r *Resigner) policyIdentities() []SigningIdentity {
+	var identities []SigningIdentity
+	switch {
+	case r.isAdHoc():
+		identities = append(identities, SigningIdentity{Hash: "-", Name: "-"})
+	case r.signingIdentity.Name != "":
+		identities = append(identities, r.signingIdentity)
+	default:
+		identities = append(identities, SigningIdentity{Name: r.config.Certificate})
+	}
+
+	exts := make([]string, 0, len(r.componentIdentities))
+	for ext := range r.componentIdentities {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	for _, ext := range exts {
+		identities = append(identities, r.componentIdentities[ext])
+	}
+	return identities
+}
+
+// policyTeam returns the team the app is signed for: the one applied to
+// its entitlements, else the one named in the signing identity
+func (r *Resigner) policyTeam(entitlements map[string]interface{}) string {
+	if r.teamID != "" {
+		return r.teamID
+	}
+	if team, _ := entitlements["com.apple.developer.team-identifier"].(string); team != "" {
+		return team
+	}
+	if appIdentifier, _ := entitlements["application-identifier"].(string); strings.Contains(appIdentifier, ".") {
+		return appIdentifier[:strings.Index(appIdentifier, ".")]
+	}
+	name := r.signingIdentity.Name
+	if name == "" {
+		name = r.config.Certificate
+	}
+	if match := identityTeamPattern.FindStringSubmatch(name); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// policyBundleIDs returns the bundle IDs of the app and its extensions
+func (r *Resigner) policyBundleIDs(appPath string) ([]string, error) {
+	components, err := r.components.find(appPath)
+	if err != nil {
+		return nil, err
+	}
+	var bundleIDs []string
+	for _, component := range components {
+		if ext := strings.ToLower(filepath.Ext(component)); ext != ".app" && ext != ".appex" {
+			continue
+		}
+		info, _, err := readPlistFile(filepath.Join(component, "Info.plist"))
+		if err != nil {
+			return nil, err
+		}
+		if bundleID, _ := info["CFBundleIdentifier"].(string); bundleID != "" {
+			bundleIDs = append(bundleIDs, bundleID)
+		}
+	}
+	return bundleIDs, nil
+}
@@ -0,0 +1,131 @@
+package resigner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"howett.net/plist"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(content), 0644)
+		return path
+	}
+
+	policy, err := LoadPolicy(write("policy.yaml", `
+certificates:
+  - "Apple Distribution: Example Corp (ABCDE12345)"
+teamIds: [ABCDE12345]
+bundleIds: ["com.example.*"]
+entitlements:
+  deny: [get-task-allow]
+`))
+	if err != nil {
+		t.Fatalf("LoadPolicy() failed: %v", err)
+	}
+	want := &Policy{
+		Certificates: []string{"Apple Distribution: Example Corp (ABCDE12345)"},
+		TeamIDs:      []string{"ABCDE12345"},
+		BundleIDs:    []string{"com.example.*"},
+		Entitlements: EntitlementPolicy{Deny: []string{"get-task-allow"}},
+	}
+	if !reflect.DeepEqual(policy, want) {
+		t.Errorf("LoadPolicy() = %+v, want %+v", policy, want)
+	}
+
+	for name, content := range map[string]string{
+		"typo.yaml":    "teamIDs: [ABCDE12345]\n",
+		"pattern.yaml": "bundleIds: [\"com.example.[\"]\n",
+	} {
+		if _, err := LoadPolicy(write(name, content)); err == nil {
+			t.Errorf("LoadPolicy(%s) should fail", name)
+		}
+	}
+}
+
+func TestEnforcePolicy(t *testing.T) {
+	app := filepath.Join(t.TempDir(), "Test.app")
+	writeBundle(t, app, buildLinkedMachO(15, 0))
+	writeBundle(t, filepath.Join(app, "PlugIns", "Share.appex"), buildLinkedMachO(15, 0))
+	setBundleID := func(dir, bundleID string) {
+		info, format, _ := readPlistFile(filepath.Join(dir, "Info.plist"))
+		info["CFBundleIdentifier"] = bundleID
+		writePlistFile(filepath.Join(dir, "Info.plist"), info, format)
+	}
+	setBundleID(app, "com.example.app")
+	setBundleID(filepath.Join(app, "PlugIns", "Share.appex"), "com.other.share")
+
+	entitlements := filepath.Join(t.TempDir(), "entitlements.plist")
+	data, _ := plist.Marshal(map[string]interface{}{
+		"application-identifier": "ABCDE12345.com.example.app",
+		"get-task-allow":         false,
+		"aps-environment":        "production",
+	}, plist.XMLFormat)
+	os.WriteFile(entitlements, data, 0644)
+
+	tests := []struct {
+		name        string
+		certificate string
+		policy      Policy
+		violations  []string
+	}{
+		{"empty policy", "Apple Distribution: Example Corp (ABCDE12345)", Policy{}, nil},
+		{"allowed", "Apple Distribution: Example Corp (ABCDE12345)", Policy{
+			Certificates: []string{"Apple Distribution: *"},
+			TeamIDs:      []string{"ABCDE12345"},
+			BundleIDs:    []string{"com.example.*", "com.other.*"},
+			Entitlements: EntitlementPolicy{Allow: []string{"application-identifier", "aps-environment"}, Deny: []string{"get-task-allow"}},
+		}, nil},
+		{"other certificate", "Apple Development: Jane Doe (ZZZZZ99999)", Policy{
+			Certificates: []string{"Apple Distribution: *"},
+		}, []string{`certificate "Apple Development: Jane Doe (ZZZZZ99999)"`}},
+		{"other team and bundle", "Apple Distribution: Example Corp (ABCDE12345)", Policy{
+			TeamIDs:   []string{"FFFFF00000"},
+			BundleIDs: []string{"com.example.*"},
+		}, []string{"team ID ABCDE12345", "bundle ID com.other.share"}},
+		{"entitlements", "Apple Distribution: Example Corp (ABCDE12345)", Policy{
+			Entitlements: EntitlementPolicy{Allow: []string{"application-identifier", "get-task-allow"}, Deny: []string{"application-*"}},
+		}, []string{"entitlement application-identifier", "entitlement aps-environment"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := tt.policy
+			r := New(Config{Certificate: tt.certificate, Policy: &policy})
+			err := r.enforcePolicy(app, entitlements)
+			if tt.violations == nil {
+				if err != nil {
+					t.Errorf("enforcePolicy() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrPolicy) {
+				t.Fatalf("enforcePolicy() = %v, want ErrPolicy", err)
+			}
+			if want := strings.Join(tt.violations, ", ") + ": " + ErrPolicy.Error(); err.Error() != want {
+				t.Errorf("enforcePolicy() = %q, want %q", err, want)
+			}
+		})
+	}
+}
+
+func TestEnforcePolicyAdHoc(t *testing.T) {
+	app := filepath.Join(t.TempDir(), "Test.app")
+	writeBundle(t, app, buildLinkedMachO(15, 0))
+
+	policy := &Policy{Certificates: []string{"Apple Distribution: *"}, TeamIDs: []string{"ABCDE12345"}}
+	if err := New(Config{AdHoc: true, Policy: policy}).enforcePolicy(app, ""); !errors.Is(err, ErrPolicy) {
+		t.Errorf("enforcePolicy() = %v, want ad-hoc refused", err)
+	}
+	policy.Certificates = append(policy.Certificates, "-")
+	if err := New(Config{AdHoc: true, Policy: policy}).enforcePolicy(app, ""); err != nil {
+		t.Errorf("enforcePolicy() = %v, want ad-hoc allowed", err)
+	}
+}
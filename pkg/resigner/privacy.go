@@ -0,0 +1,207 @@
+package resigner
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"howett.net/plist"
+)
+
+// privacyManifestName is the file name Apple looks for in each bundle
+const privacyManifestName = "PrivacyInfo.xcprivacy"
+
+// PrivacyManifest is the content of a PrivacyInfo.xcprivacy file
+type PrivacyManifest struct {
+	Tracking           bool                `plist:"NSPrivacyTracking" json:"tracking"`
+	TrackingDomains    []string            `plist:"NSPrivacyTrackingDomains" json:"tracking_domains,omitempty"`
+	CollectedDataTypes []CollectedDataType `plist:"NSPrivacyCollectedDataTypes" json:"collected_data_types,omitempty"`
+	AccessedAPITypes   []AccessedAPIType   `plist:"NSPrivacyAccessedAPITypes" json:"accessed_api_types,omitempty"`
+}
+
+// CollectedDataType declares one kind of data an app collects
+type CollectedDataType struct {
+	Type     string   `plist:"NSPrivacyCollectedDataType" json:"type"`
+	Linked   bool     `plist:"NSPrivacyCollectedDataTypeLinked" json:"linked"`
+	Tracking bool     `plist:"NSPrivacyCollectedDataTypeTracking" json:"tracking"`
+	Purposes []string `plist:"NSPrivacyCollectedDataTypePurposes" json:"purposes,omitempty"`
+}
+
+// AccessedAPIType declares a required-reason API category and the
+// approved reasons it is used for
+type AccessedAPIType struct {
+	Type    string   `plist:"NSPrivacyAccessedAPIType" json:"type"`
+	Reasons []string `plist:"NSPrivacyAccessedAPITypeReasons" json:"reasons"`
+}
+
+// BundlePrivacy is the privacy manifest of one bundle, nil when the
+// bundle ships none
+type BundlePrivacy struct {
+	// Path is the bundle's location relative to the IPA or .app root
+	Path     string           `json:"path"`
+	Manifest *PrivacyManifest `json:"manifest"`
+}
+
+// ParsePrivacyManifest reads a PrivacyInfo.xcprivacy file
+func ParsePrivacyManifest(file string) (*PrivacyManifest, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return parsePrivacyManifest(data, file)
+}
+
+func parsePrivacyManifest(data []byte, name string) (*PrivacyManifest, error) {
+	var manifest PrivacyManifest
+	if _, err := plist.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse privacy manifest %s: %w", name, err)
+	}
+	return &manifest, nil
+}
+
+// InspectPrivacyManifests reports the privacy manifest of the app, its
+// extensions and every embedded framework or resource bundle in an IPA or
+// .app directory. Bundles without a manifest are listed with a nil
+// Manifest so missing SDK declarations stand out.
+func InspectPrivacyManifests(source string) ([]BundlePrivacy, error) {
+	fsys, root, close, err := openBundleFS(source)
+	if err != nil {
+		return nil, err
+	}
+	defer close()
+
+	var bundles []BundlePrivacy
+	exts := []string{".app", ".appex", ".framework", ".bundle"}
+	err = walkBundles(fsys, root, exts, func(dir, rel string) error {
+		bundle := BundlePrivacy{Path: rel}
+		data, err := fs.ReadFile(fsys, path.Join(dir, privacyManifestName))
+		if err == nil {
+			if bundle.Manifest, err = parsePrivacyManifest(data, path.Join(rel, privacyManifestName)); err != nil {
+				return err
+			}
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		bundles = append(bundles, bundle)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(bundles) == 0 {
+		return nil, fmt.Errorf("no app bundle found in %s", source)
+	}
+	return bundles, nil
+}
+
+// mergePrivacyManifest folds extra into base: tracking is enabled if
+// either enables it, domains and reasons are unioned, and data types base
+// does not declare are appended. Keys this code does not understand are
+// kept from base, or taken from extra when base lacks them.
+func mergePrivacyManifest(base, extra map[string]interface{}) {
+	for key, value := range extra {
+		current, ok := base[key]
+		if !ok {
+			base[key] = value
+			continue
+		}
+
+		switch key {
+		case "NSPrivacyTracking":
+			a, _ := current.(bool)
+			b, _ := value.(bool)
+			base[key] = a || b
+		case "NSPrivacyTrackingDomains":
+			base[key] = unionValues(current, value)
+		case "NSPrivacyCollectedDataTypes":
+			base[key] = mergeDeclarations(current, value, "NSPrivacyCollectedDataType", "")
+		case "NSPrivacyAccessedAPITypes":
+			base[key] = mergeDeclarations(current, value, "NSPrivacyAccessedAPIType", "NSPrivacyAccessedAPITypeReasons")
+		}
+	}
+}
+
+// mergeDeclarations merges two arrays of dictionaries identified by
+// idKey. When unionKey is set, that array is unioned for declarations
+// present in both; otherwise the declaration in base wins.
+func mergeDeclarations(base, extra interface{}, idKey, unionKey string) []interface{} {
+	merged, _ := base.([]interface{})
+	merged = append([]interface{}(nil), merged...)
+	index := make(map[string]map[string]interface{})
+	for _, item := range merged {
+		if dict, ok := item.(map[string]interface{}); ok {
+			id, _ := dict[idKey].(string)
+			index[id] = dict
+		}
+	}
+
+	additions, _ := extra.([]interface{})
+	for _, item := range additions {
+		dict, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, _ := dict[idKey].(string)
+		existing, found := index[id]
+		if !found {
+			merged = append(merged, dict)
+			index[id] = dict
+			continue
+		}
+		if unionKey != "" {
+			existing[unionKey] = unionValues(existing[unionKey], dict[unionKey])
+		}
+	}
+	return merged
+}
+
+// unionValues joins two plist arrays, dropping duplicates and keeping the
+// order of first appearance
+func unionValues(a, b interface{}) []interface{} {
+	var union []interface{}
+	seen := make(map[interface{}]bool)
+	for _, list := range []interface{}{a, b} {
+		items, _ := list.([]interface{})
+		for _, item := range items {
+			// Only strings are expected here; anything else is kept as is
+			if _, ok := item.(string); !ok {
+				union = append(union, item)
+				continue
+			}
+			if !seen[item] {
+				seen[item] = true
+				union = append(union, item)
+			}
+		}
+	}
+	return union
+}
+
+// injectPrivacyManifest merges Config.PrivacyManifest into the app's own
+// PrivacyInfo.xcprivacy, creating it if the app has none
+func (r *Resigner) injectPrivacyManifest(appPath string) error {
+	if r.config.PrivacyManifest == "" {
+		return nil
+	}
+
+	extra, _, err := readPlistFile(r.config.PrivacyManifest)
+	if err != nil {
+		return err
+	}
+
+	target := filepath.Join(appPath, privacyManifestName)
+	base, format, err := readPlistFile(target)
+	if os.IsNotExist(err) {
+		r.logProgress("Adding privacy manifest")
+		return writePlistFile(target, extra, plist.XMLFormat)
+	}
+	if err != nil {
+		return err
+	}
+
+	r.logProgress("Merging privacy manifest into existing " + privacyManifestName)
+	mergePrivacyManifest(base, extra)
+	return writePlistFile(target, base, format)
+}
@@ -0,0 +1,122 @@
+package resigner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testPrivacyManifest = `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>NSPrivacyTracking</key>
+	<false/>
+	<key>NSPrivacyAccessedAPITypes</key>
+	<array>
+		<dict>
+			<key>NSPrivacyAccessedAPIType</key>
+			<string>NSPrivacyAccessedAPICategoryUserDefaults</string>
+			<key>NSPrivacyAccessedAPITypeReasons</key>
+			<array>
+				<string>CA92.1</string>
+			</array>
+		</dict>
+	</array>
+</dict>
+</plist>`
+
+func TestInspectPrivacyManifests(t *testing.T) {
+	app := filepath.Join(t.TempDir(), "Test.app")
+	framework := filepath.Join(app, "Frameworks", "SDK.framework")
+	if err := os.MkdirAll(framework, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(app, privacyManifestName), []byte(testPrivacyManifest), 0644)
+
+	bundles, err := InspectPrivacyManifests(app)
+	if err != nil {
+		t.Fatalf("InspectPrivacyManifests() failed: %v", err)
+	}
+	if len(bundles) != 2 {
+		t.Fatalf("InspectPrivacyManifests() = %+v, want 2 bundles", bundles)
+	}
+
+	want := []AccessedAPIType{{Type: "NSPrivacyAccessedAPICategoryUserDefaults", Reasons: []string{"CA92.1"}}}
+	if bundles[0].Path != "Test.app" || bundles[0].Manifest == nil || !reflect.DeepEqual(bundles[0].Manifest.AccessedAPITypes, want) {
+		t.Errorf("app manifest = %+v", bundles[0])
+	}
+	if bundles[1].Path != "Test.app/Frameworks/SDK.framework" || bundles[1].Manifest != nil {
+		t.Errorf("framework manifest = %+v, want none", bundles[1])
+	}
+}
+
+func TestMergePrivacyManifest(t *testing.T) {
+	base := map[string]interface{}{
+		"NSPrivacyTracking":        false,
+		"NSPrivacyTrackingDomains": []interface{}{"a.example.com"},
+		"NSPrivacyAccessedAPITypes": []interface{}{
+			map[string]interface{}{
+				"NSPrivacyAccessedAPIType":        "NSPrivacyAccessedAPICategoryUserDefaults",
+				"NSPrivacyAccessedAPITypeReasons": []interface{}{"CA92.1"},
+			},
+		},
+	}
+	extra := map[string]interface{}{
+		"NSPrivacyTracking":        true,
+		"NSPrivacyTrackingDomains": []interface{}{"a.example.com", "b.example.com"},
+		"NSPrivacyAccessedAPITypes": []interface{}{
+			map[string]interface{}{
+				"NSPrivacyAccessedAPIType":        "NSPrivacyAccessedAPICategoryUserDefaults",
+				"NSPrivacyAccessedAPITypeReasons": []interface{}{"1C8F.1"},
+			},
+			map[string]interface{}{
+				"NSPrivacyAccessedAPIType":        "NSPrivacyAccessedAPICategoryFileTimestamp",
+				"NSPrivacyAccessedAPITypeReasons": []interface{}{"C617.1"},
+			},
+		},
+		"NSPrivacyCollectedDataTypes": []interface{}{},
+	}
+
+	mergePrivacyManifest(base, extra)
+
+	want := map[string]interface{}{
+		"NSPrivacyTracking":        true,
+		"NSPrivacyTrackingDomains": []interface{}{"a.example.com", "b.example.com"},
+		"NSPrivacyAccessedAPITypes": []interface{}{
+			map[string]interface{}{
+				"NSPrivacyAccessedAPIType":        "NSPrivacyAccessedAPICategoryUserDefaults",
+				"NSPrivacyAccessedAPITypeReasons": []interface{}{"CA92.1", "1C8F.1"},
+			},
+			map[string]interface{}{
+				"NSPrivacyAccessedAPIType":        "NSPrivacyAccessedAPICategoryFileTimestamp",
+				"NSPrivacyAccessedAPITypeReasons": []interface{}{"C617.1"},
+			},
+		},
+		"NSPrivacyCollectedDataTypes": []interface{}{},
+	}
+	if !reflect.DeepEqual(base, want) {
+		t.Errorf("mergePrivacyManifest() = %#v\nwant %#v", base, want)
+	}
+}
+
+func TestInjectPrivacyManifest(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "extra.xcprivacy")
+	os.WriteFile(manifest, []byte(testPrivacyManifest), 0644)
+	app := filepath.Join(dir, "Test.app")
+	os.Mkdir(app, 0755)
+
+	r := New(Config{PrivacyManifest: manifest})
+	if err := r.injectPrivacyManifest(app); err != nil {
+		t.Fatalf("injectPrivacyManifest() failed: %v", err)
+	}
+
+	injected, err := ParsePrivacyManifest(filepath.Join(app, privacyManifestName))
+	if err != nil {
+		t.Fatalf("ParsePrivacyManifest() failed: %v", err)
+	}
+	if len(injected.AccessedAPITypes) != 1 {
+		t.Errorf("injected manifest = %+v", injected)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !darwin && !linux
+
+package resigner
+
+// processAlive assumes the process exists where that cannot be checked,
+// so nothing it may own is removed
+func processAlive(pid int) bool {
+	return true
+}
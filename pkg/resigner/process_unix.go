@@ -0,0 +1,11 @@
+//go:build darwin || linux
+
+package resigner
+
+import "syscall"
+
+// processAlive reports whether a process with ID pid exists
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
@@ -0,0 +1,278 @@
+package resigner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"howett.net/plist"
+)
+
+// Profile is the decoded content of a .mobileprovision file
+type Profile struct {
+	Name                        string                 `plist:"Name"`
+	UUID                        string                 `plist:"UUID"`
+	AppIDName                   string                 `plist:"AppIDName"`
+	TeamName                    string                 `plist:"TeamName"`
+	TeamIdentifier              []string               `plist:"TeamIdentifier"`
+	ApplicationIdentifierPrefix []string               `plist:"ApplicationIdentifierPrefix"`
+	Platform                    []string               `plist:"Platform"`
+	CreationDate                time.Time              `plist:"CreationDate"`
+	ExpirationDate              time.Time              `plist:"ExpirationDate"`
+	ProvisionedDevices          []string               `plist:"ProvisionedDevices"`
+	ProvisionsAllDevices        bool                   `plist:"ProvisionsAllDevices"`
+	IsXcodeManaged              bool                   `plist:"IsXcodeManaged"`
+	Entitlements                map[string]interface{} `plist:"Entitlements"`
+	DeveloperCertificates       [][]byte               `plist:"DeveloperCertificates"`
+}
+
+// ParseProfile reads and decodes a provisioning profile from disk
+func ParseProfile(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := ParseProfileData(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return profile, nil
+}
+
+// ParseProfileData decodes a provisioning profile. Profiles are CMS
+// signed messages whose payload is an XML plist stored unencrypted, so the
+// plist is located directly instead of shelling out to `security cms`.
+func ParseProfileData(data []byte) (*Profile, error) {
+	start := bytes.Index(data, []byte("<?xml"))
+	end := bytes.LastIndex(data, []byte("</plist>"))
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("provisioning profile does not contain a property list")
+	}
+
+	var profile Profile
+	if _, err := plist.Unmarshal(data[start:end+len("</plist>")], &profile); err != nil {
+		return nil, fmt.Errorf("failed to decode provisioning profile: %w", err)
+	}
+	return &profile, nil
+}
+
+// ApplicationIdentifier returns the profile's full application-identifier
+// entitlement, e.g. "TEAM123456.com.company.*"
+func (p *Profile) ApplicationIdentifier() string {
+	id, _ := p.Entitlements["application-identifier"].(string)
+	return id
+}
+
+// TeamID returns the team the profile belongs to
+func (p *Profile) TeamID() string {
+	if len(p.TeamIdentifier) > 0 {
+		return p.TeamIdentifier[0]
+	}
+	if team, ok := p.Entitlements["com.apple.developer.team-identifier"].(string); ok {
+		return team
+	}
+	return ""
+}
+
+// AppID returns the application identifier without its team prefix, e.g.
+// "com.company.*"
+func (p *Profile) AppID() string {
+	id := p.ApplicationIdentifier()
+	if prefix := p.appIDPrefix(id); prefix != "" {
+		return strings.TrimPrefix(id, prefix+".")
+	}
+	return id
+}
+
+// appIDPrefix finds which known prefix the application identifier uses
+func (p *Profile) appIDPrefix(id string) string {
+	candidates := append([]string{}, p.ApplicationIdentifierPrefix...)
+	candidates = append(candidates, p.TeamIdentifier...)
+	for _, prefix := range candidates {
+		if strings.HasPrefix(id, prefix+".") {
+			return prefix
+		}
+	}
+	// Fall back to the first component, which is always the prefix
+	if i := strings.Index(id, "."); i > 0 {
+		return id[:i]
+	}
+	return ""
+}
+
+// IsWildcard reports whether the profile's app ID is a wildcard
+func (p *Profile) IsWildcard() bool {
+	return IsWildcardAppID(p.AppID())
+}
+
+// Expired reports whether the profile has expired at the given time
+func (p *Profile) Expired(now time.Time) bool {
+	return !p.ExpirationDate.IsZero() && now.After(p.ExpirationDate)
+}
+
+// InstalledProfile is a provisioning profile installed on this Mac
+type InstalledProfile struct {
+	Path string
+	*Profile
+}
+
+// ProfileDirs returns the folders provisioning profiles are installed in:
+// Xcode's UserData folder (Xcode 16 and later) and the MobileDevice folder
+// used before it
+func ProfileDirs() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	return []string{
+		filepath.Join(home, "Library", "Developer", "Xcode", "UserData", "Provisioning Profiles"),
+		filepath.Join(home, "Library", "MobileDevice", "Provisioning Profiles"),
+	}
+}
+
+// InstalledProfiles lists the profiles in ProfileDirs
+func InstalledProfiles() []InstalledProfile {
+	return ListProfiles(ProfileDirs()...)
+}
+
+// ListProfiles parses the .mobileprovision files in dirs, sorted by name
+// with the latest expiry first. Missing folders, unreadable files and
+// copies of a profile already listed are skipped.
+func ListProfiles(dirs ...string) []InstalledProfile {
+	var profiles []InstalledProfile
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || filepath.Ext(entry.Name()) != ".mobileprovision" {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			profile, err := ParseProfile(path)
+			if err != nil || seen[profile.UUID] {
+				continue
+			}
+			seen[profile.UUID] = true
+			profiles = append(profiles, InstalledProfile{Path: path, Profile: profile})
+		}
+	}
+
+	sort.SliceStable(profiles, func(i, j int) bool {
+		if profiles[i].Name != profiles[j].Name {
+			return profiles[i].Name < profiles[j].Name
+		}
+		return profiles[i].ExpirationDate.After(profiles[j].ExpirationDate)
+	})
+	return profiles
+}
+
+// profileInstallDirs returns the folders InstallProfile checks for a copy
+// of a profile, the first being the one it installs into: MobileDevice,
+// which device installs from Xcode of every version read. Tests replace
+// it.
+var profileInstallDirs = func() []string {
+	dirs := ProfileDirs()
+	if len(dirs) == 0 {
+		return nil
+	}
+	return append(dirs[len(dirs)-1:], dirs[:len(dirs)-1]...)
+}
+
+// InstallProfile copies the profile at path into dirs[0], named after its
+// UUID as Xcode names profiles, unless one of dirs already holds a profile
+// with that UUID. It returns the path of the installed copy and whether
+// it was copied now.
+func InstallProfile(path string, dirs ...string) (string, bool, error) {
+	if len(dirs) == 0 {
+		return "", false, fmt.Errorf("no provisioning profile folder to install into")
+	}
+	profile, err := ParseProfile(path)
+	if err != nil {
+		return "", false, err
+	}
+	if profile.UUID == "" {
+		return "", false, fmt.Errorf("%s: provisioning profile has no UUID", path)
+	}
+	for _, installed := range ListProfiles(dirs...) {
+		if installed.UUID == profile.UUID {
+			return installed.Path, false, nil
+		}
+	}
+
+	if err := os.MkdirAll(dirs[0], 0755); err != nil {
+		return "", false, err
+	}
+	// Copy beside the destination first so Xcode never lists a partial file
+	dest := filepath.Join(dirs[0], profile.UUID+".mobileprovision")
+	tmp := dest + ".tmp"
+	if err := copyFileMode(path, tmp, 0644); err != nil {
+		os.Remove(tmp)
+		return "", false, err
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Remove(tmp)
+		return "", false, err
+	}
+	return dest, true, nil
+}
+
+// installProfile installs the provisioning profile the app was signed with
+// when Config.InstallProfile is set, so Xcode can install the app on
+// devices without the profile being added by hand. Only a supplied profile
+// is installed: one that came inside the input IPA is untrusted. The
+// output is already written, so failing to install only warns.
+func (r *Resigner) installProfile(appPath string) {
+	if !r.config.InstallProfile || appPath == "" || !r.needsProvisioning() {
+		return
+	}
+	if r.mobileProvision() == "" {
+		r.logProgress("Not installing the provisioning profile: only a supplied profile is installed, not one from the input")
+		return
+	}
+	path, copied, err := InstallProfile(r.mobileProvision(), profileInstallDirs()...)
+	switch {
+	case err != nil:
+		r.warn(WarningProfileInstall, appPath, "", "Cannot install the provisioning profile: %v", err)
+	case copied:
+		r.logProgress("Installed provisioning profile to " + path)
+	default:
+		r.logProgress("Provisioning profile already installed at " + path)
+	}
+}
+
+// AppIDMismatchError is returned when a bundle ID is not covered by the
+// provisioning profile's app ID
+type AppIDMismatchError struct {
+	BundleID string
+	AppID    string
+}
+
+func (e *AppIDMismatchError) Error() string {
+	return fmt.Sprintf("bundle ID %s does not match provisioning profile app ID %s", e.BundleID, e.AppID)
+}
+
+// effectiveApplicationIdentifier computes the application-identifier
+// entitlement to sign bundleID with under profile. Wildcard profiles yield
+// TEAM.<bundleID>; explicit profiles must match the bundle ID exactly.
+func effectiveApplicationIdentifier(profile *Profile, bundleID string) (string, error) {
+	appID := profile.AppID()
+	if !MatchesAppID(bundleID, appID) {
+		return "", &AppIDMismatchError{BundleID: bundleID, AppID: appID}
+	}
+	if !IsWildcardAppID(appID) {
+		return profile.ApplicationIdentifier(), nil
+	}
+
+	prefix := profile.appIDPrefix(profile.ApplicationIdentifier())
+	if prefix == "" {
+		prefix = profile.TeamID()
+	}
+	return prefix + "." + bundleID, nil
+}
@@ -0,0 +1,224 @@
+package resigner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeProfile wraps a profile plist in bytes resembling a CMS envelope
+func fakeProfile(appIdentifier string) []byte {
+	body := fmt.Sprintf(plistHeader+`<plist version="1.0">
+<dict>
+	<key>Name</key>
+	<string>Test Profile</string>
+	<key>UUID</key>
+	<string>11111111-2222-3333-4444-555555555555</string>
+	<key>TeamIdentifier</key>
+	<array>
+		<string>TEAM123456</string>
+	</array>
+	<key>ApplicationIdentifierPrefix</key>
+	<array>
+		<string>TEAM123456</string>
+	</array>
+	<key>ExpirationDate</key>
+	<date>2030-01-02T03:04:05Z</date>
+	<key>Entitlements</key>
+	<dict>
+		<key>application-identifier</key>
+		<string>%s</string>
+		<key>get-task-allow</key>
+		<true/>
+	</dict>
+</dict>
+</plist>`, appIdentifier)
+
+	envelope := []byte{0x30, 0x82, 0x1f, 0x00, 0x06, 0x09}
+	envelope = append(envelope, body...)
+	return append(envelope, 0xa0, 0x82, 0x0c, 0x00)
+}
+
+func TestParseProfileData(t *testing.T) {
+	profile, err := ParseProfileData(fakeProfile("TEAM123456.com.company.app"))
+	if err != nil {
+		t.Fatalf("ParseProfileData() failed: %v", err)
+	}
+
+	if profile.Name != "Test Profile" {
+		t.Errorf("Name = %q", profile.Name)
+	}
+	if profile.TeamID() != "TEAM123456" {
+		t.Errorf("TeamID() = %q", profile.TeamID())
+	}
+	if profile.AppID() != "com.company.app" {
+		t.Errorf("AppID() = %q", profile.AppID())
+	}
+	if profile.IsWildcard() {
+		t.Error("Explicit profile reported as wildcard")
+	}
+	want := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	if !profile.ExpirationDate.Equal(want) {
+		t.Errorf("ExpirationDate = %v, want %v", profile.ExpirationDate, want)
+	}
+	if profile.Expired(want.Add(-time.Hour)) || !profile.Expired(want.Add(time.Hour)) {
+		t.Error("Expired() does not honour ExpirationDate")
+	}
+}
+
+func TestParseProfileDataInvalid(t *testing.T) {
+	if _, err := ParseProfileData([]byte("garbage")); err == nil {
+		t.Error("Expected error for data without a plist")
+	}
+}
+
+func TestListProfiles(t *testing.T) {
+	xcode, legacy := t.TempDir(), t.TempDir()
+	other := bytes.Replace(fakeProfile("TEAM123456.*"), []byte("Test Profile"), []byte("A Wildcard"), 1)
+	other = bytes.Replace(other, []byte("11111111-"), []byte("99999999-"), 1)
+	os.WriteFile(filepath.Join(xcode, "one.mobileprovision"), fakeProfile("TEAM123456.com.company.app"), 0644)
+	os.WriteFile(filepath.Join(legacy, "copy.mobileprovision"), fakeProfile("TEAM123456.com.company.app"), 0644)
+	os.WriteFile(filepath.Join(legacy, "two.mobileprovision"), other, 0644)
+	os.WriteFile(filepath.Join(legacy, "broken.mobileprovision"), []byte("garbage"), 0644)
+	os.WriteFile(filepath.Join(legacy, "notes.txt"), nil, 0644)
+
+	profiles := ListProfiles(xcode, legacy, filepath.Join(legacy, "missing"))
+	if len(profiles) != 2 {
+		t.Fatalf("ListProfiles() found %d profiles, want 2", len(profiles))
+	}
+	if profiles[0].Name != "A Wildcard" || !profiles[0].IsWildcard() {
+		t.Errorf("profiles[0] = %s (%s)", profiles[0].Name, profiles[0].AppID())
+	}
+	if want := filepath.Join(xcode, "one.mobileprovision"); profiles[1].Path != want {
+		t.Errorf("profiles[1].Path = %q, want %q", profiles[1].Path, want)
+	}
+}
+
+func TestEffectiveApplicationIdentifier(t *testing.T) {
+	tests := []struct {
+		name          string
+		appIdentifier string
+		bundleID      string
+		want          string
+		wantMismatch  bool
+	}{
+		{"wildcard", "TEAM123456.*", "com.company.app", "TEAM123456.com.company.app", false},
+		{"prefixed wildcard", "TEAM123456.com.company.*", "com.company.app", "TEAM123456.com.company.app", false},
+		{"prefixed wildcard mismatch", "TEAM123456.com.company.*", "com.other.app", "", true},
+		{"explicit match", "TEAM123456.com.company.app", "com.company.app", "TEAM123456.com.company.app", false},
+		{"explicit mismatch", "TEAM123456.com.company.app", "com.company.other", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, err := ParseProfileData(fakeProfile(tt.appIdentifier))
+			if err != nil {
+				t.Fatalf("ParseProfileData() failed: %v", err)
+			}
+
+			got, err := effectiveApplicationIdentifier(profile, tt.bundleID)
+			var mismatch *AppIDMismatchError
+			if errors.As(err, &mismatch) != tt.wantMismatch {
+				t.Fatalf("error = %v, wantMismatch %v", err, tt.wantMismatch)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// FuzzParseProfileData checks that malformed profiles are rejected with an
+// error rather than a crash, and that decoded ones are safe to query
+func FuzzParseProfileData(f *testing.F) {
+	f.Add(fakeProfile("TEAM123456.com.company.app"))
+	f.Add(fakeProfile("TEAM123456.*"))
+	f.Add([]byte("<?xml</plist>"))
+	f.Add([]byte("</plist><?xml"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		profile, err := ParseProfileData(data)
+		if err != nil {
+			return
+		}
+		profile.AppID()
+		profile.TeamID()
+		profile.IsWildcard()
+		profile.Expired(time.Now())
+		effectiveApplicationIdentifier(profile, "com.company.app")
+	})
+}
+
+func TestInstallProfile(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "dist.mobileprovision")
+	os.WriteFile(source, fakeProfile("TEAM123456.com.company.app"), 0644)
+	legacy, xcode := filepath.Join(t.TempDir(), "Provisioning Profiles"), t.TempDir()
+
+	path, copied, err := InstallProfile(source, legacy, xcode)
+	if want := filepath.Join(legacy, "11111111-2222-3333-4444-555555555555.mobileprovision"); err != nil || !copied || path != want {
+		t.Fatalf("InstallProfile() = %q, %v, %v, want a copy at %q", path, copied, err, want)
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, fakeProfile("TEAM123456.com.company.app")) {
+		t.Error("installed copy differs from the profile")
+	}
+	if again, copied, err := InstallProfile(source, legacy, xcode); err != nil || copied || again != path {
+		t.Errorf("second InstallProfile() = %q, %v, %v, want the existing copy", again, copied, err)
+	}
+
+	// A copy Xcode installed under another name counts as installed
+	os.RemoveAll(legacy)
+	os.WriteFile(filepath.Join(xcode, "xcode.mobileprovision"), fakeProfile("TEAM123456.com.company.app"), 0644)
+	if path, copied, err := InstallProfile(source, legacy, xcode); err != nil || copied || path != filepath.Join(xcode, "xcode.mobileprovision") {
+		t.Errorf("InstallProfile() = %q, %v, %v, want the Xcode copy", path, copied, err)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Error("InstallProfile() created the folder of a profile it did not copy")
+	}
+
+	if _, _, err := InstallProfile(filepath.Join(t.TempDir(), "missing.mobileprovision"), legacy); err == nil {
+		t.Error("InstallProfile() of a missing file succeeded")
+	}
+}
+
+func TestResignerInstallProfile(t *testing.T) {
+	dir := t.TempDir()
+	saved := profileInstallDirs
+	defer func() { profileInstallDirs = saved }()
+	profileInstallDirs = func() []string { return []string{dir} }
+
+	app := filepath.Join(t.TempDir(), "Test.app")
+	os.MkdirAll(app, 0755)
+	supplied := filepath.Join(t.TempDir(), "dev.mobileprovision")
+	r := New(Config{Certificate: "Apple Distribution", MobileProvision: supplied, InstallProfile: true})
+	r.installProfile(app)
+	warnings := r.Result().Warnings
+	if len(warnings) != 1 || warnings[0].Code != WarningProfileInstall {
+		t.Fatalf("warnings = %+v, want one profile-install warning", warnings)
+	}
+
+	os.WriteFile(supplied, fakeProfile("TEAM123456.com.company.app"), 0644)
+	r.installProfile(app)
+	if profiles := ListProfiles(dir); len(profiles) != 1 {
+		t.Errorf("installed %d profiles, want 1", len(profiles))
+	}
+
+	// The profile that came inside the input is not trusted enough
+	untrusted := t.TempDir()
+	profileInstallDirs = func() []string { return []string{untrusted} }
+	os.WriteFile(filepath.Join(app, "embedded.mobileprovision"), fakeProfile("TEAM123456.com.other.app"), 0644)
+	New(Config{Certificate: "Apple Distribution", InstallProfile: true}).installProfile(app)
+	if profiles := ListProfiles(untrusted); len(profiles) != 0 {
+		t.Error("installed the profile embedded in the input")
+	}
+
+	// Ad-hoc signed apps carry no profile to install
+	empty := t.TempDir()
+	profileInstallDirs = func() []string { return []string{empty} }
+	New(Config{AdHoc: true, InstallProfile: true}).installProfile(app)
+	if profiles := ListProfiles(empty); len(profiles) != 0 {
+		t.Error("installed the profile of an ad-hoc signed app")
+	}
+}
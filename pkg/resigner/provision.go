@@ -0,0 +1,76 @@
+package resigner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// providedProfileName is the file Config.MobileProvisionData is written to
+// in the workspace's Profiles directory
+const providedProfileName = "provided.mobileprovision"
+
+// validateProvisionData checks Config.MobileProvisionData before anything
+// is written, so bad CI secrets fail with a clear error
+func (r *Resigner) validateProvisionData() error {
+	if r.config.MobileProvisionData == nil {
+		return nil
+	}
+	if r.config.MobileProvision != "" {
+		return fmt.Errorf("mobile provision file and data cannot both be given")
+	}
+	if r.isAdHoc() {
+		return fmt.Errorf("mobile provision cannot be used with ad-hoc signing")
+	}
+	if _, err := ParseProfileData(r.config.MobileProvisionData); err != nil {
+		return fmt.Errorf("mobile provision data: %w", err)
+	}
+	return nil
+}
+
+// materializeProfile writes Config.MobileProvisionData into the workspace,
+// readable by its owner only, for the run to use like a MobileProvision
+// file. closeWorkspace shreds it.
+func (r *Resigner) materializeProfile() error {
+	r.providedProfile = ""
+	if r.config.MobileProvisionData == nil {
+		return nil
+	}
+	path := filepath.Join(r.workspace.Profiles, providedProfileName)
+	if err := os.WriteFile(path, r.config.MobileProvisionData, 0600); err != nil {
+		return fmt.Errorf("failed to write provisioning profile: %w", err)
+	}
+	r.providedProfile = path
+	return nil
+}
+
+// mobileProvision returns the profile the run embeds: the MobileProvision
+// file or the materialized MobileProvisionData, "" for neither
+func (r *Resigner) mobileProvision() string {
+	if r.providedProfile != "" {
+		return r.providedProfile
+	}
+	return r.config.MobileProvision
+}
+
+// shredFile overwrites path with zeros and removes it. This is best
+// effort: copy-on-write file systems such as APFS may keep the old blocks
+// until they are reused.
+func shredFile(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err == nil {
+		_, err = f.Write(make([]byte, info.Size()))
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	f.Close()
+	if removeErr := os.Remove(path); err == nil {
+		err = removeErr
+	}
+	return err
+}
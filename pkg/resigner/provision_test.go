@@ -0,0 +1,78 @@
+package resigner
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestValidateProvisionData(t *testing.T) {
+	profile := fakeProfile("TEAM123456.com.company.app")
+	for _, tt := range []struct {
+		config Config
+		want   string
+	}{
+		{Config{Certificate: "Apple Distribution", MobileProvisionData: profile}, ""},
+		{Config{Certificate: "Apple Distribution", MobileProvisionData: profile, MobileProvision: "dist.mobileprovision"}, "cannot both be given"},
+		{Config{AdHoc: true, MobileProvisionData: profile}, "ad-hoc"},
+		{Config{Certificate: "Apple Distribution", MobileProvisionData: []byte("garbage")}, "does not contain a property list"},
+	} {
+		err := New(tt.config).validateProvisionData()
+		if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("validateProvisionData() = %v, want %q", err, tt.want)
+		}
+	}
+}
+
+func TestMaterializeProfile(t *testing.T) {
+	profile := fakeProfile("TEAM123456.com.company.app")
+	root := filepath.Join(t.TempDir(), "tmp")
+	r := New(Config{Certificate: "Apple Distribution", MobileProvisionData: profile, KeepWorkspace: true})
+	if err := r.openWorkspace(root); err != nil {
+		t.Fatalf("openWorkspace() failed: %v", err)
+	}
+	if err := r.materializeProfile(); err != nil {
+		t.Fatalf("materializeProfile() failed: %v", err)
+	}
+
+	path := r.mobileProvision()
+	if path != filepath.Join(root, WorkspaceProfiles, providedProfileName) {
+		t.Errorf("mobileProvision() = %q", path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("materialized profile missing: %v", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("materialized profile mode = %v, want 0600", info.Mode().Perm())
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, profile) {
+		t.Error("materialized profile differs from the data")
+	}
+
+	// Even a kept workspace loses the profile
+	r.closeWorkspace()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("profile survived the run: %v", err)
+	}
+	if got := r.mobileProvision(); got != "" {
+		t.Errorf("mobileProvision() after the run = %q", got)
+	}
+}
+
+func TestShredFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	os.WriteFile(path, []byte("hunter2"), 0600)
+	if err := shredFile(path); err != nil {
+		t.Fatalf("shredFile() failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("shredded file still exists: %v", err)
+	}
+	if err := shredFile(path); err == nil {
+		t.Error("shredFile() of a missing file succeeded")
+	}
+}
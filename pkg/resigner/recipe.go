@@ -0,0 +1,289 @@
+package resigner
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// RecipeExt is the extension of resign recipe archives
+const RecipeExt = ".resignrecipe"
+
+// recipeVersion is the version of the recipe format written
+const recipeVersion = 1
+
+// recipeManifest is the name of the recipe's description in the archive
+const recipeManifest = "recipe.json"
+
+// RecipeSource identifies the input a recipe was exported for
+type RecipeSource struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// recipe is the content of recipe.json. Files holds the archive names of
+// the input files the recipe carries. Secrets (the archive password) and
+// machine-specific settings (output and cache folders, the target device)
+// are never recorded; private keys never leave the keychain.
+type recipe struct {
+	Version int          `json:"version"`
+	Created time.Time    `json:"created"`
+	Source  RecipeSource `json:"source"`
+
+	Certificate    string       `json:"certificate,omitempty"`
+	BundleID       string       `json:"bundleId,omitempty"`
+	TeamID         string       `json:"teamId,omitempty"`
+	ExpectSHA256   string       `json:"expectSha256,omitempty"`
+	Concurrency    int          `json:"concurrency,omitempty"`
+	Timeout        string       `json:"timeout,omitempty"`
+	CommandTimeout string       `json:"commandTimeout,omitempty"`
+	MaxOutputSize  int64        `json:"maxOutputSize,omitempty"`
+	WarnOutputSize int64        `json:"warnOutputSize,omitempty"`
+	MDMManifest    *MDMManifest `json:"mdmManifest,omitempty"`
+
+	ComponentIdentities map[string]string `json:"componentIdentities,omitempty"`
+	EntitlementsMerge   EntitlementsMerge `json:"entitlementsMerge,omitempty"`
+	BetaReports         BetaReports       `json:"betaReports,omitempty"`
+	Compression         Compression       `json:"compression,omitempty"`
+
+	AdHoc                  bool `json:"adhoc,omitempty"`
+	Verify                 bool `json:"verify,omitempty"`
+	SkipValid              bool `json:"skipValid,omitempty"`
+	Fix                    bool `json:"fix,omitempty"`
+	NoQuarantine           bool `json:"noQuarantine,omitempty"`
+	BundleFromProfile      bool `json:"bundleFromProfile,omitempty"`
+	ForceBundleFromProfile bool `json:"forceBundleFromProfile,omitempty"`
+	SuffixOnConflict       bool `json:"suffixOnConflict,omitempty"`
+	Enterprise             bool `json:"enterprise,omitempty"`
+	AutoStrip              bool `json:"autoStrip,omitempty"`
+
+	Files recipeFiles `json:"files,omitempty"`
+}
+
+// recipeFiles names the input files stored in a recipe archive
+type recipeFiles struct {
+	Entitlements    string `json:"entitlements,omitempty"`
+	MobileProvision string `json:"provision,omitempty"`
+	PrivacyManifest string `json:"privacyManifest,omitempty"`
+}
+
+// recipeFile pairs a Config path with its slot in recipeFiles
+type recipeFile struct {
+	path *string
+	name *string
+	base string
+}
+
+// files lists the input files of config alongside their recipe entries
+func (r *recipe) files(config *Config) []recipeFile {
+	return []recipeFile{
+		{&config.Entitlements, &r.Files.Entitlements, "files/entitlements"},
+		{&config.MobileProvision, &r.Files.MobileProvision, "files/profile"},
+		{&config.PrivacyManifest, &r.Files.PrivacyManifest, "files/privacy"},
+	}
+}
+
+// ExportRecipe writes everything needed to reproduce a resign with config,
+// apart from the source itself and the signing identity, to a recipe
+// archive at path: the options, the entitlements, the provisioning
+// profile and the privacy manifest, plus the name and SHA-256 of the
+// source they were used with.
+func ExportRecipe(config Config, path string) error {
+	rec := recipe{
+		Version:                recipeVersion,
+		Created:                time.Now().UTC().Truncate(time.Second),
+		Source:                 RecipeSource{Name: filepath.Base(config.SourceIPA)},
+		Certificate:            config.Certificate,
+		BundleID:               config.BundleID,
+		TeamID:                 config.TeamID,
+		ExpectSHA256:           config.ExpectSHA256,
+		Concurrency:            config.Concurrency,
+		MaxOutputSize:          config.MaxOutputSize,
+		WarnOutputSize:         config.WarnOutputSize,
+		MDMManifest:            config.MDMManifest,
+		ComponentIdentities:    config.ComponentIdentities,
+		EntitlementsMerge:      config.EntitlementsMerge,
+		BetaReports:            config.BetaReports,
+		Compression:            config.Compression,
+		AdHoc:                  config.AdHoc,
+		Verify:                 config.Verify,
+		SkipValid:              config.SkipValid,
+		Fix:                    config.Fix,
+		NoQuarantine:           config.NoQuarantine,
+		BundleFromProfile:      config.BundleFromProfile,
+		ForceBundleFromProfile: config.ForceBundleFromProfile,
+		SuffixOnConflict:       config.SuffixOnConflict,
+		Enterprise:             config.Enterprise,
+		AutoStrip:              config.AutoStrip,
+	}
+	if config.Timeout > 0 {
+		rec.Timeout = config.Timeout.String()
+	}
+	if config.CommandTimeout > 0 {
+		rec.CommandTimeout = config.CommandTimeout.String()
+	}
+	if info, err := os.Stat(config.SourceIPA); err == nil && !info.IsDir() {
+		if rec.Source.SHA256, err = FileSHA256(config.SourceIPA); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, file := range rec.files(&config) {
+		if *file.path == "" {
+			continue
+		}
+		*file.name = file.base + filepath.Ext(*file.path)
+		if err := addRecipeFile(zw, *file.name, *file.path); err != nil {
+			return err
+		}
+	}
+	w, err := zw.Create(recipeManifest)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(rec); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// addRecipeFile stores the file at path in the archive as name
+func addRecipeFile(zw *zip.Writer, name, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, src)
+	return err
+}
+
+// ImportRecipe reads the recipe archive at path, unpacking its files into
+// dir, and returns the configuration it describes with SourceIPA unset,
+// along with the source the recipe was exported for
+func ImportRecipe(path, dir string) (Config, RecipeSource, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return Config{}, RecipeSource{}, fmt.Errorf("cannot open recipe: %w", err)
+	}
+	defer zr.Close()
+
+	entries := make(map[string]*zip.File, len(zr.File))
+	for _, file := range zr.File {
+		entries[file.Name] = file
+	}
+	manifest, ok := entries[recipeManifest]
+	if !ok {
+		return Config{}, RecipeSource{}, fmt.Errorf("%s is not a resign recipe: no %s", path, recipeManifest)
+	}
+	var rec recipe
+	if err := readRecipeJSON(manifest, &rec); err != nil {
+		return Config{}, RecipeSource{}, fmt.Errorf("invalid recipe %s: %w", path, err)
+	}
+	if rec.Version > recipeVersion {
+		return Config{}, RecipeSource{}, fmt.Errorf("recipe %s needs a newer version of ResignIPA (format %d)", path, rec.Version)
+	}
+
+	config := Config{
+		Certificate:            rec.Certificate,
+		BundleID:               rec.BundleID,
+		TeamID:                 rec.TeamID,
+		ExpectSHA256:           rec.ExpectSHA256,
+		Concurrency:            rec.Concurrency,
+		MaxOutputSize:          rec.MaxOutputSize,
+		WarnOutputSize:         rec.WarnOutputSize,
+		MDMManifest:            rec.MDMManifest,
+		ComponentIdentities:    rec.ComponentIdentities,
+		EntitlementsMerge:      rec.EntitlementsMerge,
+		BetaReports:            rec.BetaReports,
+		Compression:            rec.Compression,
+		AdHoc:                  rec.AdHoc,
+		Verify:                 rec.Verify,
+		SkipValid:              rec.SkipValid,
+		Fix:                    rec.Fix,
+		NoQuarantine:           rec.NoQuarantine,
+		BundleFromProfile:      rec.BundleFromProfile,
+		ForceBundleFromProfile: rec.ForceBundleFromProfile,
+		SuffixOnConflict:       rec.SuffixOnConflict,
+		Enterprise:             rec.Enterprise,
+		AutoStrip:              rec.AutoStrip,
+	}
+	for _, duration := range []struct {
+		value string
+		dst   *time.Duration
+	}{{rec.Timeout, &config.Timeout}, {rec.CommandTimeout, &config.CommandTimeout}} {
+		if duration.value == "" {
+			continue
+		}
+		if *duration.dst, err = time.ParseDuration(duration.value); err != nil {
+			return Config{}, RecipeSource{}, fmt.Errorf("invalid recipe %s: %w", path, err)
+		}
+	}
+
+	for _, file := range rec.files(&config) {
+		if *file.name == "" {
+			continue
+		}
+		entry, ok := entries[*file.name]
+		if !ok {
+			return Config{}, RecipeSource{}, fmt.Errorf("recipe %s is missing %s", path, *file.name)
+		}
+		// Only the names recipes are written with are extracted, so no
+		// entry can point outside dir
+		*file.path = filepath.Join(dir, file.base[len("files/"):]+filepath.Ext(*file.name))
+		if err := extractRecipeFile(entry, *file.path); err != nil {
+			return Config{}, RecipeSource{}, err
+		}
+	}
+	return config, rec.Source, nil
+}
+
+// readRecipeJSON decodes the archive entry file into v
+func readRecipeJSON(file *zip.File, v interface{}) error {
+	rc, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return json.NewDecoder(rc).Decode(v)
+}
+
+// extractRecipeFile writes the archive entry file to path
+func extractRecipeFile(file *zip.File, path string) error {
+	rc, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	dst, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, rc); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
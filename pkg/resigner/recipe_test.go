@@ -0,0 +1,86 @@
+package resigner
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecipeRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "App.ipa")
+	os.WriteFile(source, []byte("ipa"), 0644)
+	entitlements := filepath.Join(dir, "custom.plist")
+	os.WriteFile(entitlements, []byte(plistHeader+`<plist version="1.0"><dict/></plist>`), 0644)
+	profile := filepath.Join(dir, "dist.mobileprovision")
+	os.WriteFile(profile, fakeProfile("TEAM123456.com.company.app"), 0644)
+
+	config := Config{
+		SourceIPA:       source,
+		Certificate:     "Apple Distribution: Company",
+		Entitlements:    entitlements,
+		MobileProvision: profile,
+		BundleID:        "com.company.app",
+		ArchivePassword: "secret",
+		OutputDir:       filepath.Join(dir, "out"),
+		Device:          "00008110-000000000000001E",
+		Concurrency:     4,
+		CommandTimeout:  5 * time.Minute,
+		Verify:          true,
+		AutoStrip:       true,
+		MDMManifest:     &MDMManifest{URL: "https://mdm.example.com/App.ipa"},
+
+		ComponentIdentities: map[string]string{".xpc": "Developer ID Application: Company"},
+		EntitlementsMerge:   MergePreferUser,
+		BetaReports:         BetaReportsAdd,
+		Compression:         CompressionFast,
+		MaxOutputSize:       150 << 20,
+	}
+	path := filepath.Join(dir, "App"+RecipeExt)
+	if err := ExportRecipe(config, path); err != nil {
+		t.Fatalf("ExportRecipe() failed: %v", err)
+	}
+
+	unpacked := filepath.Join(dir, "unpacked")
+	imported, recipeSource, err := ImportRecipe(path, unpacked)
+	if err != nil {
+		t.Fatalf("ImportRecipe() failed: %v", err)
+	}
+	sum, _ := FileSHA256(source)
+	if recipeSource != (RecipeSource{Name: "App.ipa", SHA256: sum}) {
+		t.Errorf("source = %+v", recipeSource)
+	}
+
+	// Secrets and machine-specific settings stay behind; files come back
+	// unpacked into the new directory
+	want := config
+	want.SourceIPA, want.ArchivePassword, want.OutputDir, want.Device = "", "", "", ""
+	want.Entitlements = filepath.Join(unpacked, "entitlements.plist")
+	want.MobileProvision = filepath.Join(unpacked, "profile.mobileprovision")
+	if !reflect.DeepEqual(imported, want) {
+		t.Errorf("ImportRecipe() = %+v, want %+v", imported, want)
+	}
+	for original, copied := range map[string]string{entitlements: want.Entitlements, profile: want.MobileProvision} {
+		a, _ := os.ReadFile(original)
+		b, err := os.ReadFile(copied)
+		if err != nil || string(a) != string(b) {
+			t.Errorf("%s not restored from %s: %v", copied, original, err)
+		}
+	}
+}
+
+func TestImportRecipeRejectsOtherArchives(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "other.zip")
+	f, _ := os.Create(path)
+	zw := zip.NewWriter(f)
+	zw.Create("readme.txt")
+	zw.Close()
+	f.Close()
+
+	if _, _, err := ImportRecipe(path, t.TempDir()); err == nil {
+		t.Error("ImportRecipe() accepted an archive without recipe.json")
+	}
+}
@@ -0,0 +1,65 @@
+package resigner
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// redactedText replaces every secret in redacted text
+const redactedText = "[REDACTED]"
+
+// Redactor masks secrets, such as passwords and tokens, in text before it
+// is logged or reported. The zero value masks nothing; it is safe for
+// concurrent use.
+type Redactor struct {
+	mu      sync.RWMutex
+	secrets []string
+}
+
+// Add registers secrets to mask; empty values are ignored
+func (r *Redactor) Add(secrets ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, secret := range secrets {
+		if secret != "" {
+			r.secrets = append(r.secrets, secret)
+		}
+	}
+	// Mask longer secrets first so one containing another is fully masked
+	sort.SliceStable(r.secrets, func(i, j int) bool {
+		return len(r.secrets[i]) > len(r.secrets[j])
+	})
+}
+
+// Redact returns s with every registered secret replaced by [REDACTED]
+func (r *Redactor) Redact(s string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, redactedText)
+	}
+	return s
+}
+
+// RedactError returns err with its message redacted. The result still
+// unwraps to err, so errors.Is and errors.As keep working.
+func (r *Redactor) RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := r.Redact(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{err: err, msg: msg}
+}
+
+// redactedError is an error whose message had secrets masked
+type redactedError struct {
+	err error
+	msg string
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
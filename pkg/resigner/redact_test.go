@@ -0,0 +1,66 @@
+package resigner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	var r Redactor
+	if got := r.Redact("nothing to hide"); got != "nothing to hide" {
+		t.Errorf("zero Redactor changed text: %q", got)
+	}
+
+	r.Add("hunter2", "", "hunter2-extended")
+	got := r.Redact("password hunter2-extended then hunter2")
+	if want := "password [REDACTED] then [REDACTED]"; got != want {
+		t.Errorf("Redact() = %q, want %q", got, want)
+	}
+
+	err := r.RedactError(fmt.Errorf("unlock with hunter2: %w", ErrWrongPassword))
+	if strings.Contains(err.Error(), "hunter2") || !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("RedactError() = %v, want masked error wrapping ErrWrongPassword", err)
+	}
+	plain := errors.New("plain")
+	if r.RedactError(plain) != plain || r.RedactError(nil) != nil {
+		t.Error("RedactError() should return errors without secrets unchanged")
+	}
+}
+
+// TestSecretsNeverLogged runs a pipeline that leaks its secrets into
+// progress, warnings and the error, and checks none of them get out
+func TestSecretsNeverLogged(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "test.ipa")
+	if err := os.WriteFile(source, []byte("ipa"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	const archivePassword, token = "zip-secret", "token-secret"
+
+	leak := Stage{Name: "leak", Run: func(r *Resigner, state *State) error {
+		r.logProgress("Using archive password " + archivePassword)
+		r.logWarning("Warning: webhook token " + token + " rejected")
+		return fmt.Errorf("codesign -P %s failed: %w", archivePassword, ErrWrongPassword)
+	}}
+
+	var messages []string
+	r := New(Config{SourceIPA: source, Certificate: "Test", ArchivePassword: archivePassword},
+		WithPipeline(NewPipeline(leak)),
+		WithSecrets(token),
+		WithProgress(func(message string) { messages = append(messages, message) }),
+		WithEventHandler(func(event Event) { messages = append(messages, event.Message) }))
+
+	_, err := r.Resign()
+	if !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("Resign() error = %v, want ErrWrongPassword", err)
+	}
+	messages = append(messages, err.Error())
+	for _, message := range messages {
+		if strings.Contains(message, archivePassword) || strings.Contains(message, token) {
+			t.Errorf("secret leaked: %q", message)
+		}
+	}
+}
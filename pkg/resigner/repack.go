@@ -0,0 +1,132 @@
+package resigner
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"hash/crc32"
+	"io/fs"
+	"math"
+	"os"
+	"unicode/utf8"
+)
+
+// RepackArchive writes the directory source to target like CreateArchive,
+// but copies the compressed data of files that still match their entry in
+// the archive original instead of compressing them again. Re-signing only
+// changes executables and signature files, so most of a large app is
+// copied byte for byte. It returns how many files were reused.
+func RepackArchive(source, original, target string) (int, error) {
+	return repackArchive(source, original, target, "")
+}
+
+// repackArchive is RepackArchive compressing changed files as the
+// compression preset says
+func repackArchive(source, original, target string, compression Compression) (int, error) {
+	reader, err := zip.OpenReader(original)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	base := make(map[string]*zip.File, len(reader.File))
+	for _, f := range reader.File {
+		if reusableEntry(f) {
+			base[f.Name] = f
+		}
+	}
+	return createArchive(source, "", target, base, compression)
+}
+
+// reusableEntry reports whether f's compressed data can be copied into
+// another archive: a regular, unencrypted file stored or deflated, small
+// enough for a local header without Zip64 records
+func reusableEntry(f *zip.File) bool {
+	return f.Mode().IsRegular() &&
+		f.Flags&0x1 == 0 &&
+		(f.Method == zip.Store || f.Method == zip.Deflate) &&
+		f.CompressedSize64 < math.MaxUint32 && f.UncompressedSize64 < math.MaxUint32
+}
+
+// copyUnchangedEntry stores the file at p as name by copying f's
+// compressed data, if the file still holds exactly f's content. Nothing is
+// written when it does not.
+func copyUnchangedEntry(archive *zip.Writer, f *zip.File, p, name string, d fs.DirEntry) (bool, error) {
+	info, err := d.Info()
+	if err != nil {
+		return false, err
+	}
+	if uint64(info.Size()) != f.UncompressedSize64 {
+		return false, nil
+	}
+	file, err := os.Open(p)
+	if err != nil {
+		return false, err
+	}
+	hash := crc32.NewIEEE()
+	_, err = copyBuffered(hash, file)
+	file.Close()
+	if err != nil {
+		return false, err
+	}
+	if hash.Sum32() != f.CRC32 {
+		return false, nil
+	}
+
+	raw, err := f.OpenRaw()
+	if err != nil {
+		return false, err
+	}
+	header, err := newArchiveHeader(info, name)
+	if err != nil {
+		return false, err
+	}
+	prepareRawHeader(header, f)
+	w, err := archive.CreateRaw(header)
+	if err != nil {
+		return false, err
+	}
+	_, err = copyBuffered(w, raw)
+	return true, err
+}
+
+// prepareRawHeader fills in what CreateHeader would for a fresh entry and
+// CreateRaw leaves to the caller, so a reused entry is written exactly as
+// a compressed one: sizes and checksum, versions, flags and timestamps
+func prepareRawHeader(header *zip.FileHeader, f *zip.File) {
+	header.Method = f.Method
+	header.CRC32 = f.CRC32
+	header.CompressedSize64 = f.CompressedSize64
+	header.UncompressedSize64 = f.UncompressedSize64
+	header.CreatorVersion = header.CreatorVersion&0xff00 | 20
+	header.ReaderVersion = 20
+	// Sizes follow the data in a descriptor, as for streamed entries
+	header.Flags |= 0x8
+	if needsUTF8Flag(header.Name) {
+		header.Flags |= 0x800
+	}
+
+	// MS-DOS date and time, plus the extended timestamp CreateHeader adds
+	t := header.Modified
+	header.ModifiedDate = uint16(t.Day() + int(t.Month())<<5 + (t.Year()-1980)<<9)
+	header.ModifiedTime = uint16(t.Second()/2 + t.Minute()<<5 + t.Hour()<<11)
+	extra := []byte{0x55, 0x54, 5, 0, 1, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(extra[5:], uint32(t.Unix()))
+	header.Extra = append(header.Extra, extra...)
+}
+
+// needsUTF8Flag reports whether CreateHeader would mark name as UTF-8:
+// it is valid UTF-8 and not plain CP-437 compatible ASCII
+func needsUTF8Flag(name string) bool {
+	require := false
+	for i := 0; i < len(name); {
+		r, size := utf8.DecodeRuneInString(name[i:])
+		i += size
+		if r < 0x20 || r > 0x7d || r == 0x5c {
+			if r == utf8.RuneError && size == 1 {
+				return false
+			}
+			require = true
+		}
+	}
+	return require
+}
@@ -0,0 +1,94 @@
+package resigner
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRepackArchive(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	writeTree(t, src)
+	app := filepath.Join(src, "Payload", "Test.app")
+	os.WriteFile(filepath.Join(app, "Résumé.strings"), bytes.Repeat([]byte("\"key\" = \"value\";\n"), 512), 0644)
+	os.WriteFile(filepath.Join(app, "Assets.car"), bytes.Repeat([]byte("asset"), 4096), 0644)
+
+	original := filepath.Join(root, "original.ipa")
+	if err := CreateArchive(src, original); err != nil {
+		t.Fatal(err)
+	}
+
+	// With nothing changed, every file is reused and the output is
+	// identical to packing from scratch
+	repacked := filepath.Join(root, "repacked.ipa")
+	reused, err := RepackArchive(src, original, repacked)
+	if err != nil {
+		t.Fatalf("RepackArchive() failed: %v", err)
+	}
+	if reused != 4 {
+		t.Errorf("reused %d files, want 4", reused)
+	}
+	want, _ := os.ReadFile(original)
+	if got, _ := os.ReadFile(repacked); !bytes.Equal(got, want) {
+		t.Error("repacking an unchanged tree changed the archive")
+	}
+
+	// A changed file, even of the same size, is compressed again
+	os.WriteFile(filepath.Join(app, "Assets.car"), bytes.Repeat([]byte("ASSET"), 4096), 0644)
+	reused, err = RepackArchive(src, original, repacked)
+	if err != nil {
+		t.Fatalf("RepackArchive() failed: %v", err)
+	}
+	if reused != 3 {
+		t.Errorf("reused %d files after a change, want 3", reused)
+	}
+	fresh := filepath.Join(root, "fresh.ipa")
+	if err := CreateArchive(src, fresh); err != nil {
+		t.Fatal(err)
+	}
+	want, _ = os.ReadFile(fresh)
+	if got, _ := os.ReadFile(repacked); !bytes.Equal(got, want) {
+		t.Error("repacked archive differs from a fresh one")
+	}
+	if err := VerifyArchive(repacked); err != nil {
+		t.Errorf("VerifyArchive() failed: %v", err)
+	}
+
+	// Encrypted entries are never reused
+	reader, err := zip.OpenReader(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	for _, entry := range reader.File {
+		if entry.Name != "Payload/Test.app/Assets.car" {
+			continue
+		}
+		if !reusableEntry(entry) {
+			t.Error("deflated entry not reusable")
+		}
+		entry.Flags |= 0x1
+		if reusableEntry(entry) {
+			t.Error("encrypted entry reported reusable")
+		}
+	}
+}
+
+func BenchmarkRepackArchive(b *testing.B) {
+	root := writeBenchTree(b, 64, 256<<10)
+	original := filepath.Join(b.TempDir(), "original.ipa")
+	if err := CreateArchive(root, original); err != nil {
+		b.Fatal(err)
+	}
+	target := filepath.Join(b.TempDir(), "bench.ipa")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := RepackArchive(root, original, target); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,22 +1,192 @@
 package resigner
 
 import (
-	"archive/zip"
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
+
+	"howett.net/plist"
 )
 
 // Config holds the configuration for resigning an IPA
 type Config struct {
 	SourceIPA       string
-	Certificate     string
+	Certificate     string // signing identity; "-" signs ad-hoc
 	Entitlements    string
 	MobileProvision string
 	BundleID        string
+
+	// MobileProvisionData is the provisioning profile itself, for callers
+	// holding it in memory such as CI reading it from a secret, instead of
+	// a MobileProvision file. It is written to the workspace readable by
+	// the owner only and shredded when the run ends. Recipes do not record
+	// it.
+	MobileProvisionData []byte
+
+	// ExpectSHA256 is the hex SHA-256 digest the source file must have;
+	// ignored when empty
+	ExpectSHA256 string
+
+	// Keychain limits the signing identity to one keychain, given as a
+	// path or a name in ~/Library/Keychains, so CI can sign from its own
+	// keychain without changing the user's keychain search list; empty
+	// searches the search list
+	Keychain string
+
+	// P12 is a PKCS#12 file holding the signing certificate and its
+	// private key, for CI storing them in a secret. The run imports it
+	// into a temporary keychain, signs from that keychain only and
+	// deletes it when the run ends. P12Password decrypts it and is always
+	// masked like ArchivePassword, but security only takes it as an
+	// argument, so it shows in the process list during the import.
+	P12         []byte
+	P12Password string
+
+	// ArchivePassword decrypts password-protected (ZipCrypto) IPAs
+	ArchivePassword string
+
+	// DSYM is an optional .dSYM bundle, folder of dSYMs or zip of them to
+	// check against the signed binaries and ship next to the output
+	DSYM string
+
+	// PrivacyManifest is an optional PrivacyInfo.xcprivacy merged into the
+	// app's own manifest before signing
+	PrivacyManifest string
+
+	// OutputDir receives the resigned output; defaults to a "Resigned"
+	// folder next to the source
+	OutputDir string
+	// Overwrite replaces an existing output without asking the
+	// ConfirmFunc
+	Overwrite bool
+	// Concurrency is the number of components signed in parallel; values
+	// below 1 sign one at a time
+	Concurrency int
+	// Verify runs codesign's strict verification after signing
+	Verify bool
+
+	// Timeout bounds the whole run and CommandTimeout every external
+	// command, such as codesign waiting on a keychain prompt that nobody
+	// will answer; zero means no limit. Both fail with ErrTimeout.
+	Timeout        time.Duration
+	CommandTimeout time.Duration
+
+	// SkipValid leaves components alone that are already validly signed
+	// by the target identity and team with the same entitlements, unless
+	// a component nested in them had to be signed again
+	SkipValid bool
+
+	// CacheDir keeps extracted IPAs between runs, keyed by the archive's
+	// SHA-256. Repeat runs on the same input verify and reuse the cached
+	// tree instead of extracting it again. Entries hold decrypted content.
+	CacheDir string
+
+	// MaxOutputSize fails the run with ErrSizeBudget when the output IPA
+	// is larger, in bytes; WarnOutputSize only warns. Zero means no
+	// budget; see ParseSize.
+	MaxOutputSize  int64
+	WarnOutputSize int64
+
+	// Compression is the preset the output IPA is packaged with; empty
+	// means CompressionBalanced
+	Compression Compression
+
+	// Hooks runs scripts around pipeline stages, keyed "before-<stage>"
+	// or "after-<stage>", e.g. "after-sign". Scripts run sandboxed from
+	// the signing credentials (see runHook) and fail the run with ErrHook
+	// when they exit non-zero. HookTimeout bounds each script; zero means
+	// DefaultHookTimeout.
+	Hooks       map[string]string
+	HookTimeout time.Duration
+
+	// DumpEntitlements, if set, is a folder the entitlements every
+	// component was actually signed with are written to after signing, one
+	// plist per component mirroring the app's tree
+	DumpEntitlements string
+
+	// KeepWorkspace leaves the run's temporary directory in place instead
+	// of removing it, so a run stopped early, e.g. before packaging, can
+	// be inspected; Result.Workspace names it
+	KeepWorkspace bool
+
+	// Fix repairs common bundle defects (see FixBundle) before signing
+	Fix bool
+	// NoQuarantine removes com.apple.quarantine from the output so
+	// opening or installing it does not trigger Gatekeeper
+	NoQuarantine bool
+	// InstallProfile installs the supplied provisioning profile
+	// (MobileProvision or MobileProvisionData) into
+	// ~/Library/MobileDevice/Provisioning Profiles after a successful run,
+	// unless a profile with the same UUID is already installed
+	InstallProfile bool
+
+	// AssumeYes confirms destructive steps (overwriting outputs, dropping
+	// entitlements) without asking the ConfirmFunc
+	AssumeYes bool
+
+	// InPlace signs the .app directory given as SourceIPA directly,
+	// without copying it or creating an output package
+	InPlace bool
+
+	// AdHoc signs every component with the "-" pseudo identity and no
+	// provisioning profile; Certificate is not required
+	AdHoc bool
+
+	// TeamID overrides the team used for team-scoped entitlements. When
+	// empty it is detected from the certificate, then the profile.
+	TeamID string
+
+	// BundleFromProfile sets the bundle identifier to the provisioning
+	// profile's explicit app ID (extensions get matching derived IDs)
+	BundleFromProfile bool
+	// ForceBundleFromProfile adopts the profile's explicit app ID as the
+	// bundle identifier when it does not match, instead of failing
+	ForceBundleFromProfile bool
+
+	// Device is the UDID of a device the output will be installed on. The
+	// run checks it for a copy of the app from another team, which would
+	// block the install, and warns unless SuffixOnConflict is set or the
+	// ConfirmFunc agrees to make the bundle ID unique to the team instead.
+	// A suffixed ID needs a wildcard provisioning profile.
+	Device           string
+	SuffixOnConflict bool
+
+	// Enterprise requires an In-House provisioning profile, failing with
+	// ErrNotInHouse otherwise, and records in Result.ResignBy when the
+	// app must be re-signed before the profile expires
+	Enterprise bool
+
+	// MDMManifest, if set, writes an InstallApplication manifest for MDM
+	// servers next to the output IPA (see MDMManifestPath)
+	MDMManifest *MDMManifest
+
+	// EntitlementsMerge combines Entitlements with the provisioning
+	// profile's entitlements; empty means MergeReplace
+	EntitlementsMerge EntitlementsMerge
+
+	// BetaReports controls the beta-reports-active entitlement TestFlight
+	// checks; empty means BetaReportsAuto
+	BetaReports BetaReports
+
+	// AutoStrip removes entitlements the provisioning profile cannot
+	// satisfy instead of asking the ConflictResolver
+	AutoStrip bool
+
+	// ComponentIdentities signs nested components with the given
+	// extension, such as ".xpc" or ".systemextension", with another
+	// certificate (a name, SHA-1 hash or "-" for ad-hoc), e.g. a Developer
+	// ID identity for a Catalyst app's privileged helper. The extension
+	// must have a component handler; apps always use Certificate.
+	ComponentIdentities map[string]string
+
+	// Policy, if set, is checked just before signing; a run breaking it
+	// fails with ErrPolicy (see LoadPolicy)
+	Policy *Policy
 }
 
 // ProgressCallback is called during the resign process
@@ -26,86 +196,282 @@ type ProgressCallback func(message string)
 type Resigner struct {
 	config   Config
 	callback ProgressCallback
-	tmpDir   string
-	appDir   string
+	handlers []EventHandler
+	emitMu   sync.Mutex
+	redactor Redactor
+
+	confirmFunc      ConfirmFunc
+	conflictResolver ConflictResolver
+
+	ctx        context.Context
+	pipeline   *Pipeline
+	components *ComponentRegistry
+	workspace  Workspace
+	// eventLog records events in the workspace's Logs directory
+	eventLog *os.File
+
+	// originalBundleID and bundleID record a bundle identifier change so
+	// nested bundles can be renamed consistently
+	originalBundleID string
+	bundleID         string
+
+	// providedProfile is where Config.MobileProvisionData was written in
+	// the workspace, "" when it is not given
+	providedProfile string
+	// tempKeychain is the keychain Config.P12 was imported into, ""
+	// when it is not given
+	tempKeychain string
+	// tempKeychainPassword unlocks tempKeychain again after a hook
+	tempKeychainPassword string
+	// keychain is the path of Config.Keychain, resolved by validate, or
+	// tempKeychain
+	keychain string
+	// hooks maps Config.Hooks names to absolute script paths, resolved by
+	// validate
+	hooks map[string]string
+	// missingTools holds the optional tools checkOptionalTools did not
+	// find; features needing them are skipped
+	missingTools map[string]bool
+
+	// signingIdentity is the keychain identity resolved from
+	// Config.Certificate before signing
+	signingIdentity SigningIdentity
+	// componentIdentities are the identities of Config.ComponentIdentities
+	// resolved by preflightIdentity, keyed by lower-case extension
+	componentIdentities map[string]SigningIdentity
+	// teamID is the team the app was signed for
+	teamID string
+	// outputPath is the resigned IPA or .app written by the last run
+	outputPath string
+	// result describes the last run; emitMu guards it while running
+	result Result
+	// simulator is set when the app was built for a simulator
+	simulator bool
+	// looseCode lists the Mach-O files an app wrapper left where no
+	// component handler finds them; they are signed with the components
+	looseCode []string
+	// localOutput replaces the default output directory when the source
+	// directory is read-only or on a network share
+	localOutput string
+}
+
+// New creates a new Resigner instance configured with the given options
+func New(config Config, opts ...Option) *Resigner {
+	r := &Resigner{
+		config:     config,
+		ctx:        context.Background(),
+		pipeline:   DefaultPipeline(),
+		components: DefaultComponents(),
+	}
+	r.redactor.Add(config.ArchivePassword, config.P12Password)
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
-// NewResigner creates a new Resigner instance
+// NewResigner creates a new Resigner instance that reports progress
+// messages to callback
 func NewResigner(config Config, callback ProgressCallback) *Resigner {
-	return &Resigner{
-		config:   config,
-		callback: callback,
+	return New(config, WithProgress(callback))
+}
+
+// TeamID returns the team identifier the last run signed for
+func (r *Resigner) TeamID() string {
+	return r.teamID
+}
+
+// OutputPath returns the resigned IPA or .app written by the last run, or
+// "" if it did not get that far
+func (r *Resigner) OutputPath() string {
+	return r.outputPath
+}
+
+// Context returns the context of the current run, for use by custom stages
+func (r *Resigner) Context() context.Context {
+	return r.ctx
+}
+
+// Logf emits a formatted progress message, for use by custom stages
+func (r *Resigner) Logf(format string, args ...interface{}) {
+	r.logProgress(fmt.Sprintf(format, args...))
+}
+
+// emit delivers a plain event to the registered callback and handlers
+func (r *Resigner) emit(eventType EventType, message string) {
+	r.emitEvent(Event{Type: eventType, Message: message})
+}
+
+// emitEvent delivers a fully populated event
+func (r *Resigner) emitEvent(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	// Secrets may reach messages through paths, tool output or errors
+	event.Message = r.redactor.Redact(event.Message)
+	if event.Type == EventWarning {
+		warning := eventWarning(event)
+		warning.Message = r.redactor.Redact(warning.Message)
+		event.Warning = &warning
+	}
+	// Signing workers may emit concurrently; handlers never overlap
+	r.emitMu.Lock()
+	defer r.emitMu.Unlock()
+	if event.Warning != nil {
+		r.result.Warnings = append(r.result.Warnings, *event.Warning)
+	}
+	if r.eventLog != nil {
+		fmt.Fprintf(r.eventLog, "%s %s %s\n", event.Time.Format(time.RFC3339), event.Type, event.Message)
+	}
+	if r.callback != nil {
+		r.callback(event.Message)
+	}
+	for _, handler := range r.handlers {
+		handler(event)
 	}
 }
 
 // logProgress sends a progress message
 func (r *Resigner) logProgress(message string) {
-	if r.callback != nil {
-		r.callback(message)
+	r.emit(EventInfo, message)
+}
+
+// logWarning sends a non-fatal warning
+func (r *Resigner) logWarning(message string) {
+	r.emit(EventWarning, message)
+}
+
+// checkCanceled returns the context error once the run has been cancelled
+func (r *Resigner) checkCanceled() error {
+	if r.ctx.Err() == nil {
+		return nil
+	}
+	cause := context.Cause(r.ctx)
+	if errors.Is(cause, ErrTimeout) {
+		return cause
 	}
-	fmt.Println(message)
+	return fmt.Errorf("resign cancelled: %w", cause)
 }
 
 // Resign performs the resigning operation
-func (r *Resigner) Resign() (err error) {
+func (r *Resigner) Resign() (*Result, error) {
+	return r.ResignContext(context.Background())
+}
+
+// ResignContext performs the resigning operation, stopping early when ctx
+// is cancelled. The Result is returned even when the run fails.
+func (r *Resigner) ResignContext(ctx context.Context) (result *Result, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := r.withRunTimeout(ctx)
+	defer cancel()
+	r.ctx = ctx
+	r.resetResult()
+	start := time.Now()
+	state := &State{}
+
 	// Panic recovery
 	defer func() {
 		if rec := recover(); rec != nil {
 			err = fmt.Errorf("panic occurred: %v", rec)
-			r.logProgress(fmt.Sprintf("ERROR: %v", err))
 		}
-		// Cleanup temp directories
-		if r.tmpDir != "" {
-			os.RemoveAll(r.tmpDir)
+		err = r.redactor.RedactError(err)
+		if err != nil {
+			r.emit(EventError, fmt.Sprintf("ERROR: %v", err))
 		}
+		// Read the outcome before the workspace holding the app goes
+		r.finishResult(state.AppPath, time.Since(start))
+		r.closeWorkspace()
+		result = r.Result()
 	}()
 
 	// Validate inputs
 	if err := r.validate(); err != nil {
-		return err
+		return nil, err
 	}
 
 	r.logProgress("Start (re)sign the app...")
+	r.checkOptionalTools()
 
 	// Setup directories
 	if err := r.setupDirectories(); err != nil {
-		return fmt.Errorf("failed to setup directories: %w", err)
+		return nil, fmt.Errorf("failed to setup directories: %w", err)
 	}
-
-	// Extract or copy the app
-	appPath, err := r.extractApp()
-	if err != nil {
-		return fmt.Errorf("failed to extract app: %w", err)
+	if err := r.materializeProfile(); err != nil {
+		return nil, err
 	}
-
-	// Handle mobile provision
-	if err := r.handleMobileProvision(appPath); err != nil {
-		return fmt.Errorf("failed to handle mobile provision: %w", err)
+	if err := r.createSigningKeychain(); err != nil {
+		return nil, fmt.Errorf("failed to import p12 certificate: %w", err)
 	}
 
-	// Extract entitlements
-	entitlementsPath, err := r.extractEntitlements(appPath)
-	if err != nil {
-		return fmt.Errorf("failed to extract entitlements: %w", err)
+	// Fail fast on an unusable identity, before extracting anything
+	if _, err := r.pipeline.index(StageSign); err == nil {
+		if err := r.checkCanceled(); err != nil {
+			return nil, err
+		}
+		if err := r.preflightIdentity(); err != nil {
+			return nil, err
+		}
 	}
 
-	// Handle bundle ID
-	if err := r.handleBundleID(appPath); err != nil {
-		return fmt.Errorf("failed to handle bundle ID: %w", err)
+	// Run each stage, stopping early once cancelled
+	state.WorkDir, state.Workspace = r.workspace.Root, r.workspace
+	for _, stage := range r.pipeline.stages {
+		if err := r.checkCanceled(); err != nil {
+			return nil, err
+		}
+		stageStart := time.Now()
+		err := r.runHook("before-"+stage.Name, stage.Name, state)
+		if err == nil {
+			err = stage.Run(r, state)
+		}
+		if err == nil {
+			err = r.runHook("after-"+stage.Name, stage.Name, state)
+		}
+		r.emitMu.Lock()
+		r.result.Stages = append(r.result.Stages, StageTiming{Name: stage.Name, Duration: time.Since(stageStart)})
+		r.emitMu.Unlock()
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// Sign components
-	if err := r.signComponents(appPath, entitlementsPath); err != nil {
-		return fmt.Errorf("failed to sign components: %w", err)
+	r.installProfile(state.AppPath)
+	r.logProgress("XReSign FINISHED")
+	return nil, nil
+}
+
+// isAdHoc reports whether components are signed with the ad-hoc identity
+func (r *Resigner) isAdHoc() bool {
+	return r.config.AdHoc || r.config.Certificate == "-"
+}
+
+// identity returns the codesign identity argument
+func (r *Resigner) identity() string {
+	if r.isAdHoc() {
+		return "-"
 	}
+	if r.signingIdentity.Hash != "" {
+		return r.signingIdentity.Hash
+	}
+	return r.config.Certificate
+}
 
-	// Create resigned IPA
-	if err := r.createResignedIPA(appPath); err != nil {
-		return fmt.Errorf("failed to create resigned IPA: %w", err)
+// identityFor returns the codesign identity argument for component,
+// honouring Config.ComponentIdentities
+func (r *Resigner) identityFor(component string) string {
+	if identity, ok := r.componentIdentity(component); ok {
+		return identity.Hash
 	}
+	return r.identity()
+}
 
-	r.logProgress("XReSign FINISHED")
-	return nil
+// needsProvisioning reports whether the app must carry a provisioning
+// profile matching its entitlements. Simulator and ad-hoc signed apps
+// are not checked against one.
+func (r *Resigner) needsProvisioning() bool {
+	return !r.simulator && !r.isAdHoc()
 }
 
 // validate checks if all required inputs are valid
@@ -113,12 +479,47 @@ func (r *Resigner) validate() error {
 	if r.config.SourceIPA == "" {
 		return fmt.Errorf("source IPA path is required")
 	}
-	if r.config.Certificate == "" {
+	if r.config.AdHoc {
+		if r.config.Certificate != "" && r.config.Certificate != "-" {
+			return fmt.Errorf("certificate cannot be used with ad-hoc signing")
+		}
+		if r.config.MobileProvision != "" {
+			return fmt.Errorf("mobile provision cannot be used with ad-hoc signing")
+		}
+	} else if r.config.Certificate == "" {
 		return fmt.Errorf("certificate is required")
 	}
+	if r.config.Keychain != "" {
+		if r.isAdHoc() {
+			return fmt.Errorf("keychain cannot be used with ad-hoc signing")
+		}
+		keychain, err := resolveKeychain(r.config.Keychain)
+		if err != nil {
+			return err
+		}
+		r.keychain = keychain
+	}
+	if err := r.validateP12(); err != nil {
+		return err
+	}
+	if err := r.validateProvisionData(); err != nil {
+		return err
+	}
+	if r.config.InstallProfile && r.isAdHoc() {
+		return fmt.Errorf("installing the provisioning profile cannot be used with ad-hoc signing")
+	}
 	if _, err := os.Stat(r.config.SourceIPA); os.IsNotExist(err) {
 		return fmt.Errorf("source file does not exist: %s", r.config.SourceIPA)
 	}
+	if r.config.InPlace {
+		info, err := os.Stat(r.config.SourceIPA)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() || strings.ToLower(filepath.Ext(r.config.SourceIPA)) != ".app" {
+			return fmt.Errorf("in-place signing requires an .app directory: %s", r.config.SourceIPA)
+		}
+	}
 	if r.config.MobileProvision != "" {
 		if _, err := os.Stat(r.config.MobileProvision); os.IsNotExist(err) {
 			return fmt.Errorf("mobile provision file does not exist: %s", r.config.MobileProvision)
@@ -128,93 +529,232 @@ func (r *Resigner) validate() error {
 		if _, err := os.Stat(r.config.Entitlements); os.IsNotExist(err) {
 			return fmt.Errorf("entitlements file does not exist: %s", r.config.Entitlements)
 		}
+		if err := ValidateEntitlementsFile(r.config.Entitlements); err != nil {
+			return err
+		}
+	}
+	if r.config.DSYM != "" {
+		info, err := os.Stat(r.config.DSYM)
+		if os.IsNotExist(err) {
+			return fmt.Errorf("dSYM path does not exist: %s", r.config.DSYM)
+		}
+		if err == nil && !info.IsDir() && strings.ToLower(filepath.Ext(r.config.DSYM)) != ".zip" {
+			return fmt.Errorf("dSYM must be a directory or .zip: %s", r.config.DSYM)
+		}
+	}
+	if manifest := r.config.MDMManifest; manifest != nil {
+		if manifest.URL == "" {
+			return fmt.Errorf("MDM manifest needs the URL the IPA is served from")
+		}
+		if r.config.InPlace || strings.ToLower(filepath.Ext(r.config.SourceIPA)) != ".ipa" {
+			return fmt.Errorf("MDM manifests are only written for IPA sources")
+		}
+	}
+	if r.config.PrivacyManifest != "" {
+		if _, err := ParsePrivacyManifest(r.config.PrivacyManifest); err != nil {
+			return err
+		}
+	}
+	if r.config.BundleID != "" {
+		if err := ValidateBundleID(r.config.BundleID); err != nil {
+			return err
+		}
+		if r.config.BundleFromProfile {
+			return fmt.Errorf("bundle ID and bundle-from-profile cannot be used together")
+		}
+	}
+	if r.config.TeamID != "" {
+		if err := ValidateTeamID(r.config.TeamID); err != nil {
+			return err
+		}
+	}
+	if r.config.Policy != nil {
+		if err := r.config.Policy.Validate(); err != nil {
+			return fmt.Errorf("invalid policy: %w", err)
+		}
+	}
+	if err := r.config.EntitlementsMerge.validate(); err != nil {
+		return err
+	}
+	if err := r.config.BetaReports.validate(); err != nil {
+		return err
+	}
+	if err := r.config.Compression.validate(); err != nil {
+		return err
+	}
+	if err := r.validateHooks(); err != nil {
+		return err
+	}
+	if (r.config.MaxOutputSize > 0 || r.config.WarnOutputSize > 0) && r.config.InPlace {
+		return fmt.Errorf("size budgets apply to IPA outputs, not in-place signing")
+	}
+	for ext, certificate := range r.config.ComponentIdentities {
+		key := componentIdentityKey(ext)
+		if key == ".app" {
+			return fmt.Errorf("apps are signed with the certificate; component identities are for other kinds such as .xpc")
+		}
+		if _, ok := r.components.handler("component" + key); !ok {
+			return fmt.Errorf("no component handler for %s, so it cannot have its own identity", key)
+		}
+		if certificate == "" {
+			return fmt.Errorf("component identity for %s is empty", key)
+		}
 	}
 	return nil
 }
 
-// setupDirectories creates temporary directories
+// verifySource checks the source checksum and, for IPAs, that the
+// archive is intact
+func (r *Resigner) verifySource() error {
+	if r.config.ExpectSHA256 != "" {
+		r.logProgress("Verifying source SHA-256")
+		if err := VerifySHA256(r.config.SourceIPA, r.config.ExpectSHA256); err != nil {
+			return err
+		}
+	}
+	if strings.ToLower(filepath.Ext(r.config.SourceIPA)) == ".ipa" && !r.config.InPlace {
+		if err := VerifyArchive(r.config.SourceIPA); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setupDirectories creates the run's workspace next to the source, or in
+// a local directory when that is not possible. Every run gets a directory
+// of its own, so apps from one folder can be resigned at the same time.
 func (r *Resigner) setupDirectories() error {
 	outDir := filepath.Dir(r.config.SourceIPA)
-	tmpDir := filepath.Join(outDir, "tmp")
-	appDir := filepath.Join(tmpDir, "app")
 
-	if err := os.MkdirAll(appDir, 0755); err != nil {
-		return err
+	// In-place signing writes to the source regardless, so it keeps its
+	// workspace there and gets the plain error
+	if !r.config.InPlace && isNetworkVolume(outDir) {
+		return r.relocateWorkspace(fmt.Sprintf("%s is on a network share", outDir))
+	}
+	tmpDir, err := os.MkdirTemp(outDir, workspacePrefix)
+	if err == nil {
+		if err = r.openWorkspace(tmpDir); err != nil {
+			os.RemoveAll(tmpDir)
+		}
+	}
+	if err != nil {
+		if r.config.InPlace {
+			return err
+		}
+		return r.relocateWorkspace(fmt.Sprintf("cannot write next to the source (%v)", err))
 	}
-
-	r.tmpDir = tmpDir
-	r.appDir = appDir
 	return nil
 }
 
+// outputDir returns the directory the resigned output is written to
+func (r *Resigner) outputDir() string {
+	if r.config.InPlace {
+		return filepath.Dir(r.config.SourceIPA)
+	}
+	if r.config.OutputDir != "" {
+		return r.config.OutputDir
+	}
+	if r.localOutput != "" {
+		return r.localOutput
+	}
+	return filepath.Join(filepath.Dir(r.config.SourceIPA), "Resigned")
+}
+
 // extractApp extracts IPA or copies .app file
 func (r *Resigner) extractApp() (string, error) {
+	if r.config.InPlace {
+		r.logProgress(fmt.Sprintf("Signing in place: %s", r.config.SourceIPA))
+		return filepath.Abs(r.config.SourceIPA)
+	}
+
 	ext := strings.ToLower(filepath.Ext(r.config.SourceIPA))
 
-	if ext == ".ipa" {
+	if ext == ".ipa" && r.config.CacheDir != "" {
+		if err := r.extractCached(); err != nil {
+			return "", err
+		}
+	} else if ext == ".ipa" {
 		r.logProgress("Extracting IPA file...")
-		if err := unzip(r.config.SourceIPA, r.appDir); err != nil {
+		if encrypted, _ := IsEncryptedArchive(r.config.SourceIPA); encrypted {
+			r.logProgress("Archive is encrypted, decrypting during extraction")
+		}
+		if err := ExtractArchiveWithPassword(r.config.SourceIPA, r.workspace.Extracted, r.config.ArchivePassword); err != nil {
 			return "", err
 		}
 	} else if ext == ".app" {
 		r.logProgress("Copying .app file...")
-		payloadDir := filepath.Join(r.appDir, "Payload")
+		payloadDir := filepath.Join(r.workspace.Extracted, "Payload")
 		if err := os.MkdirAll(payloadDir, 0755); err != nil {
 			return "", err
 		}
-		if err := copyDir(r.config.SourceIPA, filepath.Join(payloadDir, filepath.Base(r.config.SourceIPA))); err != nil {
+		if err := cloneOrLinkDir(r.config.SourceIPA, filepath.Join(payloadDir, filepath.Base(r.config.SourceIPA))); err != nil {
 			return "", err
 		}
 	} else {
 		return "", fmt.Errorf("unsupported file type: %s (must be .ipa or .app)", ext)
 	}
 
-	// Get application path
-	payloadDir := filepath.Join(r.appDir, "Payload")
-	entries, err := os.ReadDir(payloadDir)
-	if err != nil {
-		return "", err
-	}
-	if len(entries) == 0 {
-		return "", fmt.Errorf("no app found in Payload directory")
-	}
-
-	appPath := filepath.Join(payloadDir, entries[0].Name())
-	return appPath, nil
+	return payloadApp(filepath.Join(r.workspace.Extracted, "Payload"))
 }
 
 // handleMobileProvision copies the mobile provision file
 func (r *Resigner) handleMobileProvision(appPath string) error {
-	if r.config.MobileProvision == "" {
+	if r.simulator {
+		if r.mobileProvision() != "" {
+			r.warn(WarningProfileIgnored, "", "", "Ignoring provisioning profile for simulator build")
+		}
+		return nil
+	}
+	if r.config.AdHoc {
+		// Ad-hoc signed apps carry no profile; a stale one only misleads
+		embedded := filepath.Join(appPath, "embedded.mobileprovision")
+		if _, err := os.Stat(embedded); err == nil {
+			r.logProgress("Ad-hoc signing: removing embedded provisioning profile")
+			return os.Remove(embedded)
+		}
+		return nil
+	}
+	if r.mobileProvision() == "" {
 		r.logProgress("Sign process using existing provisioning profile from payload")
 		return nil
 	}
 
 	r.logProgress("Copying provisioning profile into application payload")
 	dest := filepath.Join(appPath, "embedded.mobileprovision")
-	return copyFile(r.config.MobileProvision, dest)
+	return copyFile(r.mobileProvision(), dest)
 }
 
 // extractEntitlements extracts entitlements from mobile provision
 func (r *Resigner) extractEntitlements(appPath string) (string, error) {
 	r.logProgress("Extract entitlements from mobileprovision")
 
-	entitlementsPath := filepath.Join(r.tmpDir, "entitlements.plist")
+	entitlementsPath := filepath.Join(r.workspace.Entitlements, "entitlements.plist")
 
 	if r.config.Entitlements != "" {
 		if err := copyFile(r.config.Entitlements, entitlementsPath); err != nil {
 			return "", err
 		}
 		r.logProgress(fmt.Sprintf("Using provided entitlements: %s", r.config.Entitlements))
+		if err := r.mergeProfileEntitlements(appPath, entitlementsPath); err != nil {
+			return "", err
+		}
+		return entitlementsPath, nil
+	}
+
+	// Without a profile, keep what the app was signed with
+	if !r.needsProvisioning() {
+		if err := r.extractSignedEntitlements(appPath, entitlementsPath); err != nil {
+			return "", err
+		}
 		return entitlementsPath, nil
 	}
 
 	// Extract from embedded.mobileprovision
 	provisionPath := filepath.Join(appPath, "embedded.mobileprovision")
-	provisioningPlist := filepath.Join(r.tmpDir, "provisioning.plist")
+	provisioningPlist := filepath.Join(r.workspace.Profiles, "provisioning.plist")
 
 	// security cms -D -i embedded.mobileprovision
-	cmd := exec.Command("security", "cms", "-D", "-i", provisionPath)
+	cmd := r.command("security", "cms", "-D", "-i", provisionPath)
 	output, err := cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("failed to decode provisioning profile: %w", err)
@@ -225,7 +765,7 @@ func (r *Resigner) extractEntitlements(appPath string) (string, error) {
 	}
 
 	// /usr/libexec/PlistBuddy -x -c 'Print:Entitlements' provisioning.plist
-	cmd = exec.Command("/usr/libexec/PlistBuddy", "-x", "-c", "Print:Entitlements", provisioningPlist)
+	cmd = r.command("/usr/libexec/PlistBuddy", "-x", "-c", "Print:Entitlements", provisioningPlist)
 	output, err = cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("failed to extract entitlements: %w", err)
@@ -238,78 +778,301 @@ func (r *Resigner) extractEntitlements(appPath string) (string, error) {
 	return entitlementsPath, nil
 }
 
+// extractSignedEntitlements writes the entitlements the app is currently
+// signed with to path, or an empty set when it is unsigned
+func (r *Resigner) extractSignedEntitlements(appPath, path string) error {
+	entitlements := map[string]interface{}{}
+	output, err := r.command("/usr/bin/codesign", "-d", "--entitlements", "-", "--xml", appPath).Output()
+	if err == nil && len(output) > 0 {
+		if _, err := plist.Unmarshal(output, &entitlements); err != nil {
+			return fmt.Errorf("failed to decode signed entitlements: %w", err)
+		}
+	}
+	return writePlistFile(path, entitlements, plist.XMLFormat)
+}
+
 // handleBundleID changes the bundle identifier if specified
 func (r *Resigner) handleBundleID(appPath string) error {
-	if r.config.BundleID == "" {
+	bundleID := r.config.BundleID
+	if r.config.BundleFromProfile {
+		profileBundleID, err := bundleIDFromProfile(filepath.Join(appPath, "embedded.mobileprovision"))
+		if err != nil {
+			return err
+		}
+		r.logProgress(fmt.Sprintf("Using bundle identifier from provisioning profile: %s", profileBundleID))
+		bundleID = profileBundleID
+	}
+
+	if bundleID == "" {
 		r.logProgress("Sign using existing bundle identifier from payload")
 		return nil
 	}
 
-	r.logProgress(fmt.Sprintf("Changing bundle identifier with: %s", r.config.BundleID))
+	r.logProgress(fmt.Sprintf("Changing bundle identifier with: %s", bundleID))
+	return r.changeBundleID(appPath, bundleID)
+}
+
+// changeBundleID rewrites the main bundle's identifier, remembering the
+// original so nested bundles can be renamed to match
+func (r *Resigner) changeBundleID(appPath, bundleID string) error {
+	infoPlist := filepath.Join(appPath, "Info.plist")
+	if r.originalBundleID == "" {
+		if original, err := readBundleIdentifier(infoPlist); err == nil {
+			r.originalBundleID = original
+		}
+	}
+	if err := setBundleIdentifier(infoPlist, bundleID); err != nil {
+		return err
+	}
+	r.bundleID = bundleID
+	return nil
+}
+
+// derivedBundleID computes the new identifier of a nested bundle. IDs
+// prefixed by the original main bundle ID keep their suffix
+// (com.old.app.widget -> com.new.app.widget); others get a numbered one.
+func (r *Resigner) derivedBundleID(nestedID string, index int) string {
+	if r.originalBundleID != "" && strings.HasPrefix(nestedID, r.originalBundleID+".") {
+		return r.bundleID + strings.TrimPrefix(nestedID, r.originalBundleID)
+	}
+	return fmt.Sprintf("%s.extra%d", r.bundleID, index)
+}
+
+// bundleIDFromProfile returns the explicit app ID of a provisioning profile
+func bundleIDFromProfile(provisionPath string) (string, error) {
+	profile, err := ParseProfile(provisionPath)
+	if err != nil {
+		return "", err
+	}
+	appID := profile.AppID()
+	if appID == "" {
+		return "", fmt.Errorf("provisioning profile %q has no application-identifier", profile.Name)
+	}
+	if profile.IsWildcard() {
+		return "", fmt.Errorf("provisioning profile %q has wildcard app ID %s; specify the bundle ID explicitly", profile.Name, appID)
+	}
+	if err := ValidateBundleID(appID); err != nil {
+		return "", err
+	}
+	return appID, nil
+}
+
+// resolveApplicationIdentifier makes the entitlements' application-identifier
+// agree with the embedded profile: wildcard profiles get TEAM.<bundle ID>,
+// explicit profiles must match the bundle ID (or are adopted when
+// ForceBundleFromProfile is set)
+func (r *Resigner) resolveApplicationIdentifier(appPath, entitlementsPath string) error {
+	provisionPath := filepath.Join(appPath, "embedded.mobileprovision")
+	if _, err := os.Stat(provisionPath); os.IsNotExist(err) {
+		return nil
+	}
+	profile, err := ParseProfile(provisionPath)
+	if err != nil {
+		return err
+	}
+	if profile.ApplicationIdentifier() == "" {
+		return nil
+	}
+
 	infoPlist := filepath.Join(appPath, "Info.plist")
-	cmd := exec.Command("/usr/libexec/PlistBuddy", "-c", fmt.Sprintf("Set:CFBundleIdentifier %s", r.config.BundleID), infoPlist)
-	return cmd.Run()
+	bundleID, err := readBundleIdentifier(infoPlist)
+	if err != nil {
+		return err
+	}
+
+	appIdentifier, err := effectiveApplicationIdentifier(profile, bundleID)
+	var mismatch *AppIDMismatchError
+	if errors.As(err, &mismatch) && !profile.IsWildcard() {
+		if !r.config.ForceBundleFromProfile {
+			return fmt.Errorf("%w (enable force-bundle-from-profile to adopt the profile's app ID)", err)
+		}
+		r.logProgress(fmt.Sprintf("Adopting bundle identifier from provisioning profile: %s", mismatch.AppID))
+		if err := r.changeBundleID(appPath, mismatch.AppID); err != nil {
+			return err
+		}
+		appIdentifier = profile.ApplicationIdentifier()
+	} else if err != nil {
+		return err
+	}
+
+	if profile.IsWildcard() {
+		r.logProgress(fmt.Sprintf("Wildcard profile %s: using application-identifier %s", profile.ApplicationIdentifier(), appIdentifier))
+	}
+
+	entitlements, format, err := readPlistFile(entitlementsPath)
+	if err != nil {
+		return err
+	}
+	if current, _ := entitlements["application-identifier"].(string); current == appIdentifier {
+		return nil
+	}
+	entitlements["application-identifier"] = appIdentifier
+	return writePlistFile(entitlementsPath, entitlements, format)
 }
 
-// signComponents signs all app components
+// signComponents signs all app components, nested bundles before the
+// bundles containing them
 func (r *Resigner) signComponents(appPath, entitlementsPath string) error {
-	r.logProgress(fmt.Sprintf("Get list of components and sign with certificate: %s", r.config.Certificate))
+	if r.isAdHoc() {
+		r.logProgress("Get list of components and sign ad-hoc")
+	} else {
+		r.logProgress(fmt.Sprintf("Get list of components and sign with certificate: %s", r.config.Certificate))
+	}
 
 	// Find all components
-	components, err := findComponents(appPath)
+	components, err := r.components.find(appPath)
 	if err != nil {
 		return err
 	}
+	components = append(components, r.looseCode...)
+
+	// Announce the total up front so callers can estimate remaining time
+	total := len(components)
+	r.emitEvent(Event{
+		Type:    EventInfo,
+		Message: fmt.Sprintf("Found %d components to sign", total),
+		Total:   total,
+	})
+
+	graph := newSignGraph(components)
+
+	// stale marks components containing a component signed in this run;
+	// their seals are out of date even if their own signature is valid
+	var mu sync.Mutex
+	signed, skipped := 0, 0
+	stale := make(map[string]bool)
+	sign := func(component string) error {
+		mu.Lock()
+		signed++
+		current := signed
+		checkValid := r.config.SkipValid && !stale[component]
+		mu.Unlock()
+
+		if checkValid && r.signedByTarget(component, entitlementsPath) {
+			mu.Lock()
+			skipped++
+			mu.Unlock()
+			r.emitEvent(Event{
+				Type:      EventInfo,
+				Message:   fmt.Sprintf("Skipping %d/%d: %s (already signed)", current, total, filepath.Base(component)),
+				Component: component,
+				Current:   current,
+				Total:     total,
+			})
+			return nil
+		}
+
+		r.emitEvent(Event{
+			Type:      EventInfo,
+			Message:   fmt.Sprintf("Signing %d/%d: %s", current, total, filepath.Base(component)),
+			Component: component,
+			Current:   current,
+			Total:     total,
+		})
+		handler, ok := r.components.handler(component)
+		if !ok {
+			handler.Sign = CodesignComponent
+		}
+		if err := handler.Sign(r, component, entitlementsPath); err != nil {
+			return fmt.Errorf("failed to sign %s: %w", component, err)
+		}
+		if parent, ok := graph.parent[component]; ok {
+			mu.Lock()
+			stale[parent] = true
+			mu.Unlock()
+		}
+		return nil
+	}
+
+	// Rename nested bundles before anything is signed
+	r.renameNestedBundles(appPath, components, entitlementsPath)
 
+	// Sign inside-out: a wave only holds components whose nested
+	// components are already signed, so each wave signs concurrently
 	r.logProgress("Sign plugins, frameworks, dylibs")
-	extraCounter := 0
-	for _, component := range components {
-		ext := filepath.Ext(component)
-		switch ext {
-		case ".appex":
-			if r.config.BundleID != "" {
-				newBundleID := fmt.Sprintf("%s.extra%d", r.config.BundleID, extraCounter)
-				r.logProgress(fmt.Sprintf("Changing .appex bundle identifier with: %s", newBundleID))
-				infoPlist := filepath.Join(component, "Info.plist")
-				cmd := exec.Command("/usr/libexec/PlistBuddy", "-c", fmt.Sprintf("Set:CFBundleIdentifier %s", newBundleID), infoPlist)
-				if err := cmd.Run(); err != nil {
-					r.logProgress(fmt.Sprintf("Warning: Failed to change bundle ID for %s: %v", component, err))
+	var order []string
+	for _, wave := range graph.waves() {
+		if len(wave) == 1 && wave[0] == appPath {
+			r.logProgress("Sign app")
+		}
+		if err := r.signConcurrently(wave, sign); err != nil {
+			return err
+		}
+		order = append(order, wave...)
+	}
+	if skipped > 0 {
+		r.warn(WarningComponentSkipped, appPath, "", "Skipped %d of %d components already signed by the target identity", skipped, total)
+	}
+	r.collectSignatures(appPath, order)
+	return nil
+}
+
+// signConcurrently runs sign over components using up to
+// Config.Concurrency workers, returning the first error
+func (r *Resigner) signConcurrently(components []string, sign func(string) error) error {
+	workers := r.config.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(components) {
+		workers = len(components)
+	}
+
+	work := make(chan string)
+	errs := make(chan error, len(components))
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for component := range work {
+				if err := r.checkCanceled(); err != nil {
+					errs <- err
+					continue
+				}
+				if err := sign(component); err != nil {
+					errs <- err
 				}
-				extraCounter++
-			}
-			if err := r.codesign(component, entitlementsPath); err != nil {
-				return fmt.Errorf("failed to sign %s: %w", component, err)
-			}
-		case ".framework", ".dylib":
-			if err := r.codesign(component, entitlementsPath); err != nil {
-				return fmt.Errorf("failed to sign %s: %w", component, err)
 			}
-		}
+		}()
 	}
 
-	r.logProgress("Sign app")
 	for _, component := range components {
-		if filepath.Ext(component) == ".app" {
-			if err := r.codesign(component, entitlementsPath); err != nil {
-				return fmt.Errorf("failed to sign %s: %w", component, err)
-			}
+		if len(errs) > 0 {
+			break
 		}
+		work <- component
 	}
+	close(work)
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
 
+// verifySignature checks the finished app with codesign's strict checks
+func (r *Resigner) verifySignature(appPath string) error {
+	r.logProgress("Verifying signature")
+	output, err := r.command("/usr/bin/codesign", "--verify", "--deep", "--strict", appPath).streamed().CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("signature verification failed: %s - %w", strings.TrimSpace(string(output)), err)
+	}
 	return nil
 }
 
 // codesign signs a component
 func (r *Resigner) codesign(component, entitlementsPath string) error {
-	cmd := exec.Command("/usr/bin/codesign",
+	cmd := r.command("/usr/bin/codesign",
 		"--continue",
 		"--generate-entitlement-der",
 		"-f",
-		"-s", r.config.Certificate,
-		"--entitlements", entitlementsPath,
-		component)
+		"-s", r.identityFor(component))
+	cmd.Args = append(cmd.Args, r.codesignKeychainArgs()...)
+	if entitlementsPath != "" {
+		cmd.Args = append(cmd.Args, "--entitlements", entitlementsPath)
+	}
+	cmd.Args = append(cmd.Args, component)
 
-	output, err := cmd.CombinedOutput()
+	output, err := cmd.streamed().CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("codesign failed: %s - %w", string(output), err)
 	}
@@ -318,144 +1081,117 @@ func (r *Resigner) codesign(component, entitlementsPath string) error {
 
 // createResignedIPA creates the resigned IPA or copies the .app
 func (r *Resigner) createResignedIPA(appPath string) error {
-	outDir := filepath.Dir(r.config.SourceIPA)
-	resignedDir := filepath.Join(outDir, "Resigned")
-
-	// Remove and recreate Resigned directory
-	os.RemoveAll(resignedDir)
+	if r.config.InPlace {
+		r.logProgress(fmt.Sprintf("Resigned .app in place: %s", appPath))
+		r.outputPath = appPath
+		return nil
+	}
+	resignedDir := r.outputDir()
 	if err := os.MkdirAll(resignedDir, 0755); err != nil {
 		return err
 	}
 
+	target := r.outputTarget(appPath)
 	ext := strings.ToLower(filepath.Ext(r.config.SourceIPA))
 
 	if ext == ".ipa" {
-		appName := filepath.Base(appPath)
-		filename := strings.TrimSuffix(appName, filepath.Ext(appName)) + ".ipa"
-		outputPath := filepath.Join(resignedDir, filename)
-
-		r.logProgress(fmt.Sprintf("Creating the signed ipa: %s", filename))
+		outputPath := target
+		r.logProgress(fmt.Sprintf("Creating the signed ipa: %s", filepath.Base(outputPath)))
 
-		// Create zip from Payload directory
-		if err := zipDirectory(r.appDir, outputPath); err != nil {
+		// Package in the workspace first, so a failure or a blown size
+		// budget never touches the output directory
+		staged := filepath.Join(r.workspace.Output, filepath.Base(outputPath))
+		if err := r.packageWorkspace(staged); err != nil {
+			return err
+		}
+		if err := r.checkSizeBudget(appPath, staged); err != nil {
+			return err
+		}
+		if err := r.replaceOutput(outputPath); err != nil {
+			return err
+		}
+		if err := moveFile(staged, outputPath); err != nil {
 			return err
 		}
 
 		r.logProgress(fmt.Sprintf("Resigned IPA saved to: %s", outputPath))
+		r.outputPath = outputPath
 	} else if ext == ".app" {
-		appName := filepath.Base(appPath)
-		outputPath := filepath.Join(resignedDir, appName)
-
+		outputPath := target
+		if err := r.replaceOutput(outputPath); err != nil {
+			return err
+		}
 		r.logProgress("Moving resigned .app file...")
-		if err := copyDir(appPath, outputPath); err != nil {
+		if err := cloneOrCopyDir(appPath, outputPath); err != nil {
 			return err
 		}
 
 		r.logProgress(fmt.Sprintf("Resigned .app saved to: %s", outputPath))
+		r.outputPath = outputPath
 	}
 
 	return nil
 }
 
-// Helper functions
-
-// unzip extracts a zip file to a destination
-func unzip(src, dest string) error {
-	r, err := zip.OpenReader(src)
-	if err != nil {
+// replaceOutput clears the way for a new output at target. Only the
+// output being replaced is removed; anything else in the output
+// directory is left alone
+func (r *Resigner) replaceOutput(target string) error {
+	if err := r.confirmOverwrite(target); err != nil {
 		return err
 	}
-	defer r.Close()
-
-	for _, f := range r.File {
-		fpath := filepath.Join(dest, f.Name)
-
-		if f.FileInfo().IsDir() {
-			os.MkdirAll(fpath, os.ModePerm)
-			continue
-		}
-
-		if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
-			return err
-		}
-
-		outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
-		if err != nil {
-			return err
-		}
-
-		rc, err := f.Open()
-		if err != nil {
-			outFile.Close()
-			return err
-		}
+	return os.RemoveAll(target)
+}
 
-		_, err = io.Copy(outFile, rc)
-		outFile.Close()
-		rc.Close()
+// Helper functions
 
-		if err != nil {
-			return err
-		}
+// packageWorkspace archives the extracted IPA at path with
+// Config.Compression. Unless the preset recompresses everything, files
+// the run left untouched keep the source archive's compressed data rather
+// than being compressed again; encrypted sources are always recompressed.
+func (r *Resigner) packageWorkspace(path string) error {
+	compression := r.config.Compression
+	if compression != "" && compression != CompressionBalanced {
+		r.logProgress(fmt.Sprintf("Compressing with the %s preset", compression))
 	}
-	return nil
-}
-
-// zipDirectory creates a zip file from a directory
-func zipDirectory(source, target string) error {
-	zipfile, err := os.Create(target)
+	if encrypted, err := IsEncryptedArchive(r.config.SourceIPA); err != nil || encrypted || !compression.reusesSource() {
+		_, err := createArchive(r.workspace.Extracted, "", path, nil, compression)
+		return err
+	}
+	reused, err := repackArchive(r.workspace.Extracted, r.config.SourceIPA, path, compression)
 	if err != nil {
 		return err
 	}
-	defer zipfile.Close()
-
-	archive := zip.NewWriter(zipfile)
-	defer archive.Close()
-
-	err = filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		header, err := zip.FileInfoHeader(info)
-		if err != nil {
-			return err
-		}
-
-		relPath, err := filepath.Rel(source, path)
-		if err != nil {
-			return err
-		}
-		header.Name = relPath
-
-		if info.IsDir() {
-			header.Name += "/"
-		} else {
-			header.Method = zip.Deflate
-		}
-
-		writer, err := archive.CreateHeader(header)
-		if err != nil {
-			return err
-		}
+	r.logProgress(fmt.Sprintf("Reused %d unchanged files from the source IPA", reused))
+	return nil
+}
 
-		if !info.IsDir() {
-			file, err := os.Open(path)
-			if err != nil {
-				return err
-			}
-			defer file.Close()
-			_, err = io.Copy(writer, file)
-			return err
-		}
+// moveFile renames src to dst, copying it across file systems
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
 		return nil
-	})
+	}
+	if err := copyFile(src, dst); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
 
-	return err
+// refuseSymlink fails if path is a symlink, which writing to would follow
+// to wherever a crafted IPA points it
+func refuseSymlink(path string) error {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf("refusing to write through symlink %s", path)
+	}
+	return nil
 }
 
-// copyFile copies a file from src to dst
+// copyFile copies a file from src to dst, which must not be a symlink
 func copyFile(src, dst string) error {
+	if err := refuseSymlink(dst); err != nil {
+		return err
+	}
 	in, err := os.Open(src)
 	if err != nil {
 		return err
@@ -468,10 +1204,18 @@ func copyFile(src, dst string) error {
 	}
 	defer out.Close()
 
-	_, err = io.Copy(out, in)
+	_, err = copyBuffered(out, in)
 	return err
 }
 
+// copyFileMode copies a file from src to dst and gives it mode perm
+func copyFileMode(src, dst string, perm os.FileMode) error {
+	if err := copyFile(src, dst); err != nil {
+		return err
+	}
+	return os.Chmod(dst, perm)
+}
+
 // copyDir recursively copies a directory
 func copyDir(src, dst string) error {
 	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
@@ -490,54 +1234,16 @@ func copyDir(src, dst string) error {
 			return os.MkdirAll(targetPath, info.Mode())
 		}
 
-		return copyFile(path, targetPath)
-	})
-}
-
-// findComponents finds all components that need to be signed
-func findComponents(appPath string) ([]string, error) {
-	var components []string
-	var appComponents []string
-
-	err := filepath.Walk(appPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if info.IsDir() {
-			ext := filepath.Ext(path)
-			if ext == ".app" || ext == ".appex" || ext == ".framework" {
-				components = append(components, path)
-			}
-		} else {
-			ext := filepath.Ext(path)
-			if ext == ".dylib" {
-				components = append(components, path)
+		// Keep symlinks as links so framework layouts survive the copy
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
 			}
+			return os.Symlink(link, targetPath)
 		}
 
-		return nil
+		// Keep executable bits, or the copied app will not launch
+		return copyFileMode(path, targetPath, info.Mode().Perm())
 	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	// Sort so that .app files are last
-	for _, comp := range components {
-		if filepath.Ext(comp) == ".app" {
-			appComponents = append(appComponents, comp)
-		}
-	}
-
-	// Remove .app from components and sort them to be signed last
-	var nonAppComponents []string
-	for _, comp := range components {
-		if filepath.Ext(comp) != ".app" {
-			nonAppComponents = append(nonAppComponents, comp)
-		}
-	}
-
-	// Return non-.app components first, then .app components
-	return append(nonAppComponents, appComponents...), nil
 }
@@ -1,8 +1,13 @@
 package resigner
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 )
 
@@ -158,9 +163,9 @@ func TestFindComponents(t *testing.T) {
 	os.MkdirAll(appexDir, 0755)
 
 	// Find components
-	components, err := findComponents(appDir)
+	components, err := DefaultComponents().find(appDir)
 	if err != nil {
-		t.Fatalf("findComponents() failed: %v", err)
+		t.Fatalf("DefaultComponents().find() failed: %v", err)
 	}
 
 	// Check if components were found
@@ -221,6 +226,89 @@ func TestLogProgress(t *testing.T) {
 	}
 }
 
+func TestEventHandler(t *testing.T) {
+	var events []Event
+	r := New(Config{SourceIPA: "test.ipa", Certificate: "Test"}, WithEventHandler(func(e Event) {
+		events = append(events, e)
+	}))
+
+	r.logProgress("step")
+	r.logWarning("careful")
+
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	if events[0].Type != EventInfo || events[0].Message != "step" {
+		t.Errorf("Unexpected first event: %+v", events[0])
+	}
+	if events[1].Type != EventWarning || events[1].Message != "careful" {
+		t.Errorf("Unexpected second event: %+v", events[1])
+	}
+	if events[0].Time.IsZero() {
+		t.Error("Event time should be set")
+	}
+}
+
+func TestWithOutput(t *testing.T) {
+	var buf bytes.Buffer
+	r := New(Config{SourceIPA: "test.ipa", Certificate: "Test"}, WithOutput(&buf))
+
+	r.logProgress("first")
+	r.logWarning("second")
+
+	if got, want := buf.String(), "first\nsecond\n"; got != want {
+		t.Errorf("Output = %q, want %q", got, want)
+	}
+}
+
+func TestNoStdoutByDefault(t *testing.T) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	r := NewResigner(Config{SourceIPA: "test.ipa", Certificate: "Test"}, nil)
+	r.logProgress("should not be printed")
+	r.logWarning("should not be printed either")
+
+	writer.Close()
+	os.Stdout = stdout
+	printed, _ := io.ReadAll(reader)
+	if len(printed) != 0 {
+		t.Errorf("Expected no stdout output, got %q", printed)
+	}
+}
+
+func TestResignContextCanceled(t *testing.T) {
+	tmpDir := t.TempDir()
+	source := filepath.Join(tmpDir, "Test.app")
+	os.MkdirAll(source, 0755)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var gotError bool
+	r := New(Config{SourceIPA: source, Certificate: "Test"}, WithEventHandler(func(e Event) {
+		if e.Type == EventError {
+			gotError = true
+		}
+	}))
+
+	_, err := r.ResignContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if !gotError {
+		t.Error("Expected an error event")
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(tmpDir, workspacePrefix+"*")); len(leftovers) != 0 {
+		t.Errorf("Temporary directory should be removed after cancellation: %v", leftovers)
+	}
+}
+
 func TestPanicRecovery(t *testing.T) {
 	config := Config{
 		SourceIPA:   "nonexistent.ipa",
@@ -230,7 +318,7 @@ func TestPanicRecovery(t *testing.T) {
 	r := NewResigner(config, nil)
 
 	// This should not panic, even though the file doesn't exist
-	err := r.Resign()
+	_, err := r.Resign()
 	if err == nil {
 		t.Error("Expected error, got nil")
 	}
@@ -264,6 +352,122 @@ func BenchmarkFindComponents(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		findComponents(appDir)
+		DefaultComponents().find(appDir)
+	}
+}
+
+func TestDerivedBundleID(t *testing.T) {
+	r := New(Config{})
+	r.originalBundleID = "com.old.app"
+	r.bundleID = "com.new.app"
+
+	tests := []struct {
+		nestedID string
+		index    int
+		want     string
+	}{
+		{"com.old.app.widget", 0, "com.new.app.widget"},
+		{"com.old.app.share.ext", 1, "com.new.app.share.ext"},
+		{"com.old.application", 2, "com.new.app.extra2"},
+		{"", 3, "com.new.app.extra3"},
+	}
+
+	for _, tt := range tests {
+		if got := r.derivedBundleID(tt.nestedID, tt.index); got != tt.want {
+			t.Errorf("derivedBundleID(%q, %d) = %q, want %q", tt.nestedID, tt.index, got, tt.want)
+		}
+	}
+}
+
+func TestValidateAdHoc(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "test.ipa")
+	if err := os.WriteFile(source, []byte("ipa"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"no certificate", Config{SourceIPA: source, AdHoc: true}, false},
+		{"dash certificate", Config{SourceIPA: source, Certificate: "-", AdHoc: true}, false},
+		{"named certificate", Config{SourceIPA: source, Certificate: "Apple Development", AdHoc: true}, true},
+		{"with provision", Config{SourceIPA: source, MobileProvision: source, AdHoc: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(tt.config)
+			err := r.validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && r.identity() != "-" {
+				t.Errorf("identity() = %q, want -", r.identity())
+			}
+		})
+	}
+}
+
+func TestInPlace(t *testing.T) {
+	root := t.TempDir()
+	appPath := filepath.Join(root, "Test.app")
+	if err := os.Mkdir(appPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	ipaPath := filepath.Join(root, "Test.ipa")
+	if err := os.WriteFile(ipaPath, []byte("ipa"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := New(Config{SourceIPA: ipaPath, Certificate: "Test", InPlace: true}).validate(); err == nil {
+		t.Error("Expected in-place validation to reject an .ipa")
+	}
+
+	r := New(Config{SourceIPA: appPath, Certificate: "Test", InPlace: true})
+	if err := r.validate(); err != nil {
+		t.Fatalf("validate() failed: %v", err)
+	}
+	got, err := r.extractApp()
+	if err != nil {
+		t.Fatalf("extractApp() failed: %v", err)
+	}
+	if got != appPath {
+		t.Errorf("extractApp() = %q, want %q", got, appPath)
+	}
+	if r.outputDir() != root {
+		t.Errorf("outputDir() = %q, want %q", r.outputDir(), root)
+	}
+}
+
+func TestSignConcurrently(t *testing.T) {
+	r := New(Config{Concurrency: 4})
+	components := []string{"a", "b", "c", "d", "e", "f"}
+
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	err := r.signConcurrently(components, func(component string) error {
+		mu.Lock()
+		seen[component] = true
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("signConcurrently() failed: %v", err)
+	}
+	if len(seen) != len(components) {
+		t.Errorf("signed %d components, want %d", len(seen), len(components))
+	}
+
+	failure := errors.New("codesign failed")
+	err = r.signConcurrently(components, func(component string) error {
+		if component == "c" {
+			return failure
+		}
+		return nil
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("signConcurrently() error = %v, want %v", err, failure)
 	}
 }
@@ -0,0 +1,87 @@
+package resigner
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// restrictedEntitlements are granted by Apple on request only, so a profile
+// from another team rarely carries them. Each maps to what stops working
+// when the app is signed without it.
+var restrictedEntitlements = map[string]string{
+	"com.apple.developer.carplay-audio":                     "the app will not appear in CarPlay as an audio app",
+	"com.apple.developer.carplay-charging":                  "the app will not appear in CarPlay as an EV charging app",
+	"com.apple.developer.carplay-communication":             "the app will not appear in CarPlay as a communication app",
+	"com.apple.developer.carplay-driving-task":              "the app will not appear in CarPlay as a driving task app",
+	"com.apple.developer.carplay-fueling":                   "the app will not appear in CarPlay as a fueling app",
+	"com.apple.developer.carplay-maps":                      "the app will not appear in CarPlay as a navigation app",
+	"com.apple.developer.carplay-parking":                   "the app will not appear in CarPlay as a parking app",
+	"com.apple.developer.carplay-quick-ordering":            "the app will not appear in CarPlay as a quick food ordering app",
+	"com.apple.developer.playable-content":                  "the app will not appear in CarPlay",
+	"com.apple.developer.networking.networkextension":       "its VPN, content filter, DNS proxy and app proxy providers will fail to start",
+	"com.apple.developer.networking.vpn.api":                "it cannot save personal VPN configurations",
+	"com.apple.developer.networking.multicast":              "multicast and broadcast networking will be blocked",
+	"com.apple.developer.pushkit.unrestricted-voip":         "VoIP pushes will not be delivered while the app is not running",
+	"com.apple.developer.usernotifications.critical-alerts": "critical alerts will be delivered as ordinary notifications",
+}
+
+// restrictedEntitlementLosses explains, one message per entitlement, the
+// restricted entitlements of original that updated, taken from source,
+// drops entirely or in part, such as a Network Extension provider type
+// the profile lacks
+func restrictedEntitlementLosses(original, updated map[string]interface{}, source string) []string {
+	var losses []string
+	for key, effect := range restrictedEntitlements {
+		value, ok := original[key]
+		if !ok {
+			continue
+		}
+		kept, ok := updated[key]
+		if !ok {
+			losses = append(losses, fmt.Sprintf("%s is not in the entitlements being signed, taken from %s: %s", key, source, effect))
+			continue
+		}
+		if missing := missingValues(value, kept); len(missing) > 0 {
+			losses = append(losses, fmt.Sprintf("%s lacks %s in the entitlements being signed, taken from %s: %s", key, strings.Join(missing, ", "), source, effect))
+		}
+	}
+	sort.Strings(losses)
+	return losses
+}
+
+// missingValues lists the strings of an array entitlement value that
+// another value does not include
+func missingValues(value, kept interface{}) []string {
+	values, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+	keptValues, _ := kept.([]interface{})
+	present := make(map[string]bool)
+	for _, v := range keptValues {
+		if s, ok := v.(string); ok {
+			present[s] = true
+		}
+	}
+	var missing []string
+	for _, v := range values {
+		if s, ok := v.(string); ok && !present[s] && !present["*"] {
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
+
+// entitlementsSource names where the entitlements being signed come from
+func (r *Resigner) entitlementsSource() string {
+	if r.config.Entitlements == "" {
+		return "the provisioning profile"
+	}
+	file := filepath.Base(r.config.Entitlements)
+	if r.config.EntitlementsMerge == "" || r.config.EntitlementsMerge == MergeReplace {
+		return fmt.Sprintf("the entitlements file %s", file)
+	}
+	return fmt.Sprintf("the entitlements file %s merged with the provisioning profile", file)
+}
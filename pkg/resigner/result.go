@@ -0,0 +1,95 @@
+package resigner
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Result describes what a run produced. It is returned even when the run
+// fails, describing how far it got, so callers never need to re-derive
+// any of it from the log.
+type Result struct {
+	// OutputPath is the resigned IPA or .app; empty if the run did not
+	// get that far
+	OutputPath string
+	// BundleID is the main app's bundle identifier as signed
+	BundleID string
+	// TeamID is the team the app was signed for
+	TeamID string
+	// Profile is the provisioning profile embedded in the app, or nil when
+	// it carries none
+	Profile *Profile
+	// Components lists the signature of every component in the output,
+	// nested components before the bundles containing them
+	Components []SignedComponent
+	// Warnings holds every warning of the run, in order
+	Warnings []Warning
+	// Stages records how long each stage that ran took, in order
+	Stages []StageTiming
+	// ManifestPath is the MDM manifest written next to the IPA, if any
+	ManifestPath string
+	// ResignBy is the date an enterprise app must be re-signed by, set in
+	// enterprise mode
+	ResignBy time.Time
+	// Duration is the length of the whole run
+	Duration time.Duration
+	// Workspace is the run's temporary directory when Config.KeepWorkspace
+	// left it in place
+	Workspace string
+}
+
+// StageTiming is the time a pipeline stage took
+type StageTiming struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Result returns a copy of what the last run produced
+func (r *Resigner) Result() *Result {
+	r.emitMu.Lock()
+	defer r.emitMu.Unlock()
+	result := r.result
+	result.Components = append([]SignedComponent(nil), r.result.Components...)
+	result.Warnings = append([]Warning(nil), r.result.Warnings...)
+	result.Stages = append([]StageTiming(nil), r.result.Stages...)
+	return &result
+}
+
+// resetResult clears the result of a previous run, and what that run
+// learned about its app, so a reused Resigner starts afresh
+func (r *Resigner) resetResult() {
+	r.emitMu.Lock()
+	r.result = Result{}
+	r.emitMu.Unlock()
+	r.outputPath = ""
+	r.originalBundleID, r.bundleID = "", ""
+	r.signingIdentity = SigningIdentity{}
+	r.teamID = ""
+	r.simulator = false
+	r.looseCode = nil
+	r.missingTools = nil
+	r.localOutput = ""
+}
+
+// finishResult fills in the run's outcome from the app at appPath, which
+// may be empty when the run stopped before extracting it
+func (r *Resigner) finishResult(appPath string, duration time.Duration) {
+	var bundleID string
+	var profile *Profile
+	if appPath != "" {
+		bundleID, _ = readBundleIdentifier(filepath.Join(appPath, "Info.plist"))
+		provision := filepath.Join(appPath, "embedded.mobileprovision")
+		if _, err := os.Stat(provision); err == nil {
+			profile, _ = ParseProfile(provision)
+		}
+	}
+
+	r.emitMu.Lock()
+	defer r.emitMu.Unlock()
+	r.result.OutputPath = r.outputPath
+	r.result.TeamID = r.teamID
+	r.result.BundleID = bundleID
+	r.result.Profile = profile
+	r.result.Duration = duration
+}
@@ -0,0 +1,179 @@
+package resigner
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// signatureInfo describes a component's existing code signature
+type signatureInfo struct {
+	// Authority is the common name of the signing certificate; empty for
+	// ad-hoc signatures
+	Authority  string
+	TeamID     string
+	AdHoc      bool
+	Identifier string
+	// CDHash is the hash of the code directory, only printed by -dvvv
+	CDHash string
+	// SigningTime is the secure timestamp, or the signing machine's clock
+	// when there is none; zero for ad-hoc signatures
+	SigningTime time.Time
+}
+
+// signingTimeLayouts are the date formats codesign prints, which follow
+// the system locale
+var signingTimeLayouts = []string{
+	"2 Jan 2006 at 15:04:05",
+	"Jan 2, 2006 at 3:04:05 PM",
+	"2 Jan 2006 15:04:05",
+	"Jan 2, 2006, 3:04:05 PM",
+}
+
+// parseSigningTime parses a codesign date, returning zero when the
+// locale's format is not known
+func parseSigningTime(value string) time.Time {
+	// Recent macOS puts a narrow no-break space before AM/PM
+	value = strings.ReplaceAll(value, "\u202f", " ")
+	for _, layout := range signingTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// parseSignatureInfo reads the output of codesign -dvv or -dvvv
+func parseSignatureInfo(output string) signatureInfo {
+	var info signatureInfo
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		switch {
+		case !ok:
+		case key == "Authority" && info.Authority == "":
+			// The leaf certificate comes first, then its issuers
+			info.Authority = value
+		case key == "TeamIdentifier" && value != "not set":
+			info.TeamID = value
+		case key == "Signature" && value == "adhoc":
+			info.AdHoc = true
+		case key == "Identifier":
+			info.Identifier = value
+		case key == "CDHash":
+			info.CDHash = value
+		case key == "Timestamp":
+			info.SigningTime = parseSigningTime(value)
+		case key == "Signed Time" && info.SigningTime.IsZero():
+			info.SigningTime = parseSigningTime(value)
+		}
+	}
+	return info
+}
+
+// signedByTarget reports whether component already carries an intact
+// signature made by the identity and team this run signs with, and with
+// the same entitlements, so signing it again would change nothing
+func (r *Resigner) signedByTarget(component, entitlementsPath string) bool {
+	// Any edit to the component, such as a new bundle ID or profile,
+	// breaks its seal
+	if err := r.command("/usr/bin/codesign", "--verify", component).Run(); err != nil {
+		return false
+	}
+	output, err := r.command("/usr/bin/codesign", "-dvv", component).CombinedOutput()
+	if err != nil {
+		return false
+	}
+	info := parseSignatureInfo(string(output))
+	if identity, ok := r.componentIdentity(component); ok {
+		if identity.Hash == "-" {
+			if !info.AdHoc {
+				return false
+			}
+		} else if info.Authority != identity.Name {
+			return false
+		}
+	} else if r.isAdHoc() {
+		if !info.AdHoc {
+			return false
+		}
+	} else if info.Authority != r.certificateName() || (r.teamID != "" && info.TeamID != r.teamID) {
+		return false
+	}
+	return r.signedEntitlementsMatch(component, entitlementsPath)
+}
+
+// signedEntitlementsMatch reports whether component is signed with exactly
+// the entitlements in entitlementsPath, or none when it is empty
+func (r *Resigner) signedEntitlementsMatch(component, entitlementsPath string) bool {
+	want := map[string]interface{}{}
+	if entitlementsPath != "" {
+		var err error
+		if want, _, err = readPlistFile(entitlementsPath); err != nil {
+			return false
+		}
+	}
+
+	got, err := r.signedEntitlements(component)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(got, want)
+}
+
+// SignedComponent describes the signature of one component of the output,
+// such as the details MDM allowlists are keyed on
+type SignedComponent struct {
+	// Path is relative to the folder holding the app, e.g.
+	// "Test.app/Frameworks/Kit.framework"
+	Path       string
+	Identifier string
+	CDHash     string
+	TeamID     string
+	// Authority is the signing certificate's common name; empty for
+	// ad-hoc signatures
+	Authority string
+	// SigningTime is zero when codesign did not print a known date
+	SigningTime time.Time
+}
+
+// collectSignatures records the signature of every component once signing
+// is done, nested components first
+func (r *Resigner) collectSignatures(appPath string, components []string) {
+	signed := make([]SignedComponent, len(components))
+	found := make([]bool, len(components))
+	index := make(map[string]int, len(components))
+	for i, component := range components {
+		index[component] = i
+	}
+	r.signConcurrently(components, func(component string) error {
+		output, err := r.command("/usr/bin/codesign", "-dvvv", component).CombinedOutput()
+		if err != nil {
+			r.warn(WarningSignatureUnreadable, appPath, component, "Cannot read the signature of %s: %s", filepath.Base(component), strings.TrimSpace(string(output)))
+			return nil
+		}
+		info := parseSignatureInfo(string(output))
+		rel, _ := filepath.Rel(filepath.Dir(appPath), component)
+		i := index[component]
+		signed[i] = SignedComponent{
+			Path:        filepath.ToSlash(rel),
+			Identifier:  info.Identifier,
+			CDHash:      info.CDHash,
+			TeamID:      info.TeamID,
+			Authority:   info.Authority,
+			SigningTime: info.SigningTime,
+		}
+		found[i] = true
+		return nil
+	})
+
+	var collected []SignedComponent
+	for i := range signed {
+		if found[i] {
+			collected = append(collected, signed[i])
+		}
+	}
+	r.emitMu.Lock()
+	r.result.Components = collected
+	r.emitMu.Unlock()
+}
@@ -0,0 +1,87 @@
+package resigner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSignatureInfo(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   signatureInfo
+	}{
+		{
+			name: "identity",
+			output: `Executable=/tmp/Payload/Test.app/Test
+Identifier=com.example.test
+Format=app bundle with Mach-O thin (arm64)
+CodeDirectory v=20500 size=1234 flags=0x0(none) hashes=28+7 location=embedded
+Signature size=4797
+Authority=Apple Development: Jane Doe (ABCDE12345)
+Authority=Apple Worldwide Developer Relations Certification Authority
+Authority=Apple Root CA
+Signed Time=16 Oct 2026 at 10:00:00
+TeamIdentifier=TEAM123456
+Sealed Resources version=2 rules=10 files=3`,
+			want: signatureInfo{
+				Authority:   "Apple Development: Jane Doe (ABCDE12345)",
+				TeamID:      "TEAM123456",
+				Identifier:  "com.example.test",
+				SigningTime: time.Date(2026, 10, 16, 10, 0, 0, 0, time.Local),
+			},
+		},
+		{
+			name: "verbose with timestamp",
+			output: `Executable=/tmp/Payload/Test.app/Test
+Identifier=com.example.test
+CodeDirectory v=20500 size=1234 flags=0x10000(runtime) hashes=28+7 location=embedded
+CandidateCDHash sha256=0123456789abcdef0123456789abcdef01234567
+CDHash=0123456789abcdef0123456789abcdef01234567
+Authority=Apple Distribution: Example Corp (TEAM123456)
+Timestamp=Oct 16, 2026 at 9:30:15 AM
+TeamIdentifier=TEAM123456`,
+			want: signatureInfo{
+				Authority:   "Apple Distribution: Example Corp (TEAM123456)",
+				TeamID:      "TEAM123456",
+				Identifier:  "com.example.test",
+				CDHash:      "0123456789abcdef0123456789abcdef01234567",
+				SigningTime: time.Date(2026, 10, 16, 9, 30, 15, 0, time.Local),
+			},
+		},
+		{
+			name: "ad-hoc",
+			output: `Executable=/tmp/Payload/Test.app/Test
+Identifier=com.example.test
+CodeDirectory v=20400 size=1234 flags=0x2(adhoc) hashes=28+7 location=embedded
+Signature=adhoc
+TeamIdentifier=not set`,
+			want: signatureInfo{AdHoc: true, Identifier: "com.example.test"},
+		},
+		{
+			name:   "unsigned",
+			output: "/tmp/Payload/Test.app: code object is not signed at all",
+			want:   signatureInfo{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseSignatureInfo(tt.output); got != tt.want {
+				t.Errorf("parseSignatureInfo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSigningTime(t *testing.T) {
+	want := time.Date(2026, 10, 16, 21, 5, 0, 0, time.Local)
+	for _, value := range []string{"16 Oct 2026 at 21:05:00", "Oct 16, 2026 at 9:05:00 PM", "Oct 16, 2026, 9:05:00 PM"} {
+		if got := parseSigningTime(value); !got.Equal(want) {
+			t.Errorf("parseSigningTime(%q) = %v, want %v", value, got, want)
+		}
+	}
+	if got := parseSigningTime("16.10.26, 21:05"); !got.IsZero() {
+		t.Errorf("parseSigningTime() of an unknown format = %v", got)
+	}
+}
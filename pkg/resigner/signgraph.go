@@ -0,0 +1,72 @@
+package resigner
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// signGraph is the nesting graph of an app's signable components. codesign
+// seals everything inside a bundle, so a component can only be signed
+// once every component nested in it has been.
+type signGraph struct {
+	// parent maps each component to the component directly containing
+	// it; the outermost app has none
+	parent map[string]string
+	// pending counts the unsigned components directly inside each one
+	pending map[string]int
+}
+
+// newSignGraph links every component to its nearest enclosing component,
+// e.g. a framework inside an extension inside a watch app inside the app
+func newSignGraph(components []string) *signGraph {
+	g := &signGraph{parent: make(map[string]string), pending: make(map[string]int)}
+	set := make(map[string]bool, len(components))
+	for _, component := range components {
+		set[component] = true
+	}
+
+	for _, component := range components {
+		g.pending[component] += 0
+		for dir := filepath.Dir(component); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+			if set[dir] {
+				g.parent[component] = dir
+				g.pending[dir]++
+				break
+			}
+		}
+	}
+	return g
+}
+
+// waves orders the components for signing: each wave holds the
+// components whose nested components were all signed in earlier waves, so
+// a wave can be signed concurrently. Waves are sorted for stable output.
+func (g *signGraph) waves() [][]string {
+	pending := make(map[string]int, len(g.pending))
+	var ready []string
+	for component, count := range g.pending {
+		pending[component] = count
+		if count == 0 {
+			ready = append(ready, component)
+		}
+	}
+
+	var waves [][]string
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		waves = append(waves, ready)
+
+		var next []string
+		for _, component := range ready {
+			parent, ok := g.parent[component]
+			if !ok {
+				continue
+			}
+			if pending[parent]--; pending[parent] == 0 {
+				next = append(next, parent)
+			}
+		}
+		ready = next
+	}
+	return waves
+}
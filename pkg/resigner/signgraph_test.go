@@ -0,0 +1,41 @@
+package resigner
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSignGraphWaves(t *testing.T) {
+	app := filepath.Join("Payload", "Test.app")
+	join := func(parts ...string) string {
+		return filepath.Join(append([]string{app}, parts...)...)
+	}
+	watch := join("Watch", "Watch.app")
+	watchExt := join("Watch", "Watch.app", "PlugIns", "Ext.appex")
+	watchFramework := join("Watch", "Watch.app", "PlugIns", "Ext.appex", "Frameworks", "W.framework")
+	widget := join("PlugIns", "Widget.appex")
+	framework := join("Frameworks", "A.framework")
+	dylib := join("Frameworks", "libswiftCore.dylib")
+
+	// find lists parents before their children
+	components := []string{app, framework, dylib, widget, watch, watchExt, watchFramework}
+	got := newSignGraph(components).waves()
+	want := [][]string{
+		{framework, dylib, widget, watchFramework},
+		{watchExt},
+		{watch},
+		{app},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("waves() = %v, want %v", got, want)
+	}
+}
+
+func TestSignGraphAppOnly(t *testing.T) {
+	app := filepath.Join("Payload", "Test.app")
+	got := newSignGraph([]string{app}).waves()
+	if want := [][]string{{app}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("waves() = %v, want %v", got, want)
+	}
+}
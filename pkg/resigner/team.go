@@ -0,0 +1,169 @@
+package resigner
+
+import (
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// teamIDPattern matches Apple team identifiers, e.g. "ABCDE12345"
+var teamIDPattern = regexp.MustCompile(`^[A-Z0-9]{10}$`)
+
+// ValidateTeamID checks that id looks like an Apple team identifier
+func ValidateTeamID(id string) error {
+	if !teamIDPattern.MatchString(id) {
+		return fmt.Errorf("invalid team ID %q: must be 10 uppercase letters or digits", id)
+	}
+	return nil
+}
+
+// TeamIDFromCertificate returns the team identifier stored in the
+// organizational unit of an Apple signing certificate
+func TeamIDFromCertificate(der []byte) (string, error) {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	for _, ou := range cert.Subject.OrganizationalUnit {
+		if teamIDPattern.MatchString(ou) {
+			return ou, nil
+		}
+	}
+	return "", fmt.Errorf("certificate %q has no team identifier", cert.Subject.CommonName)
+}
+
+// certificateTeamID looks up the signing certificate in the keychain and
+// returns its team identifier. find-certificate -c matches names by
+// substring, so every match is listed and the one preflightIdentity
+// resolved is picked by its SHA-1 fingerprint.
+func (r *Resigner) certificateTeamID() (string, error) {
+	name := r.certificateName()
+	cmd := r.command("security", "find-certificate", "-a", "-c", name, "-p")
+	cmd.Args = append(cmd.Args, r.keychainArgs()...)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find certificate %q: %w", name, err)
+	}
+	return certificateTeam(output, name, r.signingIdentity.Hash)
+}
+
+// certificateTeam returns the team of the certificate with SHA-1
+// fingerprint hash among the PEM certificates in output. Without a hash,
+// every certificate matching name must belong to the same team.
+func certificateTeam(output []byte, name, hash string) (string, error) {
+	teams := make(map[string]bool)
+	for rest := output; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		if hash != "" {
+			if fmt.Sprintf("%X", sha1.Sum(block.Bytes)) == strings.ToUpper(hash) {
+				return TeamIDFromCertificate(block.Bytes)
+			}
+			continue
+		}
+		if team, err := TeamIDFromCertificate(block.Bytes); err == nil {
+			teams[team] = true
+		}
+	}
+
+	if hash != "" {
+		return "", fmt.Errorf("certificate %q (%s) not found in keychain", name, hash)
+	}
+	found := make([]string, 0, len(teams))
+	for team := range teams {
+		found = append(found, team)
+	}
+	sort.Strings(found)
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("certificate %q not found in keychain", name)
+	case 1:
+		return found[0], nil
+	}
+	return "", fmt.Errorf("certificate %q matches several teams (%s)", name, strings.Join(found, ", "))
+}
+
+// detectTeamID picks the team to sign for: the configured TeamID, else the
+// signing certificate's team, else the embedded profile's team
+func (r *Resigner) detectTeamID(appPath string) (string, string) {
+	if r.config.TeamID != "" {
+		return r.config.TeamID, "configuration"
+	}
+	if team, err := r.certificateTeamID(); err == nil {
+		return team, "certificate"
+	}
+	profile, err := ParseProfile(filepath.Join(appPath, "embedded.mobileprovision"))
+	if err == nil && profile.TeamID() != "" {
+		return profile.TeamID(), "provisioning profile"
+	}
+	return "", ""
+}
+
+// applyTeamID rewrites team-scoped entitlements so they agree with the team
+// the app is signed for
+func (r *Resigner) applyTeamID(appPath, entitlementsPath string) error {
+	team, source := r.detectTeamID(appPath)
+	if team == "" {
+		r.warn(WarningTeamUnknown, appPath, "", "Could not determine team ID; entitlements left unchanged")
+		return nil
+	}
+	r.teamID = team
+	r.logProgress(fmt.Sprintf("Using team ID %s (from %s)", team, source))
+
+	entitlements, format, err := readPlistFile(entitlementsPath)
+	if err != nil {
+		return err
+	}
+	if !rewriteTeamID(entitlements, team) {
+		return nil
+	}
+	return writePlistFile(entitlementsPath, entitlements, format)
+}
+
+// rewriteTeamID replaces the team prefix of the application identifier,
+// the team-identifier entitlement and keychain access groups. It reports
+// whether anything changed.
+func rewriteTeamID(entitlements map[string]interface{}, team string) bool {
+	appIdentifier, _ := entitlements["application-identifier"].(string)
+	oldTeam, _ := entitlements["com.apple.developer.team-identifier"].(string)
+	if oldTeam == "" {
+		if i := strings.Index(appIdentifier, "."); i > 0 {
+			oldTeam = appIdentifier[:i]
+		}
+	}
+	if oldTeam == "" || oldTeam == team {
+		return false
+	}
+
+	replace := func(value string) string {
+		if value == oldTeam {
+			return team
+		}
+		if strings.HasPrefix(value, oldTeam+".") {
+			return team + strings.TrimPrefix(value, oldTeam)
+		}
+		return value
+	}
+
+	if appIdentifier != "" {
+		entitlements["application-identifier"] = replace(appIdentifier)
+	}
+	if _, ok := entitlements["com.apple.developer.team-identifier"]; ok {
+		entitlements["com.apple.developer.team-identifier"] = team
+	}
+	if groups, ok := entitlements["keychain-access-groups"].([]interface{}); ok {
+		for i, group := range groups {
+			if s, ok := group.(string); ok {
+				groups[i] = replace(s)
+			}
+		}
+	}
+	return true
+}
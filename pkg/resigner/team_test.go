@@ -0,0 +1,117 @@
+package resigner
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateTeamID(t *testing.T) {
+	for _, id := range []string{"ABCDE12345", "TEAM123456"} {
+		if err := ValidateTeamID(id); err != nil {
+			t.Errorf("ValidateTeamID(%q) = %v", id, err)
+		}
+	}
+	for _, id := range []string{"", "abcde12345", "ABCDE1234", "ABCDE-1234"} {
+		if err := ValidateTeamID(id); err == nil {
+			t.Errorf("ValidateTeamID(%q) succeeded, want error", id)
+		}
+	}
+}
+
+// testCertificate returns a self-signed certificate in team
+func testCertificate(t *testing.T, name, team string) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			CommonName:         name,
+			OrganizationalUnit: []string{team},
+		},
+		NotBefore: time.Now(),
+		NotAfter:  time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func TestTeamIDFromCertificate(t *testing.T) {
+	der := testCertificate(t, "Apple Development: Test (XYZ)", "ABCDE12345")
+	team, err := TeamIDFromCertificate(der)
+	if err != nil {
+		t.Fatalf("TeamIDFromCertificate() failed: %v", err)
+	}
+	if team != "ABCDE12345" {
+		t.Errorf("TeamIDFromCertificate() = %q", team)
+	}
+}
+
+func TestRewriteTeamID(t *testing.T) {
+	entitlements := map[string]interface{}{
+		"application-identifier":              "OLDTEAM123.com.company.app",
+		"com.apple.developer.team-identifier": "OLDTEAM123",
+		"keychain-access-groups":              []interface{}{"OLDTEAM123.com.company.app", "OLDTEAM123.*", "shared"},
+	}
+
+	if !rewriteTeamID(entitlements, "NEWTEAM456") {
+		t.Fatal("rewriteTeamID() reported no change")
+	}
+
+	want := map[string]interface{}{
+		"application-identifier":              "NEWTEAM456.com.company.app",
+		"com.apple.developer.team-identifier": "NEWTEAM456",
+		"keychain-access-groups":              []interface{}{"NEWTEAM456.com.company.app", "NEWTEAM456.*", "shared"},
+	}
+	if !reflect.DeepEqual(entitlements, want) {
+		t.Errorf("got %v, want %v", entitlements, want)
+	}
+
+	if rewriteTeamID(entitlements, "NEWTEAM456") {
+		t.Error("rewriteTeamID() changed entitlements already using the team")
+	}
+}
+
+func TestCertificateTeam(t *testing.T) {
+	// "Apple Development" matches both teams' certificates by substring
+	mine := testCertificate(t, "Apple Development: Me (AAAAA11111)", "AAAAA11111")
+	other := testCertificate(t, "Apple Development: Other (BBBBB22222)", "BBBBB22222")
+	var output []byte
+	for _, der := range [][]byte{other, mine} {
+		output = append(output, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+
+	hash := strings.ToLower(fmt.Sprintf("%X", sha1.Sum(mine)))
+	if team, err := certificateTeam(output, "Apple Development", hash); err != nil || team != "AAAAA11111" {
+		t.Errorf("certificateTeam() by hash = %q, %v", team, err)
+	}
+	if _, err := certificateTeam(output, "Apple Development", strings.Repeat("0", 40)); err == nil {
+		t.Error("certificateTeam() found a certificate for an unknown hash")
+	}
+	if _, err := certificateTeam(output, "Apple Development", ""); err == nil || !strings.Contains(err.Error(), "several teams") {
+		t.Errorf("certificateTeam() of an ambiguous name = %v", err)
+	}
+	single := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: mine})
+	if team, err := certificateTeam(single, "Apple Development", ""); err != nil || team != "AAAAA11111" {
+		t.Errorf("certificateTeam() of a unique name = %q, %v", team, err)
+	}
+	if _, err := certificateTeam(nil, "Apple Development", ""); err == nil {
+		t.Error("certificateTeam() without certificates succeeded")
+	}
+}
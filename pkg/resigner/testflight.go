@@ -0,0 +1,91 @@
+package resigner
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// betaReportsKey is the entitlement App Store profiles carry so TestFlight
+// builds can be distributed; App Store Connect rejects builds whose
+// entitlements disagree with the profile about it
+const betaReportsKey = "beta-reports-active"
+
+// BetaReports is how the run handles the beta-reports-active entitlement
+type BetaReports string
+
+const (
+	// BetaReportsAuto adds the entitlement when the profile is an App
+	// Store profile granting it and removes it otherwise; it is the
+	// default
+	BetaReportsAuto BetaReports = "auto"
+	// BetaReportsAdd always signs with the entitlement
+	BetaReportsAdd BetaReports = "add"
+	// BetaReportsRemove never signs with the entitlement
+	BetaReportsRemove BetaReports = "remove"
+)
+
+// BetaReportsModes lists the valid modes, default first
+var BetaReportsModes = []BetaReports{BetaReportsAuto, BetaReportsAdd, BetaReportsRemove}
+
+// validate checks that b is empty or a known mode
+func (b BetaReports) validate() error {
+	if b == "" {
+		return nil
+	}
+	for _, known := range BetaReportsModes {
+		if b == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown beta-reports mode %q (use one of: %v)", b, BetaReportsModes)
+}
+
+// wantBetaReports decides whether the app should be signed with
+// beta-reports-active, given the embedded profile, which is nil when
+// there is none
+func wantBetaReports(mode BetaReports, profile *Profile) bool {
+	switch mode {
+	case BetaReportsAdd:
+		return true
+	case BetaReportsRemove:
+		return false
+	}
+	if profile == nil || profile.Kind() != ProfileAppStore {
+		return false
+	}
+	granted, _ := profile.Entitlements[betaReportsKey].(bool)
+	return granted
+}
+
+// applyBetaReports adds or removes beta-reports-active so the
+// entitlements match the profile, the usual TestFlight rejection after
+// re-signing an enterprise build for the App Store and the other way
+// round. Without a profile, only an explicit mode changes anything.
+func (r *Resigner) applyBetaReports(appPath, entitlementsPath string) error {
+	mode := r.config.BetaReports
+	if entitlementsPath == "" || ((mode == "" || mode == BetaReportsAuto) && !r.needsProvisioning()) {
+		return nil
+	}
+	var profile *Profile
+	if r.needsProvisioning() {
+		profile, _ = ParseProfile(filepath.Join(appPath, "embedded.mobileprovision"))
+	}
+	entitlements, format, err := readPlistFile(entitlementsPath)
+	if err != nil {
+		return err
+	}
+
+	enabled, _ := entitlements[betaReportsKey].(bool)
+	_, present := entitlements[betaReportsKey]
+	switch want := wantBetaReports(mode, profile); {
+	case want && !enabled:
+		entitlements[betaReportsKey] = true
+		r.logProgress("Adding beta-reports-active for TestFlight")
+	case !want && present:
+		delete(entitlements, betaReportsKey)
+		r.logProgress("Removing beta-reports-active, which only App Store profiles grant")
+	default:
+		return nil
+	}
+	return writePlistFile(entitlementsPath, entitlements, format)
+}
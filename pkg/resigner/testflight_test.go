@@ -0,0 +1,85 @@
+package resigner
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"howett.net/plist"
+)
+
+func TestWantBetaReports(t *testing.T) {
+	appStore := &Profile{Entitlements: map[string]interface{}{betaReportsKey: true}}
+	tests := []struct {
+		mode    BetaReports
+		profile *Profile
+		want    bool
+	}{
+		{"", appStore, true},
+		{BetaReportsAuto, appStore, true},
+		{BetaReportsAuto, &Profile{}, false},
+		{BetaReportsAuto, &Profile{ProvisionsAllDevices: true, Entitlements: appStore.Entitlements}, false},
+		{BetaReportsAuto, nil, false},
+		{BetaReportsAdd, nil, true},
+		{BetaReportsRemove, appStore, false},
+	}
+	for _, tt := range tests {
+		if got := wantBetaReports(tt.mode, tt.profile); got != tt.want {
+			t.Errorf("wantBetaReports(%q, %+v) = %v, want %v", tt.mode, tt.profile, got, tt.want)
+		}
+	}
+}
+
+func TestApplyBetaReports(t *testing.T) {
+	app := filepath.Join(t.TempDir(), "Test.app")
+	os.MkdirAll(app, 0755)
+	profile := filepath.Join(app, "embedded.mobileprovision")
+	write := func(entitlements map[string]interface{}) string {
+		path := filepath.Join(t.TempDir(), "entitlements.plist")
+		data, _ := plist.Marshal(entitlements, plist.XMLFormat)
+		os.WriteFile(path, data, 0644)
+		return path
+	}
+	config := Config{Certificate: "Apple Distribution: Example Corp (ABCDE12345)"}
+
+	// An enterprise build re-signed with an App Store profile gains the key
+	appStore := bytes.Replace(fakeProfile("ABCDE12345.com.example.app"), []byte("<key>get-task-allow</key>\n\t\t<true/>"),
+		[]byte("<key>beta-reports-active</key>\n\t\t<true/>"), 1)
+	os.WriteFile(profile, appStore, 0644)
+	path := write(map[string]interface{}{"application-identifier": "ABCDE12345.com.example.app"})
+	if err := New(config).applyBetaReports(app, path); err != nil {
+		t.Fatal(err)
+	}
+	if got, _, _ := readPlistFile(path); got[betaReportsKey] != true {
+		t.Errorf("entitlements = %v, want beta-reports-active added", got)
+	}
+
+	// ...and an App Store build re-signed In-House loses it
+	os.WriteFile(profile, inHouseProfile("ABCDE12345.com.example.app"), 0644)
+	if err := New(config).applyBetaReports(app, path); err != nil {
+		t.Fatal(err)
+	}
+	if got, _, _ := readPlistFile(path); got[betaReportsKey] != nil {
+		t.Errorf("entitlements = %v, want beta-reports-active removed", got)
+	}
+
+	config.BetaReports = BetaReportsAdd
+	if err := New(config).applyBetaReports(app, path); err != nil {
+		t.Fatal(err)
+	}
+	if got, _, _ := readPlistFile(path); got[betaReportsKey] != true {
+		t.Errorf("entitlements = %v, want beta-reports-active forced on", got)
+	}
+}
+
+func TestBetaReportsValidate(t *testing.T) {
+	for _, b := range append(BetaReportsModes, "") {
+		if err := b.validate(); err != nil {
+			t.Errorf("validate(%q) = %v", b, err)
+		}
+	}
+	if err := BetaReports("on").validate(); err == nil {
+		t.Error("validate() accepted an unknown mode")
+	}
+}
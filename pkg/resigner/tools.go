@@ -0,0 +1,83 @@
+package resigner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/resignipa/pkg/command"
+)
+
+// ErrToolMissing is wrapped by the error of FindTool for a tool that is
+// not installed
+var ErrToolMissing = errors.New("not installed")
+
+// OptionalTool is an external tool ResignIPA works without. Features
+// needing a missing tool are turned off at the start of a run, with a
+// warning, rather than failing once they are reached.
+type OptionalTool struct {
+	Name string `json:"name"`
+	// Purpose says what the tool does, and what needs it
+	Purpose string `json:"purpose"`
+	// Install says how to get the tool
+	Install string `json:"install"`
+	// Xcode marks tools shipped inside Xcode, found with xcrun when they
+	// are not in PATH
+	Xcode bool `json:"xcode,omitempty"`
+}
+
+// OptionalTools lists the optional tools setup reports on
+var OptionalTools = []OptionalTool{
+	{Name: "ideviceinstaller", Purpose: "lists the apps on a device, for --device conflict checks", Install: "brew install ideviceinstaller"},
+	{Name: "devicectl", Purpose: "installs and manages apps on iOS 17+ devices", Install: "Xcode 15 or later", Xcode: true},
+	{Name: "ios-deploy", Purpose: "installs apps on older iOS devices", Install: "brew install ios-deploy"},
+	{Name: "lipo", Purpose: "inspects and thins universal binaries", Install: "xcode-select --install"},
+	{Name: "actool", Purpose: "compiles asset catalogs", Install: "Xcode", Xcode: true},
+}
+
+// lookPath and xcrunFind locate tools; tests replace them
+var (
+	lookPath  = exec.LookPath
+	xcrunFind = func(name string) (string, error) {
+		result, err := command.New("xcrun", "--find", name).Run(context.Background())
+		return strings.TrimSpace(string(result.Stdout)), err
+	}
+)
+
+// FindTool returns the path of the named tool, looking in PATH and, for
+// Xcode tools, with xcrun. The error of a missing optional tool says how
+// to install it.
+func FindTool(name string) (string, error) {
+	if path, err := lookPath(name); err == nil {
+		return path, nil
+	}
+	var tool *OptionalTool
+	for i := range OptionalTools {
+		if OptionalTools[i].Name == name {
+			tool = &OptionalTools[i]
+		}
+	}
+	if tool == nil {
+		return "", fmt.Errorf("%s %w", name, ErrToolMissing)
+	}
+	if tool.Xcode {
+		if path, err := xcrunFind(name); err == nil && path != "" {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%s %w (install: %s)", name, ErrToolMissing, tool.Install)
+}
+
+// checkOptionalTools turns off the features of this run whose tools are
+// missing, warning about each up front
+func (r *Resigner) checkOptionalTools() {
+	r.missingTools = make(map[string]bool)
+	if r.config.Device != "" {
+		if _, err := FindTool("ideviceinstaller"); err != nil {
+			r.missingTools["ideviceinstaller"] = true
+			r.warn(WarningToolMissing, "", "", "Device %s will not be checked for conflicting apps: %v", r.config.Device, err)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package resigner
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// stubTools makes FindTool see only the tools in path and, through xcrun,
+// in xcode
+func stubTools(t *testing.T, path, xcode map[string]string) {
+	t.Helper()
+	savedLook, savedXcrun := lookPath, xcrunFind
+	t.Cleanup(func() { lookPath, xcrunFind = savedLook, savedXcrun })
+	lookPath = func(name string) (string, error) {
+		if p, ok := path[name]; ok {
+			return p, nil
+		}
+		return "", exec.ErrNotFound
+	}
+	xcrunFind = func(name string) (string, error) {
+		if p, ok := xcode[name]; ok {
+			return p, nil
+		}
+		return "", errors.New("xcrun: error: unable to find utility")
+	}
+}
+
+func TestFindTool(t *testing.T) {
+	stubTools(t, map[string]string{"lipo": "/usr/bin/lipo"},
+		map[string]string{"actool": "/Applications/Xcode.app/Contents/Developer/usr/bin/actool", "ios-deploy": "/xcode/ios-deploy"})
+
+	for name, want := range map[string]string{
+		"lipo":   "/usr/bin/lipo",
+		"actool": "/Applications/Xcode.app/Contents/Developer/usr/bin/actool",
+	} {
+		if got, err := FindTool(name); err != nil || got != want {
+			t.Errorf("FindTool(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	// Only Xcode tools are looked up with xcrun
+	_, err := FindTool("ios-deploy")
+	if !errors.Is(err, ErrToolMissing) || !strings.Contains(err.Error(), "brew install ios-deploy") {
+		t.Errorf("FindTool(ios-deploy) = %v, want ErrToolMissing with install help", err)
+	}
+	if _, err := FindTool("unknown-tool"); !errors.Is(err, ErrToolMissing) {
+		t.Errorf("FindTool(unknown-tool) = %v", err)
+	}
+}
+
+func TestCheckOptionalTools(t *testing.T) {
+	stubTools(t, nil, nil)
+	r := New(Config{Device: "00008030-001A2B3C4D5E6F70"})
+	r.checkOptionalTools()
+	if !r.missingTools["ideviceinstaller"] {
+		t.Error("missing ideviceinstaller not recorded")
+	}
+	warnings := r.Result().Warnings
+	if len(warnings) != 1 || warnings[0].Code != WarningToolMissing {
+		t.Fatalf("warnings = %+v, want one tool-missing warning", warnings)
+	}
+	if err := r.checkDeviceConflict("Test.app"); err != nil {
+		t.Errorf("checkDeviceConflict() without the tool = %v, want it skipped", err)
+	}
+
+	stubTools(t, map[string]string{"ideviceinstaller": "/opt/homebrew/bin/ideviceinstaller"}, nil)
+	r = New(Config{Device: "00008030-001A2B3C4D5E6F70"})
+	r.checkOptionalTools()
+	if len(r.missingTools) != 0 || len(r.Result().Warnings) != 0 {
+		t.Errorf("missing = %v, warnings = %+v with the tool installed", r.missingTools, r.Result().Warnings)
+	}
+}
@@ -0,0 +1,42 @@
+package resigner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/resignipa/pkg/command"
+)
+
+// VerifySignature checks that the app in an IPA or .app directory is
+// validly signed, with the strict codesign checks Config.Verify runs after
+// signing. An IPA is checked for damage first, then extracted to a
+// temporary folder that is removed afterwards.
+func VerifySignature(ctx context.Context, source string) error {
+	appPath := source
+	if strings.EqualFold(filepath.Ext(source), ".ipa") {
+		if err := VerifyArchive(source); err != nil {
+			return err
+		}
+		tmp, err := os.MkdirTemp("", "resignipa-verify-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+
+		if err := ExtractArchive(source, tmp); err != nil {
+			return err
+		}
+		if appPath, err = payloadApp(filepath.Join(tmp, "Payload")); err != nil {
+			return fmt.Errorf("%s: %w", source, err)
+		}
+	}
+
+	result, err := command.New("/usr/bin/codesign", "--verify", "--deep", "--strict", appPath).Run(ctx)
+	if err != nil {
+		return fmt.Errorf("signature verification failed: %s - %w", strings.TrimSpace(string(result.Combined)), err)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package resigner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifySignatureDamagedIPA(t *testing.T) {
+	ipa := filepath.Join(t.TempDir(), "Test.ipa")
+	os.WriteFile(ipa, []byte("not a zip"), 0644)
+
+	var corrupt *CorruptArchiveError
+	if err := VerifySignature(context.Background(), ipa); !errors.As(err, &corrupt) {
+		t.Errorf("VerifySignature() = %v, want CorruptArchiveError", err)
+	}
+}
+
+func TestVerifySignatureNoApp(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "Payload"), 0755)
+	os.WriteFile(filepath.Join(root, "Payload", "readme.txt"), []byte("text"), 0644)
+	ipa := filepath.Join(t.TempDir(), "Test.ipa")
+	if err := CreateArchive(root, ipa); err != nil {
+		t.Fatal(err)
+	}
+
+	err := VerifySignature(context.Background(), ipa)
+	if err == nil || !strings.Contains(err.Error(), "no app found") {
+		t.Errorf("VerifySignature() = %v, want no app found", err)
+	}
+}
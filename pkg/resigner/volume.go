@@ -0,0 +1,41 @@
+package resigner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// localWorkDir returns a local, writable directory to work in when the
+// source's own directory cannot be used: the home directory, or the
+// system temporary directory without one
+func localWorkDir() string {
+	if home, err := os.UserHomeDir(); err == nil {
+		if info, err := os.Stat(home); err == nil && info.IsDir() {
+			return home
+		}
+	}
+	return os.TempDir()
+}
+
+// relocateWorkspace moves the workspace, and the default output, off a
+// source directory that is read-only or on a network share. The workspace
+// goes to a fresh temporary directory and the output to Resigned/ in the
+// home directory.
+func (r *Resigner) relocateWorkspace(reason string) error {
+	tmpDir, err := os.MkdirTemp("", "resignipa-")
+	if err != nil {
+		return fmt.Errorf("%s, and no local workspace could be created: %w", reason, err)
+	}
+	if err := r.openWorkspace(tmpDir); err != nil {
+		os.RemoveAll(tmpDir)
+		return err
+	}
+
+	if r.config.OutputDir == "" {
+		r.localOutput = filepath.Join(localWorkDir(), "Resigned")
+	}
+	r.warn(WarningWorkDir, "", "", "%s; working in %s and writing output to %s",
+		reason, tmpDir, r.outputDir())
+	return nil
+}
@@ -0,0 +1,34 @@
+//go:build darwin
+
+package resigner
+
+import (
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// networkFileSystems are the statfs type names of network volumes
+var networkFileSystems = map[string]bool{
+	"smbfs":  true,
+	"afpfs":  true,
+	"nfs":    true,
+	"webdav": true,
+	"cifs":   true,
+}
+
+// isNetworkVolume reports whether path is on a network share
+func isNetworkVolume(path string) bool {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return false
+	}
+	name := make([]byte, 0, len(st.Fstypename))
+	for _, c := range st.Fstypename {
+		if c == 0 {
+			break
+		}
+		name = append(name, byte(c))
+	}
+	return networkFileSystems[strings.ToLower(string(name))]
+}
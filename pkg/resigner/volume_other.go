@@ -0,0 +1,8 @@
+//go:build !darwin
+
+package resigner
+
+// isNetworkVolume cannot tell network shares apart off macOS
+func isNetworkVolume(path string) bool {
+	return false
+}
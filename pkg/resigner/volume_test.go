@@ -0,0 +1,56 @@
+package resigner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetupDirectoriesRelocates(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// A source directory that is not there cannot hold the workspace
+	sourceDir := filepath.Join(t.TempDir(), "missing")
+	source := filepath.Join(sourceDir, "Test.ipa")
+
+	var log strings.Builder
+	r := New(Config{SourceIPA: source}, WithEventHandler(func(e Event) {
+		log.WriteString(e.Message + "\n")
+	}))
+	if err := r.setupDirectories(); err != nil {
+		t.Fatalf("setupDirectories() failed: %v", err)
+	}
+	defer r.closeWorkspace()
+
+	if strings.HasPrefix(r.workspace.Root, sourceDir) {
+		t.Errorf("workspace %s was not moved off the source directory", r.workspace.Root)
+	}
+	if info, err := os.Stat(r.workspace.Extracted); err != nil || !info.IsDir() {
+		t.Errorf("extracted directory missing: %v", err)
+	}
+	if got, want := r.outputDir(), filepath.Join(home, "Resigned"); got != want {
+		t.Errorf("outputDir() = %s, want %s", got, want)
+	}
+	if !strings.Contains(log.String(), "cannot write next to the source") {
+		t.Errorf("relocation was not reported:\n%s", log.String())
+	}
+
+	// An explicit output directory is kept
+	outDir := t.TempDir()
+	r = New(Config{SourceIPA: source, OutputDir: outDir})
+	if err := r.setupDirectories(); err != nil {
+		t.Fatalf("setupDirectories() failed: %v", err)
+	}
+	defer r.closeWorkspace()
+	if got := r.outputDir(); got != outDir {
+		t.Errorf("outputDir() = %s, want %s", got, outDir)
+	}
+
+	// In-place signing cannot move, so it fails
+	r = New(Config{SourceIPA: source, InPlace: true})
+	if err := r.setupDirectories(); err == nil {
+		t.Error("setupDirectories() succeeded in place on an unwritable directory")
+	}
+}
@@ -0,0 +1,128 @@
+package resigner
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// WarningCode classifies a warning so automation can act on it without
+// matching message text. Codes are a stable contract; messages are not.
+type WarningCode string
+
+const (
+	// WarningGeneral is any warning without a more specific code
+	WarningGeneral WarningCode = "general"
+	// WarningPlistEdit means an Info.plist or entitlements edit failed, so a
+	// nested bundle may keep its old identifier or references
+	WarningPlistEdit WarningCode = "plist-edit"
+	// WarningComponentSkipped means components were left with their existing
+	// signature
+	WarningComponentSkipped WarningCode = "component-skipped"
+	// WarningEntitlementLost means the new signature drops an entitlement the
+	// app had
+	WarningEntitlementLost WarningCode = "entitlement-lost"
+	// WarningEntitlementConflict means the app is signed with an
+	// entitlement the provisioning profile does not allow
+	WarningEntitlementConflict WarningCode = "entitlement-conflict"
+	// WarningTeamUnknown means no team ID was found, so team-scoped
+	// entitlements were left unchanged
+	WarningTeamUnknown WarningCode = "team-unknown"
+	// WarningSignatureUnreadable means a signature could not be read back, so
+	// the component is missing from Result.Components
+	WarningSignatureUnreadable WarningCode = "signature-unreadable"
+	// WarningFrameworkIssue means a framework is likely to fail validation
+	WarningFrameworkIssue WarningCode = "framework-issue"
+	// WarningDSYM means the dSYMs are missing or do not match the binaries
+	WarningDSYM WarningCode = "dsym"
+	// WarningPackageLayout means the IPA lacks a folder App Store Connect needs
+	WarningPackageLayout WarningCode = "package-layout"
+	// WarningProfileIgnored means the provisioning profile was not embedded
+	WarningProfileIgnored WarningCode = "profile-ignored"
+	// WarningAutoConfirmed means a destructive step went ahead without asking
+	WarningAutoConfirmed WarningCode = "auto-confirmed"
+	// WarningCacheDamaged means a cached workspace failed verification
+	WarningCacheDamaged WarningCode = "cache-damaged"
+	// WarningDeviceConflict means an app with the same bundle ID from
+	// another team is installed on Config.Device
+	WarningDeviceConflict WarningCode = "device-conflict"
+	// WarningDeviceUnchecked means Config.Device could not be checked for
+	// conflicting apps
+	WarningDeviceUnchecked WarningCode = "device-unchecked"
+	// WarningEnterpriseExpiry means an in-house app stops launching when
+	// its profile expires; Result.ResignBy has the date to act by
+	WarningEnterpriseExpiry WarningCode = "enterprise-expiry"
+	// WarningAppWrapping means the app is wrapped by an MDM vendor whose
+	// wrapper may reject the new signature
+	WarningAppWrapping WarningCode = "app-wrapping"
+	// WarningEncryptedBinary means the app's executable is FairPlay
+	// encrypted and cannot launch once re-signed
+	WarningEncryptedBinary WarningCode = "encrypted-binary"
+	// WarningDevelopmentEntitlement means a development-only entitlement
+	// such as get-task-allow was removed for a distribution signature
+	WarningDevelopmentEntitlement WarningCode = "development-entitlement"
+	// WarningWorkDir means the run works outside the default directories
+	WarningWorkDir WarningCode = "work-dir"
+	// WarningToolMissing means an optional tool is not installed, so the
+	// feature needing it was turned off
+	WarningToolMissing WarningCode = "tool-missing"
+	// WarningOutputSize means the output IPA is over a warning budget or
+	// too large to download over cellular
+	WarningOutputSize WarningCode = "output-size"
+	// WarningProfileInstall means Config.InstallProfile could not install
+	// the provisioning profile on this Mac
+	WarningProfileInstall WarningCode = "profile-install"
+)
+
+// Warning is a non-fatal problem found during a run. Every warning is
+// both emitted as an EventWarning and collected in Result.Warnings.
+type Warning struct {
+	Code WarningCode
+	// Component is the component the warning concerns, relative to the
+	// folder holding the app as in SignedComponent.Path; empty when it
+	// concerns the run as a whole
+	Component string
+	// Message describes the problem, without the "Warning: " prefix
+	Message string
+}
+
+// String returns the warning as shown in progress output
+func (w Warning) String() string {
+	return "Warning: " + w.Message
+}
+
+// Warn emits a warning, for use by custom stages and component handlers
+func (r *Resigner) Warn(warning Warning) {
+	if warning.Code == "" {
+		warning.Code = WarningGeneral
+	}
+	r.emitEvent(Event{Type: EventWarning, Message: warning.String(), Warning: &warning})
+}
+
+// warn emits a formatted warning; component is a path under appPath's
+// folder, or "" for the run as a whole
+func (r *Resigner) warn(code WarningCode, appPath, component, format string, args ...interface{}) {
+	r.Warn(Warning{Code: code, Component: componentName(appPath, component), Message: fmt.Sprintf(format, args...)})
+}
+
+// componentName returns component relative to the folder holding appPath,
+// the form Result reports components in
+func componentName(appPath, component string) string {
+	if component == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(filepath.Dir(appPath), component)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filepath.Base(component)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// eventWarning returns the warning an event carries, deriving a general
+// one from the message of warning events emitted without details
+func eventWarning(event Event) Warning {
+	if event.Warning != nil {
+		return *event.Warning
+	}
+	return Warning{Code: WarningGeneral, Message: strings.TrimPrefix(event.Message, "Warning: ")}
+}
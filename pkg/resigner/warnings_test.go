@@ -0,0 +1,67 @@
+package resigner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"howett.net/plist"
+)
+
+func TestWarningEvents(t *testing.T) {
+	var events []Event
+	r := New(Config{}, WithEventHandler(func(e Event) { events = append(events, e) }), WithSecrets("hunter2"))
+
+	r.Warn(Warning{Code: WarningDSYM, Component: "Test.app", Message: "password hunter2 leaked"})
+	r.logWarning("Warning: untyped")
+
+	want := []Warning{
+		{Code: WarningDSYM, Component: "Test.app", Message: "password [REDACTED] leaked"},
+		{Code: WarningGeneral, Message: "untyped"},
+	}
+	if got := r.Result().Warnings; !reflect.DeepEqual(got, want) {
+		t.Errorf("Result().Warnings = %+v, want %+v", got, want)
+	}
+	if len(events) != 2 || events[0].Message != "Warning: password [REDACTED] leaked" {
+		t.Fatalf("events = %+v", events)
+	}
+	for i, event := range events {
+		if event.Warning == nil || *event.Warning != want[i] {
+			t.Errorf("event %d carries warning %+v, want %+v", i, event.Warning, want[i])
+		}
+	}
+}
+
+func TestComponentName(t *testing.T) {
+	app := filepath.Join("tmp", "Payload", "Host.app")
+	for component, want := range map[string]string{
+		"":  "",
+		app: "Host.app",
+		filepath.Join(app, "PlugIns", "Ext.appex"): "Host.app/PlugIns/Ext.appex",
+		filepath.Join("elsewhere", "Lib.dylib"):    "Lib.dylib",
+	} {
+		if got := componentName(app, component); got != want {
+			t.Errorf("componentName(%q) = %q, want %q", component, got, want)
+		}
+	}
+}
+
+func TestRenameNestedBundlesWarnsOnPlistEdit(t *testing.T) {
+	app := filepath.Join(t.TempDir(), "Host.app")
+	ext := filepath.Join(app, "PlugIns", "Ext.appex")
+	os.MkdirAll(ext, 0755)
+	writePlistFile(filepath.Join(app, "Info.plist"), map[string]interface{}{"CFBundleIdentifier": "com.old.app"}, plist.XMLFormat)
+	os.WriteFile(filepath.Join(ext, "Info.plist"), []byte("not a plist"), 0644)
+
+	r := New(Config{})
+	if err := r.changeBundleID(app, "com.new.app"); err != nil {
+		t.Fatal(err)
+	}
+	r.renameNestedBundles(app, []string{ext, app}, "")
+
+	warnings := r.Result().Warnings
+	if len(warnings) == 0 || warnings[0].Code != WarningPlistEdit || warnings[0].Component != "Host.app/PlugIns/Ext.appex" {
+		t.Errorf("Result().Warnings = %+v, want a plist-edit warning for the extension", warnings)
+	}
+}
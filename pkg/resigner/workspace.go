@@ -0,0 +1,116 @@
+package resigner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Workspace directory names. They are a stable contract: custom stages and
+// component handlers can rely on finding each artifact under the same name
+// in every release.
+const (
+	// WorkspaceExtracted holds the unpacked IPA: Payload/ and any support
+	// folders packaged next to it
+	WorkspaceExtracted = "Extracted"
+	// WorkspaceProfiles holds the decoded provisioning profile plist,
+	// provisioning.plist
+	WorkspaceProfiles = "Profiles"
+	// WorkspaceEntitlements holds the entitlements the app is signed with,
+	// entitlements.plist, and those it was signed with before, current.plist
+	WorkspaceEntitlements = "Entitlements"
+	// WorkspaceOutput holds the packaged IPA before it is moved to the
+	// output directory
+	WorkspaceOutput = "Output"
+	// WorkspaceLogs holds events.log, every event of the run in order
+	WorkspaceLogs = "Logs"
+)
+
+// workspacePrefix starts the name of the temporary directory a run creates
+// next to its source
+const workspacePrefix = "tmp-"
+
+// Workspace is the layout of a run's temporary directory. Anything under
+// Root other than the directories listed here is private to the run.
+type Workspace struct {
+	// Root is the temporary directory, removed when the run ends
+	Root         string
+	Extracted    string
+	Profiles     string
+	Entitlements string
+	Output       string
+	Logs         string
+}
+
+// newWorkspace describes the workspace rooted at root
+func newWorkspace(root string) Workspace {
+	return Workspace{
+		Root:         root,
+		Extracted:    filepath.Join(root, WorkspaceExtracted),
+		Profiles:     filepath.Join(root, WorkspaceProfiles),
+		Entitlements: filepath.Join(root, WorkspaceEntitlements),
+		Output:       filepath.Join(root, WorkspaceOutput),
+		Logs:         filepath.Join(root, WorkspaceLogs),
+	}
+}
+
+// create makes the workspace directories
+func (w Workspace) create() error {
+	for _, dir := range []string{w.Extracted, w.Profiles, w.Entitlements, w.Output, w.Logs} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Workspace returns the layout of the current run's temporary directory,
+// for use by custom stages and component handlers. Root is empty before
+// the run has set it up.
+func (r *Resigner) Workspace() Workspace {
+	return r.workspace
+}
+
+// openWorkspace creates the workspace at root and starts its event log
+func (r *Resigner) openWorkspace(root string) error {
+	workspace := newWorkspace(root)
+	if err := workspace.create(); err != nil {
+		return err
+	}
+	eventLog, err := os.Create(filepath.Join(workspace.Logs, "events.log"))
+	if err != nil {
+		return err
+	}
+	r.emitMu.Lock()
+	r.eventLog = eventLog
+	r.emitMu.Unlock()
+	r.workspace = workspace
+	return nil
+}
+
+// closeWorkspace stops the event log and removes the workspace, unless
+// Config.KeepWorkspace asks for it to stay
+func (r *Resigner) closeWorkspace() {
+	// Secrets do not outlive the run, even in a kept workspace
+	r.deleteSigningKeychain()
+	if r.providedProfile != "" {
+		shredFile(r.providedProfile)
+		r.providedProfile = ""
+	}
+	keep := r.config.KeepWorkspace && r.workspace.Root != ""
+	if keep {
+		r.logProgress(fmt.Sprintf("Kept workspace %s", r.workspace.Root))
+	}
+	r.emitMu.Lock()
+	if keep {
+		r.result.Workspace = r.workspace.Root
+	}
+	if r.eventLog != nil {
+		r.eventLog.Close()
+		r.eventLog = nil
+	}
+	r.emitMu.Unlock()
+	if r.workspace.Root != "" && !keep {
+		os.RemoveAll(r.workspace.Root)
+	}
+}
@@ -0,0 +1,86 @@
+package resigner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWorkspace(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "tmp")
+	r := New(Config{SourceIPA: "Test.ipa"})
+	if r.Workspace().Root != "" {
+		t.Errorf("Workspace() before a run = %+v", r.Workspace())
+	}
+	if err := r.openWorkspace(root); err != nil {
+		t.Fatalf("openWorkspace() failed: %v", err)
+	}
+
+	w := r.Workspace()
+	for name, dir := range map[string]string{
+		WorkspaceExtracted:    w.Extracted,
+		WorkspaceProfiles:     w.Profiles,
+		WorkspaceEntitlements: w.Entitlements,
+		WorkspaceOutput:       w.Output,
+		WorkspaceLogs:         w.Logs,
+	} {
+		if dir != filepath.Join(root, name) {
+			t.Errorf("%s directory = %s", name, dir)
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			t.Errorf("%s directory missing: %v", name, err)
+		}
+	}
+
+	r.logWarning("Warning: something odd")
+	data, err := os.ReadFile(filepath.Join(w.Logs, "events.log"))
+	if err != nil || !strings.Contains(string(data), "warning Warning: something odd") {
+		t.Errorf("events.log = %q, %v", data, err)
+	}
+
+	r.closeWorkspace()
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Errorf("workspace not removed: %v", err)
+	}
+	// Events after the run are not logged anywhere
+	r.logProgress("done")
+}
+
+func TestKeepWorkspace(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "tmp")
+	r := New(Config{SourceIPA: "Test.ipa", KeepWorkspace: true})
+	if err := r.openWorkspace(root); err != nil {
+		t.Fatalf("openWorkspace() failed: %v", err)
+	}
+	r.closeWorkspace()
+
+	if _, err := os.Stat(root); err != nil {
+		t.Errorf("kept workspace removed: %v", err)
+	}
+	if got := r.Result().Workspace; got != root {
+		t.Errorf("Result.Workspace = %q, want %q", got, root)
+	}
+	data, _ := os.ReadFile(filepath.Join(root, WorkspaceLogs, "events.log"))
+	if !strings.Contains(string(data), "Kept workspace "+root) {
+		t.Errorf("events.log = %q", data)
+	}
+}
+
+func TestSetupDirectoriesPerRun(t *testing.T) {
+	dir := t.TempDir()
+	first := New(Config{SourceIPA: filepath.Join(dir, "First.ipa")})
+	second := New(Config{SourceIPA: filepath.Join(dir, "Second.ipa")})
+	for _, r := range []*Resigner{first, second} {
+		if err := r.setupDirectories(); err != nil {
+			t.Fatalf("setupDirectories() failed: %v", err)
+		}
+		defer r.closeWorkspace()
+	}
+
+	// Apps from one folder are resigned side by side without sharing
+	a, b := first.Workspace().Root, second.Workspace().Root
+	if a == b || filepath.Dir(a) != dir || filepath.Dir(b) != dir {
+		t.Errorf("workspaces = %s and %s, want separate ones in %s", a, b, dir)
+	}
+}
@@ -0,0 +1,221 @@
+package resigner
+
+import (
+	"debug/macho"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Mach-O load commands recording FairPlay encryption
+const (
+	loadCmdEncryptionInfo   macho.LoadCmd = 0x21
+	loadCmdEncryptionInfo64 macho.LoadCmd = 0x2c
+)
+
+// AppWrapper is an MDM app-wrapping layout found in an app
+type AppWrapper struct {
+	// Vendor names the wrapping product, such as "Microsoft Intune"
+	Vendor string
+	// Markers are the frameworks, dylibs and Info.plist keys that gave
+	// the wrapping away
+	Markers []string
+	// Advice says what to do when the re-signed app misbehaves
+	Advice string
+}
+
+// wrapperSignature describes how one vendor's wrapping shows up in a
+// bundle. Names and keys match case-insensitively anywhere in the name.
+type wrapperSignature struct {
+	vendor string
+	// names are parts of the frameworks and dylibs the wrapper injects
+	names []string
+	// keys are parts of the Info.plist keys the wrapper adds
+	keys   []string
+	advice string
+}
+
+// wrapperSignatures lists the wrapping products seen in enterprise IPAs
+var wrapperSignatures = []wrapperSignature{
+	{
+		vendor: "Appdome",
+		names:  []string{"appdome"},
+		keys:   []string{"Appdome"},
+		advice: "Appdome-fused apps check their own signature at launch; if the app quits immediately, re-sign it through Appdome's signing service instead",
+	},
+	{
+		vendor: "MobileIron AppConnect",
+		names:  []string{"AppConnect"},
+		keys:   []string{"AppConnect", "MI_AC_"},
+		advice: "AppConnect apps register with Mobile@Work through their URL schemes and keychain group; keep the bundle ID, or re-wrap the app if it no longer checks in",
+	},
+	{
+		vendor: "Microsoft Intune",
+		names:  []string{"IntuneMAM"},
+		keys:   []string{"IntuneMAMSettings"},
+		advice: "the Intune App Wrapping Tool ties the wrapper to the signing identity; re-wrap with the new certificate and profile if policies stop applying",
+	},
+	{
+		vendor: "BlackBerry Dynamics",
+		names:  []string{"BlackBerryDynamics", "GD.framework"},
+		keys:   []string{"GDApplicationID", "GDApplicationVersion"},
+		advice: "BlackBerry Dynamics activates by bundle ID; register the new ID in UEM before deploying",
+	},
+}
+
+// detectAppWrapper looks for the frameworks, dylibs and Info.plist keys
+// MDM wrapping products add, returning nil when the app is not wrapped
+func detectAppWrapper(appPath string) *AppWrapper {
+	var names []string
+	for _, dir := range []string{appPath, filepath.Join(appPath, "Frameworks")} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			switch filepath.Ext(entry.Name()) {
+			case ".framework", ".dylib":
+				names = append(names, entry.Name())
+			}
+		}
+	}
+	var keys []string
+	if info, _, err := readPlistFile(filepath.Join(appPath, "Info.plist")); err == nil {
+		for key := range info {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(names)
+	sort.Strings(keys)
+
+	for _, signature := range wrapperSignatures {
+		var markers []string
+		for _, name := range names {
+			if containsAnyFold(name, signature.names) {
+				markers = append(markers, name)
+			}
+		}
+		for _, key := range keys {
+			if containsAnyFold(key, signature.keys) {
+				markers = append(markers, "Info.plist "+key)
+			}
+		}
+		if len(markers) > 0 {
+			return &AppWrapper{Vendor: signature.vendor, Markers: markers, Advice: signature.advice}
+		}
+	}
+	return nil
+}
+
+// containsAnyFold reports whether s contains one of parts, ignoring case
+func containsAnyFold(s string, parts []string) bool {
+	for _, part := range parts {
+		if strings.Contains(strings.ToLower(s), strings.ToLower(part)) {
+			return true
+		}
+	}
+	return false
+}
+
+// looseCode lists the Mach-O files in appPath that no component handler
+// signs and that are not the executable of the bundle holding them.
+// Wrappers drop payloads like these next to the app's executable;
+// codesign seals them as resources with their old signature, so the
+// wrapper fails to load them at launch.
+func looseCode(appPath string, components *ComponentRegistry) ([]string, error) {
+	var loose []string
+	err := filepath.Walk(appPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if isCompiledResource(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		if _, ok := components.handler(path); ok {
+			return nil
+		}
+		if path == bundleExecutable(filepath.Dir(path)) || !isMachOFile(path) {
+			return nil
+		}
+		loose = append(loose, path)
+		return nil
+	})
+	return loose, err
+}
+
+// IsEncryptedBinary reports whether any slice of the Mach-O binary at path
+// is FairPlay encrypted, as binaries downloaded from the App Store are
+func IsEncryptedBinary(path string) (bool, error) {
+	var files []*macho.File
+	if fat, err := macho.OpenFat(path); err == nil {
+		defer fat.Close()
+		for _, arch := range fat.Arches {
+			files = append(files, arch.File)
+		}
+	} else if !errors.Is(err, macho.ErrNotFat) {
+		return false, fmt.Errorf("failed to read Mach-O %s: %w", path, err)
+	} else {
+		f, err := macho.Open(path)
+		if err != nil {
+			return false, fmt.Errorf("failed to read Mach-O %s: %w", path, err)
+		}
+		defer f.Close()
+		files = append(files, f)
+	}
+
+	for _, f := range files {
+		for _, load := range f.Loads {
+			raw := load.Raw()
+			if len(raw) < 20 {
+				continue
+			}
+			switch macho.LoadCmd(f.ByteOrder.Uint32(raw)) {
+			case loadCmdEncryptionInfo, loadCmdEncryptionInfo64:
+				// cryptid follows cmd, cmdsize, cryptoff and cryptsize
+				if f.ByteOrder.Uint32(raw[16:]) != 0 {
+					return true, nil
+				}
+			}
+		}
+	}
+	return false, nil
+}
+
+// checkAppWrapping diagnoses wrapped and encrypted apps, which otherwise
+// re-sign cleanly and then fail at launch. Code a wrapper leaves outside
+// the usual component folders is queued for signing with the rest.
+func (r *Resigner) checkAppWrapping(appPath string) error {
+	r.looseCode = nil
+	if executable := bundleExecutable(appPath); executable != "" {
+		if encrypted, err := IsEncryptedBinary(executable); err == nil && encrypted {
+			r.warn(WarningEncryptedBinary, appPath, executable,
+				"%s is FairPlay encrypted and will not launch once re-signed; use a decrypted build from the vendor", filepath.Base(executable))
+		}
+	}
+
+	wrapper := detectAppWrapper(appPath)
+	if wrapper == nil {
+		return nil
+	}
+	r.logProgress(fmt.Sprintf("%s app wrapping detected (%s)", wrapper.Vendor, strings.Join(wrapper.Markers, ", ")))
+	r.warn(WarningAppWrapping, appPath, appPath, "App is wrapped by %s: %s", wrapper.Vendor, wrapper.Advice)
+
+	loose, err := looseCode(appPath, r.components)
+	if err != nil {
+		return fmt.Errorf("failed to scan wrapped app: %w", err)
+	}
+	if len(loose) > 0 {
+		r.logProgress(fmt.Sprintf("Signing %d wrapper binaries outside the component folders", len(loose)))
+	}
+	r.looseCode = loose
+	return nil
+}
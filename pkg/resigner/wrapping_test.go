@@ -0,0 +1,127 @@
+package resigner
+
+import (
+	"debug/macho"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeInfoPlist writes an Info.plist holding the given extra keys
+func writeInfoPlist(t *testing.T, dir string, keys ...string) {
+	t.Helper()
+	info := `<?xml version="1.0" encoding="UTF-8"?><plist version="1.0"><dict><key>CFBundleExecutable</key><string>App</string>`
+	for _, key := range keys {
+		info += "<key>" + key + "</key><string>x</string>"
+	}
+	info += "</dict></plist>"
+	if err := os.WriteFile(filepath.Join(dir, "Info.plist"), []byte(info), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDetectAppWrapper(t *testing.T) {
+	tests := []struct {
+		name       string
+		frameworks []string
+		keys       []string
+		vendor     string
+		markers    []string
+	}{
+		{"plain app", []string{"Alamofire.framework"}, nil, "", nil},
+		{"intune", []string{"IntuneMAMSwift.framework"}, []string{"IntuneMAMSettings"}, "Microsoft Intune",
+			[]string{"IntuneMAMSwift.framework", "Info.plist IntuneMAMSettings"}},
+		{"appdome dylib", []string{"libAppdomeCore.dylib"}, nil, "Appdome", []string{"libAppdomeCore.dylib"}},
+		{"blackberry key only", nil, []string{"GDApplicationID"}, "BlackBerry Dynamics", []string{"Info.plist GDApplicationID"}},
+		{"appconnect", []string{"AppConnect.framework"}, []string{"AppConnectConfig"}, "MobileIron AppConnect",
+			[]string{"AppConnect.framework", "Info.plist AppConnectConfig"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := filepath.Join(t.TempDir(), "App.app")
+			os.MkdirAll(filepath.Join(app, "Frameworks"), 0755)
+			writeInfoPlist(t, app, tt.keys...)
+			for _, name := range tt.frameworks {
+				os.MkdirAll(filepath.Join(app, "Frameworks", name), 0755)
+			}
+
+			wrapper := detectAppWrapper(app)
+			if tt.vendor == "" {
+				if wrapper != nil {
+					t.Fatalf("detectAppWrapper() = %+v, want nil", wrapper)
+				}
+				return
+			}
+			if wrapper == nil {
+				t.Fatal("detectAppWrapper() = nil")
+			}
+			if wrapper.Vendor != tt.vendor || !reflect.DeepEqual(wrapper.Markers, tt.markers) {
+				t.Errorf("detectAppWrapper() = %s %v, want %s %v", wrapper.Vendor, wrapper.Markers, tt.vendor, tt.markers)
+			}
+		})
+	}
+}
+
+func TestLooseCode(t *testing.T) {
+	app := filepath.Join(t.TempDir(), "App.app")
+	binary := buildLinkedMachO(15, 0)
+	writeBundle(t, app, binary)
+	writeBundle(t, filepath.Join(app, "Frameworks", "Kit.framework"), binary)
+	os.WriteFile(filepath.Join(app, "Frameworks", "libKit.dylib"), binary, 0755)
+	os.WriteFile(filepath.Join(app, "wrapper_payload"), binary, 0755)
+	os.WriteFile(filepath.Join(app, "readme.txt"), []byte("text"), 0644)
+
+	loose, err := looseCode(app, DefaultComponents())
+	if err != nil {
+		t.Fatalf("looseCode() failed: %v", err)
+	}
+	want := []string{filepath.Join(app, "wrapper_payload")}
+	if !reflect.DeepEqual(loose, want) {
+		t.Errorf("looseCode() = %v, want %v", loose, want)
+	}
+}
+
+func TestIsEncryptedBinary(t *testing.T) {
+	for _, cryptid := range []uint32{0, 1} {
+		le := binary.LittleEndian
+		var data []byte
+		for _, v := range []uint32{macho.Magic64, uint32(macho.CpuArm64), 0, uint32(macho.TypeExec), 1, 24, 0, 0} {
+			data = le.AppendUint32(data, v)
+		}
+		for _, v := range []uint32{uint32(loadCmdEncryptionInfo64), 24, 0x4000, 0x1000, cryptid, 0} {
+			data = le.AppendUint32(data, v)
+		}
+		path := filepath.Join(t.TempDir(), "binary")
+		os.WriteFile(path, data, 0755)
+
+		got, err := IsEncryptedBinary(path)
+		if err != nil {
+			t.Fatalf("IsEncryptedBinary() failed: %v", err)
+		}
+		if got != (cryptid != 0) {
+			t.Errorf("IsEncryptedBinary() with cryptid %d = %v", cryptid, got)
+		}
+	}
+}
+
+func TestCheckAppWrapping(t *testing.T) {
+	app := filepath.Join(t.TempDir(), "App.app")
+	writeBundle(t, app, buildLinkedMachO(15, 0))
+	writeInfoPlist(t, app, "IntuneMAMSettings")
+	os.WriteFile(filepath.Join(app, "payload"), buildLinkedMachO(15, 0), 0755)
+
+	r := New(Config{})
+	if err := r.checkAppWrapping(app); err != nil {
+		t.Fatalf("checkAppWrapping() failed: %v", err)
+	}
+	if want := []string{filepath.Join(app, "payload")}; !reflect.DeepEqual(r.looseCode, want) {
+		t.Errorf("looseCode = %v, want %v", r.looseCode, want)
+	}
+	warnings := r.Result().Warnings
+	if len(warnings) != 1 || warnings[0].Code != WarningAppWrapping {
+		t.Errorf("Warnings = %+v, want one %s", warnings, WarningAppWrapping)
+	}
+}
@@ -0,0 +1,88 @@
+package resigner
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+)
+
+// detritusXattrs are the extended attributes codesign rejects with
+// "resource fork, Finder information, or similar detritus not allowed",
+// plus the quarantine flag added to downloads. Other attributes are left
+// alone.
+var detritusXattrs = []string{
+	"com.apple.ResourceFork",
+	"com.apple.FinderInfo",
+	quarantineXattr,
+}
+
+// StripDetritus removes the extended attributes that make codesign fail
+// from every file under root, returning "path: attribute" for each one
+// removed
+func StripDetritus(root string) ([]string, error) {
+	return stripXattrs(root, detritusXattrs)
+}
+
+// stripXattrs removes the named extended attributes from every file under
+// root, symlinks included
+func stripXattrs(root string, names []string) ([]string, error) {
+	var cleaned []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		attrs, err := listXattrs(path)
+		if err != nil {
+			return fmt.Errorf("failed to list extended attributes of %s: %w", path, err)
+		}
+		for _, attr := range attrs {
+			if !slices.Contains(names, attr) {
+				continue
+			}
+			// The attribute lives on the inode, shared with the source
+			// when the app was hard-linked into the workspace
+			if err := breakHardLink(path); err != nil {
+				return err
+			}
+			if err := removeXattr(path, attr); err != nil {
+				return fmt.Errorf("failed to remove %s from %s: %w", attr, path, err)
+			}
+			rel, _ := filepath.Rel(root, path)
+			cleaned = append(cleaned, rel+": "+attr)
+		}
+		return nil
+	})
+	return cleaned, err
+}
+
+// quarantineXattr marks files downloaded or written by quarantined apps;
+// Gatekeeper prompts before opening anything carrying it
+const quarantineXattr = "com.apple.quarantine"
+
+// stripDetritus strips detritus attributes from the app, reporting each
+// cleaned file so a later codesign failure is not the first sign of them
+func (r *Resigner) stripDetritus(appPath string) error {
+	cleaned, err := StripDetritus(appPath)
+	for _, entry := range cleaned {
+		r.logProgress("Removed extended attribute " + entry)
+	}
+	return err
+}
+
+// removeQuarantine clears the quarantine flag from the output when
+// Config.NoQuarantine is set. Files created by a quarantined process (such
+// as a GUI downloaded from the web) inherit the flag.
+func (r *Resigner) removeQuarantine() error {
+	if !r.config.NoQuarantine || r.outputPath == "" {
+		return nil
+	}
+	cleaned, err := stripXattrs(r.outputPath, []string{quarantineXattr})
+	if err != nil {
+		return fmt.Errorf("failed to remove quarantine: %w", err)
+	}
+	if len(cleaned) > 0 {
+		r.logProgress(fmt.Sprintf("Removed quarantine from %d output file(s)", len(cleaned)))
+	}
+	return nil
+}
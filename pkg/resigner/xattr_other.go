@@ -0,0 +1,13 @@
+//go:build !darwin && !linux
+
+package resigner
+
+// listXattrs reports no extended attributes where they are unsupported
+func listXattrs(path string) ([]string, error) {
+	return nil, nil
+}
+
+// removeXattr is a no-op where extended attributes are unsupported
+func removeXattr(path, name string) error {
+	return nil
+}
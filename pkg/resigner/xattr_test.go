@@ -0,0 +1,45 @@
+//go:build linux
+
+package resigner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestStripXattrs(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "file")
+	os.WriteFile(file, []byte("data"), 0644)
+
+	// Linux only allows user.* attributes for regular users
+	if err := unix.Setxattr(file, "user.detritus", []byte("x"), 0); errors.Is(err, unix.ENOTSUP) {
+		t.Skip("file system does not support extended attributes")
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	if err := unix.Setxattr(file, "user.keep", []byte("x"), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	cleaned, err := stripXattrs(root, []string{"user.detritus"})
+	if err != nil {
+		t.Fatalf("stripXattrs() failed: %v", err)
+	}
+	if want := []string{"file: user.detritus"}; !reflect.DeepEqual(cleaned, want) {
+		t.Errorf("stripXattrs() = %v, want %v", cleaned, want)
+	}
+
+	attrs, err := listXattrs(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(attrs, []string{"user.keep"}) {
+		t.Errorf("attributes after strip = %v, want [user.keep]", attrs)
+	}
+}
@@ -0,0 +1,48 @@
+//go:build darwin || linux
+
+package resigner
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// listXattrs returns the names of the extended attributes of path,
+// without following symlinks. File systems without xattr support have
+// none.
+func listXattrs(path string) ([]string, error) {
+	for {
+		size, err := unix.Llistxattr(path, nil)
+		if errors.Is(err, unix.ENOTSUP) {
+			return nil, nil
+		}
+		if err != nil || size == 0 {
+			return nil, err
+		}
+
+		buf := make([]byte, size)
+		n, err := unix.Llistxattr(path, buf)
+		if errors.Is(err, unix.ERANGE) {
+			// Attributes were added since sizing the buffer
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var names []string
+		for _, name := range bytes.Split(buf[:n], []byte{0}) {
+			if len(name) > 0 {
+				names = append(names, string(name))
+			}
+		}
+		return names, nil
+	}
+}
+
+// removeXattr removes one extended attribute, without following symlinks
+func removeXattr(path, name string) error {
+	return unix.Lremovexattr(path, name)
+}
@@ -0,0 +1,66 @@
+//go:build integration
+
+package resigner
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestArchiveZip64 archives a file larger than 4 GiB, which needs Zip64
+// sizes and offsets. The source is sparse and compresses well, but the
+// test still streams 4 GiB through deflate twice, so it only runs with
+// the integration tests.
+func TestArchiveZip64(t *testing.T) {
+	root := t.TempDir()
+	app := filepath.Join(root, "src", "Payload", "Game.app")
+	if err := os.MkdirAll(app, 0755); err != nil {
+		t.Fatal(err)
+	}
+	const size = 1<<32 + 1<<20
+	f, err := os.Create(filepath.Join(app, "assets.pak"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A marker at the end makes a wrong size or offset show up in the CRC
+	if _, err := f.WriteAt([]byte("end"), size-3); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	os.WriteFile(filepath.Join(app, "Info.plist"), []byte("plist"), 0644)
+
+	ipa := filepath.Join(root, "Game.ipa")
+	if err := CreateArchive(filepath.Join(root, "src"), ipa); err != nil {
+		t.Fatalf("CreateArchive() failed: %v", err)
+	}
+	if err := VerifyArchive(ipa); err != nil {
+		t.Fatalf("VerifyArchive() failed: %v", err)
+	}
+
+	reader, err := zip.OpenReader(ipa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	for _, entry := range reader.File {
+		rc, err := entry.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Reading to EOF checks the CRC
+		n, err := io.Copy(io.Discard, rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("reading %s: %v", entry.Name, err)
+		}
+		if n != int64(entry.UncompressedSize64) {
+			t.Errorf("%s: read %d bytes, header says %d", entry.Name, n, entry.UncompressedSize64)
+		}
+		if entry.Name == "Payload/Game.app/assets.pak" && n != size {
+			t.Errorf("assets.pak is %d bytes, want %d", n, size)
+		}
+	}
+}
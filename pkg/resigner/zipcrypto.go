@@ -0,0 +1,162 @@
+package resigner
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+var (
+	// ErrPasswordRequired is returned when extracting an encrypted
+	// archive without a password
+	ErrPasswordRequired = errors.New("archive is encrypted; a password is required")
+	// ErrWrongPassword is returned when the archive password is incorrect
+	ErrWrongPassword = errors.New("incorrect archive password")
+)
+
+// Zip flag and method values from the PKWARE APPNOTE
+const (
+	zipFlagEncrypted      = 0x1
+	zipFlagDataDescriptor = 0x8
+	zipMethodAES          = 99
+)
+
+// isEncrypted reports whether a zip entry is encrypted
+func isEncrypted(f *zip.File) bool {
+	return f.Flags&zipFlagEncrypted != 0
+}
+
+// IsEncryptedArchive reports whether any entry of the zip archive at path
+// is encrypted
+func IsEncryptedArchive(path string) (bool, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return false, err
+	}
+	defer reader.Close()
+	for _, f := range reader.File {
+		if isEncrypted(f) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// zipCryptoKeys is the state of the traditional PKWARE stream cipher
+type zipCryptoKeys [3]uint32
+
+func newZipCryptoKeys(password string) *zipCryptoKeys {
+	keys := &zipCryptoKeys{0x12345678, 0x23456789, 0x34567890}
+	for i := 0; i < len(password); i++ {
+		keys.update(password[i])
+	}
+	return keys
+}
+
+// crc32Update advances a raw CRC-32 register by one byte
+func crc32Update(crc uint32, b byte) uint32 {
+	return crc32.IEEETable[byte(crc)^b] ^ (crc >> 8)
+}
+
+func (k *zipCryptoKeys) update(b byte) {
+	k[0] = crc32Update(k[0], b)
+	k[1] = (k[1]+k[0]&0xff)*134775813 + 1
+	k[2] = crc32Update(k[2], byte(k[1]>>24))
+}
+
+func (k *zipCryptoKeys) stream() byte {
+	temp := k[2] | 2
+	return byte((temp * (temp ^ 1)) >> 8)
+}
+
+func (k *zipCryptoKeys) decrypt(b byte) byte {
+	plain := b ^ k.stream()
+	k.update(plain)
+	return plain
+}
+
+// zipCryptoReader decrypts a ZipCrypto stream
+type zipCryptoReader struct {
+	r    io.Reader
+	keys *zipCryptoKeys
+}
+
+func (z *zipCryptoReader) Read(p []byte) (int, error) {
+	n, err := z.r.Read(p)
+	for i := 0; i < n; i++ {
+		p[i] = z.keys.decrypt(p[i])
+	}
+	return n, err
+}
+
+// crcReader verifies the CRC-32 of a stream once it is fully read. A
+// mismatch is reported as zip.ErrChecksum, as for unencrypted entries:
+// the password already passed the header check, so the entry is more
+// likely damaged than the password wrong
+type crcReader struct {
+	r    io.Reader
+	want uint32
+	hash uint32
+}
+
+func (c *crcReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.hash = crc32.Update(c.hash, crc32.IEEETable, p[:n])
+	if err == io.EOF && c.hash != c.want {
+		return n, zip.ErrChecksum
+	}
+	return n, err
+}
+
+// openEncrypted returns a reader for the decrypted, decompressed contents
+// of a ZipCrypto encrypted entry
+func openEncrypted(f *zip.File, password string) (io.ReadCloser, error) {
+	if password == "" {
+		return nil, ErrPasswordRequired
+	}
+	if f.Method == zipMethodAES {
+		return nil, fmt.Errorf("AES-encrypted entries are not supported; re-export the archive with ZipCrypto (legacy) encryption")
+	}
+
+	raw, err := f.OpenRaw()
+	if err != nil {
+		return nil, err
+	}
+	keys := newZipCryptoKeys(password)
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(raw, header); err != nil {
+		return nil, err
+	}
+	for i := range header {
+		header[i] = keys.decrypt(header[i])
+	}
+	// The last header byte is a quick password check
+	check := byte(f.CRC32 >> 24)
+	if f.Flags&zipFlagDataDescriptor != 0 {
+		check = byte(f.ModifiedTime >> 8)
+	}
+	if header[11] != check {
+		return nil, ErrWrongPassword
+	}
+
+	var plain io.Reader = &zipCryptoReader{r: raw, keys: keys}
+	switch f.Method {
+	case zip.Store:
+	case zip.Deflate:
+		plain = flate.NewReader(plain)
+	default:
+		return nil, fmt.Errorf("unsupported compression method %d", f.Method)
+	}
+	return io.NopCloser(&crcReader{r: plain, want: f.CRC32}), nil
+}
+
+// openEntry opens an archive entry, decrypting it with password if needed
+func openEntry(f *zip.File, password string) (io.ReadCloser, error) {
+	if isEncrypted(f) {
+		return openEncrypted(f, password)
+	}
+	return f.Open()
+}
@@ -0,0 +1,106 @@
+package resigner
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeEncryptedZip stores content as a single ZipCrypto encrypted entry
+func writeEncryptedZip(t *testing.T, path, name, password string, content []byte) {
+	t.Helper()
+	crc := crc32.ChecksumIEEE(content)
+
+	keys := newZipCryptoKeys(password)
+	encrypt := func(b byte) byte {
+		c := b ^ keys.stream()
+		keys.update(b)
+		return c
+	}
+	var data bytes.Buffer
+	header := []byte("0123456789A")
+	header = append(header, byte(crc>>24))
+	for _, b := range append(header, content...) {
+		data.WriteByte(encrypt(b))
+	}
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	fh := &zip.FileHeader{
+		Name:               name,
+		Method:             zip.Store,
+		Flags:              zipFlagEncrypted,
+		CRC32:              crc,
+		CompressedSize64:   uint64(data.Len()),
+		UncompressedSize64: uint64(len(content)),
+	}
+	raw, err := w.CreateRaw(fh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw.Write(data.Bytes())
+	w.Close()
+
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractEncryptedArchive(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "secret.ipa")
+	content := []byte("encrypted payload")
+	writeEncryptedZip(t, src, "Payload/Test.app/Info.plist", "s3cret", content)
+
+	encrypted, err := IsEncryptedArchive(src)
+	if err != nil || !encrypted {
+		t.Fatalf("IsEncryptedArchive() = %v, %v", encrypted, err)
+	}
+
+	if err := ExtractArchive(src, filepath.Join(root, "none")); !errors.Is(err, ErrPasswordRequired) {
+		t.Errorf("without password: error = %v, want ErrPasswordRequired", err)
+	}
+	if err := ExtractArchiveWithPassword(src, filepath.Join(root, "wrong"), "nope"); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("wrong password: error = %v, want ErrWrongPassword", err)
+	}
+
+	dest := filepath.Join(root, "dest")
+	if err := ExtractArchiveWithPassword(src, dest, "s3cret"); err != nil {
+		t.Fatalf("ExtractArchiveWithPassword() failed: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dest, "Payload", "Test.app", "Info.plist"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("decrypted content = %q, want %q", got, content)
+	}
+}
+
+func TestExtractEncryptedArchiveChecksum(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "damaged.ipa")
+	name := "Payload/Test.app/Info.plist"
+	content := []byte("encrypted payload")
+	writeEncryptedZip(t, src, name, "s3cret", content)
+
+	// Flip the last encrypted byte: the header check still passes but the
+	// decrypted content no longer matches its CRC
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[30+len(name)+12+len(content)-1] ^= 0xff
+	if err := os.WriteFile(src, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = ExtractArchiveWithPassword(src, filepath.Join(root, "dest"), "s3cret")
+	if !errors.Is(err, zip.ErrChecksum) || errors.Is(err, ErrWrongPassword) {
+		t.Errorf("damaged entry: error = %v, want zip.ErrChecksum", err)
+	}
+}
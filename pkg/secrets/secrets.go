@@ -0,0 +1,130 @@
+// Package secrets looks up passwords and API keys from where they are
+// kept rather than from plaintext flags or config files. A secret is
+// referenced by a URI whose scheme names the provider:
+//
+//	env:NAME                      the environment variable NAME
+//	keychain:service[/account]    a generic password in the macOS Keychain
+//	op://vault/item/field         a 1Password item, read with the op CLI
+//	vault:path#field              a HashiCorp Vault KV secret, read with the vault CLI
+//
+// Like the storage backends, the command-line providers rely on their
+// tools' own sign-in: an unlocked keychain, an op session or service
+// account token, and VAULT_ADDR with a vault token.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/resignipa/pkg/command"
+)
+
+// ErrNotFound is returned when a reference names a secret that does not
+// exist or is empty
+var ErrNotFound = errors.New("secret not found")
+
+// Provider looks up secrets of one scheme
+type Provider interface {
+	// Lookup returns the secret ref refers to
+	Lookup(ctx context.Context, ref string) (string, error)
+}
+
+// providers maps reference schemes to their Provider; tests replace
+// entries
+var providers = map[string]Provider{
+	"env": envProvider{},
+	"keychain": cliProvider{args: func(ref string) ([]string, error) {
+		service, account, _ := strings.Cut(strings.TrimPrefix(ref, "keychain:"), "/")
+		if service == "" {
+			return nil, fmt.Errorf("keychain reference %q has no service", ref)
+		}
+		args := []string{"security", "find-generic-password", "-w", "-s", service}
+		if account != "" {
+			args = append(args, "-a", account)
+		}
+		return args, nil
+	}},
+	"op": cliProvider{args: func(ref string) ([]string, error) {
+		if strings.Count(strings.TrimPrefix(ref, "op://"), "/") < 2 {
+			return nil, fmt.Errorf("1Password reference %q must be op://vault/item/field", ref)
+		}
+		return []string{"op", "read", "--no-newline", ref}, nil
+	}},
+	"vault": cliProvider{args: func(ref string) ([]string, error) {
+		path, field, _ := strings.Cut(strings.TrimPrefix(ref, "vault:"), "#")
+		if path == "" || field == "" {
+			return nil, fmt.Errorf("Vault reference %q must be vault:path#field", ref)
+		}
+		return []string{"vault", "kv", "get", "-field=" + field, path}, nil
+	}},
+}
+
+// scheme returns the provider scheme of ref, e.g. "op" for op://...
+func scheme(ref string) string {
+	scheme, _, ok := strings.Cut(ref, ":")
+	if !ok {
+		return ""
+	}
+	return strings.ToLower(scheme)
+}
+
+// IsRef reports whether s is a secret reference rather than a plain value
+func IsRef(s string) bool {
+	_, ok := providers[scheme(s)]
+	return ok
+}
+
+// Resolve returns the secret ref refers to. Trailing newlines, which the
+// command-line tools print after the value, are removed.
+func Resolve(ctx context.Context, ref string) (string, error) {
+	provider, ok := providers[scheme(ref)]
+	if !ok {
+		return "", fmt.Errorf("unsupported secret reference %q (use env:, keychain:, op:// or vault:)", ref)
+	}
+	secret, err := provider.Lookup(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %s: %w", ref, err)
+	}
+	secret = strings.TrimRight(secret, "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("%s: %w", ref, ErrNotFound)
+	}
+	return secret, nil
+}
+
+// envProvider reads env:NAME references from the environment
+type envProvider struct{}
+
+func (envProvider) Lookup(ctx context.Context, ref string) (string, error) {
+	name := strings.TrimPrefix(ref, "env:")
+	if name == "" {
+		return "", fmt.Errorf("environment reference %q has no variable name", ref)
+	}
+	return os.Getenv(name), nil
+}
+
+// cliProvider reads secrets with a provider's command-line tool
+type cliProvider struct {
+	// args returns the command printing the secret ref refers to
+	args func(ref string) ([]string, error)
+}
+
+func (p cliProvider) Lookup(ctx context.Context, ref string) (string, error) {
+	args, err := p.args(ref)
+	if err != nil {
+		return "", err
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return "", fmt.Errorf("%s not found, install it and sign in to use %s references: %w", args[0], scheme(ref), err)
+	}
+	// Only stdout carries the secret; stderr explains failures
+	result, err := command.New(args[0], args[1:]...).Run(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%s: %s - %w", strings.Join(args[:2], " "), strings.TrimSpace(string(result.Stderr)), err)
+	}
+	return string(result.Stdout), nil
+}
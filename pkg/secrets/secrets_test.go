@@ -0,0 +1,96 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestIsRef(t *testing.T) {
+	for s, want := range map[string]bool{
+		"env:IPA_PASSWORD":         true,
+		"keychain:resignipa/ci":    true,
+		"op://CI/Signing/password": true,
+		"VAULT:secret/ci#password": true,
+		"hunter2":                  false,
+		"https://example.com":      false,
+	} {
+		if got := IsRef(s); got != want {
+			t.Errorf("IsRef(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestResolveEnv(t *testing.T) {
+	t.Setenv("RESIGNIPA_TEST_SECRET", "hunter2\n")
+	if got, err := Resolve(context.Background(), "env:RESIGNIPA_TEST_SECRET"); err != nil || got != "hunter2" {
+		t.Errorf("Resolve() = %q, %v", got, err)
+	}
+	if _, err := Resolve(context.Background(), "env:RESIGNIPA_TEST_UNSET"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve() of an unset variable = %v, want ErrNotFound", err)
+	}
+	if _, err := Resolve(context.Background(), "ftp:secret"); err == nil {
+		t.Error("Resolve() of an unknown scheme succeeded")
+	}
+}
+
+func TestProviderArgs(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"keychain:resignipa", "security find-generic-password -w -s resignipa"},
+		{"keychain:resignipa/ci", "security find-generic-password -w -s resignipa -a ci"},
+		{"op://CI/Signing/password", "op read --no-newline op://CI/Signing/password"},
+		{"vault:secret/ci/signing#password", "vault kv get -field=password secret/ci/signing"},
+		{"keychain:", ""},
+		{"op://CI/Signing", ""},
+		{"vault:secret/ci/signing", ""},
+	}
+	for _, tt := range tests {
+		args, err := providers[scheme(tt.ref)].(cliProvider).args(tt.ref)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("args(%q) = %v, want an error", tt.ref, args)
+			}
+			continue
+		}
+		if got := strings.Join(args, " "); err != nil || got != tt.want {
+			t.Errorf("args(%q) = %q, %v, want %q", tt.ref, got, err, tt.want)
+		}
+	}
+}
+
+func TestCLIProvider(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the CLI")
+	}
+	// A stand-in CLI printing its argument, or failing when asked to
+	bin := t.TempDir()
+	os.WriteFile(filepath.Join(bin, "fakecli"), []byte("#!/bin/sh\nif [ \"$2\" = missing ]; then echo 'item not found' >&2; exit 1; fi\necho \"$2\"\n"), 0755)
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	saved := providers["op"]
+	defer func() { providers["op"] = saved }()
+	providers["op"] = cliProvider{args: func(ref string) ([]string, error) {
+		return []string{"fakecli", "read", strings.TrimPrefix(ref, "op:")}, nil
+	}}
+
+	if got, err := Resolve(context.Background(), "op:hunter2"); err != nil || got != "hunter2" {
+		t.Errorf("Resolve() = %q, %v", got, err)
+	}
+	if _, err := Resolve(context.Background(), "op:missing"); err == nil || !strings.Contains(err.Error(), "item not found") {
+		t.Errorf("Resolve() of a missing item = %v", err)
+	}
+
+	providers["op"] = cliProvider{args: func(ref string) ([]string, error) {
+		return []string{"no-such-cli", "read", ref}, nil
+	}}
+	if _, err := Resolve(context.Background(), "op:hunter2"); err == nil || !strings.Contains(err.Error(), "no-such-cli not found") {
+		t.Errorf("Resolve() without the CLI = %v", err)
+	}
+}
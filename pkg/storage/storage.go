@@ -0,0 +1,142 @@
+// Package storage copies IPAs between the local disk and object stores
+// (s3:// and gs:// URLs), so only the signing itself has to happen on the
+// Mac.
+//
+// Transfers go through the providers' own command-line tools, aws and
+// gcloud, which pick up credentials from their standard chains:
+// environment variables, shared config files and profiles, SSO sessions
+// and instance or workload identities.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/resignipa/pkg/command"
+)
+
+// Backend transfers single objects between a store and local files
+type Backend interface {
+	// Download copies the object at remote to the local file path
+	Download(ctx context.Context, remote, path string) error
+	// Upload copies the local file path to the object at remote
+	Upload(ctx context.Context, path, remote string) error
+}
+
+// Location is a parsed object store URL
+type Location struct {
+	Scheme string
+	Bucket string
+	// Key is the object key, or the key prefix of a folder ending in "/"
+	Key string
+}
+
+// String returns the location as a URL
+func (l Location) String() string {
+	return l.Scheme + "://" + l.Bucket + "/" + l.Key
+}
+
+// IsDir reports whether the location names a folder rather than an object
+func (l Location) IsDir() bool {
+	return l.Key == "" || strings.HasSuffix(l.Key, "/")
+}
+
+// Base returns the last element of the key, the object's file name
+func (l Location) Base() string {
+	return path.Base(l.Key)
+}
+
+// Join returns the object called name inside the folder l
+func (l Location) Join(name string) Location {
+	key := l.Key
+	if key != "" && !strings.HasSuffix(key, "/") {
+		key += "/"
+	}
+	return Location{Scheme: l.Scheme, Bucket: l.Bucket, Key: key + name}
+}
+
+// backends maps URL schemes to their Backend; tests replace entries
+var backends = map[string]Backend{
+	"s3": cliBackend{copyArgs: func(src, dst string) []string {
+		return []string{"aws", "s3", "cp", "--only-show-errors", src, dst}
+	}},
+	"gs": cliBackend{copyArgs: func(src, dst string) []string {
+		return []string{"gcloud", "storage", "cp", src, dst}
+	}},
+}
+
+// IsRemote reports whether s is an object store URL rather than a local
+// path
+func IsRemote(s string) bool {
+	scheme, _, ok := strings.Cut(s, "://")
+	if !ok {
+		return false
+	}
+	_, known := backends[strings.ToLower(scheme)]
+	return known
+}
+
+// Parse parses an object store URL such as s3://bucket/path/App.ipa
+func Parse(s string) (Location, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return Location{}, err
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if _, ok := backends[scheme]; !ok {
+		return Location{}, fmt.Errorf("unsupported storage URL %q (use s3:// or gs://)", s)
+	}
+	if u.Host == "" {
+		return Location{}, fmt.Errorf("storage URL %q has no bucket", s)
+	}
+	return Location{Scheme: scheme, Bucket: u.Host, Key: strings.TrimPrefix(u.Path, "/")}, nil
+}
+
+// Download copies the object at loc to the local file path
+func Download(ctx context.Context, loc Location, path string) error {
+	if loc.IsDir() {
+		return fmt.Errorf("%s is a folder, not an object", loc)
+	}
+	if err := backends[loc.Scheme].Download(ctx, loc.String(), path); err != nil {
+		return fmt.Errorf("failed to download %s: %w", loc, err)
+	}
+	return nil
+}
+
+// Upload copies the local file path to the object at loc
+func Upload(ctx context.Context, path string, loc Location) error {
+	if err := backends[loc.Scheme].Upload(ctx, path, loc.String()); err != nil {
+		return fmt.Errorf("failed to upload %s: %w", loc, err)
+	}
+	return nil
+}
+
+// cliBackend copies objects with a provider's command-line tool
+type cliBackend struct {
+	// copyArgs returns the command copying src to dst
+	copyArgs func(src, dst string) []string
+}
+
+func (b cliBackend) Download(ctx context.Context, remote, path string) error {
+	return b.copy(ctx, remote, path)
+}
+
+func (b cliBackend) Upload(ctx context.Context, path, remote string) error {
+	return b.copy(ctx, path, remote)
+}
+
+func (b cliBackend) copy(ctx context.Context, src, dst string) error {
+	args := b.copyArgs(src, dst)
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return fmt.Errorf("%s not found, install it and sign in to use remote URLs: %w", args[0], err)
+	}
+	result, err := command.New(args[0], args[1:]...).Run(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %s - %w", strings.Join(args[:3], " "), strings.TrimSpace(string(result.Combined)), err)
+	}
+	return nil
+}
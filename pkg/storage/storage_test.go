@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	loc, err := Parse("s3://builds/nightly/App.ipa")
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	if loc.Scheme != "s3" || loc.Bucket != "builds" || loc.Key != "nightly/App.ipa" || loc.IsDir() {
+		t.Errorf("Parse() = %+v", loc)
+	}
+	if loc.Base() != "App.ipa" || loc.String() != "s3://builds/nightly/App.ipa" {
+		t.Errorf("Base() = %q, String() = %q", loc.Base(), loc.String())
+	}
+
+	dir, _ := Parse("GS://builds/resigned/")
+	if !dir.IsDir() || dir.Scheme != "gs" {
+		t.Errorf("Parse() of a folder = %+v", dir)
+	}
+	if got := dir.Join("App.ipa").String(); got != "gs://builds/resigned/App.ipa" {
+		t.Errorf("Join() = %s", got)
+	}
+	bucket, _ := Parse("s3://builds")
+	if got := bucket.Join("App.ipa").String(); got != "s3://builds/App.ipa" {
+		t.Errorf("Join() on a bucket = %s", got)
+	}
+
+	for _, bad := range []string{"ftp://host/App.ipa", "s3:///App.ipa", "App.ipa"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestIsRemote(t *testing.T) {
+	for s, want := range map[string]bool{
+		"s3://b/App.ipa":  true,
+		"gs://b/App.ipa":  true,
+		"/tmp/App.ipa":    false,
+		"App.ipa":         false,
+		"https://x/a.ipa": false,
+	} {
+		if got := IsRemote(s); got != want {
+			t.Errorf("IsRemote(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
+
+// fakeBackend records transfers instead of making them
+type fakeBackend struct {
+	calls []string
+}
+
+func (b *fakeBackend) Download(ctx context.Context, remote, path string) error {
+	b.calls = append(b.calls, "get "+remote)
+	return os.WriteFile(path, []byte("ipa"), 0644)
+}
+
+func (b *fakeBackend) Upload(ctx context.Context, path, remote string) error {
+	b.calls = append(b.calls, "put "+remote)
+	return nil
+}
+
+func TestTransfers(t *testing.T) {
+	fake := &fakeBackend{}
+	saved := backends["s3"]
+	backends["s3"] = fake
+	defer func() { backends["s3"] = saved }()
+
+	local := filepath.Join(t.TempDir(), "App.ipa")
+	loc, _ := Parse("s3://builds/App.ipa")
+	if err := Download(context.Background(), loc, local); err != nil {
+		t.Fatalf("Download() failed: %v", err)
+	}
+	if err := Upload(context.Background(), local, loc); err != nil {
+		t.Fatalf("Upload() failed: %v", err)
+	}
+	folder, _ := Parse("s3://builds/")
+	if err := Download(context.Background(), folder, local); err == nil {
+		t.Error("Download() of a folder succeeded")
+	}
+	if got := strings.Join(fake.calls, ", "); got != "get s3://builds/App.ipa, put s3://builds/App.ipa" {
+		t.Errorf("transfers = %s", got)
+	}
+}
+
+func TestCLIBackend(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the CLI")
+	}
+	// A stand-in CLI that copies its last two arguments like the real ones
+	bin := t.TempDir()
+	os.WriteFile(filepath.Join(bin, "fakecli"), []byte("#!/bin/sh\nfor a; do src=$dst; dst=$a; done\ncp \"$src\" \"$dst\"\n"), 0755)
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	backend := cliBackend{copyArgs: func(src, dst string) []string {
+		return []string{"fakecli", "s3", "cp", src, dst}
+	}}
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "src.ipa"), filepath.Join(dir, "dst.ipa")
+	os.WriteFile(src, []byte("ipa"), 0644)
+	if err := backend.Upload(context.Background(), src, dst); err != nil {
+		t.Fatalf("Upload() failed: %v", err)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "ipa" {
+		t.Errorf("copied %q", data)
+	}
+
+	err := backend.Download(context.Background(), filepath.Join(dir, "missing"), dst)
+	if err == nil || !strings.Contains(err.Error(), "fakecli s3 cp") {
+		t.Errorf("Download() of a missing object = %v", err)
+	}
+
+	missing := cliBackend{copyArgs: func(src, dst string) []string {
+		return []string{"no-such-cli", "s3", "cp", src, dst}
+	}}
+	if err := missing.Upload(context.Background(), src, dst); err == nil || !strings.Contains(err.Error(), "no-such-cli not found") {
+		t.Errorf("Upload() without the CLI = %v", err)
+	}
+}
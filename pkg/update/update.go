@@ -0,0 +1,126 @@
+// Package update checks GitHub releases for a newer version of ResignIPA.
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Repository is the GitHub repository releases are published to
+const Repository = "ak3ilb/ResignIPA"
+
+// APIURL is the GitHub API endpoint; tests point it at a local server
+var APIURL = "https://api.github.com"
+
+// Release is a published GitHub release
+type Release struct {
+	Tag string `json:"tag_name"`
+	URL string `json:"html_url"`
+	// Name is the release title and Notes its description, in markdown
+	Name        string    `json:"name"`
+	Notes       string    `json:"body"`
+	PublishedAt time.Time `json:"published_at"`
+	Assets      []Asset   `json:"assets"`
+}
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// DownloadURL returns the download link of the binary built for arch,
+// such as "arm64", or the release page when no asset matches so users can
+// pick one themselves
+func (r *Release) DownloadURL(arch string) string {
+	for _, asset := range r.Assets {
+		name := asset.Name
+		for _, ext := range []string{".zip", ".tar.gz", ".dmg"} {
+			name = strings.TrimSuffix(name, ext)
+		}
+		if strings.HasSuffix(name, "-"+arch) {
+			return asset.URL
+		}
+	}
+	return r.URL
+}
+
+// Latest fetches the newest non-prerelease release of repo
+func Latest(ctx context.Context, client *http.Client, repo string) (*Release, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, APIURL+"/repos/"+repo+"/releases/latest", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query releases: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to query releases: %s", resp.Status)
+	}
+
+	var release Release
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to decode release: %w", err)
+	}
+	if release.Tag == "" {
+		return nil, fmt.Errorf("release has no tag")
+	}
+	return &release, nil
+}
+
+// Newer reports whether version latest is newer than current. Versions
+// are compared numerically, e.g. "v1.10.0" > "1.9.2"; a current version
+// that is not a release (such as "dev" or a commit) is never outdated.
+func Newer(current, latest string) bool {
+	cur, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	next, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	for i := 0; i < len(cur) || i < len(next); i++ {
+		var a, b int
+		if i < len(cur) {
+			a = cur[i]
+		}
+		if i < len(next) {
+			b = next[i]
+		}
+		if a != b {
+			return b > a
+		}
+	}
+	return false
+}
+
+// parseVersion splits "v1.2.3" into its numbers, ignoring pre-release
+// and build suffixes such as "-rc1" or "-3-gabc123-dirty"
+func parseVersion(version string) ([]int, bool) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	if version == "" {
+		return nil, false
+	}
+	var parts []int
+	for _, field := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, false
+		}
+		parts = append(parts, n)
+	}
+	return parts, true
+}
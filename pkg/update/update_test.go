@@ -0,0 +1,79 @@
+package update
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		current, latest string
+		want            bool
+	}{
+		{"v1.2.3", "v1.2.4", true},
+		{"1.9.2", "v1.10.0", true},
+		{"v1.2", "v1.2.0", false},
+		{"v2.0.0", "v1.9.9", false},
+		{"v1.2.3-4-gabc1234-dirty", "v1.2.3", false},
+		{"v1.2.3-rc1", "v1.3.0", true},
+		{"dev", "v9.9.9", false},
+		{"abc1234", "v1.0.0", false},
+		{"v1.0.0", "nightly", false},
+	}
+	for _, tt := range tests {
+		if got := Newer(tt.current, tt.latest); got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tt.current, tt.latest, got, tt.want)
+		}
+	}
+}
+
+func TestLatest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/repo/releases/latest" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"tag_name": "v1.4.0", "html_url": "https://example.com/v1.4.0", "draft": false,
+			"name": "ResignIPA 1.4", "body": "- Faster signing", "published_at": "2024-05-01T12:00:00Z",
+			"assets": [{"name": "resignipa-arm64", "browser_download_url": "https://example.com/resignipa-arm64"}]}`))
+	}))
+	defer server.Close()
+	defer func(url string) { APIURL = url }(APIURL)
+	APIURL = server.URL
+
+	release, err := Latest(context.Background(), server.Client(), "owner/repo")
+	if err != nil {
+		t.Fatalf("Latest() failed: %v", err)
+	}
+	if release.Tag != "v1.4.0" || release.URL != "https://example.com/v1.4.0" || release.Notes != "- Faster signing" ||
+		len(release.Assets) != 1 || release.PublishedAt.IsZero() {
+		t.Errorf("Latest() = %+v", release)
+	}
+
+	if _, err := Latest(context.Background(), server.Client(), "owner/missing"); err == nil {
+		t.Error("Latest() should fail for a missing repository")
+	}
+}
+
+func TestDownloadURL(t *testing.T) {
+	release := &Release{
+		URL: "https://example.com/releases/v1.4.0",
+		Assets: []Asset{
+			{Name: "resignipa-amd64", URL: "https://example.com/resignipa-amd64"},
+			{Name: "resignipa-arm64.sha256", URL: "https://example.com/resignipa-arm64.sha256"},
+			{Name: "resignipa-arm64.zip", URL: "https://example.com/resignipa-arm64.zip"},
+		},
+	}
+	tests := []struct{ arch, want string }{
+		{"amd64", "https://example.com/resignipa-amd64"},
+		{"arm64", "https://example.com/resignipa-arm64.zip"},
+		{"386", "https://example.com/releases/v1.4.0"},
+	}
+	for _, tt := range tests {
+		if got := release.DownloadURL(tt.arch); got != tt.want {
+			t.Errorf("DownloadURL(%q) = %q, want %q", tt.arch, got, tt.want)
+		}
+	}
+}